@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common/funding"
+	"github.com/339-Labs/exchange-market/common/index"
+	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/database"
+	"github.com/339-Labs/exchange-market/redis"
+	"github.com/ethereum/go-ethereum/log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	readHeaderTimeout = 5 * time.Second
+	writeTimeout      = 10 * time.Second
+	requestTimeout    = 5 * time.Second
+	staleAfter        = 30 * time.Second // 超过该时间未更新视为行情过期
+	defaultQueryLimit = 100              // 列表查询的默认条数
+	maxQueryLimit     = 1000             // 列表查询的最大条数
+)
+
+// Api 行情查询接口, 优先读取同进程的内存行情, 其次读取redis
+type Api struct {
+	db       *database.DB
+	redis    *redis.RedisClient
+	registry *maps.Registry
+	indexes  *index.Store
+	funding  *funding.Store
+
+	addr     string
+	server   *http.Server
+	shutdown context.CancelCauseFunc
+	stopped  atomic.Bool
+}
+
+// NewApi 创建接口服务, db、redis、registry、indexes、funding 均可为空
+func NewApi(config *config.Config, db *database.DB, redis *redis.RedisClient, registry *maps.Registry, indexes *index.Store, funding *funding.Store, shutdown context.CancelCauseFunc) (*Api, error) {
+	if config.HttpServerConfig.Port <= 0 {
+		return nil, fmt.Errorf("invalid http port %d", config.HttpServerConfig.Port)
+	}
+
+	api := &Api{
+		db:       db,
+		redis:    redis,
+		registry: registry,
+		indexes:  indexes,
+		funding:  funding,
+		addr:     net.JoinHostPort(config.HttpServerConfig.Host, strconv.Itoa(config.HttpServerConfig.Port)),
+		shutdown: shutdown,
+	}
+	api.server = &http.Server{
+		Handler:           api.routes(),
+		ReadHeaderTimeout: readHeaderTimeout,
+		WriteTimeout:      writeTimeout,
+	}
+	return api, nil
+}
+
+func (a *Api) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", a.addr)
+	if err != nil {
+		return fmt.Errorf("listen %s: %w", a.addr, err)
+	}
+
+	go func() {
+		if err := a.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			a.shutdown(fmt.Errorf("http server: %w", err))
+		}
+	}()
+
+	log.Info("api server started", "addr", a.addr)
+	return nil
+}
+
+func (a *Api) Stop(ctx context.Context) error {
+	err := a.server.Shutdown(ctx)
+	a.stopped.Store(true)
+	log.Info("api server stopped")
+	return err
+}
+
+func (a *Api) Stopped() bool {
+	return a.stopped.Load()
+}
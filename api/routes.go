@@ -0,0 +1,476 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/index"
+	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/common/symbols"
+	"github.com/339-Labs/exchange-market/database/arbitrage"
+	funding2 "github.com/339-Labs/exchange-market/database/funding"
+	"github.com/339-Labs/exchange-market/database/symbol"
+	"github.com/ethereum/go-ethereum/log"
+	goredis "github.com/redis/go-redis/v9"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sourceMemory = "memory"
+	sourceRedis  = "redis"
+)
+
+// PriceResponse 行情及数据来源
+type PriceResponse struct {
+	*maps.PriceData
+	Source string `json:"source"`
+}
+
+// IndexResponse 指数价格及数据来源
+type IndexResponse struct {
+	*index.Result
+	Source string `json:"source"`
+}
+
+// FeedHealth 单个行情的更新时间
+type FeedHealth struct {
+	LastUpdate int64 `json:"last_update"` // 毫秒, 未更新过为0
+	AgeMs      int64 `json:"age_ms"`
+	Stale      bool  `json:"stale"`
+}
+
+// VenueHealth 交易所的行情更新情况
+type VenueHealth struct {
+	Exchange string                `json:"exchange"`
+	Feeds    map[string]FeedHealth `json:"feeds"`
+}
+
+// HealthResponse 健康检查结果
+type HealthResponse struct {
+	Status string        `json:"status"`
+	Redis  string        `json:"redis"`
+	Venues []VenueHealth `json:"venues"`
+}
+
+// routes 注册路由
+func (a *Api) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/price/{exchange}/{instType}/{symbol...}", a.handlePrice)
+	mux.HandleFunc("GET /api/v1/markets/{symbol...}", a.handleMarkets)
+	mux.HandleFunc("GET /api/v1/index/{instType}/{symbol...}", a.handleIndex)
+	mux.HandleFunc("GET /api/v1/symbols", a.handleSymbols)
+	mux.HandleFunc("GET /api/v1/arbitrage", a.handleArbitrage)
+	mux.HandleFunc("GET /api/v1/funding/{symbol...}", a.handleFunding)
+	mux.HandleFunc("GET /api/v1/funding-history/{symbol...}", a.handleFundingHistory)
+	mux.HandleFunc("GET /api/v1/carry", a.handleCarry)
+	mux.HandleFunc("GET /health", a.handleHealth)
+	return mux
+}
+
+// handlePrice 交易所某个交易对的最新价、标记价格和资金费率, 例如 /api/v1/price/bn/perp/BTC/USDT
+func (a *Api) handlePrice(w http.ResponseWriter, r *http.Request) {
+	exchange, ok := common.ParseExchange(r.PathValue("exchange"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "unknown exchange")
+		return
+	}
+	instType, ok := parseInstType(r.PathValue("instType"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "inst type must be spot or perp")
+		return
+	}
+	unifiedSymbol, ok := parseSymbol(r.PathValue("symbol"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "symbol must be BASE/QUOTE")
+		return
+	}
+
+	if venue, ok := a.registry.Get(exchange); ok {
+		if price, ok := venue.Lookup(exchange, instType, unifiedSymbol); ok {
+			writeJSON(w, http.StatusOK, PriceResponse{PriceData: price, Source: sourceMemory})
+			return
+		}
+	}
+
+	if a.redis == nil {
+		writeError(w, http.StatusNotFound, "price not found")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+	defer cancel()
+
+	price, err := a.redis.GetMarketPrice(ctx, string(exchange), instType, unifiedSymbol)
+	if errors.Is(err, goredis.Nil) {
+		writeError(w, http.StatusNotFound, "price not found")
+		return
+	}
+	if err != nil {
+		log.Error("get market price failed", "exchange", exchange, "symbol", unifiedSymbol, "err", err)
+		writeError(w, http.StatusInternalServerError, "get price failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, PriceResponse{PriceData: price, Source: sourceRedis})
+}
+
+// handleMarkets 所有交易所某个交易对的行情, 可用 inst_type 过滤, 例如 /api/v1/markets/BTC/USDT?inst_type=perp
+func (a *Api) handleMarkets(w http.ResponseWriter, r *http.Request) {
+	unifiedSymbol, ok := parseSymbol(r.PathValue("symbol"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "symbol must be BASE/QUOTE")
+		return
+	}
+	instTypes := []string{common.InstTypeSpot, common.InstTypePerp}
+	if value := r.URL.Query().Get("inst_type"); value != "" {
+		instType, ok := parseInstType(value)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "inst type must be spot or perp")
+			return
+		}
+		instTypes = []string{instType}
+	}
+
+	// 先读取redis, 同进程的交易所用内存行情覆盖
+	prices := make(map[string]PriceResponse)
+	if a.redis != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+		defer cancel()
+
+		list, err := a.redis.GetPricesBySymbol(ctx, unifiedSymbol)
+		if err != nil {
+			log.Error("get prices by symbol failed", "symbol", unifiedSymbol, "err", err)
+			writeError(w, http.StatusInternalServerError, "get prices failed")
+			return
+		}
+		for _, price := range list {
+			prices[price.Exchange+":"+price.InstType] = PriceResponse{PriceData: price, Source: sourceRedis}
+		}
+	}
+
+	for _, exchange := range a.registry.Exchanges() {
+		venue, _ := a.registry.Get(exchange)
+		for _, instType := range instTypes {
+			if price, ok := venue.Lookup(exchange, instType, unifiedSymbol); ok {
+				prices[string(exchange)+":"+instType] = PriceResponse{PriceData: price, Source: sourceMemory}
+			}
+		}
+	}
+
+	result := make([]PriceResponse, 0, len(prices))
+	for _, price := range prices {
+		if containsString(instTypes, price.InstType) {
+			result = append(result, price)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Exchange != result[j].Exchange {
+			return result[i].Exchange < result[j].Exchange
+		}
+		return result[i].InstType < result[j].InstType
+	})
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleIndex 跨交易所指数价格及成分, 例如 /api/v1/index/spot/BTC/USDT
+func (a *Api) handleIndex(w http.ResponseWriter, r *http.Request) {
+	instType, ok := parseInstType(r.PathValue("instType"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "inst type must be spot or perp")
+		return
+	}
+	unifiedSymbol, ok := parseSymbol(r.PathValue("symbol"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "symbol must be BASE/QUOTE")
+		return
+	}
+
+	if result, ok := a.indexes.Get(instType, unifiedSymbol); ok {
+		writeJSON(w, http.StatusOK, IndexResponse{Result: result, Source: sourceMemory})
+		return
+	}
+
+	if a.redis == nil {
+		writeError(w, http.StatusNotFound, "index not found")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+	defer cancel()
+
+	result, err := a.redis.GetIndexPrice(ctx, instType, unifiedSymbol)
+	if errors.Is(err, goredis.Nil) {
+		writeError(w, http.StatusNotFound, "index not found")
+		return
+	}
+	if err != nil {
+		log.Error("get index price failed", "symbol", unifiedSymbol, "err", err)
+		writeError(w, http.StatusInternalServerError, "get index failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, IndexResponse{Result: result, Source: sourceRedis})
+}
+
+// handleSymbols market_symbol 中的交易对, 可用 exchange、inst_type 过滤
+func (a *Api) handleSymbols(w http.ResponseWriter, r *http.Request) {
+	if a.db == nil {
+		writeError(w, http.StatusServiceUnavailable, "database not configured")
+		return
+	}
+
+	var exchange string
+	if value := r.URL.Query().Get("exchange"); value != "" {
+		parsed, ok := common.ParseExchange(value)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "unknown exchange")
+			return
+		}
+		exchange = string(parsed)
+	}
+
+	symbols, err := a.db.MarketSymbol.QueryMarketSymbols(exchange, r.URL.Query().Get("inst_type"))
+	if err != nil {
+		log.Error("query market symbols failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "query symbols failed")
+		return
+	}
+	if symbols == nil {
+		symbols = []symbol.MarketSymbol{}
+	}
+	writeJSON(w, http.StatusOK, symbols)
+}
+
+// handleArbitrage 套利机会, 按开始时间倒序, 可用 symbol、inst_type、exchange、active、since、limit 过滤
+// 例如 /api/v1/arbitrage?symbol=BTC/USDT&active=true
+func (a *Api) handleArbitrage(w http.ResponseWriter, r *http.Request) {
+	if a.db == nil {
+		writeError(w, http.StatusServiceUnavailable, "database not configured")
+		return
+	}
+
+	query := r.URL.Query()
+	var filter arbitrage.ArbitrageFilter
+	if value := query.Get("symbol"); value != "" {
+		unifiedSymbol, ok := parseSymbol(value)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "symbol must be BASE/QUOTE")
+			return
+		}
+		filter.UnifiedSymbol = unifiedSymbol
+	}
+	if value := query.Get("inst_type"); value != "" {
+		instType, ok := parseInstType(value)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "inst type must be spot or perp")
+			return
+		}
+		filter.InstType = instType
+	}
+	if value := query.Get("exchange"); value != "" {
+		exchange, ok := common.ParseExchange(value)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "unknown exchange")
+			return
+		}
+		filter.Exchange = string(exchange)
+	}
+	filter.Active = query.Get("active") == "true"
+
+	if value := query.Get("since"); value != "" {
+		since, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "since must be a millisecond timestamp")
+			return
+		}
+		filter.Since = since
+	}
+	limit, ok := parseLimit(query.Get("limit"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "limit must be between 1 and 1000")
+		return
+	}
+	filter.Limit = limit
+
+	opportunities, err := a.db.ArbitrageOpportunity.QueryArbitrageOpportunities(filter)
+	if err != nil {
+		log.Error("query arbitrage opportunities failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "query opportunities failed")
+		return
+	}
+	if opportunities == nil {
+		opportunities = []arbitrage.ArbitrageOpportunity{}
+	}
+	writeJSON(w, http.StatusOK, opportunities)
+}
+
+// handleFunding 统一永续交易对在各交易所的资金费率、年化费率、基差和跨所费率差, 例如 /api/v1/funding/BTC/USDT
+func (a *Api) handleFunding(w http.ResponseWriter, r *http.Request) {
+	unifiedSymbol, ok := parseSymbol(r.PathValue("symbol"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "symbol must be BASE/QUOTE")
+		return
+	}
+	report, ok := a.funding.Get(unifiedSymbol)
+	if !ok {
+		writeError(w, http.StatusNotFound, "funding not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handleCarry 按年化收益率排序的资金费套利机会, 默认返回前100个, 例如 /api/v1/carry?limit=20
+func (a *Api) handleCarry(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parseLimit(r.URL.Query().Get("limit"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "limit must be between 1 and 1000")
+		return
+	}
+	writeJSON(w, http.StatusOK, a.funding.Carries(limit))
+}
+
+// handleFundingHistory 资金费结算记录, 按结算时间倒序, 可用 exchange、since、limit 过滤
+// 例如 /api/v1/funding-history/BTC/USDT?exchange=okx
+func (a *Api) handleFundingHistory(w http.ResponseWriter, r *http.Request) {
+	if a.db == nil {
+		writeError(w, http.StatusServiceUnavailable, "database not configured")
+		return
+	}
+	unifiedSymbol, ok := parseSymbol(r.PathValue("symbol"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "symbol must be BASE/QUOTE")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := funding2.FundingHistoryFilter{UnifiedSymbol: unifiedSymbol}
+	if value := query.Get("exchange"); value != "" {
+		exchange, ok := common.ParseExchange(value)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "unknown exchange")
+			return
+		}
+		filter.Exchange = string(exchange)
+	}
+	if value := query.Get("since"); value != "" {
+		since, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "since must be a millisecond timestamp")
+			return
+		}
+		filter.Since = since
+	}
+	limit, ok := parseLimit(query.Get("limit"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "limit must be between 1 and 1000")
+		return
+	}
+	filter.Limit = limit
+
+	history, err := a.db.FundingRateHistory.QueryFundingRateHistory(filter)
+	if err != nil {
+		log.Error("query funding rate history failed", "symbol", unifiedSymbol, "err", err)
+		writeError(w, http.StatusInternalServerError, "query funding history failed")
+		return
+	}
+	if history == nil {
+		history = []funding2.FundingRateHistory{}
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
+// handleHealth 各交易所的行情更新时间, 有过期行情或redis不可用时返回503
+func (a *Api) handleHealth(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	response := HealthResponse{Status: "ok", Redis: "disabled", Venues: make([]VenueHealth, 0)}
+
+	if a.redis != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+		defer cancel()
+
+		response.Redis = "ok"
+		if err := a.redis.Ping(ctx); err != nil {
+			response.Redis = err.Error()
+			response.Status = "degraded"
+		}
+	}
+
+	for _, exchange := range a.registry.Exchanges() {
+		venue, _ := a.registry.Get(exchange)
+		health := VenueHealth{Exchange: string(exchange), Feeds: make(map[string]FeedHealth)}
+		for name, lastWrite := range venue.Freshness() {
+			feed := FeedHealth{Stale: true}
+			if !lastWrite.IsZero() {
+				age := now.Sub(lastWrite)
+				feed = FeedHealth{LastUpdate: lastWrite.UnixMilli(), AgeMs: age.Milliseconds(), Stale: age > staleAfter}
+			}
+			if feed.Stale {
+				response.Status = "degraded"
+			}
+			health.Feeds[name] = feed
+		}
+		response.Venues = append(response.Venues, health)
+	}
+
+	status := http.StatusOK
+	if response.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, response)
+}
+
+// parseInstType 解析产品类型 spot 或 perp
+func parseInstType(value string) (string, bool) {
+	switch strings.ToLower(value) {
+	case common.InstTypeSpot:
+		return common.InstTypeSpot, true
+	case common.InstTypePerp:
+		return common.InstTypePerp, true
+	}
+	return "", false
+}
+
+// parseSymbol 解析统一交易对, 返回 redis 键使用的 BASE/QUOTE, 结算币种由 instType 区分
+func parseSymbol(value string) (string, bool) {
+	symbol, err := symbols.Parse(value)
+	if err != nil {
+		return "", false
+	}
+	return symbol.Pair(), true
+}
+
+// parseLimit 解析查询条数, 为空时返回 defaultQueryLimit
+func parseLimit(value string) (int, bool) {
+	if value == "" {
+		return defaultQueryLimit, true
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 || limit > maxQueryLimit {
+		return 0, false
+	}
+	return limit, true
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Error("write response failed", "err", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/index"
+	"github.com/339-Labs/exchange-market/common/maps"
+)
+
+func TestApi_PriceFromMemory(t *testing.T) {
+	featurePriceMap := maps.NewPriceMap(10)
+	rateMap := maps.NewPriceMap(10)
+	featurePriceMap.Write("BTC-USDT-SWAP", &maps.PriceData{Symbol: "BTC-USDT-SWAP", Price: common.MustParseDecimal("101"), Timestamp: "1"})
+	rateMap.Write("BTC-USDT-SWAP", &maps.PriceData{Symbol: "BTC-USDT-SWAP", FundingRate: common.MustParseDecimal("0.0001"), Timestamp: "1"})
+
+	registry := maps.NewRegistry()
+	registry.Register(common.Okx, &maps.VenueMaps{Spot: maps.NewPriceMap(10), Feature: featurePriceMap, Rate: rateMap})
+	api := &Api{registry: registry}
+	handler := api.routes()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/price/okx/perp/btc/usdt", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var price PriceResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &price); err != nil {
+		t.Fatal(err)
+	}
+	if price.Price.String() != "101" || price.FundingRate.String() != "0.0001" || price.UnifiedSymbol != "BTC/USDT" || price.Source != sourceMemory {
+		t.Fatalf("unexpected price %+v", price)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/price/okx/spot/BTC/USDT", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected not found, got %d", rec.Code)
+	}
+
+	// 现货从未写入, 健康检查返回503
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected degraded health, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestApi_IndexFromMemory(t *testing.T) {
+	indexes := index.NewStore()
+	indexes.Set(&index.Result{Symbol: "BTC/USDT", InstType: common.InstTypeSpot, Method: index.MethodMedian, Price: common.MustParseDecimal("100.2")})
+	handler := (&Api{registry: maps.NewRegistry(), indexes: indexes}).routes()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/index/spot/btc/usdt", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result IndexResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Price.String() != "100.2" || result.Source != sourceMemory {
+		t.Fatalf("unexpected index %+v", result)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/index/perp/BTC/USDT", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected not found, got %d", rec.Code)
+	}
+}
@@ -2,17 +2,23 @@ package bitget
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/339-Labs/exchange-market/common"
 	"github.com/339-Labs/exchange-market/common/client"
+	"github.com/339-Labs/exchange-market/common/symbols"
 	symbol2 "github.com/339-Labs/exchange-market/database/symbol"
 	"github.com/ethereum/go-ethereum/log"
+	"math/big"
+	"strconv"
 	"time"
 )
 
 var TypeTransformError = errors.New("type transform error")
 
+const symbolTypeDelivery = "delivery"
+
 func (c *Client) InitSpotSymbol() error {
 
 	header := make(map[string]string, 0)
@@ -21,124 +27,226 @@ func (c *Client) InitSpotSymbol() error {
 		return err
 	}
 
-	symbols, err := c.parseMarketResponse(resp, "Spot")
-	log.Info("====", len(symbols))
-	return errors.New("bitget: init spot symbol error")
+	symbols, err := c.parseMarketResponse(resp, symbol2.InstTypeSpot, "")
+	if err != nil {
+		return fmt.Errorf("bitget: init spot symbol: %w", err)
+	}
+
+	log.Info("bitget spot symbols loaded", "count", len(symbols))
+	return c.db.MarketSymbol.SyncMarketSymbols(string(common.BitGet), symbol2.InstTypeSpot, symbols)
+}
+
+// symbolInfo 现货和合约交易对信息, 数字均为字符串, 现货和合约使用的字段不同
+type symbolInfo struct {
+	Symbol    string `json:"symbol"`
+	BaseCoin  string `json:"baseCoin"`
+	QuoteCoin string `json:"quoteCoin"`
+
+	// 现货
+	Status            string `json:"status"`
+	PricePrecision    string `json:"pricePrecision"`
+	QuantityPrecision string `json:"quantityPrecision"`
+	MinTradeAmount    string `json:"minTradeAmount"`
+
+	// 合约
+	SymbolType   string `json:"symbolType"`
+	SymbolStatus string `json:"symbolStatus"`
+	PricePlace   string `json:"pricePlace"`
+	PriceEndStep string `json:"priceEndStep"`
+	SizeMultiple string `json:"sizeMultiplier"`
+	MinTradeNum  string `json:"minTradeNum"`
+	LaunchTime   string `json:"launchTime"`
+	DeliveryTime string `json:"deliveryTime"`
+
+	MinTradeUSDT string `json:"minTradeUSDT"`
 }
 
 // API响应结构体
 type APIResponse struct {
-	Code        string        `json:"code"`
-	Msg         string        `json:"msg"`
-	RequestTime int64         `json:"requestTime"`
-	Data        []interface{} `json:"data"`
+	Code        string       `json:"code"`
+	Msg         string       `json:"msg"`
+	RequestTime int64        `json:"requestTime"`
+	Data        []symbolInfo `json:"data"`
 }
 
 // 响应结果结构体
 type ResponseResult struct {
 	Response *client.RESTResponse // 假设这是您的响应类型
+	Settle   string               // 产品类型对应的结算币种, 币本位为空
 	Error    error
 }
 
-func (c *Client) InitFeatureSymbol() error {
+// productSettles 合约产品类型及结算币种, 币本位合约以交易币结算
+var productSettles = map[string]string{
+	"USDT-FUTURES": "USDT",
+	"USDC-FUTURES": "USDC",
+	"COIN-FUTURES": "",
+}
 
-	productTypes := []string{"USDT-FUTURES", "USDC_FUTURES", "COIN_FUTURES"}
+// InitFeatureSymbol 加载永续和交割合约
+func (c *Client) InitFeatureSymbol() error {
 
 	header := make(map[string]string, 0)
 
-	var allSymbols []symbol2.MarketSymbol
+	var perps, deliveries []symbol2.MarketSymbol
 
 	// 并发请求所有产品类型
-	responses := make(chan ResponseResult, len(productTypes))
+	responses := make(chan ResponseResult, len(productSettles))
 
-	for _, productType := range productTypes {
-		go func(pt string) {
+	for productType, settle := range productSettles {
+		go func(pt string, settle string) {
 			url := fmt.Sprintf("/api/v2/mix/market/contracts?productType=%s", pt)
 			resp, err := c.resty.GET(context.Background(), url, header)
-			responses <- ResponseResult{Response: resp, Error: err}
-		}(productType)
+			responses <- ResponseResult{Response: resp, Settle: settle, Error: err}
+		}(productType, settle)
 	}
 
 	// 收集所有响应
-	for i := 0; i < len(productTypes); i++ {
+	for i := 0; i < len(productSettles); i++ {
 		result := <-responses
 		if result.Error != nil {
-			return result.Error
+			return fmt.Errorf("bitget: init feature symbol: %w", result.Error)
 		}
 
-		symbols, err := c.parseMarketResponse(result.Response, "Feature")
+		symbols, err := c.parseMarketResponse(result.Response, symbol2.InstTypeFeature, result.Settle)
 		if err != nil {
-			return err
+			return fmt.Errorf("bitget: init feature symbol: %w", err)
 		}
 
-		allSymbols = append(allSymbols, symbols...)
+		for _, marketSymbol := range symbols {
+			if marketSymbol.InstType == symbol2.InstTypeDelivery {
+				deliveries = append(deliveries, marketSymbol)
+			} else {
+				perps = append(perps, marketSymbol)
+			}
+		}
 	}
 
-	log.Info("====", len(allSymbols))
-
-	return errors.New("bitget: init feature symbol error")
+	log.Info("bitget feature symbols loaded", "perp", len(perps), "delivery", len(deliveries))
+	return errors.Join(
+		c.db.MarketSymbol.SyncMarketSymbols(string(common.BitGet), symbol2.InstTypeFeature, perps),
+		c.db.MarketSymbol.SyncMarketSymbols(string(common.BitGet), symbol2.InstTypeDelivery, deliveries),
+	)
 }
 
-// 解析市场响应的通用方法
-func (c *Client) parseMarketResponse(resp *client.RESTResponse, InstType string) ([]symbol2.MarketSymbol, error) {
+// 解析市场响应的通用方法, 合约按 symbolType 区分永续和交割, settle 为空时以交易币结算
+func (c *Client) parseMarketResponse(resp *client.RESTResponse, InstType string, settle string) ([]symbol2.MarketSymbol, error) {
 	if !resp.IsSuccess() || resp.StatusCode != 200 {
 		return nil, fmt.Errorf("request failed: status code %d", resp.StatusCode)
 	}
 
-	jsonMap := common.BytesToMap(resp.Body)
-
-	// 检查响应码
-	code, ok := jsonMap["code"].(string)
-	if !ok || code != "00000" {
-		return nil, fmt.Errorf("api error: code=%v, msg=%v", jsonMap["code"], jsonMap["msg"])
+	var apiResp APIResponse
+	if err := json.Unmarshal(resp.Body, &apiResp); err != nil {
+		return nil, fmt.Errorf("invalid data format: %w", err)
 	}
 
-	// 检查消息
-	msg, ok := jsonMap["msg"].(string)
-	if !ok || msg != "success" {
-		return nil, fmt.Errorf("api response error: %v", jsonMap["msg"])
-	}
-
-	// 解析data字段
-	datas, ok := jsonMap["data"].([]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid data format")
+	// 检查响应码
+	if apiResp.Code != "00000" {
+		return nil, fmt.Errorf("api error: code=%s, msg=%s", apiResp.Code, apiResp.Msg)
 	}
+	datas := apiResp.Data
 
-	symbols := make([]symbol2.MarketSymbol, 0, len(datas))
+	list := make([]symbol2.MarketSymbol, 0, len(datas))
 	currentTime := uint64(time.Now().UnixMilli())
 
-	for _, item := range datas {
-		data, ok := item.(map[string]interface{})
-		if !ok {
+	for _, data := range datas {
+		if data.Symbol == "" || data.BaseCoin == "" {
 			continue
 		}
 
-		symbol, ok := data["symbol"].(string)
-		if !ok {
-			continue
+		marketSymbol := symbol2.MarketSymbol{
+			Symbol:      data.Symbol,
+			InstType:    InstType,
+			Exchange:    string(common.BitGet),
+			ChainId:     symbol2.CexChainId,
+			Base:        data.BaseCoin,
+			Quote:       data.QuoteCoin,
+			MinNotional: common.DecimalFromString(data.MinTradeUSDT),
+			Timestamp:   currentTime,
 		}
 
-		baseCoin, ok := data["baseCoin"].(string)
-		if !ok {
-			continue
+		var ok bool
+		if InstType == symbol2.InstTypeSpot {
+			if marketSymbol.Status, ok = spotStatus(data.Status); !ok {
+				continue
+			}
+			marketSymbol.TickSize = common.PrecisionStep(atoi(data.PricePrecision))
+			marketSymbol.LotSize = common.PrecisionStep(atoi(data.QuantityPrecision))
+			marketSymbol.MinQty = common.DecimalFromString(data.MinTradeAmount)
+		} else {
+			if marketSymbol.Status, ok = contractStatus(data.SymbolStatus); !ok {
+				continue
+			}
+			// 价格步长为 priceEndStep * 10^-pricePlace, 合约数量以交易币计
+			marketSymbol.TickSize = common.DecimalFromString(placeStep(atoi(data.PricePlace), data.PriceEndStep))
+			marketSymbol.LotSize = common.DecimalFromString(data.SizeMultiple)
+			marketSymbol.MinQty = common.DecimalFromString(data.MinTradeNum)
+			marketSymbol.ContractValue = common.NewDecimal(1, 0)
+			marketSymbol.Settle = settle
+			if settle == "" {
+				marketSymbol.Settle = data.BaseCoin
+			}
+			marketSymbol.ListTime = parseMillis(data.LaunchTime)
+			if data.SymbolType == symbolTypeDelivery {
+				marketSymbol.InstType = symbol2.InstTypeDelivery
+				marketSymbol.ExpiryTime = parseMillis(data.DeliveryTime)
+			}
 		}
 
-		quoteCoin, _ := data["quoteCoin"].(string)
+		marketSymbol.UnifiedSymbol = symbols.NewSymbol(data.BaseCoin, data.QuoteCoin, marketSymbol.Settle).WithExpiry(marketSymbol.ExpiryTime).String()
+		list = append(list, marketSymbol)
+	}
 
-		marketSymbol := symbol2.MarketSymbol{
-			Symbol:        symbol,
-			UnifiedSymbol: baseCoin + "/" + quoteCoin,
-			InstType:      InstType,
-			Exchange:      "BitGet",
-			ChainId:       "999999",
-			Base:          baseCoin,
-			Quote:         quoteCoin,
-			Timestamp:     currentTime,
-		}
+	return list, nil
+}
+
+// spotStatus 现货 online 可交易, gray 灰度待上线, halt 暂停, offline 视为下架
+func spotStatus(status string) (string, bool) {
+	switch status {
+	case "online":
+		return symbol2.StatusTrading, true
+	case "gray":
+		return symbol2.StatusPending, true
+	case "halt":
+		return symbol2.StatusSuspended, true
+	default:
+		return "", false
+	}
+}
 
-		symbols = append(symbols, marketSymbol)
+// contractStatus 合约 normal 可交易, listed 待上线, maintain、limit_open、restrictedAPI 暂停, off 视为下架
+func contractStatus(status string) (string, bool) {
+	switch status {
+	case "normal":
+		return symbol2.StatusTrading, true
+	case "listed":
+		return symbol2.StatusPending, true
+	case "maintain", "limit_open", "restrictedAPI":
+		return symbol2.StatusSuspended, true
+	default:
+		return "", false
 	}
+}
+
+// placeStep 计算 endStep * 10^-place, endStep 为空时按1计算
+func placeStep(place int, endStep string) string {
+	step, ok := new(big.Int).SetString(endStep, 10)
+	if !ok {
+		step = big.NewInt(1)
+	}
+	if place <= 0 {
+		return step.String()
+	}
+	denom := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(place)), nil)
+	return new(big.Rat).SetFrac(step, denom).FloatString(place)
+}
+
+func atoi(value string) int {
+	n, _ := strconv.Atoi(value)
+	return n
+}
 
-	return symbols, nil
+func parseMillis(value string) uint64 {
+	millis, _ := strconv.ParseUint(value, 10, 64)
+	return millis
 }
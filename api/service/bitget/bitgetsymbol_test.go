@@ -1 +1,21 @@
 package bitget
+
+import "testing"
+
+func TestPlaceStep(t *testing.T) {
+	cases := []struct {
+		place   int
+		endStep string
+		want    string
+	}{
+		{1, "5", "0.5"},
+		{2, "1", "0.01"},
+		{4, "", "0.0001"},
+		{0, "1", "1"},
+	}
+	for _, c := range cases {
+		if got := placeStep(c.place, c.endStep); got != c.want {
+			t.Fatalf("placeStep(%d, %q) = %s, want %s", c.place, c.endStep, got, c.want)
+		}
+	}
+}
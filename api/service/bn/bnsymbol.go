@@ -2,146 +2,178 @@ package bn
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/client"
+	"github.com/339-Labs/exchange-market/common/symbols"
 	symbol2 "github.com/339-Labs/exchange-market/database/symbol"
+	"github.com/339-Labs/exchange-market/exchange/cex/bn/constants"
 	"github.com/ethereum/go-ethereum/log"
-	"strings"
 	"time"
 )
 
-func (c *Client) InitSpotSymbol() error {
+const (
+	filterPrice       = "PRICE_FILTER"
+	filterLotSize     = "LOT_SIZE"
+	filterNotional    = "NOTIONAL"     // 现货
+	filterMinNotional = "MIN_NOTIONAL" // 现货旧规则和U本位合约
 
-	header := make(map[string]string, 0)
-	resp, err := c.resty.GET(context.Background(), "/api/v3/ticker/price", header)
-	if err != nil {
-		return err
-	}
+	contractPerpetual      = "PERPETUAL"
+	contractCurrentQuarter = "CURRENT_QUARTER"
+	contractNextQuarter    = "NEXT_QUARTER"
+)
 
-	if resp.IsSuccess() && resp.StatusCode == 200 {
-		arrMap := common.BytesToArrMap(resp.Body)
+// symbolFilter 交易规则, 不同 filterType 使用不同字段
+type symbolFilter struct {
+	FilterType  string `json:"filterType"`
+	TickSize    string `json:"tickSize"`
+	StepSize    string `json:"stepSize"`
+	MinQty      string `json:"minQty"`
+	MinNotional string `json:"minNotional"` // 现货
+	Notional    string `json:"notional"`    // U本位合约
+}
 
-		symbols := make([]symbol2.MarketSymbol, len(arrMap), len(arrMap))
-		for _, v := range arrMap {
-			symbol, _ := v["symbol"].(string)
+// symbolInfo 交易对信息, 合约字段只在U本位合约返回
+type symbolInfo struct {
+	Symbol       string         `json:"symbol"`
+	Status       string         `json:"status"`
+	BaseAsset    string         `json:"baseAsset"`
+	QuoteAsset   string         `json:"quoteAsset"`
+	MarginAsset  string         `json:"marginAsset"`
+	ContractType string         `json:"contractType"`
+	OnboardDate  uint64         `json:"onboardDate"`
+	DeliveryDate uint64         `json:"deliveryDate"`
+	Filters      []symbolFilter `json:"filters"`
+}
 
-			sym, base, quote, err := c.handlerSymbol(symbol)
-			if err != nil {
-				continue
-			} else {
+// exchangeInfoRsp /api/v3/exchangeInfo 和 /fapi/v1/exchangeInfo 响应
+type exchangeInfoRsp struct {
+	Symbols []symbolInfo `json:"symbols"`
+}
 
-				var marketSymbol = symbol2.MarketSymbol{
-					Symbol:        symbol,
-					UnifiedSymbol: sym,
-					InstType:      "Spot",
-					Exchange:      "Bn",
-					ChainId:       "999999",
-					Base:          base,
-					Quote:         quote,
-					Timestamp:     uint64(time.Now().UnixMilli()),
-				}
-				symbols = append(symbols, marketSymbol)
+func (c *Client) InitSpotSymbol() error {
+	list, err := c.getExchangeInfo(c.resty, constants.SpotExchangeInfoPath)
+	if err != nil {
+		return fmt.Errorf("bn: init spot symbol: %w", err)
+	}
 
-			}
+	symbols := make([]symbol2.MarketSymbol, 0, len(list))
+	for _, vv := range list {
+		if marketSymbol, ok := toMarketSymbol(vv); ok {
+			symbols = append(symbols, marketSymbol)
 		}
-		log.Info("====", len(symbols))
 	}
-	return errors.New("bn: init spot symbol error")
+
+	log.Info("bn spot symbols loaded", "count", len(symbols))
+	return c.db.MarketSymbol.SyncMarketSymbols(string(common.BN), symbol2.InstTypeSpot, symbols)
 }
 
+// InitFeatureSymbol 加载U本位永续和交割合约
 func (c *Client) InitFeatureSymbol() error {
-	header := make(map[string]string, 0)
-	resp, err := c.resty.GET(context.Background(), "/fapi/v1/ticker/price", header)
+	list, err := c.getExchangeInfo(c.fapi, constants.FuturesExchangeInfoPath)
 	if err != nil {
-		return err
+		return fmt.Errorf("bn: init feature symbol: %w", err)
 	}
 
-	if resp.IsSuccess() && resp.StatusCode == 200 {
-		arrMap := common.BytesToArrMap(resp.Body)
-
-		symbols := make([]symbol2.MarketSymbol, len(arrMap), len(arrMap))
-		for _, v := range arrMap {
-			symbol, _ := v["symbol"].(string)
-
-			sym, base, quote, err := c.handlerSymbol(symbol)
-			if err != nil {
-				continue
-			} else {
+	perps := make([]symbol2.MarketSymbol, 0, len(list))
+	deliveries := make([]symbol2.MarketSymbol, 0)
+	for _, vv := range list {
+		marketSymbol, ok := toMarketSymbol(vv)
+		if !ok {
+			continue
+		}
 
-				var marketSymbol = symbol2.MarketSymbol{
-					Symbol:        symbol,
-					UnifiedSymbol: sym,
-					InstType:      "Feature",
-					Exchange:      "Bn",
-					ChainId:       "999999",
-					Base:          base,
-					Quote:         quote,
-					Timestamp:     uint64(time.Now().UnixMilli()),
-				}
-				symbols = append(symbols, marketSymbol)
+		// U本位合约一张为一个币
+		marketSymbol.ContractValue = common.NewDecimal(1, 0)
+		marketSymbol.Settle = vv.MarginAsset
+		marketSymbol.ListTime = vv.OnboardDate
 
-			}
+		switch vv.ContractType {
+		case contractPerpetual:
+			marketSymbol.UnifiedSymbol = symbols.NewSymbol(vv.BaseAsset, vv.QuoteAsset, vv.MarginAsset).String()
+			perps = append(perps, marketSymbol)
+		case contractCurrentQuarter, contractNextQuarter:
+			marketSymbol.ExpiryTime = vv.DeliveryDate
+			marketSymbol.UnifiedSymbol = symbols.NewSymbol(vv.BaseAsset, vv.QuoteAsset, vv.MarginAsset).WithExpiry(vv.DeliveryDate).String()
+			deliveries = append(deliveries, marketSymbol)
 		}
-		log.Info("====", len(symbols))
 	}
-	return errors.New("bn: init feature symbol error")
+
+	log.Info("bn feature symbols loaded", "perp", len(perps), "delivery", len(deliveries))
+	return errors.Join(
+		c.db.MarketSymbol.SyncMarketSymbols(string(common.BN), symbol2.InstTypeFeature, perps),
+		c.db.MarketSymbol.SyncMarketSymbols(string(common.BN), symbol2.InstTypeDelivery, deliveries),
+	)
 }
 
-func (c *Client) handlerSymbol(symbol string) (string, string, string, error) {
-	if strings.HasSuffix(symbol, "USDT") {
-		base := strings.TrimSuffix(symbol, "USDT")
-		quote := "USDT"
-		return base + "/" + quote, base, quote, nil
-	}
-	if strings.HasSuffix(symbol, "BTC") {
-		base := strings.TrimSuffix(symbol, "BTC")
-		quote := "BTC"
-		return base + "/" + quote, base, quote, nil
-	}
-	if strings.HasSuffix(symbol, "ETH") {
-		base := strings.TrimSuffix(symbol, "ETH")
-		quote := "ETH"
-		return base + "/" + quote, base, quote, nil
-	}
-	if strings.HasSuffix(symbol, "USDC") {
-		base := strings.TrimSuffix(symbol, "USDC")
-		quote := "USDC"
-		return base + "/" + quote, base, quote, nil
-	}
-	if strings.HasSuffix(symbol, "BNB") {
-		base := strings.TrimSuffix(symbol, "BNB")
-		quote := "BNB"
-		return base + "/" + quote, base, quote, nil
+// getExchangeInfo 请求交易规则
+func (c *Client) getExchangeInfo(rest client.REST, path string) ([]symbolInfo, error) {
+	header := make(map[string]string, 0)
+	resp, err := rest.GET(context.Background(), path, header)
+	if err != nil {
+		return nil, err
 	}
-	if strings.HasSuffix(symbol, "TRY") {
-		base := strings.TrimSuffix(symbol, "TRY")
-		quote := "TRY"
-		return base + "/" + quote, base, quote, nil
+
+	if !resp.IsSuccess() || resp.StatusCode != constants.StatusOK {
+		return nil, fmt.Errorf("request failed: status code %d, body=%s", resp.StatusCode, string(resp.Body))
 	}
-	if strings.HasSuffix(symbol, "FDUSD") {
-		base := strings.TrimSuffix(symbol, "FDUSD")
-		quote := "FDUSD"
-		return base + "/" + quote, base, quote, nil
+
+	var rsp exchangeInfoRsp
+	if err := json.Unmarshal(resp.Body, &rsp); err != nil {
+		return nil, fmt.Errorf("invalid data format: %w", err)
 	}
-	if strings.HasSuffix(symbol, "JPY") {
-		base := strings.TrimSuffix(symbol, "JPY")
-		quote := "JPY"
-		return base + "/" + quote, base, quote, nil
+	return rsp.Symbols, nil
+}
+
+// toMarketSymbol 转换交易对信息, 已下架或交割中的交易对返回 false
+func toMarketSymbol(info symbolInfo) (symbol2.MarketSymbol, bool) {
+	status, ok := symbolStatus(info.Status)
+	if !ok || info.Symbol == "" || info.BaseAsset == "" || info.QuoteAsset == "" {
+		return symbol2.MarketSymbol{}, false
 	}
-	if strings.HasSuffix(symbol, "EUR") {
-		base := strings.TrimSuffix(symbol, "EUR")
-		quote := "EUR"
-		return base + "/" + quote, base, quote, nil
+
+	marketSymbol := symbol2.MarketSymbol{
+		Symbol:        info.Symbol,
+		UnifiedSymbol: symbols.NewSymbol(info.BaseAsset, info.QuoteAsset, "").String(),
+		ChainId:       symbol2.CexChainId,
+		Base:          info.BaseAsset,
+		Quote:         info.QuoteAsset,
+		Status:        status,
+		Timestamp:     uint64(time.Now().UnixMilli()),
 	}
-	if strings.HasSuffix(symbol, "MXN") {
-		base := strings.TrimSuffix(symbol, "MXN")
-		quote := "MXN"
-		return base + "/" + quote, base, quote, nil
+
+	for _, filter := range info.Filters {
+		switch filter.FilterType {
+		case filterPrice:
+			marketSymbol.TickSize = common.DecimalFromString(filter.TickSize)
+		case filterLotSize:
+			marketSymbol.LotSize = common.DecimalFromString(filter.StepSize)
+			marketSymbol.MinQty = common.DecimalFromString(filter.MinQty)
+		case filterNotional, filterMinNotional:
+			if filter.MinNotional != "" {
+				marketSymbol.MinNotional = common.DecimalFromString(filter.MinNotional)
+			} else {
+				marketSymbol.MinNotional = common.DecimalFromString(filter.Notional)
+			}
+		}
 	}
-	if strings.HasSuffix(symbol, "BRL") {
-		base := strings.TrimSuffix(symbol, "BRL")
-		quote := "BRL"
-		return base + "/" + quote, base, quote, nil
+	return marketSymbol, true
+}
+
+// symbolStatus 交易所状态转换为 market_symbol 状态
+func symbolStatus(status string) (string, bool) {
+	switch status {
+	case "TRADING":
+		return symbol2.StatusTrading, true
+	case "PRE_TRADING", "PENDING_TRADING":
+		return symbol2.StatusPending, true
+	case "BREAK", "HALT", "AUCTION_MATCH", "END_OF_DAY", "POST_TRADING":
+		// BREAK 为临时停止交易
+		return symbol2.StatusSuspended, true
+	default:
+		// CLOSE、SETTLING、DELIVERING 等已下架或已交割
+		return "", false
 	}
-	return "", "", "", errors.New("bn: handler symbol error")
 }
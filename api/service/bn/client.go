@@ -5,12 +5,14 @@ import (
 	"github.com/339-Labs/exchange-market/common/client"
 	"github.com/339-Labs/exchange-market/config"
 	"github.com/339-Labs/exchange-market/database"
+	"github.com/339-Labs/exchange-market/exchange/cex/bn/constants"
 )
 
 type Client struct {
 	config config.Config
 	db     database.DB
 	resty  client.REST
+	fapi   client.REST // U本位合约接口
 }
 
 func NewClient(config config.Config, db database.DB) service.HandlerSymbolAdaptor {
@@ -19,5 +21,6 @@ func NewClient(config config.Config, db database.DB) service.HandlerSymbolAdapto
 		config: config,
 		db:     db,
 		resty:  rest,
+		fapi:   client.NewRESTClient(constants.FuturesApiUrl),
 	}
 }
@@ -2,91 +2,190 @@ package bybit
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/symbols"
 	symbol2 "github.com/339-Labs/exchange-market/database/symbol"
 	"github.com/ethereum/go-ethereum/log"
+	"net/url"
+	"strconv"
 	"time"
 )
 
-func (c *Client) InitSpotSymbol() error {
+const (
+	instrumentsPath         = "/v5/market/instruments-info"
+	instrumentsLimit        = 1000 // 单页最大数量, 仅合约支持分页
+	contractLinearPerpetual = "LinearPerpetual"
+	contractLinearFutures   = "LinearFutures"
+)
 
-	header := make(map[string]string, 0)
-	resp, err := c.resty.GET(context.Background(), "/v5/market/instruments-info?category=spot", header)
+// instrument 交易对信息, 时间为毫秒字符串
+type instrument struct {
+	Symbol       string `json:"symbol"`
+	ContractType string `json:"contractType"`
+	Status       string `json:"status"`
+	BaseCoin     string `json:"baseCoin"`
+	QuoteCoin    string `json:"quoteCoin"`
+	SettleCoin   string `json:"settleCoin"`
+	LaunchTime   string `json:"launchTime"`
+	DeliveryTime string `json:"deliveryTime"`
+	PriceFilter  struct {
+		TickSize string `json:"tickSize"`
+	} `json:"priceFilter"`
+	LotSizeFilter struct {
+		BasePrecision    string `json:"basePrecision"` // 现货数量步长
+		QtyStep          string `json:"qtyStep"`       // 合约数量步长
+		MinOrderQty      string `json:"minOrderQty"`
+		MinOrderAmt      string `json:"minOrderAmt"`      // 现货最小下单金额
+		MinNotionalValue string `json:"minNotionalValue"` // 合约最小下单金额
+	} `json:"lotSizeFilter"`
+}
+
+// instrumentsRsp /v5/market/instruments-info 响应, retCode 为数字
+type instrumentsRsp struct {
+	RetCode int    `json:"retCode"`
+	RetMsg  string `json:"retMsg"`
+	Result  struct {
+		List           []instrument `json:"list"`
+		NextPageCursor string       `json:"nextPageCursor"`
+	} `json:"result"`
+}
+
+func (c *Client) InitSpotSymbol() error {
+	list, err := c.getInstruments("spot")
 	if err != nil {
-		return err
+		return fmt.Errorf("bybit: init spot symbol: %w", err)
 	}
 
-	if resp.IsSuccess() && resp.StatusCode == 200 {
-		rspMap := common.BytesToMap(resp.Body)
-		retMsg, ok1 := rspMap["retMsg"].(string)
-		retCode, ok2 := rspMap["code"].(string)
-
-		if ok1 && ok2 && retCode == "0" && retMsg == "success" {
-			result, _ := rspMap["result"].(map[string]interface{})
-			if list, ok := result["list"].([]map[string]interface{}); ok && len(list) > 0 {
-				symbols := make([]symbol2.MarketSymbol, 0, len(list))
-				for _, vv := range list {
-
-					symbol, _ := vv["symbol"].(string)
-					baseCoin, _ := vv["baseCoin"].(string)
-					quoteCoin, _ := vv["quoteCoin"].(string)
-
-					var marketSymbol = symbol2.MarketSymbol{
-						Symbol:        symbol,
-						UnifiedSymbol: baseCoin + "/" + quoteCoin,
-						InstType:      "Spot",
-						Exchange:      "ByBit",
-						ChainId:       "999999",
-						Base:          baseCoin,
-						Quote:         quoteCoin,
-						Timestamp:     uint64(time.Now().UnixMilli()),
-					}
-					symbols = append(symbols, marketSymbol)
-
-				}
-				log.Info("====", len(symbols))
-			}
+	symbols := make([]symbol2.MarketSymbol, 0, len(list))
+	for _, vv := range list {
+		marketSymbol, ok := toMarketSymbol(vv)
+		if !ok {
+			continue
 		}
 
+		marketSymbol.LotSize = common.DecimalFromString(vv.LotSizeFilter.BasePrecision)
+		marketSymbol.MinNotional = common.DecimalFromString(vv.LotSizeFilter.MinOrderAmt)
+		symbols = append(symbols, marketSymbol)
 	}
-	return errors.New("bybit: init spot symbol error")
+
+	log.Info("bybit spot symbols loaded", "count", len(symbols))
+	return c.db.MarketSymbol.SyncMarketSymbols(string(common.ByBit), symbol2.InstTypeSpot, symbols)
 }
 
+// InitFeatureSymbol 加载USDT永续和交割合约
 func (c *Client) InitFeatureSymbol() error {
-	header := make(map[string]string, 0)
-	resp, err := c.resty.GET(context.Background(), "/fapi/v1/ticker/price", header)
+	list, err := c.getInstruments("linear")
 	if err != nil {
-		return err
+		return fmt.Errorf("bybit: init feature symbol: %w", err)
 	}
 
-	if resp.IsSuccess() && resp.StatusCode == 200 {
-		arrMap := common.BytesToArrMap(resp.Body)
-
-		symbols := make([]symbol2.MarketSymbol, len(arrMap), len(arrMap))
-		for _, v := range arrMap {
-			symbol, _ := v["symbol"].(string)
-			baseCoin, _ := v["baseCoin"].(string)
-			quoteCoin, _ := v["quoteCoin"].(string)
-			contractType, _ := v[" contractType"].(string)
-			status, _ := v["status"].(string)
-
-			if "LinearPerpetual" == contractType && "Trading" == status {
-				var marketSymbol = symbol2.MarketSymbol{
-					Symbol:        symbol,
-					UnifiedSymbol: baseCoin + "/" + quoteCoin,
-					InstType:      "Feature",
-					Exchange:      "Bn",
-					ChainId:       "999999",
-					Base:          baseCoin,
-					Quote:         quoteCoin,
-					Timestamp:     uint64(time.Now().UnixMilli()),
-				}
-				symbols = append(symbols, marketSymbol)
-			}
+	perps := make([]symbol2.MarketSymbol, 0, len(list))
+	deliveries := make([]symbol2.MarketSymbol, 0)
+	for _, vv := range list {
+		marketSymbol, ok := toMarketSymbol(vv)
+		if !ok {
+			continue
+		}
+
+		// linear 合约一张为一个币
+		marketSymbol.LotSize = common.DecimalFromString(vv.LotSizeFilter.QtyStep)
+		marketSymbol.MinNotional = common.DecimalFromString(vv.LotSizeFilter.MinNotionalValue)
+		marketSymbol.ContractValue = common.NewDecimal(1, 0)
+		marketSymbol.Settle = vv.SettleCoin
+
+		switch vv.ContractType {
+		case contractLinearPerpetual:
+			marketSymbol.UnifiedSymbol = symbols.NewSymbol(vv.BaseCoin, vv.QuoteCoin, vv.SettleCoin).String()
+			perps = append(perps, marketSymbol)
+		case contractLinearFutures:
+			marketSymbol.ExpiryTime = parseMillis(vv.DeliveryTime)
+			marketSymbol.UnifiedSymbol = symbols.NewSymbol(vv.BaseCoin, vv.QuoteCoin, vv.SettleCoin).WithExpiry(marketSymbol.ExpiryTime).String()
+			deliveries = append(deliveries, marketSymbol)
+		}
+	}
+
+	log.Info("bybit feature symbols loaded", "perp", len(perps), "delivery", len(deliveries))
+	return errors.Join(
+		c.db.MarketSymbol.SyncMarketSymbols(string(common.ByBit), symbol2.InstTypeFeature, perps),
+		c.db.MarketSymbol.SyncMarketSymbols(string(common.ByBit), symbol2.InstTypeDelivery, deliveries),
+	)
+}
+
+// getInstruments 按分页游标请求全部交易对
+func (c *Client) getInstruments(category string) ([]instrument, error) {
+	header := make(map[string]string, 0)
+	list := make([]instrument, 0)
+	cursor := ""
+
+	for {
+		params := url.Values{}
+		params.Set("category", category)
+		params.Set("limit", fmt.Sprint(instrumentsLimit))
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+
+		resp, err := c.resty.GET(context.Background(), instrumentsPath+"?"+params.Encode(), header)
+		if err != nil {
+			return nil, err
+		}
+		if !resp.IsSuccess() || resp.StatusCode != 200 {
+			return nil, fmt.Errorf("request failed: status code %d, body=%s", resp.StatusCode, string(resp.Body))
+		}
+
+		var rsp instrumentsRsp
+		if err := json.Unmarshal(resp.Body, &rsp); err != nil {
+			return nil, fmt.Errorf("invalid data format: %w", err)
+		}
+		if rsp.RetCode != 0 {
+			return nil, fmt.Errorf("api error: retCode=%d, retMsg=%s", rsp.RetCode, rsp.RetMsg)
+		}
 
+		list = append(list, rsp.Result.List...)
+		if rsp.Result.NextPageCursor == "" || rsp.Result.NextPageCursor == cursor {
+			return list, nil
 		}
-		log.Info("====", len(symbols))
+		cursor = rsp.Result.NextPageCursor
 	}
-	return errors.New("bybit: init feature symbol error")
+}
+
+// toMarketSymbol 转换交易对信息, 已下架或交割中的交易对返回 false
+func toMarketSymbol(vv instrument) (symbol2.MarketSymbol, bool) {
+	status, ok := symbolStatus(vv.Status)
+	if !ok || vv.Symbol == "" {
+		return symbol2.MarketSymbol{}, false
+	}
+
+	return symbol2.MarketSymbol{
+		Symbol:        vv.Symbol,
+		UnifiedSymbol: symbols.NewSymbol(vv.BaseCoin, vv.QuoteCoin, "").String(),
+		ChainId:       symbol2.CexChainId,
+		Base:          vv.BaseCoin,
+		Quote:         vv.QuoteCoin,
+		Status:        status,
+		TickSize:      common.DecimalFromString(vv.PriceFilter.TickSize),
+		MinQty:        common.DecimalFromString(vv.LotSizeFilter.MinOrderQty),
+		ListTime:      parseMillis(vv.LaunchTime),
+		Timestamp:     uint64(time.Now().UnixMilli()),
+	}, true
+}
+
+// symbolStatus Trading 可交易, PreLaunch 待上线, Delivering、Closed 视为下架
+func symbolStatus(status string) (string, bool) {
+	switch status {
+	case "Trading":
+		return symbol2.StatusTrading, true
+	case "PreLaunch":
+		return symbol2.StatusPending, true
+	default:
+		return "", false
+	}
+}
+
+func parseMillis(value string) uint64 {
+	millis, _ := strconv.ParseUint(value, 10, 64)
+	return millis
 }
@@ -0,0 +1,27 @@
+package gateio
+
+import (
+	"errors"
+	"github.com/339-Labs/exchange-market/api/service"
+	"github.com/339-Labs/exchange-market/common/client"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/database"
+)
+
+var errBlockChainHTTPError = errors.New("GateIo http request error")
+
+type Client struct {
+	config config.Config
+	db     database.DB
+	resty  client.REST
+}
+
+func NewClient(config config.Config, db database.DB) service.HandlerSymbolAdaptor {
+	rest := client.NewRESTClient(config.ExchangeConfig.GateIo.ApiUrl)
+
+	return &Client{
+		config: config,
+		db:     db,
+		resty:  rest,
+	}
+}
@@ -0,0 +1,183 @@
+package gateio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/client"
+	"github.com/339-Labs/exchange-market/common/symbols"
+	symbol2 "github.com/339-Labs/exchange-market/database/symbol"
+	"github.com/339-Labs/exchange-market/exchange/cex/gateio/constants"
+	"github.com/ethereum/go-ethereum/log"
+	"strings"
+	"time"
+)
+
+// currencyPair 现货交易对, 时间为秒
+type currencyPair struct {
+	Id              string `json:"id"`
+	Base            string `json:"base"`
+	Quote           string `json:"quote"`
+	MinBaseAmount   string `json:"min_base_amount"`
+	MinQuoteAmount  string `json:"min_quote_amount"`
+	AmountPrecision int    `json:"amount_precision"`
+	Precision       int    `json:"precision"`
+	TradeStatus     string `json:"trade_status"`
+	BuyStart        int64  `json:"buy_start"`
+}
+
+// contract 永续和交割合约, 交割合约名称为 BTC_USDT_20200814, 时间为秒
+type contract struct {
+	Name             string  `json:"name"`
+	Underlying       string  `json:"underlying"` // 交割合约的标的 BTC_USDT
+	QuantoMultiplier string  `json:"quanto_multiplier"`
+	OrderPriceRound  string  `json:"order_price_round"`
+	OrderSizeMin     int64   `json:"order_size_min"`
+	InDelisting      bool    `json:"in_delisting"`
+	CreateTime       float64 `json:"create_time"`
+	ExpireTime       int64   `json:"expire_time"`
+}
+
+func (c *Client) InitSpotSymbol() error {
+
+	var list []currencyPair
+	if err := c.getArray(constants.SpotCurrencyPairsPath, &list); err != nil {
+		return fmt.Errorf("gateio: init spot symbol: %w", err)
+	}
+
+	marketSymbols := make([]symbol2.MarketSymbol, 0, len(list))
+	for _, vv := range list {
+
+		status, ok := spotStatus(vv.TradeStatus)
+		if vv.Id == "" || !ok {
+			continue
+		}
+
+		var marketSymbol = symbol2.MarketSymbol{
+			Symbol:        vv.Id,
+			UnifiedSymbol: symbols.NewSymbol(vv.Base, vv.Quote, "").String(),
+			ChainId:       symbol2.CexChainId,
+			Base:          vv.Base,
+			Quote:         vv.Quote,
+			Status:        status,
+			TickSize:      common.PrecisionStep(vv.Precision),
+			LotSize:       common.PrecisionStep(vv.AmountPrecision),
+			MinQty:        common.DecimalFromString(vv.MinBaseAmount),
+			MinNotional:   common.DecimalFromString(vv.MinQuoteAmount),
+			ListTime:      uint64(vv.BuyStart) * 1000,
+			Timestamp:     uint64(time.Now().UnixMilli()),
+		}
+		marketSymbols = append(marketSymbols, marketSymbol)
+	}
+
+	log.Info("gateio spot symbols loaded", "count", len(marketSymbols))
+	return c.db.MarketSymbol.SyncMarketSymbols(string(common.GateIo), symbol2.InstTypeSpot, marketSymbols)
+}
+
+// InitFeatureSymbol 加载USDT永续和交割合约
+func (c *Client) InitFeatureSymbol() error {
+
+	var perpList []contract
+	if err := c.getArray(constants.FuturesContractsPath, &perpList); err != nil {
+		return fmt.Errorf("gateio: init feature symbol: %w", err)
+	}
+	var deliveryList []contract
+	if err := c.getArray(constants.DeliveryContractsPath, &deliveryList); err != nil {
+		return fmt.Errorf("gateio: init delivery symbol: %w", err)
+	}
+
+	perps := make([]symbol2.MarketSymbol, 0, len(perpList))
+	for _, vv := range perpList {
+		// 合约名称 BTC_USDT
+		if marketSymbol, ok := toMarketSymbol(vv, vv.Name); ok {
+			perps = append(perps, marketSymbol)
+		}
+	}
+
+	deliveries := make([]symbol2.MarketSymbol, 0, len(deliveryList))
+	for _, vv := range deliveryList {
+		if marketSymbol, ok := toMarketSymbol(vv, vv.Underlying); ok {
+			marketSymbol.ExpiryTime = uint64(vv.ExpireTime) * 1000
+			deliveries = append(deliveries, marketSymbol)
+		}
+	}
+
+	log.Info("gateio feature symbols loaded", "perp", len(perps), "delivery", len(deliveries))
+	return errors.Join(
+		c.db.MarketSymbol.SyncMarketSymbols(string(common.GateIo), symbol2.InstTypeFeature, perps),
+		c.db.MarketSymbol.SyncMarketSymbols(string(common.GateIo), symbol2.InstTypeDelivery, deliveries),
+	)
+}
+
+// toMarketSymbol 转换合约信息, 下架中的合约返回 false; 合约数量单位为张, 一张为 quanto_multiplier 个币
+func toMarketSymbol(vv contract, pair string) (symbol2.MarketSymbol, bool) {
+	baseCoin, quoteCoin, ok := splitSymbol(pair)
+	if !ok || vv.InDelisting {
+		return symbol2.MarketSymbol{}, false
+	}
+
+	return symbol2.MarketSymbol{
+		Symbol:        vv.Name,
+		UnifiedSymbol: symbols.NewSymbol(baseCoin, quoteCoin, quoteCoin).WithExpiry(uint64(vv.ExpireTime) * 1000).String(),
+		ChainId:       symbol2.CexChainId,
+		Base:          baseCoin,
+		Quote:         quoteCoin,
+		Status:        symbol2.StatusTrading,
+		TickSize:      common.DecimalFromString(vv.OrderPriceRound),
+		LotSize:       common.NewDecimal(1, 0),
+		MinQty:        common.NewDecimal(vv.OrderSizeMin, 0),
+		ContractValue: common.DecimalFromString(vv.QuantoMultiplier),
+		Settle:        quoteCoin,
+		ListTime:      uint64(vv.CreateTime * 1000),
+		Timestamp:     uint64(time.Now().UnixMilli()),
+	}, true
+}
+
+// spotStatus tradable 可交易, buyable、sellable 只能单向交易视为暂停, untradable 视为下架
+func spotStatus(status string) (string, bool) {
+	switch status {
+	case constants.SpotTradable:
+		return symbol2.StatusTrading, true
+	case constants.SpotBuyable, constants.SpotSellable:
+		return symbol2.StatusSuspended, true
+	default:
+		return "", false
+	}
+}
+
+// getArray 请求数组接口并解析到 out
+func (c *Client) getArray(path string, out interface{}) error {
+	header := make(map[string]string, 0)
+	resp, err := c.resty.GET(context.Background(), path, header)
+	if err != nil {
+		return err
+	}
+	return parseArrayResponse(resp, out)
+}
+
+// parseArrayResponse gateio 接口成功时直接返回数组, 失败时返回 {"label":"","message":""}
+func parseArrayResponse(resp *client.RESTResponse, out interface{}) error {
+	if !resp.IsSuccess() || resp.StatusCode != 200 {
+		var rspErr struct {
+			Label   string `json:"label"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(resp.Body, &rspErr)
+		return fmt.Errorf("%w: status code %d, label=%s, message=%s", errBlockChainHTTPError, resp.StatusCode, rspErr.Label, rspErr.Message)
+	}
+
+	if err := json.Unmarshal(resp.Body, out); err != nil {
+		return fmt.Errorf("invalid data format: %w", err)
+	}
+	return nil
+}
+
+func splitSymbol(symbol string) (base, quote string, ok bool) {
+	parts := strings.Split(symbol, "_")
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
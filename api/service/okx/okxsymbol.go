@@ -2,98 +2,160 @@ package okx
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/symbols"
 	symbol2 "github.com/339-Labs/exchange-market/database/symbol"
 	"github.com/ethereum/go-ethereum/log"
+	"strconv"
 	"strings"
 	"time"
 )
 
-func (c *Client) InitSpotSymbol() error {
+// instrument 交易产品信息, 时间为毫秒字符串
+type instrument struct {
+	InstId     string `json:"instId"`
+	BaseCcy    string `json:"baseCcy"`
+	QuoteCcy   string `json:"quoteCcy"`
+	SettleCcy  string `json:"settleCcy"`
+	InstFamily string `json:"instFamily"`
+	CtVal      string `json:"ctVal"`
+	TickSz     string `json:"tickSz"`
+	LotSz      string `json:"lotSz"`
+	MinSz      string `json:"minSz"`
+	ListTime   string `json:"listTime"`
+	ExpTime    string `json:"expTime"`
+	State      string `json:"state"`
+}
 
-	header := make(map[string]string, 0)
-	resp, err := c.resty.GET(context.Background(), "/api/v5/public/instruments?instType=SPOT", header)
+// instrumentsRsp /api/v5/public/instruments 响应
+type instrumentsRsp struct {
+	Code string       `json:"code"`
+	Msg  string       `json:"msg"`
+	Data []instrument `json:"data"`
+}
+
+func (c *Client) InitSpotSymbol() error {
+	rsp, err := c.getInstruments("/api/v5/public/instruments?instType=SPOT")
 	if err != nil {
-		return err
+		return fmt.Errorf("okx: init spot symbol: %w", err)
 	}
 
-	if resp.IsSuccess() && resp.StatusCode == 200 {
-		rspMap := common.BytesToMap(resp.Body)
-		if retCode, ok := rspMap["code"].(string); ok && retCode == "0" {
-
-			if list, ok1 := rspMap["data"].([]map[string]interface{}); ok1 {
-				symbols := make([]symbol2.MarketSymbol, 0, len(list))
-				for _, vv := range list {
+	symbols := make([]symbol2.MarketSymbol, 0, len(rsp.Data))
+	for _, vv := range rsp.Data {
+		if marketSymbol, ok := toMarketSymbol(vv, vv.BaseCcy, vv.QuoteCcy); ok {
+			symbols = append(symbols, marketSymbol)
+		}
+	}
 
-					symbol, _ := vv["instId"].(string)
-					baseCoin, _ := vv["baseCcy"].(string)
-					quoteCoin, _ := vv["quoteCcy"].(string)
+	log.Info("okx spot symbols loaded", "count", len(symbols))
+	return c.db.MarketSymbol.SyncMarketSymbols(string(common.Okx), symbol2.InstTypeSpot, symbols)
+}
 
-					var marketSymbol = symbol2.MarketSymbol{
-						Symbol:        symbol,
-						UnifiedSymbol: baseCoin + "/" + quoteCoin,
-						InstType:      "Spot",
-						Exchange:      "Okx",
-						ChainId:       "999999",
-						Base:          baseCoin,
-						Quote:         quoteCoin,
-						Timestamp:     uint64(time.Now().UnixMilli()),
-					}
-					symbols = append(symbols, marketSymbol)
+// InitFeatureSymbol 加载永续和交割合约
+func (c *Client) InitFeatureSymbol() error {
+	perps, err := c.loadContracts("/api/v5/public/instruments?instType=SWAP")
+	if err != nil {
+		return fmt.Errorf("okx: init feature symbol: %w", err)
+	}
+	deliveries, err := c.loadContracts("/api/v5/public/instruments?instType=FUTURES")
+	if err != nil {
+		return fmt.Errorf("okx: init delivery symbol: %w", err)
+	}
 
-				}
-				log.Info("====", len(symbols))
+	log.Info("okx feature symbols loaded", "perp", len(perps), "delivery", len(deliveries))
+	return errors.Join(
+		c.db.MarketSymbol.SyncMarketSymbols(string(common.Okx), symbol2.InstTypeFeature, perps),
+		c.db.MarketSymbol.SyncMarketSymbols(string(common.Okx), symbol2.InstTypeDelivery, deliveries),
+	)
+}
 
-			}
+// loadContracts 合约的币种从 instFamily 中解析
+func (c *Client) loadContracts(path string) ([]symbol2.MarketSymbol, error) {
+	rsp, err := c.getInstruments(path)
+	if err != nil {
+		return nil, err
+	}
 
+	symbols := make([]symbol2.MarketSymbol, 0, len(rsp.Data))
+	for _, vv := range rsp.Data {
+		base, quote, ok := splitSymbol(vv.InstFamily)
+		if !ok {
+			continue
+		}
+		if marketSymbol, ok := toMarketSymbol(vv, base, quote); ok {
+			marketSymbol.ContractValue = common.DecimalFromString(vv.CtVal)
+			marketSymbol.Settle = vv.SettleCcy
+			marketSymbol.ExpiryTime = parseMillis(vv.ExpTime)
+			symbols = append(symbols, marketSymbol)
 		}
-
 	}
-	return errors.New("okx: init spot symbol error")
+	return symbols, nil
 }
 
-func (c *Client) InitFeatureSymbol() error {
+// getInstruments 请求交易产品基础信息
+func (c *Client) getInstruments(path string) (*instrumentsRsp, error) {
 	header := make(map[string]string, 0)
-	resp, err := c.resty.GET(context.Background(), "/api/v5/public/instruments?instType=SWAP", header)
+	resp, err := c.resty.GET(context.Background(), path, header)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if resp.IsSuccess() && resp.StatusCode == 200 {
-		rspMap := common.BytesToMap(resp.Body)
-		if retCode, ok := rspMap["code"].(string); ok && retCode == "0" {
-
-			if list, ok1 := rspMap["data"].([]map[string]interface{}); ok1 {
-				symbols := make([]symbol2.MarketSymbol, 0, len(list))
-				for _, vv := range list {
-
-					symbol, _ := vv["instId"].(string)
-
-					instFamily, _ := vv["instFamily"].(string)
-					if base, quote, ok := splitSymbol(instFamily); ok {
+	if !resp.IsSuccess() || resp.StatusCode != 200 {
+		return nil, fmt.Errorf("request failed: status code %d, body=%s", resp.StatusCode, string(resp.Body))
+	}
 
-						var marketSymbol = symbol2.MarketSymbol{
-							Symbol:        symbol,
-							UnifiedSymbol: base + "/" + quote,
-							InstType:      "Feature",
-							Exchange:      "Okx",
-							ChainId:       "999999",
-							Base:          base,
-							Quote:         quote,
-							Timestamp:     uint64(time.Now().UnixMilli()),
-						}
-						symbols = append(symbols, marketSymbol)
+	var rsp instrumentsRsp
+	if err := json.Unmarshal(resp.Body, &rsp); err != nil {
+		return nil, fmt.Errorf("invalid data format: %w", err)
+	}
+	if rsp.Code != "0" {
+		return nil, fmt.Errorf("api error: code=%s, msg=%s", rsp.Code, rsp.Msg)
+	}
+	return &rsp, nil
+}
 
-					}
-				}
-				log.Info("====", len(symbols))
+// toMarketSymbol 转换交易产品信息, 状态无法识别的产品返回 false
+func toMarketSymbol(vv instrument, base, quote string) (symbol2.MarketSymbol, bool) {
+	status, ok := symbolStatus(vv.State)
+	if !ok || vv.InstId == "" {
+		return symbol2.MarketSymbol{}, false
+	}
 
-			}
+	return symbol2.MarketSymbol{
+		Symbol:        vv.InstId,
+		UnifiedSymbol: symbols.NewSymbol(base, quote, vv.SettleCcy).WithExpiry(parseMillis(vv.ExpTime)).String(),
+		ChainId:       symbol2.CexChainId,
+		Base:          base,
+		Quote:         quote,
+		Status:        status,
+		TickSize:      common.DecimalFromString(vv.TickSz),
+		LotSize:       common.DecimalFromString(vv.LotSz),
+		MinQty:        common.DecimalFromString(vv.MinSz),
+		ListTime:      parseMillis(vv.ListTime),
+		Timestamp:     uint64(time.Now().UnixMilli()),
+	}, true
+}
 
-		}
+// symbolStatus live 可交易, suspend 暂停, preopen 待上线, test 为测试产品不加载
+func symbolStatus(state string) (string, bool) {
+	switch state {
+	case "live":
+		return symbol2.StatusTrading, true
+	case "suspend":
+		return symbol2.StatusSuspended, true
+	case "preopen":
+		return symbol2.StatusPending, true
+	default:
+		return "", false
 	}
-	return errors.New("okx: init feature symbol error")
+}
+
+func parseMillis(value string) uint64 {
+	millis, _ := strconv.ParseUint(value, 10, 64)
+	return millis
 }
 
 func splitSymbol(symbol string) (base, quote string, ok bool) {
@@ -2,16 +2,25 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/339-Labs/exchange-market/api"
+	"github.com/339-Labs/exchange-market/common/arbitrage"
 	"github.com/339-Labs/exchange-market/common/cliapp"
+	"github.com/339-Labs/exchange-market/common/event"
+	"github.com/339-Labs/exchange-market/common/funding"
+	"github.com/339-Labs/exchange-market/common/index"
+	"github.com/339-Labs/exchange-market/common/maps"
 	"github.com/339-Labs/exchange-market/common/opio"
 	"github.com/339-Labs/exchange-market/config"
 	"github.com/339-Labs/exchange-market/database"
 	flags2 "github.com/339-Labs/exchange-market/flags"
 	"github.com/339-Labs/exchange-market/redis"
 	"github.com/339-Labs/exchange-market/service"
+	"github.com/339-Labs/exchange-market/worker"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/urfave/cli/v2"
+	"strings"
 )
 
 func NewCli(GitCommit string, GitData string) *cli.App {
@@ -28,28 +37,18 @@ func NewCli(GitCommit string, GitData string) *cli.App {
 				Action:      runMigrations,
 			},
 			{
-				Name:        "run bn",
-				Description: fmt.Sprintf("run bn task"),
-				Flags:       flags,
-				Action:      cliapp.LifecycleCmd(runBnTask),
+				Name:        "run",
+				Usage:       "run exchanges, e.g. run --exchanges bn,okx or run --all",
+				Description: fmt.Sprintf("run exchange tasks in one process, a crashed exchange is restarted alone"),
+				Flags:       flags2.RunFlags,
+				Action:      cliapp.LifecycleCmd(runExchanges),
 			},
 			{
-				Name:        "run okx",
-				Description: fmt.Sprintf("run okx task"),
-				Flags:       flags,
-				Action:      cliapp.LifecycleCmd(runOkxTask),
-			},
-			{
-				Name:        "run bybit",
-				Description: fmt.Sprintf("run bybit task"),
-				Flags:       flags,
-				Action:      cliapp.LifecycleCmd(runBybitTask),
-			},
-			{
-				Name:        "run bitget",
-				Description: fmt.Sprintf("run bitget task"),
-				Flags:       flags,
-				Action:      cliapp.LifecycleCmd(runBitgetTask),
+				Name:        "sync-symbols",
+				Usage:       "sync symbols into market_symbol, e.g. sync-symbols --all --interval 1h",
+				Description: fmt.Sprintf("load symbols from exchanges on a schedule, symbols no longer listed are marked delisted"),
+				Flags:       flags2.SyncSymbolsFlags,
+				Action:      runSyncSymbols,
 			},
 		},
 	}
@@ -78,13 +77,30 @@ func runMigrations(ctx *cli.Context) error {
 	return db.ExecuteSQLMigration(config.Migrations)
 }
 
-func runBnTask(ctx *cli.Context, shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error) {
+// exchangeNames --exchanges 或 --all 指定的交易所
+func exchangeNames(ctx *cli.Context) ([]string, error) {
+	names := ctx.StringSlice(flags2.ExchangesFlag.Name)
+	if ctx.Bool(flags2.AllExchangesFlag.Name) {
+		names = service.VenueNames
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no exchange specified, use --exchanges or --all")
+	}
+	return names, nil
+}
+
+func runExchanges(ctx *cli.Context, shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error) {
+	names, err := exchangeNames(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	config, err := config.NewConfig(ctx)
 	if err != nil {
 		log.Error("failed to load config", "err", err)
 		return nil, err
 	}
+
 	db, err := database.NewDB(&config.SlaveDBConfig)
 	if err != nil {
 		log.Error("failed to connect to database", "err", err)
@@ -93,74 +109,144 @@ func runBnTask(ctx *cli.Context, shutdown context.CancelCauseFunc) (cliapp.Lifec
 
 	redis, err := redis.NewRedisClient(config.RedisConfig)
 	if err != nil {
-		log.Error("failed to connect to database", "err", err)
+		log.Error("failed to connect to redis", "err", err)
+		_ = db.Close()
 		return nil, err
 	}
 
-	return service.NewHandlerBN(config, db, redis, shutdown)
-}
+	supervisor := service.NewSupervisor()
+	supervisor.OnStopped = func() error {
+		return errors.Join(db.Close(), redis.Close())
+	}
 
-func runOkxTask(ctx *cli.Context, shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error) {
+	registry := maps.NewRegistry()
+	// 各交易所的统一行情事件, 消费方通过 Add 注册
+	sink := event.NewMulti()
+	registered := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if registered[name] {
+			continue
+		}
+		factory, err := service.NewVenueFactory(name, config, db, redis, registry, sink)
+		if err != nil {
+			_ = supervisor.OnStopped()
+			return nil, err
+		}
+		supervisor.Register(name, factory)
+		registered[name] = true
+	}
 
-	config, err := config.NewConfig(ctx)
-	if err != nil {
-		log.Error("failed to load config", "err", err)
-		return nil, err
+	// 跨交易所指数价格, 未配置交易对时不启动; 权重统计和计算结果在重启之间共享
+	indexes := index.NewStore()
+	if len(config.IndexConfig.Symbols) > 0 {
+		stats := index.NewStats()
+		sink.Add(stats)
+		supervisor.Register("index", func(shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error) {
+			return service.NewHandlerIndex(config, redis, registry, stats, indexes, shutdown)
+		})
 	}
-	db, err := database.NewDB(&config.SlaveDBConfig)
-	if err != nil {
-		log.Error("failed to connect to database", "err", err)
-		return nil, err
+
+	// 跨交易所套利机会, 最优买卖价在重启之间共享
+	if config.ArbitrageConfig.Enabled {
+		quotes := arbitrage.NewQuotes()
+		sink.Add(quotes)
+		supervisor.Register("arbitrage", func(shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error) {
+			return service.NewHandlerArbitrage(config, db, quotes, shutdown)
+		})
 	}
 
-	redis, err := redis.NewRedisClient(config.RedisConfig)
-	if err != nil {
-		log.Error("failed to connect to database", "err", err)
-		return nil, err
+	// 资金费率分析, 结算跟踪和分析结果在重启之间共享
+	fundingStore := funding.NewStore()
+	if config.FundingConfig.Enabled {
+		tracker := funding.NewTracker()
+		sink.Add(tracker)
+		supervisor.Register("funding", func(shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error) {
+			return service.NewHandlerFunding(config, db, registry, tracker, fundingStore, shutdown)
+		})
 	}
 
-	return service.NewHandlerOkx(config, db, redis, shutdown)
+	// Uniswap V2 交易对发现和定价, 未配置节点或 factory 时不启动
+	if uniswapV2 := config.ExchangeConfig.UniswapV2; uniswapV2.RpcUrl != "" && uniswapV2.Factory != "" {
+		supervisor.Register("uniswapv2", func(shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error) {
+			return service.NewHandlerUniswapV2(config, db, redis, registry, shutdown)
+		})
+	}
+
+	// Uniswap V3 池子发现和定价, 未配置节点或 factory 时不启动
+	if uniswapV3 := config.ExchangeConfig.UniswapV3; uniswapV3.RpcUrl != "" && uniswapV3.Factory != "" {
+		supervisor.Register("uniswapv3", func(shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error) {
+			return service.NewHandlerUniswapV3(config, db, redis, registry, shutdown)
+		})
+	}
+
+	// 查询接口, 端口未配置时不启动
+	if config.HttpServerConfig.Port > 0 {
+		supervisor.Register("api", func(shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error) {
+			return api.NewApi(config, db, redis, registry, indexes, fundingStore, shutdown)
+		})
+	}
+
+	return supervisor, nil
 }
 
-func runBybitTask(ctx *cli.Context, shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error) {
+func runSyncSymbols(ctx *cli.Context) error {
+	if !ctx.Bool(flags2.SymbolSyncOnceFlag.Name) {
+		return cliapp.LifecycleCmd(runSymbolSyncTask)(ctx)
+	}
+
+	names, err := exchangeNames(ctx)
+	if err != nil {
+		return err
+	}
 
 	config, err := config.NewConfig(ctx)
 	if err != nil {
 		log.Error("failed to load config", "err", err)
-		return nil, err
+		return err
 	}
+
 	db, err := database.NewDB(&config.SlaveDBConfig)
 	if err != nil {
 		log.Error("failed to connect to database", "err", err)
-		return nil, err
+		return err
 	}
+	defer func(db *database.DB) {
+		err := db.Close()
+		if err != nil {
+			log.Error("fail to close database", "err", err)
+		}
+	}(db)
 
-	redis, err := redis.NewRedisClient(config.RedisConfig)
+	loaders, err := service.NewSymbolLoaders(names, config, db)
 	if err != nil {
-		log.Error("failed to connect to database", "err", err)
-		return nil, err
+		return err
 	}
-
-	return service.NewHandlerByBit(config, db, redis, shutdown)
+	return worker.SyncSymbols(loaders)
 }
 
-func runBitgetTask(ctx *cli.Context, shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error) {
+func runSymbolSyncTask(ctx *cli.Context, shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error) {
+	names, err := exchangeNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	config, err := config.NewConfig(ctx)
 	if err != nil {
 		log.Error("failed to load config", "err", err)
 		return nil, err
 	}
+
 	db, err := database.NewDB(&config.SlaveDBConfig)
 	if err != nil {
 		log.Error("failed to connect to database", "err", err)
 		return nil, err
 	}
 
-	redis, err := redis.NewRedisClient(config.RedisConfig)
+	handler, err := service.NewHandlerSymbolSync(config, db, names, ctx.Duration(flags2.SymbolSyncIntervalFlag.Name), shutdown)
 	if err != nil {
-		log.Error("failed to connect to database", "err", err)
+		_ = db.Close()
 		return nil, err
 	}
-
-	return service.NewHandlerBitGet(config, db, redis, shutdown)
+	return handler, nil
 }
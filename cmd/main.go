@@ -1,10 +1,23 @@
 package main
 
 import (
+	"context"
+	"github.com/339-Labs/exchange-market/common/opio"
 	"github.com/ethereum/go-ethereum/log"
 	"os"
 )
 
+var (
+	GitCommit = ""
+	GitDate   = ""
+)
+
 func main() {
 	log.SetDefault(log.NewLogger(log.NewTerminalHandlerWithLevel(os.Stdout, log.LevelInfo, true)))
+	app := NewCli(GitCommit, GitDate)
+	ctx := opio.WithInterruptBlocker(context.Background())
+	if err := app.RunContext(ctx, os.Args); err != nil {
+		log.Error("Application failed", "err", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,181 @@
+package arbitrage
+
+import (
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/google/uuid"
+	"time"
+)
+
+const edgeScale = 8 // 价差比例的小数位数
+
+// Options 检测参数
+type Options struct {
+	Fees        map[common.Exchange]common.Decimal // 各交易所的吃单手续费率, 例如 0.001
+	DefaultFee  common.Decimal                     // 未配置的交易所使用的手续费率
+	Threshold   common.Decimal                     // 扣除手续费后的最小价差比例
+	MinDuration time.Duration                      // 价差持续超过该时间才视为机会
+	MaxAge      time.Duration                      // 超过该时间未更新的报价不参与比较
+}
+
+// fee 交易所的手续费率
+func (o Options) fee(exchange common.Exchange) common.Decimal {
+	if fee, ok := o.Fees[exchange]; ok {
+		return fee
+	}
+	return o.DefaultFee
+}
+
+// Opportunity 在 BuyExchange 按卖一价买入、在 SellExchange 按买一价卖出的套利机会
+type Opportunity struct {
+	GUID         uuid.UUID
+	Symbol       string
+	InstType     string
+	BuyExchange  common.Exchange
+	SellExchange common.Exchange
+	BuyPrice     common.Decimal // 买入交易所的卖一价
+	SellPrice    common.Decimal // 卖出交易所的买一价
+	Size         common.Decimal // 两边挂单数量的较小值, 未知时为空
+	GrossEdge    common.Decimal // (SellPrice - BuyPrice) / BuyPrice
+	NetEdge      common.Decimal // 扣除两边手续费后的价差比例
+	MaxNetEdge   common.Decimal // 持续期间的最大净价差比例
+	StartTime    time.Time      // 净价差首次超过阈值的时间
+	EndTime      time.Time      // 机会结束时间, 未结束为零值
+}
+
+// candidate 净价差超过阈值但可能尚未达到最短持续时间的机会
+type candidate struct {
+	opportunity *Opportunity
+	opened      bool
+}
+
+// Detector 比较各交易所的最优买卖价, 净价差持续超过阈值 MinDuration 后打开机会, 低于阈值或换了交易所后关闭
+// 每个交易对同时只跟踪净价差最大的一组交易所, 非并发安全
+type Detector struct {
+	options    Options
+	candidates map[Market]*candidate
+}
+
+// NewDetector 创建检测器
+func NewDetector(options Options) *Detector {
+	return &Detector{
+		options:    options,
+		candidates: make(map[Market]*candidate),
+	}
+}
+
+// Evaluate 根据当前报价更新机会, 返回本次打开和关闭的机会
+func (d *Detector) Evaluate(quotes map[Market][]Quote, now time.Time) ([]*Opportunity, []*Opportunity) {
+	var opened, closed []*Opportunity
+
+	for market, list := range quotes {
+		best := d.best(market, list, now)
+		current, ok := d.candidates[market]
+
+		if ok && (best == nil || best.BuyExchange != current.opportunity.BuyExchange || best.SellExchange != current.opportunity.SellExchange) {
+			if current.opened {
+				current.opportunity.EndTime = now
+				closed = append(closed, current.opportunity)
+			}
+			delete(d.candidates, market)
+			current, ok = nil, false
+		}
+		if best == nil {
+			continue
+		}
+
+		if !ok {
+			best.GUID = uuid.New()
+			best.StartTime = now
+			best.MaxNetEdge = best.NetEdge
+			current = &candidate{opportunity: best}
+			d.candidates[market] = current
+		} else {
+			opportunity := current.opportunity
+			opportunity.BuyPrice, opportunity.SellPrice, opportunity.Size = best.BuyPrice, best.SellPrice, best.Size
+			opportunity.GrossEdge, opportunity.NetEdge = best.GrossEdge, best.NetEdge
+			if best.NetEdge.GreaterThan(opportunity.MaxNetEdge) {
+				opportunity.MaxNetEdge = best.NetEdge
+			}
+		}
+
+		if !current.opened && now.Sub(current.opportunity.StartTime) >= d.options.MinDuration {
+			current.opened = true
+			opened = append(opened, current.opportunity)
+		}
+	}
+
+	// 报价已全部消失的交易对
+	for market, current := range d.candidates {
+		if _, ok := quotes[market]; ok {
+			continue
+		}
+		if current.opened {
+			current.opportunity.EndTime = now
+			closed = append(closed, current.opportunity)
+		}
+		delete(d.candidates, market)
+	}
+	return opened, closed
+}
+
+// CloseAll 关闭所有已打开的机会并清空跟踪状态, 停止检测时调用
+func (d *Detector) CloseAll(now time.Time) []*Opportunity {
+	closed := make([]*Opportunity, 0)
+	for market, current := range d.candidates {
+		if current.opened {
+			current.opportunity.EndTime = now
+			closed = append(closed, current.opportunity)
+		}
+		delete(d.candidates, market)
+	}
+	return closed
+}
+
+// best 净价差最大且超过阈值的一组交易所, 没有时返回nil
+func (d *Detector) best(market Market, quotes []Quote, now time.Time) *Opportunity {
+	var best *Opportunity
+	for _, buy := range quotes {
+		if d.stale(buy, now) {
+			continue
+		}
+		for _, sell := range quotes {
+			if sell.Exchange == buy.Exchange || d.stale(sell, now) || !sell.Bid.GreaterThan(buy.Ask) {
+				continue
+			}
+
+			gross := sell.Bid.Sub(buy.Ask).DivRound(buy.Ask, edgeScale)
+			net := gross.Sub(d.options.fee(buy.Exchange)).Sub(d.options.fee(sell.Exchange))
+			if net.LessThan(d.options.Threshold) || (best != nil && !net.GreaterThan(best.NetEdge)) {
+				continue
+			}
+			best = &Opportunity{
+				Symbol:       market.Symbol,
+				InstType:     market.InstType,
+				BuyExchange:  buy.Exchange,
+				SellExchange: sell.Exchange,
+				BuyPrice:     buy.Ask,
+				SellPrice:    sell.Bid,
+				Size:         minSize(buy.AskSize, sell.BidSize),
+				GrossEdge:    gross,
+				NetEdge:      net,
+			}
+		}
+	}
+	return best
+}
+
+// stale 报价是否过期
+func (d *Detector) stale(quote Quote, now time.Time) bool {
+	return d.options.MaxAge > 0 && now.Sub(quote.UpdatedAt) > d.options.MaxAge
+}
+
+// minSize 较小的挂单数量, 任一边未知时返回未设置
+func minSize(a, b common.Decimal) common.Decimal {
+	if !a.IsSet() || !b.IsSet() {
+		return common.Decimal{}
+	}
+	if a.LessThan(b) {
+		return a
+	}
+	return b
+}
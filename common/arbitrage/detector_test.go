@@ -0,0 +1,61 @@
+package arbitrage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
+)
+
+func TestDetector(t *testing.T) {
+	start := time.Now()
+	detector := NewDetector(Options{
+		Fees:        map[common.Exchange]common.Decimal{common.BN: common.MustParseDecimal("0.0005")},
+		DefaultFee:  common.MustParseDecimal("0.001"),
+		Threshold:   common.MustParseDecimal("0.001"),
+		MinDuration: 2 * time.Second,
+		MaxAge:      5 * time.Second,
+	})
+	market := Market{InstType: common.InstTypeSpot, Symbol: "BTC/USDT"}
+	quotes := func(now time.Time, okxBid string) map[Market][]Quote {
+		return map[Market][]Quote{market: {
+			{Exchange: common.BN, Bid: common.MustParseDecimal("99.9"), Ask: common.MustParseDecimal("100"), AskSize: common.MustParseDecimal("2"), UpdatedAt: now},
+			{Exchange: common.Okx, Bid: common.MustParseDecimal(okxBid), BidSize: common.MustParseDecimal("1"), Ask: common.MustParseDecimal("101"), UpdatedAt: now},
+		}}
+	}
+
+	// 毛价差 0.3%, 扣除 0.05% 和 0.1% 手续费后 0.15%, 未达到最短持续时间
+	if opened, closed := detector.Evaluate(quotes(start, "100.3"), start); len(opened) != 0 || len(closed) != 0 {
+		t.Fatalf("unexpected opportunity %v %v", opened, closed)
+	}
+
+	opened, _ := detector.Evaluate(quotes(start, "100.4"), start.Add(2*time.Second))
+	if len(opened) != 1 {
+		t.Fatalf("expected opened opportunity, got %d", len(opened))
+	}
+	opportunity := opened[0]
+	if opportunity.BuyExchange != common.BN || opportunity.SellExchange != common.Okx || opportunity.NetEdge.String() != "0.00250000" ||
+		opportunity.MaxNetEdge.String() != "0.00250000" || opportunity.Size.String() != "1" {
+		t.Fatalf("unexpected opportunity %+v", opportunity)
+	}
+
+	// 扣除手续费后低于阈值, 机会关闭
+	_, closed := detector.Evaluate(quotes(start, "100.2"), start.Add(3*time.Second))
+	if len(closed) != 1 || closed[0].GUID != opportunity.GUID || closed[0].EndTime.IsZero() {
+		t.Fatalf("expected closed opportunity, got %v", closed)
+	}
+}
+
+func TestQuotes(t *testing.T) {
+	quotes := NewQuotes()
+	header := event.NewHeader(event.Market{Exchange: common.ByBit, InstType: common.InstTypePerp, Symbol: "BTCUSDT", Unified: "BTC/USDT"}, 0)
+	quotes.Emit(&event.Ticker{Header: header, Last: common.MustParseDecimal("100")})
+	quotes.Emit(&event.BBO{Header: header, Bid: common.MustParseDecimal("99"), Ask: common.MustParseDecimal("101")})
+
+	snapshot := quotes.Snapshot()
+	list := snapshot[Market{InstType: common.InstTypePerp, Symbol: "BTC/USDT"}]
+	if len(list) != 1 || list[0].Bid.String() != "99" || list[0].UpdatedAt.IsZero() {
+		t.Fatalf("unexpected quotes %+v", snapshot)
+	}
+}
@@ -0,0 +1,88 @@
+package arbitrage
+
+import (
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
+	"sync"
+	"time"
+)
+
+// Quote 交易所某个交易对的最优买卖价
+type Quote struct {
+	Exchange  common.Exchange
+	Bid       common.Decimal
+	BidSize   common.Decimal
+	Ask       common.Decimal
+	AskSize   common.Decimal
+	UpdatedAt time.Time // 本地接收时间
+}
+
+// Market 统一交易对和产品类型
+type Market struct {
+	InstType string // spot 或 perp
+	Symbol   string // 统一交易对 BASE/QUOTE
+}
+
+// Quotes 从统一行情事件中记录各交易所的最优买卖价, 注册到 event.Multi 使用
+type Quotes struct {
+	mu     sync.RWMutex
+	quotes map[Market]map[common.Exchange]Quote
+}
+
+// NewQuotes 创建最优买卖价缓存
+func NewQuotes() *Quotes {
+	return &Quotes{
+		quotes: make(map[Market]map[common.Exchange]Quote),
+	}
+}
+
+// Emit 只处理带买卖价的 Ticker 和 BBO, 在交易所推送线程中调用
+func (q *Quotes) Emit(e event.Event) {
+	switch e := e.(type) {
+	case *event.Ticker:
+		q.update(&e.Header, e.Bid, e.BidSize, e.Ask, e.AskSize)
+	case *event.BBO:
+		q.update(&e.Header, e.Bid, e.BidSize, e.Ask, e.AskSize)
+	}
+}
+
+// Snapshot 所有交易对的最优买卖价副本
+func (q *Quotes) Snapshot() map[Market][]Quote {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	result := make(map[Market][]Quote, len(q.quotes))
+	for market, venues := range q.quotes {
+		list := make([]Quote, 0, len(venues))
+		for _, quote := range venues {
+			list = append(list, quote)
+		}
+		result[market] = list
+	}
+	return result
+}
+
+// update 买价或卖价缺失时忽略
+func (q *Quotes) update(header *event.Header, bid, bidSize, ask, askSize common.Decimal) {
+	if header.Unified == "" || bid.Sign() <= 0 || ask.Sign() <= 0 {
+		return
+	}
+	market := Market{InstType: header.InstType, Symbol: header.Unified}
+	quote := Quote{
+		Exchange:  header.Exchange,
+		Bid:       bid,
+		BidSize:   bidSize,
+		Ask:       ask,
+		AskSize:   askSize,
+		UpdatedAt: time.Unix(0, header.ReceiveTime),
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	venues, ok := q.quotes[market]
+	if !ok {
+		venues = make(map[common.Exchange]Quote)
+		q.quotes[market] = venues
+	}
+	venues[header.Exchange] = quote
+}
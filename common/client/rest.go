@@ -207,8 +207,8 @@ func (c *RESTClient) SetHeaders(headers map[string]string) {
 	}
 }
 
-// UnmarshalJSON 解析JSON响应
-func (r *RESTResponse) UnmarshalJSON(v interface{}) error {
+// Unmarshal 解析JSON响应
+func (r *RESTResponse) Unmarshal(v interface{}) error {
 	return json.Unmarshal(r.Body, v)
 }
 
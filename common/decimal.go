@@ -0,0 +1,441 @@
+package common
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DivisionPrecision Div 结果保留的小数位数
+const DivisionPrecision = 18
+
+// maxExponent 科学计数法允许的最大指数, 避免 1e999999999 这类输入分配超大整数
+const maxExponent = 1000
+
+var (
+	ErrInvalidDecimal = errors.New("invalid decimal")
+	ErrDivisionByZero = errors.New("decimal division by zero")
+)
+
+var (
+	bigZero = big.NewInt(0)
+	bigTen  = big.NewInt(10)
+)
+
+// RoundMode 舍入方式
+type RoundMode int
+
+const (
+	RoundHalfUp RoundMode = iota // 四舍五入, 0.5 远离零
+	RoundDown                    // 向零截断
+	RoundFloor                   // 向负无穷, 买价按最小变动价位取整
+	RoundCeil                    // 向正无穷, 卖价按最小变动价位取整
+)
+
+// Decimal 定点小数, 值为 coef * 10^-scale, 保留交易所推送的小数位数, 例如 "16500.10"
+// 零值表示未设置, 编码为空字符串; 解析和运算得到的结果总是已设置
+// Decimal 不可变, 运算返回新的值, 比较大小需使用 Cmp / Equal 而不是 ==
+type Decimal struct {
+	coef  *big.Int
+	scale int32
+}
+
+// NewDecimal 创建 coef * 10^-scale, scale 为负数时表示乘以 10^-scale
+func NewDecimal(coef int64, scale int32) Decimal {
+	return NewDecimalFromBigInt(big.NewInt(coef), scale)
+}
+
+// NewDecimalFromBigInt 创建 coef * 10^-scale, 会复制 coef
+func NewDecimalFromBigInt(coef *big.Int, scale int32) Decimal {
+	if coef == nil {
+		coef = bigZero
+	}
+	value := new(big.Int).Set(coef)
+	if scale < 0 {
+		value.Mul(value, pow10(-scale))
+		scale = 0
+	}
+	return Decimal{coef: value, scale: scale}
+}
+
+// NewDecimalFromRat 有理数按 scale 位小数四舍五入
+func NewDecimalFromRat(rat *big.Rat, scale int32) Decimal {
+	if rat == nil {
+		return Decimal{}
+	}
+	num := new(big.Int).Mul(rat.Num(), pow10(max(scale, 0)))
+	return Decimal{coef: divRound(num, rat.Denom(), RoundHalfUp), scale: max(scale, 0)}
+}
+
+// NewDecimalFromFloat 浮点数转换为最短的十进制表示, NaN 和无穷返回未设置
+func NewDecimalFromFloat(value float64) Decimal {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return Decimal{}
+	}
+	return MustParseDecimal(strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// NewDecimalFromBigFloat big.Float 转换为能唯一表示其值的十进制, nil 或无穷返回未设置
+func NewDecimalFromBigFloat(value *big.Float) Decimal {
+	if value == nil || value.IsInf() {
+		return Decimal{}
+	}
+	return MustParseDecimal(value.Text('f', -1))
+}
+
+// ParseDecimal 解析交易所推送的十进制字符串, 支持符号和科学计数法, 例如 "-0.01"、"1.5e-8"
+func ParseDecimal(value string) (Decimal, error) {
+	s := strings.TrimSpace(value)
+	if s == "" {
+		return Decimal{}, fmt.Errorf("%w: empty string", ErrInvalidDecimal)
+	}
+
+	var exp int64
+	if idx := strings.IndexAny(s, "eE"); idx >= 0 {
+		parsed, err := strconv.ParseInt(s[idx+1:], 10, 32)
+		if err != nil || parsed > maxExponent || parsed < -maxExponent {
+			return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, value)
+		}
+		exp = parsed
+		s = s[:idx]
+	}
+
+	negative := false
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	digits := intPart + fracPart
+	if digits == "" || strings.TrimLeft(digits, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, value)
+	}
+
+	coef, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, value)
+	}
+	if negative {
+		coef.Neg(coef)
+	}
+	return NewDecimalFromBigInt(coef, int32(int64(len(fracPart))-exp)), nil
+}
+
+// MustParseDecimal 解析失败时 panic, 只用于常量和测试
+func MustParseDecimal(value string) Decimal {
+	d, err := ParseDecimal(value)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// DecimalFromString 解析交易所推送的字段, 空字符串或无法解析时返回未设置
+func DecimalFromString(value string) Decimal {
+	d, err := ParseDecimal(value)
+	if err != nil {
+		return Decimal{}
+	}
+	return d
+}
+
+// IsSet 是否已设置
+func (d Decimal) IsSet() bool {
+	return d.coef != nil
+}
+
+// Scale 小数位数
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Coefficient 去掉小数点后的整数
+func (d Decimal) Coefficient() *big.Int {
+	return new(big.Int).Set(d.int())
+}
+
+func (d Decimal) Add(other Decimal) Decimal {
+	a, b, scale := align(d, other)
+	return Decimal{coef: a.Add(a, b), scale: scale}
+}
+
+func (d Decimal) Sub(other Decimal) Decimal {
+	a, b, scale := align(d, other)
+	return Decimal{coef: a.Sub(a, b), scale: scale}
+}
+
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.int(), other.int()), scale: d.scale + other.scale}
+}
+
+// Div 除法, 结果保留 DivisionPrecision 位小数, 除数为零时 panic
+func (d Decimal) Div(other Decimal) Decimal {
+	return d.DivRound(other, DivisionPrecision)
+}
+
+// DivRound 除法, 结果按 scale 位小数四舍五入, 除数为零时 panic
+func (d Decimal) DivRound(other Decimal, scale int32) Decimal {
+	if other.Sign() == 0 {
+		panic(ErrDivisionByZero)
+	}
+	scale = max(scale, 0)
+	// d / other = (a * 10^-sa) / (b * 10^-sb), 结果放大 10^scale 后取整
+	num := new(big.Int).Set(d.int())
+	den := new(big.Int).Set(other.int())
+	shift := int64(scale) - int64(d.scale) + int64(other.scale)
+	if shift >= 0 {
+		num.Mul(num, pow10(int32(shift)))
+	} else {
+		den.Mul(den, pow10(int32(-shift)))
+	}
+	return Decimal{coef: divRound(num, den, RoundHalfUp), scale: scale}
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+func (d Decimal) Abs() Decimal {
+	return Decimal{coef: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+// Sign 返回 -1、0 或 1, 未设置视为0
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp 比较大小, 返回 -1、0 或 1
+func (d Decimal) Cmp(other Decimal) int {
+	a, b, _ := align(d, other)
+	return a.Cmp(b)
+}
+
+// Equal 数值相等, "1.0" 与 "1" 相等; 未设置只与未设置相等
+func (d Decimal) Equal(other Decimal) bool {
+	if d.IsSet() != other.IsSet() {
+		return false
+	}
+	return d.Cmp(other) == 0
+}
+
+func (d Decimal) LessThan(other Decimal) bool {
+	return d.Cmp(other) < 0
+}
+
+func (d Decimal) GreaterThan(other Decimal) bool {
+	return d.Cmp(other) > 0
+}
+
+// Round 四舍五入到 scale 位小数, 小数位数不足时保持不变
+func (d Decimal) Round(scale int32) Decimal {
+	return d.RoundScale(scale, RoundHalfUp)
+}
+
+// Truncate 截断到 scale 位小数
+func (d Decimal) Truncate(scale int32) Decimal {
+	return d.RoundScale(scale, RoundDown)
+}
+
+// RoundScale 按 mode 舍入到 scale 位小数, 小数位数不足时保持不变
+func (d Decimal) RoundScale(scale int32, mode RoundMode) Decimal {
+	if !d.IsSet() || scale >= d.scale {
+		return d
+	}
+	scale = max(scale, 0)
+	return Decimal{coef: divRound(d.coef, pow10(d.scale-scale), mode), scale: scale}
+}
+
+// RoundStep 按步长取整, 用于价格对齐最小变动价位、数量对齐数量步长, 结果的小数位数与步长一致
+// 步长未设置或不大于0时返回原值
+func (d Decimal) RoundStep(step Decimal, mode RoundMode) Decimal {
+	if !d.IsSet() || step.Sign() <= 0 {
+		return d
+	}
+	a, b, _ := align(d, step)
+	count := divRound(a, b, mode)
+	return Decimal{coef: count.Mul(count, step.coef), scale: step.scale}
+}
+
+// Rat 转换为有理数
+func (d Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.int(), pow10(d.scale))
+}
+
+// Float64 转换为浮点数, 可能丢失精度
+func (d Decimal) Float64() float64 {
+	value, _ := strconv.ParseFloat(d.String(), 64)
+	return value
+}
+
+// String 十进制字符串, 不使用科学计数法, 未设置时为空字符串
+func (d Decimal) String() string {
+	if !d.IsSet() {
+		return ""
+	}
+
+	digits := new(big.Int).Abs(d.coef).String()
+	if d.scale > 0 {
+		if pad := int(d.scale) - len(digits) + 1; pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		point := len(digits) - int(d.scale)
+		digits = digits[:point] + "." + digits[point:]
+	}
+	if d.coef.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// StringFixed 四舍五入到 scale 位小数, 不足时补零, 例如 "1.5" -> "1.50"
+func (d Decimal) StringFixed(scale int32) string {
+	if !d.IsSet() {
+		return ""
+	}
+	rounded := d.Round(scale)
+	if scale > rounded.scale {
+		rounded = Decimal{coef: new(big.Int).Mul(rounded.coef, pow10(scale-rounded.scale)), scale: scale}
+	}
+	return rounded.String()
+}
+
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalText(text []byte) error {
+	return d.setString(string(text))
+}
+
+// MarshalJSON 编码为字符串, 避免精度丢失
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON 支持字符串和数字, null 和空字符串为未设置
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		*d = Decimal{}
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+	return d.setString(text)
+}
+
+// MarshalBinary redis 写入时使用
+func (d Decimal) MarshalBinary() ([]byte, error) {
+	return d.MarshalText()
+}
+
+func (d *Decimal) UnmarshalBinary(data []byte) error {
+	return d.setString(string(data))
+}
+
+// ScanRedis redis 读取到结构体时使用
+func (d *Decimal) ScanRedis(value string) error {
+	return d.setString(value)
+}
+
+// Value 写入数据库, 未设置时为空字符串, 与 VARCHAR NOT NULL 列兼容
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan 从数据库读取, 支持字符串、数字和 NULL
+func (d *Decimal) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*d = Decimal{}
+		return nil
+	case string:
+		return d.setString(v)
+	case []byte:
+		return d.setString(string(v))
+	case int64:
+		*d = NewDecimal(v, 0)
+		return nil
+	case float64:
+		*d = NewDecimalFromFloat(v)
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported scan type %T", ErrInvalidDecimal, value)
+}
+
+// setString 空字符串为未设置
+func (d *Decimal) setString(value string) error {
+	if strings.TrimSpace(value) == "" {
+		*d = Decimal{}
+		return nil
+	}
+	parsed, err := ParseDecimal(value)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// int 系数, 未设置时为0, 返回值不可修改
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return bigZero
+	}
+	return d.coef
+}
+
+// align 两个数放大到相同小数位数后的系数, 返回新分配的整数
+func align(a, b Decimal) (*big.Int, *big.Int, int32) {
+	x, y := new(big.Int).Set(a.int()), new(big.Int).Set(b.int())
+	switch {
+	case a.scale > b.scale:
+		y.Mul(y, pow10(a.scale-b.scale))
+		return x, y, a.scale
+	case b.scale > a.scale:
+		x.Mul(x, pow10(b.scale-a.scale))
+		return x, y, b.scale
+	}
+	return x, y, a.scale
+}
+
+// divRound 整数除法按 mode 取整, den 不为0
+func divRound(num, den *big.Int, mode RoundMode) *big.Int {
+	if den.Sign() < 0 {
+		num, den = new(big.Int).Neg(num), new(big.Int).Neg(den)
+	}
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() == 0 {
+		return quo
+	}
+
+	switch mode {
+	case RoundHalfUp:
+		if new(big.Int).Lsh(new(big.Int).Abs(rem), 1).Cmp(den) >= 0 {
+			quo.Add(quo, big.NewInt(int64(num.Sign())))
+		}
+	case RoundFloor:
+		if num.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		}
+	case RoundCeil:
+		if num.Sign() > 0 {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return quo
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
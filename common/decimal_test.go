@@ -0,0 +1,127 @@
+package common
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	cases := map[string]string{
+		"16500.10": "16500.10",
+		"-0.01":    "-0.01",
+		"+3":       "3",
+		".5":       "0.5",
+		"1.5e-8":   "0.000000015",
+		"2E3":      "2000",
+		" 7 ":      "7",
+	}
+	for input, expect := range cases {
+		d, err := ParseDecimal(input)
+		if err != nil || d.String() != expect {
+			t.Fatalf("parse %q: expected %s, got %s, err %v", input, expect, d, err)
+		}
+	}
+
+	for _, input := range []string{"", "abc", "1.2.3", "-", "1e", "1e99999", "--1"} {
+		if _, err := ParseDecimal(input); err == nil {
+			t.Fatalf("expected error for %q", input)
+		}
+	}
+	if DecimalFromString("").IsSet() || DecimalFromString("NaN").IsSet() {
+		t.Fatal("expected unset decimal")
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	a, b := MustParseDecimal("100.25"), MustParseDecimal("0.5")
+
+	if got := a.Add(b).String(); got != "100.75" {
+		t.Fatalf("add: %s", got)
+	}
+	if got := b.Sub(a).String(); got != "-99.75" {
+		t.Fatalf("sub: %s", got)
+	}
+	if got := a.Mul(b).String(); got != "50.125" {
+		t.Fatalf("mul: %s", got)
+	}
+	if got := MustParseDecimal("1").DivRound(MustParseDecimal("3"), 4).String(); got != "0.3333" {
+		t.Fatalf("div: %s", got)
+	}
+	if got := MustParseDecimal("2").Div(MustParseDecimal("3")).String(); got != "0.666666666666666667" {
+		t.Fatalf("div: %s", got)
+	}
+	if !MustParseDecimal("1.0").Equal(MustParseDecimal("1")) || MustParseDecimal("0").Equal(Decimal{}) {
+		t.Fatal("unexpected equality")
+	}
+	if !b.LessThan(a) || a.Cmp(a) != 0 {
+		t.Fatal("unexpected comparison")
+	}
+	if got := NewDecimalFromRat(big.NewRat(2, 3), 3).String(); got != "0.667" {
+		t.Fatalf("rat: %s", got)
+	}
+	if got := NewDecimalFromFloat(0.1).String(); got != "0.1" {
+		t.Fatalf("float: %s", got)
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	if got := MustParseDecimal("-1.25").Round(1).String(); got != "-1.3" {
+		t.Fatalf("round: %s", got)
+	}
+	if got := MustParseDecimal("1.29").Truncate(1).String(); got != "1.2" {
+		t.Fatalf("truncate: %s", got)
+	}
+	if got := MustParseDecimal("1.5").StringFixed(3); got != "1.500" {
+		t.Fatalf("fixed: %s", got)
+	}
+
+	tick := MustParseDecimal("0.05")
+	price := MustParseDecimal("100.23")
+	cases := map[RoundMode]string{RoundHalfUp: "100.25", RoundDown: "100.20", RoundFloor: "100.20", RoundCeil: "100.25"}
+	for mode, expect := range cases {
+		if got := price.RoundStep(tick, mode).String(); got != expect {
+			t.Fatalf("round step mode %d: expected %s, got %s", mode, expect, got)
+		}
+	}
+	if got := MustParseDecimal("-100.23").RoundStep(tick, RoundFloor).String(); got != "-100.25" {
+		t.Fatalf("round step floor: %s", got)
+	}
+	if got := price.RoundStep(Decimal{}, RoundHalfUp); !got.Equal(price) {
+		t.Fatalf("round step without tick: %s", got)
+	}
+}
+
+func TestDecimalEncoding(t *testing.T) {
+	type row struct {
+		Price Decimal `json:"price"`
+		Rate  Decimal `json:"rate"`
+	}
+
+	data, err := json.Marshal(row{Price: MustParseDecimal("0.000100")})
+	if err != nil || string(data) != `{"price":"0.000100","rate":""}` {
+		t.Fatalf("marshal: %s %v", data, err)
+	}
+
+	var decoded row
+	if err := json.Unmarshal([]byte(`{"price":16500.1,"rate":null}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Price.String() != "16500.1" || decoded.Rate.IsSet() {
+		t.Fatalf("unmarshal: %+v", decoded)
+	}
+	if err := json.Unmarshal([]byte(`{"price":"abc"}`), &decoded); err == nil {
+		t.Fatal("expected error for invalid price")
+	}
+
+	var scanned Decimal
+	if err := scanned.Scan([]byte("42.5")); err != nil || scanned.String() != "42.5" {
+		t.Fatalf("scan: %s %v", scanned, err)
+	}
+	if value, _ := (Decimal{}).Value(); value != "" {
+		t.Fatalf("value: %v", value)
+	}
+	if err := scanned.ScanRedis(""); err != nil || scanned.IsSet() {
+		t.Fatalf("scan redis: %s %v", scanned, err)
+	}
+}
@@ -0,0 +1,195 @@
+package event
+
+import (
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/symbols"
+	"time"
+)
+
+// Type 事件类型
+type Type string
+
+const (
+	TypeTicker       Type = "ticker"
+	TypeBBO          Type = "bbo"
+	TypeTrade        Type = "trade"
+	TypeBookUpdate   Type = "book_update"
+	TypeKline        Type = "kline"
+	TypeMarkPrice    Type = "mark_price"
+	TypeFundingRate  Type = "funding_rate"
+	TypeOpenInterest Type = "open_interest"
+)
+
+// Side 成交方向
+type Side string
+
+const (
+	SideBuy  Side = "buy"
+	SideSell Side = "sell"
+)
+
+// Event 各交易所统一的行情事件
+type Event interface {
+	Type() Type
+	Meta() *Header
+}
+
+// Market 行情所属的交易所和交易对
+type Market struct {
+	Exchange common.Exchange `json:"exchange"`
+	InstType string          `json:"inst_type"` // spot 或 perp
+	Symbol   string          `json:"symbol"`    // 交易所原始交易对
+	Unified  string          `json:"unified"`   // 统一交易对 BASE/QUOTE
+}
+
+// NewMarket 创建交易对标识, 统一交易对由 symbols.Default 转换
+func NewMarket(exchange common.Exchange, instType string, symbol string) Market {
+	return Market{
+		Exchange: exchange,
+		InstType: instType,
+		Symbol:   symbol,
+		Unified:  symbols.Default.Unify(exchange, instType, symbol),
+	}
+}
+
+// Header 事件的公共字段, 时间均为纳秒
+type Header struct {
+	Market
+	ExchangeTime int64 `json:"exchange_time"` // 交易所推送时间
+	ReceiveTime  int64 `json:"receive_time"`  // 本地接收时间
+}
+
+// NewHeader 创建事件公共字段, exchangeTimeMs 为交易所推送的毫秒时间, 接收时间取当前时间
+func NewHeader(market Market, exchangeTimeMs int64) Header {
+	return Header{
+		Market:       market,
+		ExchangeTime: exchangeTimeMs * int64(time.Millisecond),
+		ReceiveTime:  time.Now().UnixNano(),
+	}
+}
+
+func (h *Header) Meta() *Header {
+	return h
+}
+
+// Level 订单簿的一档, 数量为0表示删除该价位
+type Level struct {
+	Price common.Decimal `json:"price"`
+	Size  common.Decimal `json:"size"`
+}
+
+// Ticker 24小时行情
+type Ticker struct {
+	Header
+	Last        common.Decimal `json:"last"`
+	Open        common.Decimal `json:"open"`
+	High        common.Decimal `json:"high"`
+	Low         common.Decimal `json:"low"`
+	Volume      common.Decimal `json:"volume"`       // 基础币种成交量
+	QuoteVolume common.Decimal `json:"quote_volume"` // 计价币种成交额
+	Bid         common.Decimal `json:"bid"`
+	BidSize     common.Decimal `json:"bid_size"`
+	Ask         common.Decimal `json:"ask"`
+	AskSize     common.Decimal `json:"ask_size"`
+}
+
+func (*Ticker) Type() Type { return TypeTicker }
+
+// BBO 最优买卖价
+type BBO struct {
+	Header
+	Bid     common.Decimal `json:"bid"`
+	BidSize common.Decimal `json:"bid_size"`
+	Ask     common.Decimal `json:"ask"`
+	AskSize common.Decimal `json:"ask_size"`
+}
+
+func (*BBO) Type() Type { return TypeBBO }
+
+// Trade 逐笔成交
+type Trade struct {
+	Header
+	TradeId string         `json:"trade_id"`
+	Price   common.Decimal `json:"price"`
+	Size    common.Decimal `json:"size"`
+	Side    Side           `json:"side"` // 主动成交方向
+}
+
+func (*Trade) Type() Type { return TypeTrade }
+
+// BookUpdate 订单簿全量或增量
+type BookUpdate struct {
+	Header
+	Snapshot      bool    `json:"snapshot"` // true 为全量, 否则为增量
+	FirstUpdateId int64   `json:"first_update_id,omitempty"`
+	FinalUpdateId int64   `json:"final_update_id"`
+	Bids          []Level `json:"bids"`
+	Asks          []Level `json:"asks"`
+}
+
+func (*BookUpdate) Type() Type { return TypeBookUpdate }
+
+// Kline K线
+type Kline struct {
+	Header
+	Interval    string         `json:"interval"`
+	OpenTime    int64          `json:"open_time"`  // 纳秒
+	CloseTime   int64          `json:"close_time"` // 纳秒
+	Open        common.Decimal `json:"open"`
+	High        common.Decimal `json:"high"`
+	Low         common.Decimal `json:"low"`
+	Close       common.Decimal `json:"close"`
+	Volume      common.Decimal `json:"volume"`
+	QuoteVolume common.Decimal `json:"quote_volume"`
+	Closed      bool           `json:"closed"` // K线是否已完结
+}
+
+func (*Kline) Type() Type { return TypeKline }
+
+// MarkPrice 标记价格和指数价格
+type MarkPrice struct {
+	Header
+	MarkPrice  common.Decimal `json:"mark_price"`
+	IndexPrice common.Decimal `json:"index_price"`
+}
+
+func (*MarkPrice) Type() Type { return TypeMarkPrice }
+
+// FundingRate 资金费率
+type FundingRate struct {
+	Header
+	Rate            common.Decimal `json:"rate"`
+	NextFundingTime int64          `json:"next_funding_time,omitempty"` // 纳秒
+}
+
+func (*FundingRate) Type() Type { return TypeFundingRate }
+
+// OpenInterest 持仓量
+type OpenInterest struct {
+	Header
+	OpenInterest      common.Decimal `json:"open_interest"`       // 合约张数或基础币种数量
+	OpenInterestValue common.Decimal `json:"open_interest_value"` // 计价币种价值
+}
+
+func (*OpenInterest) Type() Type { return TypeOpenInterest }
+
+// Levels 将交易所推送的 [价格, 数量, ...] 转换为订单簿档位, 无法解析的档位被跳过
+func Levels(raw [][]string) []Level {
+	levels := make([]Level, 0, len(raw))
+	for _, item := range raw {
+		if len(item) < 2 {
+			continue
+		}
+		price, size := common.DecimalFromString(item[0]), common.DecimalFromString(item[1])
+		if !price.IsSet() || !size.IsSet() {
+			continue
+		}
+		levels = append(levels, Level{Price: price, Size: size})
+	}
+	return levels
+}
+
+// Millis 毫秒时间转换为纳秒
+func Millis(ms int64) int64 {
+	return ms * int64(time.Millisecond)
+}
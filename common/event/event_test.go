@@ -0,0 +1,36 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/339-Labs/exchange-market/common"
+)
+
+func TestLevels(t *testing.T) {
+	levels := Levels([][]string{{"100.5", "2"}, {"bad", "1"}, {"101"}})
+	if len(levels) != 1 || levels[0].Price.String() != "100.5" || levels[0].Size.String() != "2" {
+		t.Fatalf("unexpected levels %+v", levels)
+	}
+}
+
+func TestSink(t *testing.T) {
+	market := NewMarket(common.BN, common.InstTypeSpot, "BTCUSDT")
+	if market.Unified != "BTC/USDT" {
+		t.Fatalf("unexpected unified symbol %s", market.Unified)
+	}
+
+	var received []Event
+	multi := NewMulti()
+	multi.Add(SinkFunc(func(event Event) {
+		received = append(received, event)
+	}))
+	multi.Emit(&Ticker{Header: NewHeader(market, 1700000000123), Last: common.MustParseDecimal("100")})
+
+	if len(received) != 1 || received[0].Type() != TypeTicker {
+		t.Fatalf("unexpected events %+v", received)
+	}
+	header := received[0].Meta()
+	if header.ExchangeTime != 1700000000123000000 || header.ReceiveTime == 0 {
+		t.Fatalf("unexpected header %+v", header)
+	}
+}
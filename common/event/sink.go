@@ -0,0 +1,52 @@
+package event
+
+import "sync"
+
+// Sink 行情事件的接收方, 由交易所连接在推送线程中调用, 实现不应阻塞
+type Sink interface {
+	Emit(event Event)
+}
+
+// SinkFunc 函数形式的 Sink
+type SinkFunc func(event Event)
+
+func (f SinkFunc) Emit(event Event) {
+	f(event)
+}
+
+// Discard 丢弃所有事件, 未设置 Sink 时使用
+var Discard Sink = SinkFunc(func(Event) {})
+
+// Multi 将事件依次分发给多个 Sink, 可在运行中追加
+type Multi struct {
+	mu    sync.RWMutex
+	sinks []Sink
+}
+
+// NewMulti 创建多路分发
+func NewMulti(sinks ...Sink) *Multi {
+	return &Multi{sinks: sinks}
+}
+
+// Add 追加接收方
+func (m *Multi) Add(sink Sink) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sinks = append(m.sinks, sink)
+}
+
+func (m *Multi) Emit(event Event) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, sink := range m.sinks {
+		sink.Emit(event)
+	}
+}
+
+// OrDiscard 返回非空的 Sink
+func OrDiscard(sink Sink) Sink {
+	if sink == nil {
+		return Discard
+	}
+	return sink
+}
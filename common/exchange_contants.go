@@ -1,5 +1,7 @@
 package common
 
+import "strings"
+
 type Exchange string
 
 const (
@@ -7,6 +9,29 @@ const (
 	BN     Exchange = "BN"
 	ByBit  Exchange = "ByBit"
 	Okx    Exchange = "Okx"
+	GateIo Exchange = "GateIo"
+
+	UniswapV2 Exchange = "UniswapV2"
+	UniswapV3 Exchange = "UniswapV3"
 
 	SymbolLink = "_"
 )
+
+// Exchanges 支持的交易所
+var Exchanges = []Exchange{BN, Okx, ByBit, BitGet, GateIo, UniswapV2, UniswapV3}
+
+// ParseExchange 不区分大小写解析交易所名称
+func ParseExchange(name string) (Exchange, bool) {
+	for _, exchange := range Exchanges {
+		if strings.EqualFold(string(exchange), name) {
+			return exchange, true
+		}
+	}
+	return "", false
+}
+
+// 行情的产品类型, 用于redis键和接口
+const (
+	InstTypeSpot = "spot"
+	InstTypePerp = "perp"
+)
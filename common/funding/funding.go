@@ -0,0 +1,121 @@
+package funding
+
+import (
+	"github.com/339-Labs/exchange-market/common"
+	"sort"
+	"time"
+)
+
+const (
+	rateScale = 8 // 年化费率和基差的小数位数
+	year      = 365 * 24 * time.Hour
+)
+
+// 套利方式
+const (
+	CarryCashAndCarry = "cash_and_carry" // 同一交易所买入现货、卖出永续, 收取正的资金费
+	CarryCrossVenue   = "cross_venue"    // 在资金费率高的交易所卖出永续、低的交易所买入永续
+)
+
+// Venue 交易所某个永续的资金费率和基差, 需要的数据缺失时对应字段为空
+type Venue struct {
+	Exchange        string         `json:"exchange"`
+	Symbol          string         `json:"symbol"` // 交易所原始交易对
+	FundingRate     common.Decimal `json:"funding_rate"`
+	Annualized      common.Decimal `json:"annualized"`        // 年化资金费率
+	Interval        int64          `json:"interval"`          // 结算间隔, 毫秒
+	NextFundingTime int64          `json:"next_funding_time"` // 毫秒, 未知为0
+	PerpPrice       common.Decimal `json:"perp_price"`        // 优先使用标记价格
+	SpotPrice       common.Decimal `json:"spot_price"`
+	Basis           common.Decimal `json:"basis"` // (永续价格 - 现货价格) / 现货价格
+}
+
+// Differential 两个交易所的年化资金费率差, 在 Short 卖出永续、在 Long 买入永续
+type Differential struct {
+	Long   string         `json:"long"`
+	Short  string         `json:"short"`
+	Spread common.Decimal `json:"spread"` // Short 的年化费率 - Long 的年化费率
+}
+
+// Report 统一永续交易对的资金费率分析
+type Report struct {
+	Symbol        string         `json:"symbol"` // 统一交易对 BASE/QUOTE
+	Venues        []Venue        `json:"venues"`
+	Differentials []Differential `json:"differentials"` // 按费率差从大到小排序
+	Timestamp     int64          `json:"timestamp"`     // 毫秒
+}
+
+// Carry 资金费套利机会
+type Carry struct {
+	Symbol string         `json:"symbol"`
+	Type   string         `json:"type"`  // cash_and_carry 或 cross_venue
+	Long   string         `json:"long"`  // 买入的交易所, cash_and_carry 买入现货
+	Short  string         `json:"short"` // 卖出永续的交易所
+	Yield  common.Decimal `json:"yield"` // 年化收益率, 不含手续费
+	Basis  common.Decimal `json:"basis"` // cash_and_carry 开仓时的基差
+}
+
+// Annualize 按结算间隔折算年化费率, 间隔不大于0时返回未设置
+func Annualize(rate common.Decimal, interval time.Duration) common.Decimal {
+	if !rate.IsSet() || interval <= 0 {
+		return common.Decimal{}
+	}
+	return rate.Mul(common.NewDecimal(int64(year/time.Millisecond), 0)).DivRound(common.NewDecimal(interval.Milliseconds(), 0), rateScale)
+}
+
+// Basis 永续相对现货的基差比例, 任一价格缺失时返回未设置
+func Basis(perp, spot common.Decimal) common.Decimal {
+	if perp.Sign() <= 0 || spot.Sign() <= 0 {
+		return common.Decimal{}
+	}
+	return perp.Sub(spot).DivRound(spot, rateScale)
+}
+
+// NewReport 计算年化费率、基差和交易所之间的费率差, venues 需已填充费率、间隔和价格
+func NewReport(symbol string, venues []Venue, now time.Time) *Report {
+	sort.Slice(venues, func(i, j int) bool { return venues[i].Exchange < venues[j].Exchange })
+	for i := range venues {
+		venues[i].Annualized = Annualize(venues[i].FundingRate, time.Duration(venues[i].Interval)*time.Millisecond)
+		venues[i].Basis = Basis(venues[i].PerpPrice, venues[i].SpotPrice)
+	}
+
+	differentials := make([]Differential, 0)
+	for _, short := range venues {
+		for _, long := range venues {
+			if short.Exchange == long.Exchange || !short.Annualized.IsSet() || !long.Annualized.IsSet() {
+				continue
+			}
+			if spread := short.Annualized.Sub(long.Annualized); spread.Sign() > 0 {
+				differentials = append(differentials, Differential{Long: long.Exchange, Short: short.Exchange, Spread: spread})
+			}
+		}
+	}
+	sort.SliceStable(differentials, func(i, j int) bool { return differentials[i].Spread.GreaterThan(differentials[j].Spread) })
+
+	return &Report{Symbol: symbol, Venues: venues, Differentials: differentials, Timestamp: now.UnixMilli()}
+}
+
+// Carries 资金费套利机会: 资金费为正且有现货价格的交易所可以做期现套利, 费率差最大的一组交易所可以做跨所套利
+func (r *Report) Carries() []Carry {
+	carries := make([]Carry, 0)
+	for _, venue := range r.Venues {
+		if venue.Annualized.Sign() > 0 && venue.SpotPrice.Sign() > 0 {
+			carries = append(carries, Carry{Symbol: r.Symbol, Type: CarryCashAndCarry, Long: venue.Exchange, Short: venue.Exchange, Yield: venue.Annualized, Basis: venue.Basis})
+		}
+	}
+	if len(r.Differentials) > 0 {
+		best := r.Differentials[0]
+		carries = append(carries, Carry{Symbol: r.Symbol, Type: CarryCrossVenue, Long: best.Long, Short: best.Short, Yield: best.Spread})
+	}
+	return carries
+}
+
+// RankCarries 按年化收益率从高到低排序
+func RankCarries(reports []*Report) []Carry {
+	carries := make([]Carry, 0)
+	for _, report := range reports {
+		carries = append(carries, report.Carries()...)
+	}
+	sort.SliceStable(carries, func(i, j int) bool { return carries[i].Yield.GreaterThan(carries[j].Yield) })
+	return carries
+}
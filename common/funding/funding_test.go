@@ -0,0 +1,60 @@
+package funding
+
+import (
+	"testing"
+	"time"
+
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
+)
+
+func TestReport(t *testing.T) {
+	if got := Annualize(common.MustParseDecimal("0.0001"), 8*time.Hour).String(); got != "0.10950000" {
+		t.Fatalf("annualize: %s", got)
+	}
+
+	interval := (8 * time.Hour).Milliseconds()
+	report := NewReport("BTC/USDT", []Venue{
+		{Exchange: "Okx", FundingRate: common.MustParseDecimal("0.0003"), Interval: interval},
+		{Exchange: "BN", FundingRate: common.MustParseDecimal("0.0001"), Interval: interval,
+			PerpPrice: common.MustParseDecimal("101"), SpotPrice: common.MustParseDecimal("100")},
+		{Exchange: "ByBit", FundingRate: common.MustParseDecimal("-0.0001"), Interval: (4 * time.Hour).Milliseconds()},
+	}, time.Now())
+
+	if report.Venues[0].Exchange != "BN" || report.Venues[0].Basis.String() != "0.01000000" {
+		t.Fatalf("unexpected venues %+v", report.Venues)
+	}
+	best := report.Differentials[0]
+	if best.Short != "Okx" || best.Long != "ByBit" || best.Spread.String() != "0.54750000" || len(report.Differentials) != 3 {
+		t.Fatalf("unexpected differentials %+v", report.Differentials)
+	}
+
+	carries := RankCarries([]*Report{report})
+	if len(carries) != 2 || carries[0].Type != CarryCrossVenue || carries[1].Type != CarryCashAndCarry || carries[1].Long != "BN" {
+		t.Fatalf("unexpected carries %+v", carries)
+	}
+}
+
+func TestTrackerSettlement(t *testing.T) {
+	tracker := NewTracker()
+	market := event.Market{Exchange: common.BN, InstType: common.InstTypePerp, Symbol: "BTCUSDT", Unified: "BTC/USDT"}
+	first := time.UnixMilli(1700006400000)
+	emit := func(rate string, next time.Time) {
+		tracker.Emit(&event.FundingRate{Header: event.Header{Market: market}, Rate: common.MustParseDecimal(rate), NextFundingTime: next.UnixNano()})
+	}
+
+	emit("0.0001", first)
+	emit("0.0002", first)
+	if len(tracker.DrainSettlements()) != 0 {
+		t.Fatal("unexpected settlement before funding time advances")
+	}
+
+	emit("0.0003", first.Add(8*time.Hour))
+	settlements := tracker.DrainSettlements()
+	if len(settlements) != 1 || settlements[0].Rate.String() != "0.0002" || !settlements[0].FundingTime.Equal(first) {
+		t.Fatalf("unexpected settlements %+v", settlements)
+	}
+	if schedule, ok := tracker.Schedule(common.BN, "BTC/USDT"); !ok || schedule.Interval != 8*time.Hour {
+		t.Fatalf("unexpected schedule %+v", schedule)
+	}
+}
@@ -0,0 +1,61 @@
+package funding
+
+import (
+	"strings"
+	"sync"
+)
+
+// Store 最近一次计算的资金费率分析, 供查询接口读取
+type Store struct {
+	mu      sync.RWMutex
+	reports map[string]*Report
+	carries []Carry
+}
+
+// NewStore 创建资金费率分析缓存
+func NewStore() *Store {
+	return &Store{
+		reports: make(map[string]*Report),
+	}
+}
+
+// Set 替换全部交易对的分析结果并重新排序套利机会
+func (s *Store) Set(reports []*Report) {
+	if s == nil {
+		return
+	}
+	result := make(map[string]*Report, len(reports))
+	for _, report := range reports {
+		result[report.Symbol] = report
+	}
+	carries := RankCarries(reports)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reports = result
+	s.carries = carries
+}
+
+// Get 统一交易对的分析结果
+func (s *Store) Get(unifiedSymbol string) (*Report, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	report, ok := s.reports[strings.ToUpper(unifiedSymbol)]
+	return report, ok
+}
+
+// Carries 年化收益率最高的 limit 个套利机会, limit 不大于0时返回全部
+func (s *Store) Carries(limit int) []Carry {
+	if s == nil {
+		return []Carry{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if limit <= 0 || limit > len(s.carries) {
+		limit = len(s.carries)
+	}
+	return append([]Carry{}, s.carries[:limit]...)
+}
@@ -0,0 +1,121 @@
+package funding
+
+import (
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
+	"sync"
+	"time"
+)
+
+// maxPendingSettlements 等待保存的结算记录上限, 超过时丢弃最早的记录
+const maxPendingSettlements = 10_000
+
+// Settlement 一次资金费结算, 费率取结算前最后一次推送的费率
+type Settlement struct {
+	Exchange      common.Exchange
+	Symbol        string // 交易所原始交易对
+	UnifiedSymbol string
+	Rate          common.Decimal
+	FundingTime   time.Time // 结算时间
+}
+
+// venueState 交易所某个永续的资金费率状态
+type venueState struct {
+	symbol          string
+	rate            common.Decimal
+	nextFundingTime time.Time
+	interval        time.Duration // 由相邻两次结算时间推算, 未知为0
+}
+
+// Schedule 交易所某个永续的下次结算时间和结算间隔
+type Schedule struct {
+	NextFundingTime time.Time
+	Interval        time.Duration // 未知为0
+}
+
+// Tracker 从统一行情事件中记录各交易所永续的下次结算时间, 结算时间推进时记录上一期的结算, 注册到 event.Multi 使用
+type Tracker struct {
+	mu          sync.Mutex
+	states      map[trackerKey]*venueState
+	settlements []Settlement
+}
+
+type trackerKey struct {
+	exchange common.Exchange
+	unified  string
+}
+
+// NewTracker 创建资金费率跟踪
+func NewTracker() *Tracker {
+	return &Tracker{
+		states: make(map[trackerKey]*venueState),
+	}
+}
+
+// Emit 只处理带下次结算时间的永续资金费率, 在交易所推送线程中调用
+func (t *Tracker) Emit(e event.Event) {
+	rate, ok := e.(*event.FundingRate)
+	if !ok || rate.InstType != common.InstTypePerp || rate.Unified == "" || rate.NextFundingTime <= 0 || !rate.Rate.IsSet() {
+		return
+	}
+	next := time.Unix(0, rate.NextFundingTime)
+	key := trackerKey{exchange: rate.Exchange, unified: rate.Unified}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state, ok := t.states[key]
+	if !ok {
+		t.states[key] = &venueState{symbol: rate.Symbol, rate: rate.Rate, nextFundingTime: next}
+		return
+	}
+
+	if next.After(state.nextFundingTime) {
+		t.settlements = append(t.settlements, Settlement{
+			Exchange:      rate.Exchange,
+			Symbol:        state.symbol,
+			UnifiedSymbol: rate.Unified,
+			Rate:          state.rate,
+			FundingTime:   state.nextFundingTime,
+		})
+		t.trim()
+		state.interval = next.Sub(state.nextFundingTime)
+		state.nextFundingTime = next
+	}
+	state.symbol = rate.Symbol
+	state.rate = rate.Rate
+}
+
+// Schedule 交易所某个永续的结算时间, 未收到过时返回false
+func (t *Tracker) Schedule(exchange common.Exchange, unifiedSymbol string) (Schedule, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state, ok := t.states[trackerKey{exchange: exchange, unified: unifiedSymbol}]
+	if !ok {
+		return Schedule{}, false
+	}
+	return Schedule{NextFundingTime: state.nextFundingTime, Interval: state.interval}, true
+}
+
+// DrainSettlements 取出尚未保存的结算记录
+func (t *Tracker) DrainSettlements() []Settlement {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	settlements := t.settlements
+	t.settlements = nil
+	return settlements
+}
+
+// Restore 保存失败时放回结算记录, 下次重试
+func (t *Tracker) Restore(settlements []Settlement) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.settlements = append(settlements, t.settlements...)
+	t.trim()
+}
+
+// trim 超过上限时丢弃最早的结算记录
+func (t *Tracker) trim() {
+	if over := len(t.settlements) - maxPendingSettlements; over > 0 {
+		t.settlements = t.settlements[over:]
+	}
+}
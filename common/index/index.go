@@ -0,0 +1,193 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Method 指数价格的计算方式
+type Method string
+
+const (
+	MethodMedian    Method = "median"    // 中位数
+	MethodVolume    Method = "volume"    // 按24小时成交额加权
+	MethodLiquidity Method = "liquidity" // 按买一卖一挂单金额加权
+)
+
+// 成分被剔除的原因
+const (
+	ExcludedStale    = "stale"     // 行情过期
+	ExcludedOutlier  = "outlier"   // 偏离中位数超过阈值
+	ExcludedNoWeight = "no_weight" // 加权方式下缺少成交额或挂单
+)
+
+const (
+	weightedExtraScale = 2 // 加权结果在成分最大小数位基础上多保留的位数
+	weightScale        = 6 // 权重占比的小数位数
+)
+
+// ErrNoSources 剔除后可用的成分不足
+var ErrNoSources = errors.New("not enough index sources")
+
+// ParseMethod 解析计算方式, 不区分大小写
+func ParseMethod(value string) (Method, error) {
+	switch method := Method(strings.ToLower(strings.TrimSpace(value))); method {
+	case MethodMedian, MethodVolume, MethodLiquidity:
+		return method, nil
+	}
+	return "", fmt.Errorf("unknown index method %q, supported: median,volume,liquidity", value)
+}
+
+// Options 计算参数
+type Options struct {
+	Method       Method
+	MaxAge       time.Duration  // 超过该时间未更新的成分被剔除
+	MaxDeviation common.Decimal // 偏离中位数的最大比例, 例如 0.05; 未设置时不剔除
+	MinSources   int            // 剔除后至少需要的成分数
+}
+
+// Source 单个交易所的行情
+type Source struct {
+	Exchange  common.Exchange
+	Price     common.Decimal
+	Volume    common.Decimal // 24小时计价币种成交额
+	Liquidity common.Decimal // 买一卖一挂单金额
+	UpdatedAt time.Time
+}
+
+// Constituent 指数成分, Excluded 不为空时未参与计算
+type Constituent struct {
+	Exchange  string         `json:"exchange"`
+	Price     common.Decimal `json:"price"`
+	Weight    common.Decimal `json:"weight"` // 参与计算的权重占比
+	UpdatedAt int64          `json:"updated_at"`
+	Excluded  string         `json:"excluded,omitempty"`
+}
+
+// Result 指数价格
+type Result struct {
+	Symbol       string         `json:"symbol"`    // 统一交易对 BASE/QUOTE
+	InstType     string         `json:"inst_type"` // spot 或 perp
+	Method       Method         `json:"method"`
+	Price        common.Decimal `json:"price"`
+	Timestamp    int64          `json:"timestamp"` // 毫秒
+	Constituents []Constituent  `json:"constituents"`
+}
+
+// Compute 剔除过期和偏离过大的成分后计算指数价格, 成分按交易所排序
+// 加权方式下所有成分都缺少权重时退回中位数, Result.Method 为实际使用的方式
+func Compute(symbol, instType string, sources []Source, options Options, now time.Time) (*Result, error) {
+	result := &Result{
+		Symbol:       symbol,
+		InstType:     instType,
+		Method:       options.Method,
+		Timestamp:    now.UnixMilli(),
+		Constituents: make([]Constituent, len(sources)),
+	}
+
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Exchange < sources[j].Exchange })
+	fresh := make([]int, 0, len(sources))
+	for i, source := range sources {
+		result.Constituents[i] = Constituent{Exchange: string(source.Exchange), Price: source.Price, UpdatedAt: source.UpdatedAt.UnixMilli()}
+		if source.Price.Sign() <= 0 || source.UpdatedAt.IsZero() || (options.MaxAge > 0 && now.Sub(source.UpdatedAt) > options.MaxAge) {
+			result.Constituents[i].Excluded = ExcludedStale
+			continue
+		}
+		fresh = append(fresh, i)
+	}
+	if len(fresh) == 0 {
+		return result, ErrNoSources
+	}
+
+	// 按中位数剔除偏离过大的成分
+	prices := make([]common.Decimal, len(fresh))
+	for i, idx := range fresh {
+		prices[i] = sources[idx].Price
+	}
+	mid := Median(prices)
+	included := make([]int, 0, len(fresh))
+	for _, idx := range fresh {
+		if options.MaxDeviation.IsSet() && sources[idx].Price.Sub(mid).Abs().GreaterThan(mid.Mul(options.MaxDeviation)) {
+			result.Constituents[idx].Excluded = ExcludedOutlier
+			continue
+		}
+		included = append(included, idx)
+	}
+
+	if options.Method == MethodVolume || options.Method == MethodLiquidity {
+		weighted := make([]int, 0, len(included))
+		for _, idx := range included {
+			if weightOf(sources[idx], options.Method).Sign() > 0 {
+				weighted = append(weighted, idx)
+			}
+		}
+		if len(weighted) > 0 {
+			for _, idx := range included {
+				if weightOf(sources[idx], options.Method).Sign() <= 0 {
+					result.Constituents[idx].Excluded = ExcludedNoWeight
+				}
+			}
+			included = weighted
+		} else {
+			result.Method = MethodMedian
+		}
+	}
+	if len(included) == 0 || len(included) < options.MinSources {
+		return result, ErrNoSources
+	}
+
+	if result.Method == MethodMedian {
+		prices = prices[:0]
+		for _, idx := range included {
+			prices = append(prices, sources[idx].Price)
+		}
+		result.Price = Median(prices)
+		share := common.NewDecimal(1, 0).DivRound(common.NewDecimal(int64(len(included)), 0), weightScale)
+		for _, idx := range included {
+			result.Constituents[idx].Weight = share
+		}
+		return result, nil
+	}
+
+	var total, sum common.Decimal
+	var scale int32
+	for _, idx := range included {
+		weight := weightOf(sources[idx], result.Method)
+		total = total.Add(weight)
+		sum = sum.Add(sources[idx].Price.Mul(weight))
+		scale = max(scale, sources[idx].Price.Scale())
+	}
+	result.Price = sum.DivRound(total, scale+weightedExtraScale)
+	for _, idx := range included {
+		result.Constituents[idx].Weight = weightOf(sources[idx], result.Method).DivRound(total, weightScale)
+	}
+	return result, nil
+}
+
+// Median 中位数, 偶数个时取中间两个的平均值
+func Median(values []common.Decimal) common.Decimal {
+	if len(values) == 0 {
+		return common.Decimal{}
+	}
+	sorted := make([]common.Decimal, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].LessThan(sorted[j]) })
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return sorted[mid-1].Add(sorted[mid]).Mul(common.NewDecimal(5, 1))
+}
+
+// weightOf 成分在加权方式下的权重
+func weightOf(source Source, method Method) common.Decimal {
+	if method == MethodLiquidity {
+		return source.Liquidity
+	}
+	return source.Volume
+}
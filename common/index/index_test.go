@@ -0,0 +1,101 @@
+package index
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
+)
+
+func source(exchange common.Exchange, price string, volume string, age time.Duration, now time.Time) Source {
+	return Source{
+		Exchange:  exchange,
+		Price:     common.MustParseDecimal(price),
+		Volume:    common.DecimalFromString(volume),
+		UpdatedAt: now.Add(-age),
+	}
+}
+
+func TestComputeMedian(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	options := Options{Method: MethodMedian, MaxAge: 10 * time.Second, MaxDeviation: common.MustParseDecimal("0.05"), MinSources: 2}
+	sources := []Source{
+		source(common.Okx, "100.2", "", time.Second, now),
+		source(common.BN, "100.1", "", time.Second, now),
+		source(common.ByBit, "120", "", time.Second, now),    // 偏离过大
+		source(common.BitGet, "99", "", time.Minute, now),    // 过期
+		source(common.GateIo, "100.3", "", time.Second, now), // 参与计算
+	}
+
+	result, err := Compute("BTC/USDT", common.InstTypeSpot, sources, options, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Price.String() != "100.2" || result.Method != MethodMedian {
+		t.Fatalf("unexpected index %s %s", result.Price, result.Method)
+	}
+
+	excluded := make(map[string]string)
+	for _, constituent := range result.Constituents {
+		excluded[constituent.Exchange] = constituent.Excluded
+	}
+	if excluded[string(common.ByBit)] != ExcludedOutlier || excluded[string(common.BitGet)] != ExcludedStale || excluded[string(common.BN)] != "" {
+		t.Fatalf("unexpected constituents %+v", result.Constituents)
+	}
+
+	options.MinSources = 4
+	if _, err := Compute("BTC/USDT", common.InstTypeSpot, sources, options, now); !errors.Is(err, ErrNoSources) {
+		t.Fatalf("expected not enough sources, got %v", err)
+	}
+}
+
+func TestComputeWeighted(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	options := Options{Method: MethodVolume, MaxAge: 10 * time.Second}
+	sources := []Source{
+		source(common.BN, "100", "300", 0, now),
+		source(common.Okx, "104", "100", 0, now),
+		source(common.ByBit, "90", "", 0, now),
+	}
+
+	result, err := Compute("BTC/USDT", common.InstTypePerp, sources, options, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Price.String() != "101.00" || result.Constituents[0].Weight.String() != "0.750000" {
+		t.Fatalf("unexpected index %s %+v", result.Price, result.Constituents)
+	}
+	if result.Constituents[1].Exchange != string(common.ByBit) || result.Constituents[1].Excluded != ExcludedNoWeight {
+		t.Fatalf("unexpected constituents %+v", result.Constituents)
+	}
+
+	// 所有成分都没有权重时退回中位数
+	for i := range sources {
+		sources[i].Volume = common.Decimal{}
+	}
+	result, err = Compute("BTC/USDT", common.InstTypePerp, sources, options, now)
+	if err != nil || result.Method != MethodMedian || result.Price.String() != "100" {
+		t.Fatalf("unexpected fallback %+v %v", result, err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	stats := NewStats()
+	market := event.Market{Exchange: common.Okx, InstType: common.InstTypeSpot, Symbol: "BTC-USDT", Unified: "BTC/USDT"}
+	stats.Emit(&event.Ticker{
+		Header:      event.Header{Market: market},
+		QuoteVolume: common.MustParseDecimal("1000"),
+		Bid:         common.MustParseDecimal("100"),
+		BidSize:     common.MustParseDecimal("2"),
+		Ask:         common.MustParseDecimal("101"),
+		AskSize:     common.MustParseDecimal("1"),
+	})
+	stats.Emit(&event.BBO{Header: event.Header{Market: market}, Bid: common.MustParseDecimal("100"), BidSize: common.MustParseDecimal("1")})
+
+	volume, liquidity := stats.Lookup(common.Okx, common.InstTypeSpot, "BTC/USDT")
+	if volume.String() != "1000" || liquidity.String() != "100" {
+		t.Fatalf("unexpected stats %s %s", volume, liquidity)
+	}
+}
@@ -0,0 +1,75 @@
+package index
+
+import (
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
+	"sync"
+)
+
+// venueStats 交易所某个交易对最近一次的成交额和挂单金额
+type venueStats struct {
+	volume    common.Decimal
+	liquidity common.Decimal
+}
+
+// Stats 从统一行情事件中记录各交易所的成交额和买一卖一挂单金额, 作为加权的权重, 注册到 event.Multi 使用
+type Stats struct {
+	mu    sync.RWMutex
+	stats map[string]venueStats
+}
+
+// NewStats 创建权重统计
+func NewStats() *Stats {
+	return &Stats{
+		stats: make(map[string]venueStats),
+	}
+}
+
+// Emit 只处理 Ticker 和 BBO, 在交易所推送线程中调用
+func (s *Stats) Emit(e event.Event) {
+	switch e := e.(type) {
+	case *event.Ticker:
+		s.update(e.Market, e.QuoteVolume, topOfBook(e.Bid, e.BidSize, e.Ask, e.AskSize))
+	case *event.BBO:
+		s.update(e.Market, common.Decimal{}, topOfBook(e.Bid, e.BidSize, e.Ask, e.AskSize))
+	}
+}
+
+// Lookup 交易所某个交易对的成交额和挂单金额, 未收到过时返回未设置
+func (s *Stats) Lookup(exchange common.Exchange, instType string, unifiedSymbol string) (common.Decimal, common.Decimal) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stats := s.stats[statsKey(exchange, instType, unifiedSymbol)]
+	return stats.volume, stats.liquidity
+}
+
+// update 未设置的字段保留上次的值
+func (s *Stats) update(market event.Market, volume, liquidity common.Decimal) {
+	if market.Unified == "" || (!volume.IsSet() && !liquidity.IsSet()) {
+		return
+	}
+	key := statsKey(market.Exchange, market.InstType, market.Unified)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats[key]
+	if volume.IsSet() {
+		stats.volume = volume
+	}
+	if liquidity.IsSet() {
+		stats.liquidity = liquidity
+	}
+	s.stats[key] = stats
+}
+
+// topOfBook 买一卖一挂单金额, 缺少数量时返回未设置
+func topOfBook(bid, bidSize, ask, askSize common.Decimal) common.Decimal {
+	if !bidSize.IsSet() && !askSize.IsSet() {
+		return common.Decimal{}
+	}
+	return bid.Mul(bidSize).Add(ask.Mul(askSize))
+}
+
+func statsKey(exchange common.Exchange, instType string, unifiedSymbol string) string {
+	return string(exchange) + ":" + instType + ":" + unifiedSymbol
+}
@@ -0,0 +1,44 @@
+package index
+
+import (
+	"strings"
+	"sync"
+)
+
+// Store 最近一次计算的指数价格, 供查询接口读取
+type Store struct {
+	mu      sync.RWMutex
+	results map[string]*Result
+}
+
+// NewStore 创建指数价格缓存
+func NewStore() *Store {
+	return &Store{
+		results: make(map[string]*Result),
+	}
+}
+
+// Set 保存指数价格, 覆盖同一交易对的旧值
+func (s *Store) Set(result *Result) {
+	if s == nil || result == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[storeKey(result.InstType, result.Symbol)] = result
+}
+
+// Get 获取指数价格, instType 为 spot 或 perp
+func (s *Store) Get(instType string, unifiedSymbol string) (*Result, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result, ok := s.results[storeKey(instType, unifiedSymbol)]
+	return result, ok
+}
+
+func storeKey(instType string, unifiedSymbol string) string {
+	return instType + ":" + strings.ToUpper(unifiedSymbol)
+}
@@ -1,23 +1,43 @@
 package maps
 
 import (
+	"github.com/339-Labs/exchange-market/common"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
 type PriceData struct {
-	Symbol      string `json:"symbol"`
-	Price       string `json:"price"`
-	FundingRate string `json:"funding_rate"`
-	MarkPrice   string `json:"mark_price"`
-	Timestamp   string `json:"timestamp"`
+	Symbol      string         `json:"symbol"`
+	Price       common.Decimal `json:"price"`
+	FundingRate common.Decimal `json:"funding_rate"`
+	MarkPrice   common.Decimal `json:"mark_price"`
+	Timestamp   string         `json:"timestamp"`
+
+	// 写入redis前由 worker 填充
+	Exchange      string `json:"exchange,omitempty"`
+	InstType      string `json:"inst_type,omitempty"`      // spot 或 perp
+	UnifiedSymbol string `json:"unified_symbol,omitempty"` // BTC/USDT
+}
+
+// Equal 比较所有字段, 价格按数值比较
+func (p PriceData) Equal(other PriceData) bool {
+	return p.Symbol == other.Symbol &&
+		p.Price.Equal(other.Price) &&
+		p.FundingRate.Equal(other.FundingRate) &&
+		p.MarkPrice.Equal(other.MarkPrice) &&
+		p.Timestamp == other.Timestamp &&
+		p.Exchange == other.Exchange &&
+		p.InstType == other.InstType &&
+		p.UnifiedSymbol == other.UnifiedSymbol
 }
 
 type PriceMap struct {
 	mu   sync.RWMutex
 	data map[string]*PriceData // 用指针节省拷贝开销
 
+	lastWrite atomic.Int64 // 最后写入时间, 毫秒
+
 	// 双buffer
 	writeBuffer *sync.Map
 	readBuffer  *sync.Map
@@ -54,6 +74,7 @@ func (p *PriceMap) Write(key string, value *PriceData) {
 	p.mu.Lock()
 	p.data[key] = value
 	p.mu.Unlock()
+	p.touch()
 }
 
 func (p *PriceMap) WriteBatch(data map[string]*PriceData) {
@@ -63,6 +84,15 @@ func (p *PriceMap) WriteBatch(data map[string]*PriceData) {
 	for key, value := range data {
 		p.data[key] = value
 	}
+	p.touch()
+}
+
+// Delete 删除行情
+func (p *PriceMap) Delete(key string) {
+	p.mu.Lock()
+	delete(p.data, key)
+	p.mu.Unlock()
+	p.touch()
 }
 
 func (p *PriceMap) Read(key string) (*PriceData, bool) {
@@ -96,6 +126,20 @@ func (p *PriceMap) ReadAllKeys() []string {
 	return keys
 }
 
+// LastWrite 最后写入时间, 未写入过时返回零值
+func (p *PriceMap) LastWrite() time.Time {
+	ms := p.lastWrite.Load()
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// touch 记录写入时间
+func (p *PriceMap) touch() {
+	p.lastWrite.Store(time.Now().UnixMilli())
+}
+
 // 获取数据数量
 func (p *PriceMap) Size() int {
 	p.mu.RLock()
@@ -144,6 +188,7 @@ func (p *PriceMap) flushReadBuffer() {
 		p.readBuffer.Delete(key)
 		return true
 	})
+	p.touch()
 }
 
 // 双buffer交换机制 启动定期刷新goroutine
@@ -0,0 +1,153 @@
+package maps
+
+import (
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/symbols"
+	"sort"
+	"sync"
+	"time"
+)
+
+// VenueMaps 交易所的内存行情, Mark 和 Rate 为空时标记价格和资金费率在 Feature 中
+type VenueMaps struct {
+	Spot    *PriceMap
+	Feature *PriceMap
+	Mark    *PriceMap
+	Rate    *PriceMap
+}
+
+// Lookup 按统一交易对 BASE/QUOTE 查找行情, instType 为 spot 或 perp, 永续合并标记价格和资金费率
+// 通过解析器的反向映射得到交易所交易对后直接读取
+func (v *VenueMaps) Lookup(exchange common.Exchange, instType string, unifiedSymbol string) (*PriceData, bool) {
+	source := v.source(instType)
+	if source == nil {
+		return nil, false
+	}
+
+	symbol, err := symbols.Parse(unifiedSymbol)
+	if err != nil {
+		return nil, false
+	}
+	key, ok := symbols.Default.ToVenue(exchange, instType, symbol)
+	if !ok {
+		return nil, false
+	}
+	value, ok := readMap(source, key)
+	if !ok {
+		return nil, false
+	}
+	return v.merge(exchange, instType, symbol.Pair(), key, value), true
+}
+
+// All 某个产品类型的全部行情, 字段与 Lookup 相同
+func (v *VenueMaps) All(exchange common.Exchange, instType string) []*PriceData {
+	source := v.source(instType)
+	if source == nil {
+		return nil
+	}
+
+	values := source.ReadAll()
+	result := make([]*PriceData, 0, len(values))
+	for key, value := range values {
+		if value == nil {
+			continue
+		}
+		result = append(result, v.merge(exchange, instType, symbols.Default.Unify(exchange, instType, value.Symbol), key, value))
+	}
+	return result
+}
+
+// source 产品类型对应的行情
+func (v *VenueMaps) source(instType string) *PriceMap {
+	if instType == common.InstTypePerp {
+		return v.Feature
+	}
+	return v.Spot
+}
+
+// merge 复制行情并填充交易所、产品类型和统一交易对, 永续合并标记价格和资金费率
+func (v *VenueMaps) merge(exchange common.Exchange, instType string, unifiedSymbol string, key string, value *PriceData) *PriceData {
+	price := *value
+	if instType == common.InstTypePerp {
+		if mark, ok := readMap(v.Mark, key); ok && mark.MarkPrice.IsSet() {
+			price.MarkPrice = mark.MarkPrice
+		}
+		if rate, ok := readMap(v.Rate, key); ok && rate.FundingRate.IsSet() {
+			price.FundingRate = rate.FundingRate
+		}
+	}
+	price.Exchange = string(exchange)
+	price.InstType = instType
+	price.UnifiedSymbol = unifiedSymbol
+	return &price
+}
+
+// Freshness 各行情的最后写入时间, key 为 spot、feature、mark、rate
+func (v *VenueMaps) Freshness() map[string]time.Time {
+	result := make(map[string]time.Time)
+	for name, priceMap := range map[string]*PriceMap{"spot": v.Spot, "feature": v.Feature, "mark": v.Mark, "rate": v.Rate} {
+		if priceMap != nil {
+			result[name] = priceMap.LastWrite()
+		}
+	}
+	return result
+}
+
+// readMap 读取可为空的 PriceMap
+func readMap(priceMap *PriceMap, key string) (*PriceData, bool) {
+	if priceMap == nil {
+		return nil, false
+	}
+	value, ok := priceMap.Read(key)
+	return value, ok && value != nil
+}
+
+// Registry 同一进程内各交易所的内存行情, 供查询接口读取
+type Registry struct {
+	mu     sync.RWMutex
+	venues map[common.Exchange]*VenueMaps
+}
+
+// NewRegistry 创建行情注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		venues: make(map[common.Exchange]*VenueMaps),
+	}
+}
+
+// Register 注册交易所的内存行情, 交易所重启后覆盖旧的行情
+func (r *Registry) Register(exchange common.Exchange, venue *VenueMaps) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.venues[exchange] = venue
+}
+
+// Get 获取交易所的内存行情
+func (r *Registry) Get(exchange common.Exchange) (*VenueMaps, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	venue, ok := r.venues[exchange]
+	return venue, ok
+}
+
+// Exchanges 已注册的交易所, 按名称排序
+func (r *Registry) Exchanges() []common.Exchange {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]common.Exchange, 0, len(r.venues))
+	for exchange := range r.venues {
+		result = append(result, exchange)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
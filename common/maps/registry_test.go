@@ -0,0 +1,41 @@
+package maps
+
+import (
+	"testing"
+
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/symbols"
+)
+
+func TestVenueMapsLookup(t *testing.T) {
+	// 交割合约先注册, BTC/USDT 仍然指向永续合约
+	symbols.Default.Register(common.BN, common.InstTypePerp, "BTCUSDT_250627", symbols.NewSymbol("BTC", "USDT", "USDT").WithExpiry(1751011200000))
+	symbols.Default.Register(common.BN, common.InstTypePerp, "BTCUSDT", symbols.NewSymbol("BTC", "USDT", "USDT"))
+	pool := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+	symbols.Default.Register(common.UniswapV2, common.InstTypeSpot, pool, symbols.NewSymbol("USDC", "WETH", ""))
+
+	feature, mark := NewPriceMap(8), NewPriceMap(8)
+	feature.Write("BTCUSDT_250627", &PriceData{Symbol: "BTCUSDT_250627", Price: common.MustParseDecimal("101")})
+	feature.Write("BTCUSDT", &PriceData{Symbol: "BTCUSDT", Price: common.MustParseDecimal("100")})
+	mark.Write("BTCUSDT", &PriceData{Symbol: "BTCUSDT", MarkPrice: common.MustParseDecimal("99")})
+	cex := &VenueMaps{Feature: feature, Mark: mark}
+
+	got, ok := cex.Lookup(common.BN, common.InstTypePerp, "btc/usdt")
+	if !ok || got.Symbol != "BTCUSDT" || got.UnifiedSymbol != "BTC/USDT" || !got.MarkPrice.Equal(common.MustParseDecimal("99")) {
+		t.Fatalf("Lookup(BTC/USDT) = %+v, %v", got, ok)
+	}
+	if got, ok := cex.Lookup(common.BN, common.InstTypePerp, "BTC/USDT:USDT-250627"); !ok || got.Symbol != "BTCUSDT_250627" {
+		t.Fatalf("Lookup(BTC/USDT:USDT-250627) = %+v, %v", got, ok)
+	}
+
+	// 池子地址按校验和格式写入
+	spot := NewPriceMap(8)
+	spot.Write(pool, &PriceData{Symbol: pool, Price: common.MustParseDecimal("0.0004")})
+	dex := &VenueMaps{Spot: spot}
+	if got, ok := dex.Lookup(common.UniswapV2, common.InstTypeSpot, "USDC/WETH"); !ok || got.Symbol != pool {
+		t.Fatalf("Lookup(USDC/WETH) = %+v, %v", got, ok)
+	}
+	if _, ok := dex.Lookup(common.UniswapV2, common.InstTypeSpot, "bad"); ok {
+		t.Fatal("Lookup(bad) found")
+	}
+}
@@ -0,0 +1,25 @@
+package orderbook
+
+import (
+	"hash/crc32"
+	"strings"
+)
+
+// Checksum 计算OKX/Bitget格式的CRC32校验和
+// 取买卖盘前 depth 档，按 买1:卖1:买2:卖2... 交替拼接原始 "价格:数量" 字符串，结果按有符号32位整数返回
+func (b *OrderBook) Checksum(depth int) int32 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	parts := make([]string, 0, depth*4)
+	for i := 0; i < depth; i++ {
+		if i < len(b.bids) {
+			parts = append(parts, b.bids[i].RawPrice, b.bids[i].RawSize)
+		}
+		if i < len(b.asks) {
+			parts = append(parts, b.asks[i].RawPrice, b.asks[i].RawSize)
+		}
+	}
+
+	return int32(crc32.ChecksumIEEE([]byte(strings.Join(parts, ":"))))
+}
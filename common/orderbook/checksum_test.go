@@ -0,0 +1,29 @@
+package orderbook
+
+import (
+	"hash/crc32"
+	"testing"
+)
+
+func TestOrderBook_Checksum(t *testing.T) {
+	book := NewOrderBook("BTC-USDT")
+	err := book.Load(
+		[][]string{{"3366.1", "7.0", "0", "3"}, {"3366.8", "9", "0", "1"}},
+		[][]string{{"3366.9", "1.0", "0", "1"}, {"3368", "8.1", "0", "2"}, {"3370", "2", "0", "1"}},
+		1, 0,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 原始字符串不做格式化，买卖交替，缺失的档位跳过
+	expected := int32(crc32.ChecksumIEEE([]byte("3366.8:9:3366.9:1.0:3366.1:7.0:3368:8.1:3370:2")))
+	if checksum := book.Checksum(25); checksum != expected {
+		t.Fatalf("expected checksum %d, got %d", expected, checksum)
+	}
+
+	expected = int32(crc32.ChecksumIEEE([]byte("3366.8:9:3366.9:1.0")))
+	if checksum := book.Checksum(1); checksum != expected {
+		t.Fatalf("expected depth-1 checksum %d, got %d", expected, checksum)
+	}
+}
@@ -0,0 +1,79 @@
+package orderbook
+
+import (
+	"sort"
+	"sync"
+)
+
+// Manager 单个交易所的订单簿集合，按交易对管理
+type Manager struct {
+	Exchange string
+
+	books map[string]*OrderBook
+	mu    sync.RWMutex
+}
+
+// NewManager 创建订单簿管理器
+func NewManager(exchange string) *Manager {
+	return &Manager{
+		Exchange: exchange,
+		books:    make(map[string]*OrderBook),
+	}
+}
+
+// GetOrCreate 获取交易对订单簿，不存在时创建
+func (m *Manager) GetOrCreate(symbol string) *OrderBook {
+	m.mu.RLock()
+	book, ok := m.books[symbol]
+	m.mu.RUnlock()
+	if ok {
+		return book
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if book, ok = m.books[symbol]; ok {
+		return book
+	}
+	book = NewOrderBook(symbol)
+	m.books[symbol] = book
+	return book
+}
+
+// Get 获取交易对订单簿
+func (m *Manager) Get(symbol string) (*OrderBook, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	book, ok := m.books[symbol]
+	return book, ok
+}
+
+// Reader 获取交易对订单簿的查询接口
+func (m *Manager) Reader(symbol string) (Reader, bool) {
+	book, ok := m.Get(symbol)
+	if !ok {
+		return nil, false
+	}
+	return book, true
+}
+
+// Remove 移除交易对订单簿
+func (m *Manager) Remove(symbol string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.books, symbol)
+}
+
+// Symbols 所有交易对
+func (m *Manager) Symbols() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	symbols := make([]string, 0, len(m.books))
+	for symbol := range m.books {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
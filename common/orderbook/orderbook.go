@@ -0,0 +1,285 @@
+package orderbook
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Side 买卖方向
+type Side int
+
+const (
+	Bid Side = iota // 买盘
+	Ask             // 卖盘
+)
+
+func (s Side) String() string {
+	if s == Bid {
+		return "bid"
+	}
+	return "ask"
+}
+
+// Level 价格档位
+type Level struct {
+	Price    float64 `json:"price"`
+	Size     float64 `json:"size"`
+	RawPrice string  `json:"-"` // 交易所原始价格字符串，用于校验和计算
+	RawSize  string  `json:"-"` // 交易所原始数量字符串，用于校验和计算
+}
+
+// Reader 订单簿查询接口，所有交易所的本地订单簿均通过该接口对外提供查询
+type Reader interface {
+	BestBid() (Level, bool)
+	BestAsk() (Level, bool)
+	TopN(n int) (bids []Level, asks []Level)
+	CumulativeDepth(side Side, price float64) float64
+}
+
+// OrderBook 本地订单簿，买盘按价格降序、卖盘按价格升序排列
+type OrderBook struct {
+	Symbol       string
+	LastUpdateId int64 // 最后一次更新ID
+	UpdateTime   int64 // 最后一次更新时间(毫秒)
+
+	bids []Level
+	asks []Level
+	mu   sync.RWMutex
+}
+
+// NewOrderBook 创建订单簿
+func NewOrderBook(symbol string) *OrderBook {
+	return &OrderBook{
+		Symbol: symbol,
+		bids:   make([]Level, 0),
+		asks:   make([]Level, 0),
+	}
+}
+
+// ParseLevel 解析交易所推送的档位 [price, size, ...]
+func ParseLevel(raw []string) (Level, error) {
+	if len(raw) < 2 {
+		return Level{}, fmt.Errorf("invalid level: %v", raw)
+	}
+	price, err := strconv.ParseFloat(raw[0], 64)
+	if err != nil {
+		return Level{}, fmt.Errorf("invalid price %q: %w", raw[0], err)
+	}
+	size, err := strconv.ParseFloat(raw[1], 64)
+	if err != nil {
+		return Level{}, fmt.Errorf("invalid size %q: %w", raw[1], err)
+	}
+	return Level{Price: price, Size: size, RawPrice: raw[0], RawSize: raw[1]}, nil
+}
+
+// Load 使用全量快照重置订单簿
+func (b *OrderBook) Load(bids [][]string, asks [][]string, lastUpdateId int64, updateTime int64) error {
+	bidLevels, err := parseLevels(bids)
+	if err != nil {
+		return err
+	}
+	askLevels, err := parseLevels(asks)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(bidLevels, func(i, j int) bool { return bidLevels[i].Price > bidLevels[j].Price })
+	sort.Slice(askLevels, func(i, j int) bool { return askLevels[i].Price < askLevels[j].Price })
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.bids = bidLevels
+	b.asks = askLevels
+	b.LastUpdateId = lastUpdateId
+	b.UpdateTime = updateTime
+	return nil
+}
+
+// Apply 应用增量更新，数量为0表示删除该档位
+func (b *OrderBook) Apply(bids [][]string, asks [][]string, updateId int64, updateTime int64) error {
+	bidLevels, err := parseLevels(bids)
+	if err != nil {
+		return err
+	}
+	askLevels, err := parseLevels(asks)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, level := range bidLevels {
+		b.bids = upsertLevel(b.bids, level, Bid)
+	}
+	for _, level := range askLevels {
+		b.asks = upsertLevel(b.asks, level, Ask)
+	}
+	b.LastUpdateId = updateId
+	b.UpdateTime = updateTime
+	return nil
+}
+
+// Truncate 只保留前 depth 档，用于固定档位的订单簿
+func (b *OrderBook) Truncate(depth int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.bids) > depth {
+		b.bids = b.bids[:depth]
+	}
+	if len(b.asks) > depth {
+		b.asks = b.asks[:depth]
+	}
+}
+
+// Clear 清空订单簿
+func (b *OrderBook) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.bids = b.bids[:0]
+	b.asks = b.asks[:0]
+	b.LastUpdateId = 0
+	b.UpdateTime = 0
+}
+
+// GetLastUpdateId 获取最后一次更新ID
+func (b *OrderBook) GetLastUpdateId() int64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.LastUpdateId
+}
+
+// GetUpdateTime 获取最后一次更新时间
+func (b *OrderBook) GetUpdateTime() int64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.UpdateTime
+}
+
+// BestBid 最优买价
+func (b *OrderBook) BestBid() (Level, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if len(b.bids) == 0 {
+		return Level{}, false
+	}
+	return b.bids[0], true
+}
+
+// BestAsk 最优卖价
+func (b *OrderBook) BestAsk() (Level, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if len(b.asks) == 0 {
+		return Level{}, false
+	}
+	return b.asks[0], true
+}
+
+// TopN 获取买卖盘前 n 档
+func (b *OrderBook) TopN(n int) ([]Level, []Level) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return copyLevels(b.bids, n), copyLevels(b.asks, n)
+}
+
+// CumulativeDepth 从盘口到指定价格(含)的累计挂单量
+// 买盘累计价格 >= price 的档位，卖盘累计价格 <= price 的档位
+func (b *OrderBook) CumulativeDepth(side Side, price float64) float64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	total := 0.0
+	if side == Bid {
+		for _, level := range b.bids {
+			if level.Price < price {
+				break
+			}
+			total += level.Size
+		}
+		return total
+	}
+
+	for _, level := range b.asks {
+		if level.Price > price {
+			break
+		}
+		total += level.Size
+	}
+	return total
+}
+
+// Depth 买卖盘档位数量
+func (b *OrderBook) Depth() (int, int) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.bids), len(b.asks)
+}
+
+// IsCrossed 买一价是否大于等于卖一价，出现即说明订单簿已损坏
+func (b *OrderBook) IsCrossed() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if len(b.bids) == 0 || len(b.asks) == 0 {
+		return false
+	}
+	return b.bids[0].Price >= b.asks[0].Price
+}
+
+func parseLevels(raw [][]string) ([]Level, error) {
+	levels := make([]Level, 0, len(raw))
+	for _, item := range raw {
+		level, err := ParseLevel(item)
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+// upsertLevel 在有序档位中插入、更新或删除档位
+func upsertLevel(levels []Level, level Level, side Side) []Level {
+	idx := sort.Search(len(levels), func(i int) bool {
+		if side == Bid {
+			return levels[i].Price <= level.Price
+		}
+		return levels[i].Price >= level.Price
+	})
+
+	exists := idx < len(levels) && levels[idx].Price == level.Price
+	if level.Size == 0 {
+		if exists {
+			levels = append(levels[:idx], levels[idx+1:]...)
+		}
+		return levels
+	}
+
+	if exists {
+		levels[idx] = level
+		return levels
+	}
+
+	levels = append(levels, Level{})
+	copy(levels[idx+1:], levels[idx:])
+	levels[idx] = level
+	return levels
+}
+
+func copyLevels(levels []Level, n int) []Level {
+	if n <= 0 || n > len(levels) {
+		n = len(levels)
+	}
+	result := make([]Level, n)
+	copy(result, levels[:n])
+	return result
+}
@@ -0,0 +1,107 @@
+package orderbook
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOrderBook_Apply(t *testing.T) {
+	book := NewOrderBook("BTCUSDT")
+	err := book.Load(
+		[][]string{{"100", "1"}, {"101", "2"}, {"99", "3"}},
+		[][]string{{"103", "1"}, {"102", "2"}},
+		10, 0,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bid, _ := book.BestBid()
+	ask, _ := book.BestAsk()
+	if bid.Price != 101 || ask.Price != 102 {
+		t.Fatalf("unexpected top of book: bid %v ask %v", bid, ask)
+	}
+
+	// 删除买一、新增买盘、更新卖盘
+	err = book.Apply(
+		[][]string{{"101", "0"}, {"100.5", "4"}},
+		[][]string{{"102", "5"}, {"104", "1"}},
+		11, 0,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bids, asks := book.TopN(3)
+	expectBids := []float64{100.5, 100, 99}
+	expectAsks := []float64{102, 103, 104}
+	for i := range expectBids {
+		if bids[i].Price != expectBids[i] {
+			t.Fatalf("bid %d: expected %v, got %v", i, expectBids[i], bids[i].Price)
+		}
+		if asks[i].Price != expectAsks[i] {
+			t.Fatalf("ask %d: expected %v, got %v", i, expectAsks[i], asks[i].Price)
+		}
+	}
+
+	if depth := book.CumulativeDepth(Bid, 100); depth != 5 {
+		t.Fatalf("expected bid depth 5, got %v", depth)
+	}
+	if depth := book.CumulativeDepth(Ask, 103); depth != 6 {
+		t.Fatalf("expected ask depth 6, got %v", depth)
+	}
+	if book.GetLastUpdateId() != 11 {
+		t.Fatalf("expected last update id 11, got %d", book.GetLastUpdateId())
+	}
+}
+
+func TestSynchronizer_Resync(t *testing.T) {
+	snapshots := make(chan *Snapshot, 2)
+	snapshots <- &Snapshot{LastUpdateId: 100, Bids: [][]string{{"10", "1"}}, Asks: [][]string{{"11", "1"}}}
+	snapshots <- &Snapshot{LastUpdateId: 200, Bids: [][]string{{"10", "2"}}, Asks: [][]string{{"11", "2"}}}
+
+	book := NewOrderBook("BTCUSDT")
+	synchronizer := NewSynchronizer(book, func(symbol string) (*Snapshot, error) {
+		return <-snapshots, nil
+	})
+
+	// 快照之前的增量被丢弃，跨越快照的增量被应用
+	_ = synchronizer.Push(&Delta{FirstUpdateId: 95, FinalUpdateId: 100, Bids: [][]string{{"9", "1"}}})
+	_ = synchronizer.Push(&Delta{FirstUpdateId: 101, FinalUpdateId: 102, Bids: [][]string{{"10.5", "1"}}})
+	waitSynced(t, synchronizer)
+
+	if book.GetLastUpdateId() != 102 {
+		t.Fatalf("expected last update id 102, got %d", book.GetLastUpdateId())
+	}
+	if bid, _ := book.BestBid(); bid.Price != 10.5 {
+		t.Fatalf("expected best bid 10.5, got %v", bid.Price)
+	}
+	if depth := book.CumulativeDepth(Bid, 9); depth != 2 {
+		t.Fatalf("expected stale delta to be dropped, bid depth %v", depth)
+	}
+
+	// 断档触发重新同步
+	err := synchronizer.Push(&Delta{FirstUpdateId: 150, FinalUpdateId: 201})
+	if !errors.Is(err, ErrSequenceGap) {
+		t.Fatalf("expected sequence gap, got %v", err)
+	}
+	waitSynced(t, synchronizer)
+
+	if book.GetLastUpdateId() != 201 {
+		t.Fatalf("expected last update id 201, got %d", book.GetLastUpdateId())
+	}
+	if bid, _ := book.BestBid(); bid.Size != 2 {
+		t.Fatalf("expected book reloaded from snapshot, got %v", bid)
+	}
+}
+
+func waitSynced(t *testing.T, synchronizer *Synchronizer) {
+	deadline := time.Now().Add(2 * time.Second)
+	for !synchronizer.Synced() {
+		if time.Now().After(deadline) {
+			t.Fatal("synchronizer not synced")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,206 @@
+package orderbook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/339-Labs/exchange-market/common/retry"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	defaultMaxBuffer  = 1000
+	snapshotAttempts  = 3
+	maxSyncRounds     = 5
+	syncRetryWaitTime = time.Second
+)
+
+// ErrSequenceGap 增量更新ID不连续
+var ErrSequenceGap = errors.New("order book sequence gap")
+
+// Delta 增量更新, FirstUpdateId/FinalUpdateId 对应币安的 U/u
+type Delta struct {
+	FirstUpdateId int64
+	FinalUpdateId int64
+	EventTime     int64
+	Bids          [][]string
+	Asks          [][]string
+}
+
+// Snapshot 全量快照
+type Snapshot struct {
+	LastUpdateId int64
+	Bids         [][]string
+	Asks         [][]string
+}
+
+// SnapshotFetcher 获取交易对全量快照
+type SnapshotFetcher func(symbol string) (*Snapshot, error)
+
+// Synchronizer 快照+增量同步器
+// 未同步时缓存增量并拉取快照，丢弃快照之前的增量后依次应用；同步后检测更新ID是否连续，出现断档自动重新同步
+type Synchronizer struct {
+	book      *OrderBook
+	fetch     SnapshotFetcher
+	maxBuffer int
+
+	// OnResync 触发重新同步时回调
+	OnResync func(symbol string, reason error)
+
+	buffer  []*Delta
+	synced  bool
+	syncing bool
+	mu      sync.Mutex
+}
+
+// NewSynchronizer 创建同步器
+func NewSynchronizer(book *OrderBook, fetch SnapshotFetcher) *Synchronizer {
+	return &Synchronizer{
+		book:      book,
+		fetch:     fetch,
+		maxBuffer: defaultMaxBuffer,
+		buffer:    make([]*Delta, 0),
+	}
+}
+
+// Book 获取同步的订单簿
+func (s *Synchronizer) Book() *OrderBook {
+	return s.book
+}
+
+// Synced 是否已完成同步
+func (s *Synchronizer) Synced() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.synced
+}
+
+// Push 推入一条增量更新
+func (s *Synchronizer) Push(delta *Delta) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.synced {
+		s.bufferDelta(delta)
+		s.startSync(nil)
+		return nil
+	}
+
+	last := s.book.GetLastUpdateId()
+
+	// 过期的增量直接丢弃
+	if delta.FinalUpdateId <= last {
+		return nil
+	}
+
+	// 更新ID断档，重新同步
+	if delta.FirstUpdateId > last+1 {
+		err := fmt.Errorf("%w: %s expect %d, got %d", ErrSequenceGap, s.book.Symbol, last+1, delta.FirstUpdateId)
+		s.synced = false
+		s.buffer = s.buffer[:0]
+		s.bufferDelta(delta)
+		s.startSync(err)
+		return err
+	}
+
+	return s.book.Apply(delta.Bids, delta.Asks, delta.FinalUpdateId, delta.EventTime)
+}
+
+// Resync 强制重新同步
+func (s *Synchronizer) Resync(reason error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.synced = false
+	s.buffer = s.buffer[:0]
+	s.startSync(reason)
+}
+
+func (s *Synchronizer) bufferDelta(delta *Delta) {
+	if len(s.buffer) >= s.maxBuffer {
+		s.buffer = s.buffer[1:]
+	}
+	s.buffer = append(s.buffer, delta)
+}
+
+// startSync 启动同步协程，调用方需持有锁
+func (s *Synchronizer) startSync(reason error) {
+	if s.syncing {
+		return
+	}
+	s.syncing = true
+	go s.sync(reason)
+}
+
+func (s *Synchronizer) sync(reason error) {
+	symbol := s.book.Symbol
+	if reason != nil {
+		log.Warn("order book resync", "symbol", symbol, "reason", reason)
+		if s.OnResync != nil {
+			s.OnResync(symbol, reason)
+		}
+	}
+
+	for round := 0; round < maxSyncRounds; round++ {
+		snapshot, err := retry.Do(context.Background(), snapshotAttempts, retry.Exponential(), func() (*Snapshot, error) {
+			return s.fetch(symbol)
+		})
+		if err != nil {
+			log.Error("fetch order book snapshot failed", "symbol", symbol, "err", err)
+			break
+		}
+
+		done, err := s.applySnapshot(snapshot)
+		if done {
+			log.Info("order book synced", "symbol", symbol, "lastUpdateId", snapshot.LastUpdateId)
+			return
+		}
+		if err != nil {
+			log.Warn("apply order book snapshot failed", "symbol", symbol, "err", err)
+		}
+		time.Sleep(syncRetryWaitTime)
+	}
+
+	// 同步失败，等待下一条增量再次触发
+	s.mu.Lock()
+	s.syncing = false
+	s.mu.Unlock()
+}
+
+// applySnapshot 加载快照并应用缓存的增量，返回是否同步完成
+func (s *Synchronizer) applySnapshot(snapshot *Snapshot) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 快照早于缓存的第一条增量，需要重新获取快照
+	if len(s.buffer) > 0 && s.buffer[0].FirstUpdateId > snapshot.LastUpdateId+1 {
+		return false, fmt.Errorf("snapshot %d is older than buffered delta %d", snapshot.LastUpdateId, s.buffer[0].FirstUpdateId)
+	}
+
+	if err := s.book.Load(snapshot.Bids, snapshot.Asks, snapshot.LastUpdateId, time.Now().UnixMilli()); err != nil {
+		return false, err
+	}
+
+	last := snapshot.LastUpdateId
+	for _, delta := range s.buffer {
+		if delta.FinalUpdateId <= last {
+			continue
+		}
+		if delta.FirstUpdateId > last+1 {
+			s.buffer = s.buffer[:0]
+			return false, fmt.Errorf("%w: %s expect %d, got %d", ErrSequenceGap, s.book.Symbol, last+1, delta.FirstUpdateId)
+		}
+		if err := s.book.Apply(delta.Bids, delta.Asks, delta.FinalUpdateId, delta.EventTime); err != nil {
+			return false, err
+		}
+		last = delta.FinalUpdateId
+	}
+
+	s.buffer = s.buffer[:0]
+	s.synced = true
+	s.syncing = false
+	return true, nil
+}
@@ -0,0 +1,167 @@
+package symbols
+
+import (
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/ethereum/go-ethereum/log"
+	"strings"
+	"sync"
+)
+
+// quoteCoins 无分隔符交易对(BTCUSDT)识别计价币种, 长的在前避免 USD 先于 USDT 匹配
+var quoteCoins = []string{"FDUSD", "USDT", "USDC", "BUSD", "TUSD", "USDE", "DAI", "USD", "BTC", "ETH", "BNB", "EUR", "TRY", "BRL", "JPY", "MXN"}
+
+// Default 进程内共享的解析器, 由 UniverseTask 按 market_symbol 注册
+var Default = NewResolver()
+
+type venueKey struct {
+	exchange common.Exchange
+	instType string // spot 或 perp
+	symbol   string
+}
+
+// Resolver 交易所交易对与统一交易对的双向转换
+// 优先使用由交易所返回的 base/quote 注册的映射, 未注册的交易对按交易所的命名规则解析
+type Resolver struct {
+	toSymbol map[venueKey]Symbol // 交易所交易对 -> 统一交易对
+	toVenue  map[venueKey]string // 统一交易对 -> 交易所交易对
+	mu       sync.RWMutex
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{
+		toSymbol: make(map[venueKey]Symbol),
+		toVenue:  make(map[venueKey]string),
+	}
+}
+
+// Register 注册交易所交易对, 同时按完整交易对和 BASE/QUOTE 建立反向映射
+// 反向映射保留交易所交易对的原始大小写(池子地址为校验和格式), BASE/QUOTE 优先指向永续合约
+func (r *Resolver) Register(exchange common.Exchange, instType string, venueSymbol string, symbol Symbol) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.toSymbol[venueKey{exchange, instType, strings.ToUpper(venueSymbol)}] = symbol
+	r.toVenue[venueKey{exchange, instType, symbol.String()}] = venueSymbol
+	if pairKey := (venueKey{exchange, instType, symbol.Pair()}); r.toVenue[pairKey] == "" || symbol.Expiry == "" {
+		r.toVenue[pairKey] = venueSymbol
+	}
+}
+
+// RegisterUnified 按 market_symbol 中的统一交易对注册, 无法解析时忽略
+func (r *Resolver) RegisterUnified(exchange common.Exchange, instType string, venueSymbol string, unified string) {
+	symbol, err := Parse(unified)
+	if err != nil {
+		log.Warn("skip invalid unified symbol", "exchange", exchange, "symbol", venueSymbol, "unified", unified, "err", err)
+		return
+	}
+	r.Register(exchange, instType, venueSymbol, symbol)
+}
+
+// Resolve 交易所交易对转换为统一交易对
+func (r *Resolver) Resolve(exchange common.Exchange, instType string, venueSymbol string) (Symbol, bool) {
+	venueSymbol = strings.ToUpper(venueSymbol)
+
+	r.mu.RLock()
+	symbol, ok := r.toSymbol[venueKey{exchange, instType, venueSymbol}]
+	r.mu.RUnlock()
+	if ok {
+		return symbol, true
+	}
+	return parseVenue(exchange, instType, venueSymbol)
+}
+
+// Unify 交易所交易对转换为 BASE/QUOTE, 无法识别时返回大写的原始交易对
+func (r *Resolver) Unify(exchange common.Exchange, instType string, venueSymbol string) string {
+	if symbol, ok := r.Resolve(exchange, instType, venueSymbol); ok {
+		return symbol.Pair()
+	}
+	return strings.ToUpper(venueSymbol)
+}
+
+// ToVenue 统一交易对转换为交易所交易对, 交割合约只能通过注册的映射转换
+func (r *Resolver) ToVenue(exchange common.Exchange, instType string, symbol Symbol) (string, bool) {
+	r.mu.RLock()
+	venueSymbol, ok := r.toVenue[venueKey{exchange, instType, symbol.String()}]
+	if !ok {
+		venueSymbol, ok = r.toVenue[venueKey{exchange, instType, symbol.Pair()}]
+	}
+	r.mu.RUnlock()
+	if ok {
+		return venueSymbol, true
+	}
+	return formatVenue(exchange, instType, symbol)
+}
+
+// VenueSymbols 批量转换统一交易对, 无法转换的忽略
+func (r *Resolver) VenueSymbols(exchange common.Exchange, instType string, unified []string) []string {
+	result := make([]string, 0, len(unified))
+	for _, item := range unified {
+		symbol, err := Parse(item)
+		if err != nil {
+			continue
+		}
+		if venueSymbol, ok := r.ToVenue(exchange, instType, symbol); ok {
+			result = append(result, venueSymbol)
+		}
+	}
+	return result
+}
+
+// parseVenue 按交易所命名规则解析
+// okx: BTC-USDT, BTC-USDT-SWAP; gateio: BTC_USDT; bn/bybit/bitget: BTCUSDT
+func parseVenue(exchange common.Exchange, instType string, venueSymbol string) (Symbol, bool) {
+	var base, quote string
+	switch exchange {
+	case common.Okx:
+		parts := strings.Split(venueSymbol, "-")
+		if len(parts) < 2 {
+			return Symbol{}, false
+		}
+		base, quote = parts[0], parts[1]
+	case common.GateIo:
+		parts := strings.Split(venueSymbol, common.SymbolLink)
+		if len(parts) != 2 {
+			return Symbol{}, false
+		}
+		base, quote = parts[0], parts[1]
+	default:
+		for _, coin := range quoteCoins {
+			if prefix, ok := strings.CutSuffix(venueSymbol, coin); ok && prefix != "" {
+				base, quote = prefix, coin
+				break
+			}
+		}
+	}
+	if base == "" || quote == "" {
+		return Symbol{}, false
+	}
+
+	settle := ""
+	if instType == common.InstTypePerp {
+		// USD 计价为币本位合约, 以交易币结算
+		settle = quote
+		if quote == "USD" {
+			settle = base
+		}
+	}
+	return NewSymbol(base, quote, settle), true
+}
+
+// formatVenue 按交易所命名规则拼接
+func formatVenue(exchange common.Exchange, instType string, symbol Symbol) (string, bool) {
+	if symbol.Expiry != "" || symbol.Base == "" || symbol.Quote == "" {
+		return "", false
+	}
+
+	switch exchange {
+	case common.Okx:
+		if instType == common.InstTypePerp {
+			return symbol.Asset() + "-" + symbol.Quote + "-SWAP", true
+		}
+		return symbol.Asset() + "-" + symbol.Quote, true
+	case common.GateIo:
+		return symbol.Asset() + common.SymbolLink + symbol.Quote, true
+	default:
+		return symbol.Asset() + symbol.Quote, true
+	}
+}
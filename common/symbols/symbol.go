@@ -0,0 +1,122 @@
+package symbols
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// aliases 币种别名, 统一为常用名称
+var aliases = map[string]string{
+	"XBT": "BTC",
+	"XDG": "DOGE",
+}
+
+// multiplierPrefix 倍数前缀 1000PEPE、10000LADYS、1MBABYDOGE, 1INCH 这类以1开头的币种不匹配
+var multiplierPrefix = regexp.MustCompile(`^(10+|1M)([A-Z].*)$`)
+
+// Symbol 统一交易对 BASE/QUOTE[:SETTLE][-YYMMDD]
+// 现货 BTC/USDT, USDT永续 BTC/USDT:USDT, 币本位永续 BTC/USD:BTC, 交割合约 BTC/USDT:USDT-250627
+// 带倍数的交易对保留前缀 1000PEPE/USDT:USDT, 价格为 Multiplier 个 Base 的价格
+type Symbol struct {
+	Base       string // 去掉倍数前缀并转换别名后的币种
+	Quote      string
+	Settle     string // 合约结算币种, 现货为空
+	Expiry     string // 交割合约到期日 YYMMDD, 现货和永续为空
+	Multiplier int64  // 倍数, 没有前缀时为1
+}
+
+// NewSymbol 由交易所返回的币种创建交易对, 统一大小写、别名和倍数前缀
+func NewSymbol(base, quote, settle string) Symbol {
+	base, multiplier := splitMultiplier(normalizeAsset(base))
+	return Symbol{
+		Base:       base,
+		Quote:      normalizeAsset(quote),
+		Settle:     normalizeAsset(settle),
+		Multiplier: multiplier,
+	}
+}
+
+// WithExpiry 设置交割合约的到期时间, 毫秒, 0 表示不交割
+func (s Symbol) WithExpiry(expiryMs uint64) Symbol {
+	if expiryMs > 0 {
+		s.Expiry = time.UnixMilli(int64(expiryMs)).UTC().Format("060102")
+	}
+	return s
+}
+
+// Asset 带倍数前缀的币种 1000PEPE, 1M 前缀统一为 1000000
+func (s Symbol) Asset() string {
+	if s.Multiplier > 1 {
+		return strconv.FormatInt(s.Multiplier, 10) + s.Base
+	}
+	return s.Base
+}
+
+// Pair 不带结算币种的交易对 BTC/USDT, 用于同一产品类型内的跨交易所比较
+func (s Symbol) Pair() string {
+	return s.Asset() + "/" + s.Quote
+}
+
+// String 完整的统一交易对
+func (s Symbol) String() string {
+	result := s.Pair()
+	if s.Settle != "" {
+		result += ":" + s.Settle
+	}
+	if s.Expiry != "" {
+		result += "-" + s.Expiry
+	}
+	return result
+}
+
+// IsContract 是否为合约
+func (s Symbol) IsContract() bool {
+	return s.Settle != ""
+}
+
+// Parse 解析统一交易对, 兼容没有结算币种的 BTC/USDT
+func Parse(unified string) (Symbol, error) {
+	pair, contract, isContract := strings.Cut(strings.ToUpper(strings.TrimSpace(unified)), ":")
+	base, quote, ok := strings.Cut(pair, "/")
+	if !ok || base == "" || quote == "" {
+		return Symbol{}, fmt.Errorf("invalid unified symbol %q", unified)
+	}
+
+	settle, expiry, _ := strings.Cut(contract, "-")
+	if isContract && settle == "" {
+		return Symbol{}, fmt.Errorf("invalid unified symbol %q", unified)
+	}
+
+	symbol := NewSymbol(base, quote, settle)
+	symbol.Expiry = expiry
+	return symbol, nil
+}
+
+func normalizeAsset(asset string) string {
+	asset = strings.ToUpper(strings.TrimSpace(asset))
+	if alias, ok := aliases[asset]; ok {
+		return alias
+	}
+	return asset
+}
+
+// splitMultiplier 拆分倍数前缀, 1000PEPE -> PEPE, 1000
+func splitMultiplier(asset string) (string, int64) {
+	match := multiplierPrefix.FindStringSubmatch(asset)
+	if match == nil {
+		return asset, 1
+	}
+
+	base := normalizeAsset(match[2])
+	if match[1] == "1M" {
+		return base, 1_000_000
+	}
+	multiplier, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return asset, 1
+	}
+	return base, multiplier
+}
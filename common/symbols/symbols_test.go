@@ -0,0 +1,80 @@
+package symbols
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/339-Labs/exchange-market/common"
+)
+
+func TestNewSymbol(t *testing.T) {
+	cases := []struct {
+		base, quote, settle string
+		expiry              uint64
+		want                string
+		multiplier          int64
+	}{
+		{"btc", "usdt", "", 0, "BTC/USDT", 1},
+		{"XBT", "USD", "XBT", 0, "BTC/USD:BTC", 1},
+		{"1000PEPE", "USDT", "USDT", 0, "1000PEPE/USDT:USDT", 1000},
+		{"1MBABYDOGE", "USDT", "USDT", 0, "1000000BABYDOGE/USDT:USDT", 1000000},
+		{"1INCH", "USDT", "", 0, "1INCH/USDT", 1},
+		{"BTC", "USDT", "USDT", 1751011200000, "BTC/USDT:USDT-250627", 1},
+	}
+	for _, c := range cases {
+		symbol := NewSymbol(c.base, c.quote, c.settle).WithExpiry(c.expiry)
+		if symbol.String() != c.want || symbol.Multiplier != c.multiplier {
+			t.Errorf("NewSymbol(%s, %s, %s) = %s x%d, want %s x%d", c.base, c.quote, c.settle, symbol, symbol.Multiplier, c.want, c.multiplier)
+		}
+
+		parsed, err := Parse(c.want)
+		if err != nil || parsed != symbol {
+			t.Errorf("Parse(%s) = %+v, %v, want %+v", c.want, parsed, err, symbol)
+		}
+	}
+
+	for _, invalid := range []string{"BTCUSDT", "BTC/", "BTC/USDT:"} {
+		if _, err := Parse(invalid); err == nil {
+			t.Errorf("Parse(%s) expected error", invalid)
+		}
+	}
+}
+
+func TestResolver(t *testing.T) {
+	cases := []struct {
+		exchange common.Exchange
+		instType string
+		venue    string
+		want     string
+	}{
+		{common.BN, common.InstTypeSpot, "BTCUSDT", "BTC/USDT"},
+		{common.BN, common.InstTypeSpot, "USDCUSDT", "USDC/USDT"},
+		{common.BN, common.InstTypeSpot, "ETHFDUSD", "ETH/FDUSD"},
+		{common.BN, common.InstTypePerp, "1000PEPEUSDT", "1000PEPE/USDT:USDT"},
+		{common.ByBit, common.InstTypePerp, "BTCUSD", "BTC/USD:BTC"},
+		{common.BitGet, common.InstTypeSpot, "ethusdc", "ETH/USDC"},
+		{common.Okx, common.InstTypeSpot, "BTC-USDT", "BTC/USDT"},
+		{common.Okx, common.InstTypePerp, "BTC-USDT-SWAP", "BTC/USDT:USDT"},
+		{common.GateIo, common.InstTypeSpot, "BTC_USDT", "BTC/USDT"},
+	}
+
+	resolver := NewResolver()
+	for _, c := range cases {
+		symbol, ok := resolver.Resolve(c.exchange, c.instType, c.venue)
+		if !ok || symbol.String() != c.want {
+			t.Errorf("Resolve(%s, %s) = %s, want %s", c.exchange, c.venue, symbol, c.want)
+		}
+		if venue, ok := resolver.ToVenue(c.exchange, c.instType, symbol); !ok || venue != strings.ToUpper(c.venue) {
+			t.Errorf("ToVenue(%s, %s) = %s, want %s", c.exchange, symbol, venue, c.venue)
+		}
+	}
+
+	// 注册的映射优先于命名规则, 报价币种不在列表中也能识别
+	resolver.RegisterUnified(common.BN, common.InstTypeSpot, "BTCPLN", "BTC/PLN")
+	if got := resolver.Unify(common.BN, common.InstTypeSpot, "BTCPLN"); got != "BTC/PLN" {
+		t.Errorf("Unify(BTCPLN) = %s, want BTC/PLN", got)
+	}
+	if got := resolver.VenueSymbols(common.BN, common.InstTypeSpot, []string{"BTC/PLN", "ETH/USDT", "bad"}); len(got) != 2 || got[0] != "BTCPLN" || got[1] != "ETHUSDT" {
+		t.Errorf("VenueSymbols = %v", got)
+	}
+}
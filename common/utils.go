@@ -108,6 +108,11 @@ func BytesToArrMap(bytes []byte) []map[string]interface{} {
 	return tempMap
 }
 
+// PrecisionStep 小数位数转换为步长, 例如 2 -> 0.01, 0 -> 1
+func PrecisionStep(precision int) Decimal {
+	return NewDecimal(1, int32(max(precision, 0)))
+}
+
 func NewParams() map[string]string {
 	return make(map[string]string)
 }
@@ -0,0 +1,185 @@
+package ws
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ShardConn 分片连接池中的单个连接
+type ShardConn[T comparable] interface {
+	// SubscribeTopics 订阅并注册到连接的订阅注册表
+	SubscribeTopics(topics []T) error
+	// UnsubscribeTopics 取消订阅并从订阅注册表移除
+	UnsubscribeTopics(topics []T) error
+	// Close 关闭连接
+	Close() error
+}
+
+// ShardPoolConfig 分片连接池配置
+type ShardPoolConfig struct {
+	MaxTopicsPerConn int           // 单连接的最大订阅数, 交易所限制
+	BatchSize        int           // 单次订阅请求的最大topic数
+	BatchInterval    time.Duration // 两次订阅请求的间隔
+}
+
+// ShardPool 按单连接的最大订阅数把订阅分配到多个连接
+// Sync 传入期望的完整订阅集合, 新增的订阅优先放到未满的连接, 全部已满时创建新连接, 不再需要的订阅取消
+type ShardPool[T comparable] struct {
+	config  ShardPoolConfig
+	newConn func(index int) (ShardConn[T], error)
+
+	conns  []ShardConn[T]
+	topics []map[T]struct{} // 各连接的订阅
+	owner  map[T]int        // 订阅 -> 连接下标
+	mu     sync.Mutex
+}
+
+// NewShardPool 创建分片连接池, newConn 的 index 为连接下标, 可用于复用已有的第一个连接
+func NewShardPool[T comparable](config ShardPoolConfig, newConn func(index int) (ShardConn[T], error)) *ShardPool[T] {
+	if config.MaxTopicsPerConn <= 0 {
+		config.MaxTopicsPerConn = 1
+	}
+	if config.BatchSize <= 0 || config.BatchSize > config.MaxTopicsPerConn {
+		config.BatchSize = config.MaxTopicsPerConn
+	}
+	return &ShardPool[T]{
+		config:  config,
+		newConn: newConn,
+		owner:   make(map[T]int),
+	}
+}
+
+// Sync 调整订阅为 desired, 返回新增和取消的订阅数量
+// 订阅部分失败时保留已发送的批次, 失败的批次从连接的订阅注册表移除, 未发送和失败的topic不记录, 下次 Sync 时重试
+func (p *ShardPool[T]) Sync(desired []T) (added int, removed int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	wanted := make(map[T]struct{}, len(desired))
+	for _, topic := range desired {
+		wanted[topic] = struct{}{}
+	}
+
+	// 取消不再需要的订阅
+	stale := make(map[int][]T)
+	for topic, index := range p.owner {
+		if _, ok := wanted[topic]; !ok {
+			stale[index] = append(stale[index], topic)
+		}
+	}
+	for index, topics := range stale {
+		for _, topic := range topics {
+			delete(p.owner, topic)
+			delete(p.topics[index], topic)
+		}
+		removed += len(topics)
+		if _, unsubErr := p.sendBatches(topics, p.conns[index].UnsubscribeTopics); unsubErr != nil {
+			err = errors.Join(err, fmt.Errorf("unsubscribe on conn %d: %w", index, unsubErr))
+		}
+	}
+
+	// 新增订阅, 按连接分组
+	pending := make(map[int][]T)
+	order := make([]int, 0)
+	for _, topic := range desired {
+		if _, ok := p.owner[topic]; ok {
+			continue
+		}
+
+		index, connErr := p.freeConn(pending)
+		if connErr != nil {
+			err = errors.Join(err, connErr)
+			break
+		}
+		if _, ok := pending[index]; !ok {
+			order = append(order, index)
+		}
+		pending[index] = append(pending[index], topic)
+		p.owner[topic] = index
+	}
+
+	for _, index := range order {
+		topics := pending[index]
+		sent, subErr := p.sendBatches(topics, p.conns[index].SubscribeTopics)
+		if subErr != nil {
+			err = errors.Join(err, fmt.Errorf("subscribe on conn %d: %w", index, subErr))
+			failed := topics[sent:min(sent+p.config.BatchSize, len(topics))]
+			// 订阅前已加入注册表, 移除后重连时不会重放
+			_ = p.conns[index].UnsubscribeTopics(failed)
+			for _, topic := range topics[sent:] {
+				delete(p.owner, topic)
+			}
+		}
+		for _, topic := range topics[:sent] {
+			p.topics[index][topic] = struct{}{}
+		}
+		added += sent
+	}
+
+	return added, removed, err
+}
+
+// freeConn 返回有剩余容量的连接下标, 全部已满时创建新连接
+func (p *ShardPool[T]) freeConn(pending map[int][]T) (int, error) {
+	for index := range p.conns {
+		if len(p.topics[index])+len(pending[index]) < p.config.MaxTopicsPerConn {
+			return index, nil
+		}
+	}
+
+	index := len(p.conns)
+	conn, err := p.newConn(index)
+	if err != nil {
+		return 0, fmt.Errorf("create conn %d: %w", index, err)
+	}
+	p.conns = append(p.conns, conn)
+	p.topics = append(p.topics, make(map[T]struct{}))
+	return index, nil
+}
+
+// sendBatches 按批次大小和间隔发送请求, 返回失败前已发送的topic数量
+func (p *ShardPool[T]) sendBatches(topics []T, send func([]T) error) (int, error) {
+	for start := 0; start < len(topics); start += p.config.BatchSize {
+		if start > 0 && p.config.BatchInterval > 0 {
+			time.Sleep(p.config.BatchInterval)
+		}
+		end := min(start+p.config.BatchSize, len(topics))
+		if err := send(topics[start:end]); err != nil {
+			return start, err
+		}
+	}
+	return len(topics), nil
+}
+
+// Conns 连接数量
+func (p *ShardPool[T]) Conns() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.conns)
+}
+
+// Len 当前订阅数量
+func (p *ShardPool[T]) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.owner)
+}
+
+// Close 关闭所有连接
+func (p *ShardPool[T]) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var result error
+	for _, conn := range p.conns {
+		if err := conn.Close(); err != nil {
+			result = errors.Join(result, err)
+		}
+	}
+	p.conns = nil
+	p.topics = nil
+	p.owner = make(map[T]int)
+	return result
+}
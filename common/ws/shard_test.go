@@ -0,0 +1,100 @@
+package ws
+
+import (
+	"errors"
+	"testing"
+)
+
+type mockShardConn struct {
+	topics map[string]bool
+	fail   bool
+	failOn string // 批次包含该topic时发送失败, 与注册表一样先记录再发送
+	closed bool
+}
+
+func (m *mockShardConn) SubscribeTopics(topics []string) error {
+	if m.fail {
+		return errors.New("send failed")
+	}
+	for _, topic := range topics {
+		m.topics[topic] = true
+	}
+	for _, topic := range topics {
+		if topic == m.failOn {
+			return errors.New("send failed")
+		}
+	}
+	return nil
+}
+
+func (m *mockShardConn) UnsubscribeTopics(topics []string) error {
+	for _, topic := range topics {
+		delete(m.topics, topic)
+	}
+	return nil
+}
+
+func (m *mockShardConn) Close() error {
+	m.closed = true
+	return nil
+}
+
+func TestShardPool_Sync(t *testing.T) {
+	conns := make([]*mockShardConn, 0)
+	pool := NewShardPool(ShardPoolConfig{MaxTopicsPerConn: 2, BatchSize: 1}, func(index int) (ShardConn[string], error) {
+		conn := &mockShardConn{topics: make(map[string]bool)}
+		conns = append(conns, conn)
+		return conn, nil
+	})
+
+	added, removed, err := pool.Sync([]string{"a", "b", "c"})
+	if err != nil || added != 3 || removed != 0 {
+		t.Fatalf("unexpected sync result added=%d removed=%d err=%v", added, removed, err)
+	}
+	if pool.Conns() != 2 || !conns[0].topics["a"] || !conns[0].topics["b"] || !conns[1].topics["c"] {
+		t.Fatalf("topics not sharded by limit")
+	}
+
+	// b 下架后 d 复用空出的位置
+	added, removed, err = pool.Sync([]string{"a", "c", "d"})
+	if err != nil || added != 1 || removed != 1 {
+		t.Fatalf("unexpected sync result added=%d removed=%d err=%v", added, removed, err)
+	}
+	if pool.Conns() != 2 || conns[0].topics["b"] || !conns[0].topics["d"] {
+		t.Fatalf("freed slot not reused")
+	}
+
+	// 订阅失败的topic下次重试
+	conns[1].fail = true
+	_, _, err = pool.Sync([]string{"a", "c", "d", "e", "f"})
+	if err == nil || pool.Len() != 4 {
+		t.Fatalf("expected failed topics to be dropped, len=%d err=%v", pool.Len(), err)
+	}
+
+	if err := pool.Close(); err != nil || !conns[0].closed || !conns[1].closed {
+		t.Fatalf("conns not closed")
+	}
+}
+
+func TestShardPool_PartialFailure(t *testing.T) {
+	conn := &mockShardConn{topics: make(map[string]bool), failOn: "c"}
+	pool := NewShardPool(ShardPoolConfig{MaxTopicsPerConn: 4, BatchSize: 1}, func(index int) (ShardConn[string], error) {
+		return conn, nil
+	})
+
+	// a、b 已发送, c 失败, d 未发送
+	added, _, err := pool.Sync([]string{"a", "b", "c", "d"})
+	if err == nil || added != 2 || pool.Len() != 2 {
+		t.Fatalf("unexpected sync result added=%d len=%d err=%v", added, pool.Len(), err)
+	}
+	if !conn.topics["a"] || !conn.topics["b"] || conn.topics["c"] || conn.topics["d"] {
+		t.Fatalf("conn topics %v, want only a and b", conn.topics)
+	}
+
+	// 重试时只订阅未记录的topic
+	conn.failOn = ""
+	added, _, err = pool.Sync([]string{"a", "b", "c", "d"})
+	if err != nil || added != 2 || pool.Len() != 4 {
+		t.Fatalf("unexpected retry result added=%d len=%d err=%v", added, pool.Len(), err)
+	}
+}
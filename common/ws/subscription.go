@@ -0,0 +1,160 @@
+package ws
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	defaultSubscribeBatchSize = 10
+	defaultLoginTimeout       = 10 * time.Second
+	loginPollInterval         = 100 * time.Millisecond
+)
+
+// Subscriber 交易所订阅适配接口，由各交易所的消息处理器实现
+type Subscriber interface {
+	// RequireLogin 是否需要登录
+	RequireLogin() bool
+	// Login 发送登录请求
+	Login() error
+	// IsLoggedIn 检查是否已登录
+	IsLoggedIn() bool
+	// SendSubscribe 按交易所格式批量发送订阅请求
+	SendSubscribe(topics []interface{}) error
+}
+
+// SubscriptionRegistry 订阅注册表，记录当前生效的订阅，重连后按批次重放
+type SubscriptionRegistry struct {
+	subscriber    Subscriber
+	batchSize     int
+	batchInterval time.Duration
+	loginTimeout  time.Duration
+
+	topics map[interface{}]uint64 // 订阅 -> 加入顺序
+	seq    uint64
+	mu     sync.RWMutex
+}
+
+// NewSubscriptionRegistry 创建订阅注册表
+// batchSize 为单次订阅请求允许携带的最大topic数, batchInterval 为两次订阅请求的间隔(交易所限频)
+func NewSubscriptionRegistry(subscriber Subscriber, batchSize int, batchInterval time.Duration) *SubscriptionRegistry {
+	if batchSize <= 0 {
+		batchSize = defaultSubscribeBatchSize
+	}
+	return &SubscriptionRegistry{
+		subscriber:    subscriber,
+		batchSize:     batchSize,
+		batchInterval: batchInterval,
+		loginTimeout:  defaultLoginTimeout,
+		topics:        make(map[interface{}]uint64),
+	}
+}
+
+// Add 添加订阅
+func (r *SubscriptionRegistry) Add(topic interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.topics[topic]; ok {
+		return
+	}
+	r.seq++
+	r.topics[topic] = r.seq
+}
+
+// Remove 移除订阅
+func (r *SubscriptionRegistry) Remove(topic interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.topics, topic)
+}
+
+// Has 是否存在订阅
+func (r *SubscriptionRegistry) Has(topic interface{}) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.topics[topic]
+	return ok
+}
+
+// Len 订阅数量
+func (r *SubscriptionRegistry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.topics)
+}
+
+// List 按加入顺序返回所有订阅
+func (r *SubscriptionRegistry) List() []interface{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]interface{}, 0, len(r.topics))
+	for topic := range r.topics {
+		list = append(list, topic)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return r.topics[list[i]] < r.topics[list[j]]
+	})
+	return list
+}
+
+// Batches 按批次大小切分所有订阅
+func (r *SubscriptionRegistry) Batches() [][]interface{} {
+	list := r.List()
+	batches := make([][]interface{}, 0, (len(list)+r.batchSize-1)/r.batchSize)
+	for start := 0; start < len(list); start += r.batchSize {
+		end := start + r.batchSize
+		if end > len(list) {
+			end = len(list)
+		}
+		batches = append(batches, list[start:end])
+	}
+	return batches
+}
+
+// Restore 连接建立后恢复会话：需要登录时先登录，再按批次重放所有订阅，返回重放的订阅数量
+func (r *SubscriptionRegistry) Restore() (int, error) {
+	if r.subscriber == nil {
+		return 0, fmt.Errorf("subscriber is not set")
+	}
+
+	if r.subscriber.RequireLogin() {
+		if err := r.subscriber.Login(); err != nil {
+			return 0, fmt.Errorf("login failed: %w", err)
+		}
+		if err := r.waitLogin(); err != nil {
+			return 0, err
+		}
+	}
+
+	count := 0
+	for i, batch := range r.Batches() {
+		if i > 0 && r.batchInterval > 0 {
+			time.Sleep(r.batchInterval)
+		}
+		if err := r.subscriber.SendSubscribe(batch); err != nil {
+			return count, fmt.Errorf("resubscribe batch %d failed: %w", i, err)
+		}
+		count += len(batch)
+	}
+
+	log.Info("subscriptions restored", "count", count)
+	return count, nil
+}
+
+// waitLogin 等待登录响应
+func (r *SubscriptionRegistry) waitLogin() error {
+	deadline := time.Now().Add(r.loginTimeout)
+	for !r.subscriber.IsLoggedIn() {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("login timeout after %s", r.loginTimeout)
+		}
+		time.Sleep(loginPollInterval)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package ws
+
+import (
+	"errors"
+	"testing"
+)
+
+type mockSubscriber struct {
+	needLogin bool
+	loggedIn  bool
+	logins    int
+	batches   [][]interface{}
+	failAt    int
+}
+
+func (m *mockSubscriber) RequireLogin() bool { return m.needLogin }
+
+func (m *mockSubscriber) Login() error {
+	m.logins++
+	m.loggedIn = true
+	return nil
+}
+
+func (m *mockSubscriber) IsLoggedIn() bool { return m.loggedIn }
+
+func (m *mockSubscriber) SendSubscribe(topics []interface{}) error {
+	if m.failAt > 0 && len(m.batches)+1 == m.failAt {
+		return errors.New("send failed")
+	}
+	m.batches = append(m.batches, topics)
+	return nil
+}
+
+func TestSubscriptionRegistry_Restore(t *testing.T) {
+	sub := &mockSubscriber{needLogin: true}
+	registry := NewSubscriptionRegistry(sub, 2, 0)
+
+	for _, topic := range []string{"a", "b", "c", "d", "e"} {
+		registry.Add(topic)
+	}
+	registry.Add("b")
+	registry.Remove("d")
+
+	count, err := registry.Restore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 {
+		t.Fatalf("expected 4 topics restored, got %d", count)
+	}
+	if sub.logins != 1 {
+		t.Fatalf("expected 1 login, got %d", sub.logins)
+	}
+
+	expected := [][]interface{}{{"a", "b"}, {"c", "e"}}
+	if len(sub.batches) != len(expected) {
+		t.Fatalf("expected %d batches, got %d", len(expected), len(sub.batches))
+	}
+	for i, batch := range expected {
+		for j, topic := range batch {
+			if sub.batches[i][j] != topic {
+				t.Fatalf("batch %d: expected %v, got %v", i, batch, sub.batches[i])
+			}
+		}
+	}
+}
+
+func TestSubscriptionRegistry_RestoreError(t *testing.T) {
+	sub := &mockSubscriber{failAt: 2}
+	registry := NewSubscriptionRegistry(sub, 1, 0)
+	registry.Add("a")
+	registry.Add("b")
+
+	count, err := registry.Restore()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if count != 1 {
+		t.Fatalf("expected 1 topic restored before failure, got %d", count)
+	}
+	if sub.logins != 0 {
+		t.Fatalf("expected no login, got %d", sub.logins)
+	}
+}
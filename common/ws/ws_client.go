@@ -6,6 +6,7 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/robfig/cron"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -57,14 +58,19 @@ type GenericWebSocketClient struct {
 	PingCron         *cron.Cron
 	LastReceivedTime time.Time
 
+	// 订阅注册表，重连后自动重放
+	Subscriptions *SubscriptionRegistry
+
 	// 回调函数
 	OnConnected    func()
 	OnDisconnected func()
 	OnReconnecting func(attempt int)
+	OnResubscribed func(count int, err error)
 
 	// 控制
 	stopChan       chan struct{}
 	reconnectCount int
+	reconnecting   atomic.Bool
 	isRunning      bool
 	mu             sync.RWMutex
 }
@@ -99,6 +105,16 @@ func (c *GenericWebSocketClient) SetCallbacks(onConnected, onDisconnected func()
 	c.OnReconnecting = onReconnecting
 }
 
+// SetSubscriptionRegistry 设置订阅注册表
+func (c *GenericWebSocketClient) SetSubscriptionRegistry(registry *SubscriptionRegistry) {
+	c.Subscriptions = registry
+}
+
+// SetResubscribeCallback 设置订阅重放回调，count 为重放的订阅数量，err 为空表示重放成功
+func (c *GenericWebSocketClient) SetResubscribeCallback(onResubscribed func(count int, err error)) {
+	c.OnResubscribed = onResubscribed
+}
+
 // Start 启动WebSocket客户端
 func (c *GenericWebSocketClient) Start() error {
 	c.mu.Lock()
@@ -132,6 +148,7 @@ func (c *GenericWebSocketClient) Start() error {
 		c.startPing()
 	}
 
+	// 首次连接由调用方登录和订阅, 订阅注册表只在重连后重放, 避免与调用方的订阅重复发送
 	return nil
 }
 
@@ -212,6 +229,12 @@ func (c *GenericWebSocketClient) disconnect() error {
 
 // reconnect 重连
 func (c *GenericWebSocketClient) reconnect() {
+	// 同一时间只允许一个重连流程
+	if !c.reconnecting.CompareAndSwap(false, true) {
+		return
+	}
+	defer c.reconnecting.Store(false)
+
 	c.reconnectCount++
 
 	if c.Config.MaxReconnectAttempts > 0 && c.reconnectCount > c.Config.MaxReconnectAttempts {
@@ -233,6 +256,26 @@ func (c *GenericWebSocketClient) reconnect() {
 	err := c.connect()
 	if err != nil {
 		log.Error("Reconnection failed: %s", err)
+		return
+	}
+
+	// 重新登录并重放订阅
+	c.restoreSession()
+}
+
+// restoreSession 重连后恢复会话：登录并重放订阅注册表中的所有订阅
+func (c *GenericWebSocketClient) restoreSession() {
+	if c.Subscriptions == nil {
+		return
+	}
+
+	count, err := c.Subscriptions.Restore()
+	if err != nil {
+		log.Error("restore subscriptions failed", "count", count, "err", err)
+	}
+
+	if c.OnResubscribed != nil {
+		c.OnResubscribed(count, err)
 	}
 }
 
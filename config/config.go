@@ -3,14 +3,19 @@ package config
 import (
 	"github.com/339-Labs/exchange-market/flags"
 	"github.com/urfave/cli/v2"
+	"time"
 )
 
 type Config struct {
 	Migrations       string
-	HttpServerConfig ServerConfig   `json:"http_server_config"`
-	SlaveDBConfig    DBConfig       `json:"slave_db_config"`
-	RedisConfig      RedisConfig    `json:"redis_config"`
-	ExchangeConfig   ExchangeConfig `json:"exchange_config"`
+	HttpServerConfig ServerConfig    `json:"http_server_config"`
+	SlaveDBConfig    DBConfig        `json:"slave_db_config"`
+	RedisConfig      RedisConfig     `json:"redis_config"`
+	ExchangeConfig   ExchangeConfig  `json:"exchange_config"`
+	UniverseConfig   UniverseConfig  `json:"universe_config"`
+	IndexConfig      IndexConfig     `json:"index_config"`
+	ArbitrageConfig  ArbitrageConfig `json:"arbitrage_config"`
+	FundingConfig    FundingConfig   `json:"funding_config"`
 }
 
 type ServerConfig struct {
@@ -30,6 +35,45 @@ type RedisConfig struct {
 	Address  string `json:"address"`
 	Password string `json:"password"`
 	Username string `json:"username"`
+	// LegacyKeys 同时写入旧的 market_data:<symbol> 键, 读取方迁移完成后关闭
+	LegacyKeys bool `json:"legacy_keys"`
+}
+
+// UniverseConfig 从 market_symbol 加载订阅交易对的过滤条件
+// Allow、Deny 的元素可以是币种 BTC、统一交易对 BTC/USDT 或交易所原始交易对 BTCUSDT
+type UniverseConfig struct {
+	Quotes  []string      `json:"quotes"` // 计价币种, 为空时不过滤
+	Allow   []string      `json:"allow"`  // 白名单, 为空时不过滤
+	Deny    []string      `json:"deny"`   // 黑名单
+	Refresh time.Duration `json:"refresh"`
+}
+
+// IndexConfig 跨交易所指数价格, Symbols 为空时不计算
+type IndexConfig struct {
+	Symbols      []string      `json:"symbols"` // 统一交易对 BASE/QUOTE
+	Method       string        `json:"method"`  // median、volume 或 liquidity
+	Interval     time.Duration `json:"interval"`
+	MaxAge       time.Duration `json:"max_age"`       // 超过该时间未更新的交易所被剔除
+	MaxDeviation float64       `json:"max_deviation"` // 偏离中位数的最大比例, 0 为不剔除
+	MinSources   int           `json:"min_sources"`
+}
+
+// ArbitrageConfig 跨交易所套利机会检测
+type ArbitrageConfig struct {
+	Enabled     bool          `json:"enabled"`
+	TakerFees   []string      `json:"taker_fees"`  // 交易所=手续费率, 例如 bn=0.001
+	DefaultFee  float64       `json:"default_fee"` // 未配置的交易所使用的手续费率
+	Threshold   float64       `json:"threshold"`   // 扣除手续费后的最小价差比例
+	MinDuration time.Duration `json:"min_duration"`
+	Interval    time.Duration `json:"interval"`
+	MaxAge      time.Duration `json:"max_age"` // 超过该时间未更新的报价不参与比较
+}
+
+// FundingConfig 资金费率和基差分析
+type FundingConfig struct {
+	Enabled  bool          `json:"enabled"`
+	Interval time.Duration `json:"interval"`
+	Period   time.Duration `json:"period"` // 未观察到交易所的结算间隔前使用的默认间隔
 }
 
 type ExchangeConfig struct {
@@ -40,6 +84,7 @@ type ExchangeConfig struct {
 	GateIo CexExchangeConfig `json:"gateio"`
 
 	UniswapV2 DexExchangeConfig `json:"unswapV2"`
+	UniswapV3 DexExchangeConfig `json:"uniswapV3"`
 }
 
 type CexExchangeConfig struct {
@@ -52,9 +97,17 @@ type CexExchangeConfig struct {
 	TimeOut      int64  `json:"timeout"`
 }
 
+// DexExchangeConfig 链上交易所, RpcUrl 或 Factory 为空时不启动
 type DexExchangeConfig struct {
-	RpcUrl   string `json:"rpc_url"`
-	WsRpcUrl string `json:"ws_rpc_url"`
+	RpcUrl        string        `json:"rpc_url"`
+	WsRpcUrl      string        `json:"ws_rpc_url"`
+	Factory       string        `json:"factory"`        // factory 合约地址
+	Pools         []string      `json:"pools"`          // 计算价格的池子地址, 每个统一交易对只能选一个池子, 为空时只发现池子不计算价格
+	Interval      time.Duration `json:"interval"`       // 发现交易对和刷新价格的间隔
+	BatchSize     int           `json:"batch_size"`     // 每次最多发现的交易对数量
+	StartBlock    uint64        `json:"start_block"`    // factory 合约的部署区块, 按事件发现池子时从该区块回填
+	DepthPercents []string      `json:"depth_percents"` // 合成深度的价格变动百分比, 例如 1 表示 1%, 为空时不计算深度
+	Confirmations uint64        `json:"confirmations"`  // 处理日志前需要的确认区块数, 为0时处理最新区块并在回滚时撤销
 }
 
 func NewConfig(ctx *cli.Context) (*Config, error) {
@@ -72,9 +125,38 @@ func NewConfig(ctx *cli.Context) (*Config, error) {
 			Name: ctx.String(flags.SlaveDbNameFlag.Name),
 		},
 		RedisConfig: RedisConfig{
-			Address:  ctx.String(flags.RedisAddressFlag.Name),
-			Password: ctx.String(flags.RedisPasswordFlag.Name),
-			Username: ctx.String(flags.RedisUserNameFlag.Name),
+			Address:    ctx.String(flags.RedisAddressFlag.Name),
+			Password:   ctx.String(flags.RedisPasswordFlag.Name),
+			Username:   ctx.String(flags.RedisUserNameFlag.Name),
+			LegacyKeys: ctx.Bool(flags.RedisLegacyKeysFlag.Name),
+		},
+		UniverseConfig: UniverseConfig{
+			Quotes:  ctx.StringSlice(flags.UniverseQuotesFlag.Name),
+			Allow:   ctx.StringSlice(flags.UniverseAllowFlag.Name),
+			Deny:    ctx.StringSlice(flags.UniverseDenyFlag.Name),
+			Refresh: ctx.Duration(flags.UniverseRefreshFlag.Name),
+		},
+		IndexConfig: IndexConfig{
+			Symbols:      ctx.StringSlice(flags.IndexSymbolsFlag.Name),
+			Method:       ctx.String(flags.IndexMethodFlag.Name),
+			Interval:     ctx.Duration(flags.IndexIntervalFlag.Name),
+			MaxAge:       ctx.Duration(flags.IndexMaxAgeFlag.Name),
+			MaxDeviation: ctx.Float64(flags.IndexMaxDeviationFlag.Name),
+			MinSources:   ctx.Int(flags.IndexMinSourcesFlag.Name),
+		},
+		ArbitrageConfig: ArbitrageConfig{
+			Enabled:     ctx.Bool(flags.ArbitrageEnabledFlag.Name),
+			TakerFees:   ctx.StringSlice(flags.ArbitrageTakerFeesFlag.Name),
+			DefaultFee:  ctx.Float64(flags.ArbitrageDefaultFeeFlag.Name),
+			Threshold:   ctx.Float64(flags.ArbitrageThresholdFlag.Name),
+			MinDuration: ctx.Duration(flags.ArbitrageMinDurationFlag.Name),
+			Interval:    ctx.Duration(flags.ArbitrageIntervalFlag.Name),
+			MaxAge:      ctx.Duration(flags.ArbitrageMaxAgeFlag.Name),
+		},
+		FundingConfig: FundingConfig{
+			Enabled:  ctx.Bool(flags.FundingEnabledFlag.Name),
+			Interval: ctx.Duration(flags.FundingIntervalFlag.Name),
+			Period:   ctx.Duration(flags.FundingPeriodFlag.Name),
 		},
 		ExchangeConfig: ExchangeConfig{
 			Bn: CexExchangeConfig{
@@ -82,13 +164,13 @@ func NewConfig(ctx *cli.Context) (*Config, error) {
 				ApiSecretKey: ctx.String(flags.BnApiSecretKeyFlag.Name),
 				ApiUrl:       ctx.String(flags.BnApiUrlFlag.Name),
 				WsUrl:        ctx.String(flags.BnWsUrlFlag.Name),
-				WsUrlFeature: ctx.String(flags.ByBitWsUrlFeature.Name),
+				WsUrlFeature: ctx.String(flags.BnWsUrlFeature.Name),
 				Passphrase:   ctx.String(flags.BnPassphrase.Name),
 				TimeOut:      ctx.Int64(flags.BnTimeOut.Name),
 			},
 			Okx: CexExchangeConfig{
 				ApiKey:       ctx.String(flags.OkxApiKeyFlag.Name),
-				ApiSecretKey: ctx.String(flags.BnApiSecretKeyFlag.Name),
+				ApiSecretKey: ctx.String(flags.OkxApiSecretKeyFlag.Name),
 				ApiUrl:       ctx.String(flags.OkxApiUrlFlag.Name),
 				WsUrl:        ctx.String(flags.OkxWsUrlFlag.Name),
 				Passphrase:   ctx.String(flags.OkxPassphrase.Name),
@@ -111,7 +193,33 @@ func NewConfig(ctx *cli.Context) (*Config, error) {
 				Passphrase:   ctx.String(flags.BitGetPassphrase.Name),
 				TimeOut:      ctx.Int64(flags.BitGetTimeOut.Name),
 			},
-			GateIo: CexExchangeConfig{},
+			GateIo: CexExchangeConfig{
+				ApiKey:       ctx.String(flags.GateIoApiKeyFlag.Name),
+				ApiSecretKey: ctx.String(flags.GateIoApiSecretKeyFlag.Name),
+				ApiUrl:       ctx.String(flags.GateIoApiUrlFlag.Name),
+				WsUrl:        ctx.String(flags.GateIoWsUrlFlag.Name),
+				WsUrlFeature: ctx.String(flags.GateIoWsUrlFeature.Name),
+				TimeOut:      ctx.Int64(flags.GateIoTimeOut.Name),
+			},
+			UniswapV2: DexExchangeConfig{
+				RpcUrl:        ctx.String(flags.UniswapV2RpcUrlFlag.Name),
+				WsRpcUrl:      ctx.String(flags.UniswapV2WsRpcUrlFlag.Name),
+				Factory:       ctx.String(flags.UniswapV2FactoryFlag.Name),
+				Pools:         ctx.StringSlice(flags.UniswapV2PoolsFlag.Name),
+				Interval:      ctx.Duration(flags.UniswapV2IntervalFlag.Name),
+				BatchSize:     ctx.Int(flags.UniswapV2BatchSizeFlag.Name),
+				Confirmations: ctx.Uint64(flags.UniswapV2ConfirmationsFlag.Name),
+			},
+			UniswapV3: DexExchangeConfig{
+				RpcUrl:        ctx.String(flags.UniswapV3RpcUrlFlag.Name),
+				WsRpcUrl:      ctx.String(flags.UniswapV3WsRpcUrlFlag.Name),
+				Factory:       ctx.String(flags.UniswapV3FactoryFlag.Name),
+				Pools:         ctx.StringSlice(flags.UniswapV3PoolsFlag.Name),
+				Interval:      ctx.Duration(flags.UniswapV3IntervalFlag.Name),
+				StartBlock:    ctx.Uint64(flags.UniswapV3StartBlockFlag.Name),
+				DepthPercents: ctx.StringSlice(flags.UniswapV3DepthPercentsFlag.Name),
+				Confirmations: ctx.Uint64(flags.UniswapV3ConfirmationsFlag.Name),
+			},
 		},
 	}, nil
 }
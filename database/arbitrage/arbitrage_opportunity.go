@@ -0,0 +1,100 @@
+package arbitrage
+
+import (
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultQueryLimit 查询未指定条数时的默认条数
+const defaultQueryLimit = 100
+
+type ArbitrageOpportunity struct {
+	GUID          uuid.UUID `gorm:"primaryKey"`
+	UnifiedSymbol string
+	InstType      string // spot 或 perp
+	BuyExchange   string
+	SellExchange  string
+	BuyPrice      common.Decimal // 买入交易所的卖一价
+	SellPrice     common.Decimal // 卖出交易所的买一价
+	Size          common.Decimal // 两边挂单数量的较小值, 未知时为空
+	GrossEdge     common.Decimal
+	NetEdge       common.Decimal // 扣除手续费后的价差比例
+	MaxNetEdge    common.Decimal
+	StartTime     uint64 // 毫秒
+	EndTime       uint64 // 毫秒, 未结束为0
+	Timestamp     uint64
+}
+
+// TableName 表名为 arbitrage_opportunity, 避免 gorm 使用复数表名
+func (ArbitrageOpportunity) TableName() string {
+	return "arbitrage_opportunity"
+}
+
+// upsertColumns 机会已存在时更新的字段
+var upsertColumns = []string{"buy_price", "sell_price", "size", "gross_edge", "net_edge", "max_net_edge", "end_time", "timestamp"}
+
+// ArbitrageFilter 查询条件, 字段为空时不过滤
+type ArbitrageFilter struct {
+	UnifiedSymbol string
+	InstType      string
+	Exchange      string // 买入或卖出交易所
+	Active        bool   // 只查询未结束的机会
+	Since         uint64 // 开始时间不早于该毫秒时间
+	Limit         int
+}
+
+type arbitrageOpportunityDB struct {
+	gorm *gorm.DB
+}
+
+func NewArbitrageOpportunityDB(db *gorm.DB) ArbitrageOpportunityDB {
+	return &arbitrageOpportunityDB{
+		gorm: db,
+	}
+}
+
+type ArbitrageOpportunityDB interface {
+	UpsertArbitrageOpportunities(*[]ArbitrageOpportunity) error
+	QueryArbitrageOpportunities(filter ArbitrageFilter) ([]ArbitrageOpportunity, error)
+}
+
+// UpsertArbitrageOpportunities 按 guid 写入机会, 已存在时更新价格、价差和结束时间
+func (db *arbitrageOpportunityDB) UpsertArbitrageOpportunities(opportunities *[]ArbitrageOpportunity) error {
+	if len(*opportunities) == 0 {
+		return nil
+	}
+	result := db.gorm.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "guid"}},
+		DoUpdates: clause.AssignmentColumns(upsertColumns),
+	}).CreateInBatches(opportunities, len(*opportunities))
+	return result.Error
+}
+
+// QueryArbitrageOpportunities 按开始时间倒序查询机会
+func (db *arbitrageOpportunityDB) QueryArbitrageOpportunities(filter ArbitrageFilter) ([]ArbitrageOpportunity, error) {
+	var opportunities []ArbitrageOpportunity
+	query := db.gorm.Model(&ArbitrageOpportunity{})
+	if filter.UnifiedSymbol != "" {
+		query = query.Where("unified_symbol = ?", filter.UnifiedSymbol)
+	}
+	if filter.InstType != "" {
+		query = query.Where("inst_type = ?", filter.InstType)
+	}
+	if filter.Exchange != "" {
+		query = query.Where("(buy_exchange = ? OR sell_exchange = ?)", filter.Exchange, filter.Exchange)
+	}
+	if filter.Active {
+		query = query.Where("end_time = 0")
+	}
+	if filter.Since > 0 {
+		query = query.Where("start_time >= ?", filter.Since)
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultQueryLimit
+	}
+	result := query.Order("start_time DESC").Limit(limit).Find(&opportunities)
+	return opportunities, result.Error
+}
@@ -5,6 +5,10 @@ import (
 	"fmt"
 	"github.com/339-Labs/exchange-market/common/retry"
 	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/database/arbitrage"
+	"github.com/339-Labs/exchange-market/database/dex"
+	"github.com/339-Labs/exchange-market/database/funding"
+	"github.com/339-Labs/exchange-market/database/symbol"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/pkg/errors"
 	"gorm.io/driver/postgres"
@@ -15,6 +19,16 @@ import (
 
 type DB struct {
 	gorm *gorm.DB
+
+	MarketSymbol        symbol.MarketSymbolDB
+	SymbolSpotPrices    symbol.SymbolSpotPricesDB
+	SymbolFuturesPrices symbol.SymbolFuturesPricesDB
+
+	ArbitrageOpportunity arbitrage.ArbitrageOpportunityDB
+	FundingRateHistory   funding.FundingRateHistoryDB
+
+	DexPool       dex.DexPoolDB
+	DexCheckpoint dex.DexCheckpointDB
 }
 
 func NewDB(dbConfig *config.DBConfig) (*DB, error) {
@@ -48,18 +62,30 @@ func NewDB(dbConfig *config.DBConfig) (*DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	return &DB{gorm: gorm}, nil
+	return newDB(gorm), nil
 }
 
 func (db *DB) Transaction(fn func(db *DB) error) error {
 	return db.gorm.Transaction(func(tx *gorm.DB) error {
-		txDB := &DB{
-			gorm: tx,
-		}
-		return fn(txDB)
+		return fn(newDB(tx))
 	})
 }
 
+func newDB(gorm *gorm.DB) *DB {
+	return &DB{
+		gorm:                gorm,
+		MarketSymbol:        symbol.NewMarketSymbolDB(gorm),
+		SymbolSpotPrices:    symbol.NewSymbolSpotPricesDB(gorm),
+		SymbolFuturesPrices: symbol.NewSymbolFuturesPricesDB(gorm),
+
+		ArbitrageOpportunity: arbitrage.NewArbitrageOpportunityDB(gorm),
+		FundingRateHistory:   funding.NewFundingRateHistoryDB(gorm),
+
+		DexPool:       dex.NewDexPoolDB(gorm),
+		DexCheckpoint: dex.NewDexCheckpointDB(gorm),
+	}
+}
+
 func (db *DB) Close() error {
 	sql, err := db.gorm.DB()
 	if err != nil {
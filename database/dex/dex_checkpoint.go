@@ -0,0 +1,63 @@
+package dex
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// DexCheckpoint 链上索引的进度, 例如已处理的 allPairs 下标或区块高度
+type DexCheckpoint struct {
+	Exchange  string `gorm:"primaryKey"`
+	ChainId   string `gorm:"primaryKey"`
+	Name      string `gorm:"primaryKey"`
+	Value     uint64
+	Timestamp uint64
+}
+
+// TableName 表名为 dex_checkpoint, 避免 gorm 使用复数表名
+func (DexCheckpoint) TableName() string {
+	return "dex_checkpoint"
+}
+
+type dexCheckpointDB struct {
+	gorm *gorm.DB
+}
+
+func NewDexCheckpointDB(db *gorm.DB) DexCheckpointDB {
+	return &dexCheckpointDB{
+		gorm: db,
+	}
+}
+
+type DexCheckpointDB interface {
+	GetCheckpoint(exchange string, chainId string, name string) (uint64, error)
+	SaveCheckpoint(exchange string, chainId string, name string, value uint64) error
+}
+
+// GetCheckpoint 查询进度, 不存在时返回0
+func (db *dexCheckpointDB) GetCheckpoint(exchange string, chainId string, name string) (uint64, error) {
+	var checkpoint DexCheckpoint
+	result := db.gorm.Where("exchange = ? AND chain_id = ? AND name = ?", exchange, chainId, name).Take(&checkpoint)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	return checkpoint.Value, result.Error
+}
+
+// SaveCheckpoint 写入或覆盖进度
+func (db *dexCheckpointDB) SaveCheckpoint(exchange string, chainId string, name string, value uint64) error {
+	checkpoint := DexCheckpoint{
+		Exchange:  exchange,
+		ChainId:   chainId,
+		Name:      name,
+		Value:     value,
+		Timestamp: uint64(time.Now().UnixMilli()),
+	}
+	result := db.gorm.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "exchange"}, {Name: "chain_id"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "timestamp"}),
+	}).Create(&checkpoint)
+	return result.Error
+}
@@ -0,0 +1,77 @@
+package dex
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// DexPool 链上交易对池子, 记录两个代币的地址、符号和精度, 价格为 token1/token0
+type DexPool struct {
+	GUID        uuid.UUID `gorm:"primaryKey"`
+	Exchange    string
+	ChainId     string
+	Address     string // 池子合约地址
+	Token0      string
+	Token1      string
+	Symbol0     string
+	Symbol1     string
+	Decimals0   uint8
+	Decimals1   uint8
+	Fee         uint32 // V3 手续费档位, 单位为百万分之一, V2 为0
+	TickSpacing int32  // V3 tick 间隔, V2 为0
+	Block       uint64 // 创建池子的区块, 未知为0
+	Timestamp   uint64
+}
+
+// TableName 表名为 dex_pool, 避免 gorm 使用复数表名
+func (DexPool) TableName() string {
+	return "dex_pool"
+}
+
+type dexPoolDB struct {
+	gorm *gorm.DB
+}
+
+func NewDexPoolDB(db *gorm.DB) DexPoolDB {
+	return &dexPoolDB{
+		gorm: db,
+	}
+}
+
+type DexPoolDB interface {
+	SaveDexPools(*[]DexPool) error
+	QueryDexPools(exchange string, chainId string) ([]DexPool, error)
+}
+
+// SaveDexPools 写入池子, (exchange, chain_id, address) 已存在时忽略
+func (db *dexPoolDB) SaveDexPools(pools *[]DexPool) error {
+	if len(*pools) == 0 {
+		return nil
+	}
+	now := uint64(time.Now().UnixMilli())
+	for i := range *pools {
+		if (*pools)[i].GUID == uuid.Nil {
+			(*pools)[i].GUID = uuid.New()
+		}
+		if (*pools)[i].Timestamp == 0 {
+			(*pools)[i].Timestamp = now
+		}
+	}
+	result := db.gorm.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "exchange"}, {Name: "chain_id"}, {Name: "address"}},
+		DoNothing: true,
+	}).CreateInBatches(pools, 500)
+	return result.Error
+}
+
+// QueryDexPools 查询某条链上某个交易所的全部池子
+func (db *dexPoolDB) QueryDexPools(exchange string, chainId string) ([]DexPool, error) {
+	var pools []DexPool
+	result := db.gorm.Model(&DexPool{}).
+		Where("exchange = ? AND chain_id = ?", exchange, chainId).
+		Order("block, timestamp, address").
+		Find(&pools)
+	return pools, result.Error
+}
@@ -0,0 +1,82 @@
+package funding
+
+import (
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultQueryLimit 查询未指定条数时的默认条数
+const defaultQueryLimit = 100
+
+type FundingRateHistory struct {
+	GUID          uuid.UUID `gorm:"primaryKey"`
+	Exchange      string
+	Symbol        string
+	UnifiedSymbol string
+	FundingRate   common.Decimal
+	FundingTime   uint64 // 结算时间, 毫秒
+	Timestamp     uint64
+}
+
+// TableName 表名为 funding_rate_history, 避免 gorm 使用复数表名
+func (FundingRateHistory) TableName() string {
+	return "funding_rate_history"
+}
+
+// FundingHistoryFilter 查询条件, 字段为空时不过滤
+type FundingHistoryFilter struct {
+	UnifiedSymbol string
+	Exchange      string
+	Since         uint64 // 结算时间不早于该毫秒时间
+	Limit         int
+}
+
+type fundingRateHistoryDB struct {
+	gorm *gorm.DB
+}
+
+func NewFundingRateHistoryDB(db *gorm.DB) FundingRateHistoryDB {
+	return &fundingRateHistoryDB{
+		gorm: db,
+	}
+}
+
+type FundingRateHistoryDB interface {
+	SaveFundingRateHistory(*[]FundingRateHistory) error
+	QueryFundingRateHistory(filter FundingHistoryFilter) ([]FundingRateHistory, error)
+}
+
+// SaveFundingRateHistory 写入结算记录, (exchange, symbol, funding_time) 已存在时忽略
+func (db *fundingRateHistoryDB) SaveFundingRateHistory(history *[]FundingRateHistory) error {
+	if len(*history) == 0 {
+		return nil
+	}
+	result := db.gorm.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "exchange"}, {Name: "symbol"}, {Name: "funding_time"}},
+		DoNothing: true,
+	}).CreateInBatches(history, len(*history))
+	return result.Error
+}
+
+// QueryFundingRateHistory 按结算时间倒序查询
+func (db *fundingRateHistoryDB) QueryFundingRateHistory(filter FundingHistoryFilter) ([]FundingRateHistory, error) {
+	var history []FundingRateHistory
+	query := db.gorm.Model(&FundingRateHistory{})
+	if filter.UnifiedSymbol != "" {
+		query = query.Where("unified_symbol = ?", filter.UnifiedSymbol)
+	}
+	if filter.Exchange != "" {
+		query = query.Where("exchange = ?", filter.Exchange)
+	}
+	if filter.Since > 0 {
+		query = query.Where("funding_time >= ?", filter.Since)
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultQueryLimit
+	}
+	result := query.Order("funding_time DESC, exchange").Limit(limit).Find(&history)
+	return history, result.Error
+}
@@ -1,8 +1,25 @@
 package symbol
 
 import (
+	"github.com/339-Labs/exchange-market/common"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// 交易对的产品类型和状态
+const (
+	InstTypeSpot     = "Spot"
+	InstTypeFeature  = "Feature"  // 永续合约
+	InstTypeDelivery = "Delivery" // 交割合约, 到期时间为 ExpiryTime
+
+	StatusTrading   = "Trading"
+	StatusSuspended = "Suspended" // 暂停交易
+	StatusPending   = "Pending"   // 待上线
+	StatusDelisted  = "Delisted"
+
+	CexChainId = "999999" // 中心化交易所的 chain id
 )
 
 type MarketSymbol struct {
@@ -14,9 +31,29 @@ type MarketSymbol struct {
 	ChainId       string
 	Base          string
 	Quote         string
+	Status        string         // Trading、Suspended、Pending 或 Delisted
+	TickSize      common.Decimal // 价格最小变动
+	LotSize       common.Decimal // 数量步长, 合约为张数步长
+	MinQty        common.Decimal // 最小下单数量
+	MinNotional   common.Decimal // 最小下单金额
+	ContractValue common.Decimal // 合约面值, 一张合约对应的币数量, 现货为空
+	Settle        string         // 结算币种, 现货为空
+	ListTime      uint64         // 上线时间, 毫秒
+	ExpiryTime    uint64         // 交割时间, 毫秒, 现货和永续为0
 	Timestamp     uint64
 }
 
+// TableName 表名为 market_symbol, 避免 gorm 使用复数表名
+func (MarketSymbol) TableName() string {
+	return "market_symbol"
+}
+
+// syncColumns 交易对已存在时更新的字段
+var syncColumns = []string{
+	"unified_symbol", "chain_id", "base", "quote", "status",
+	"tick_size", "lot_size", "min_qty", "min_notional", "contract_value", "settle", "list_time", "expiry_time", "timestamp",
+}
+
 type marketSymbolDB struct {
 	gorm *gorm.DB
 }
@@ -30,6 +67,9 @@ func NewMarketSymbolDB(db *gorm.DB) MarketSymbolDB {
 type MarketSymbolDB interface {
 	SaveMarketSymbol(*[]MarketSymbol) error
 	UpdateMarketSymbol(*[]MarketSymbol) error
+	QueryMarketSymbols(exchange string, instType string) ([]MarketSymbol, error)
+	SyncMarketSymbols(exchange string, instType string, symbols []MarketSymbol) error
+	UpsertMarketSymbols(exchange string, instType string, symbols []MarketSymbol) error
 }
 
 func (db *marketSymbolDB) SaveMarketSymbol(symbolMappings *[]MarketSymbol) error {
@@ -41,3 +81,77 @@ func (db *marketSymbolDB) UpdateMarketSymbol(symbolMappings *[]MarketSymbol) err
 	result := db.gorm.Save(&symbolMappings)
 	return result.Error
 }
+
+// QueryMarketSymbols 查询交易对, exchange 或 instType 为空时不过滤
+func (db *marketSymbolDB) QueryMarketSymbols(exchange string, instType string) ([]MarketSymbol, error) {
+	var symbols []MarketSymbol
+	query := db.gorm.Model(&MarketSymbol{})
+	if exchange != "" {
+		query = query.Where("exchange = ?", exchange)
+	}
+	if instType != "" {
+		query = query.Where("inst_type = ?", instType)
+	}
+	result := query.Order("exchange, inst_type, symbol").Find(&symbols)
+	return symbols, result.Error
+}
+
+// SyncMarketSymbols 按 (exchange, inst_type, symbol) 写入交易所当前的交易对, 不在列表中的交易对标记为下架
+// symbols 为空时不做任何修改, 避免接口异常时把全部交易对标记为下架
+func (db *marketSymbolDB) SyncMarketSymbols(exchange string, instType string, symbols []MarketSymbol) error {
+	if len(symbols) == 0 {
+		return nil
+	}
+
+	now := uint64(time.Now().UnixMilli())
+	prepareSymbols(exchange, instType, symbols, now)
+	active := make([]string, 0, len(symbols))
+	for i := range symbols {
+		active = append(active, symbols[i].Symbol)
+	}
+
+	return db.gorm.Transaction(func(tx *gorm.DB) error {
+		if err := upsertSymbols(tx, symbols); err != nil {
+			return err
+		}
+
+		return tx.Model(&MarketSymbol{}).
+			Where("exchange = ? AND inst_type = ? AND status <> ?", exchange, instType, StatusDelisted).
+			Where("symbol NOT IN ?", active).
+			Updates(map[string]interface{}{"status": StatusDelisted, "timestamp": now}).Error
+	})
+}
+
+// UpsertMarketSymbols 按 (exchange, inst_type, symbol) 写入交易对, 不修改列表以外的交易对, 用于链上增量发现的交易对
+func (db *marketSymbolDB) UpsertMarketSymbols(exchange string, instType string, symbols []MarketSymbol) error {
+	if len(symbols) == 0 {
+		return nil
+	}
+	prepareSymbols(exchange, instType, symbols, uint64(time.Now().UnixMilli()))
+	return upsertSymbols(db.gorm, symbols)
+}
+
+// prepareSymbols 填充主键、交易所、产品类型和默认状态
+func prepareSymbols(exchange string, instType string, symbols []MarketSymbol, now uint64) {
+	for i := range symbols {
+		if symbols[i].GUID == uuid.Nil {
+			symbols[i].GUID = uuid.New()
+		}
+		symbols[i].Exchange = exchange
+		symbols[i].InstType = instType
+		if symbols[i].Status == "" {
+			symbols[i].Status = StatusTrading
+		}
+		if symbols[i].Timestamp == 0 {
+			symbols[i].Timestamp = now
+		}
+	}
+}
+
+// upsertSymbols 交易对已存在时更新 syncColumns
+func upsertSymbols(tx *gorm.DB, symbols []MarketSymbol) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "exchange"}, {Name: "inst_type"}, {Name: "symbol"}},
+		DoUpdates: clause.AssignmentColumns(syncColumns),
+	}).CreateInBatches(&symbols, 500).Error
+}
@@ -1,17 +1,19 @@
 package symbol
 
 import (
+	"github.com/339-Labs/exchange-market/common"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SymbolFuturesPrices struct {
 	GUID          uuid.UUID `gorm:"primaryKey"`
 	Symbol        string
 	UnifiedSymbol string
-	Price         string
-	MarkPrice     string
-	FundingRate   string
+	Price         common.Decimal
+	MarkPrice     common.Decimal
+	FundingRate   common.Decimal
 	Exchange      string
 	ChainId       string
 	Base          string
@@ -32,6 +34,7 @@ func NewSymbolFuturesPricesDB(db *gorm.DB) SymbolFuturesPricesDB {
 type SymbolFuturesPricesDB interface {
 	SaveSymbolFuturesPrices(*[]SymbolFuturesPrices) error
 	UpdateSymbolFuturesPrices(*[]SymbolFuturesPrices) error
+	UpsertSymbolFuturesPrices(*[]SymbolFuturesPrices) error
 }
 
 func (db *symbolFuturesPricesDB) SaveSymbolFuturesPrices(futuresPrices *[]SymbolFuturesPrices) error {
@@ -43,3 +46,15 @@ func (db *symbolFuturesPricesDB) UpdateSymbolFuturesPrices(futuresPrices *[]Symb
 	result := db.gorm.Save(&futuresPrices)
 	return result.Error
 }
+
+// UpsertSymbolFuturesPrices 按 (exchange, symbol) 写入最新价格, 已存在时更新价格、标记价格、资金费率和时间
+func (db *symbolFuturesPricesDB) UpsertSymbolFuturesPrices(futuresPrices *[]SymbolFuturesPrices) error {
+	if len(*futuresPrices) == 0 {
+		return nil
+	}
+	result := db.gorm.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "exchange"}, {Name: "symbol"}},
+		DoUpdates: clause.AssignmentColumns([]string{"price", "mark_price", "funding_rate", "timestamp"}),
+	}).CreateInBatches(futuresPrices, len(*futuresPrices))
+	return result.Error
+}
@@ -1,15 +1,17 @@
 package symbol
 
 import (
+	"github.com/339-Labs/exchange-market/common"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SymbolSpotPrices struct {
 	GUID          uuid.UUID `gorm:"primaryKey"`
 	Symbol        string
 	UnifiedSymbol string
-	Price         string
+	Price         common.Decimal
 	Exchange      string
 	ChainId       string
 	Base          string
@@ -30,6 +32,7 @@ func NewSymbolSpotPricesDB(db *gorm.DB) SymbolSpotPricesDB {
 type SymbolSpotPricesDB interface {
 	SaveSymbolSpotPrices(*[]SymbolSpotPrices) error
 	UpdateSymbolSpotPrices(*[]SymbolSpotPrices) error
+	UpsertSymbolSpotPrices(*[]SymbolSpotPrices) error
 }
 
 func (db *symbolSpotPricesDB) SaveSymbolSpotPrices(symbolSpotPrices *[]SymbolSpotPrices) error {
@@ -41,3 +44,15 @@ func (db *symbolSpotPricesDB) UpdateSymbolSpotPrices(symbolSpotPrices *[]SymbolS
 	result := db.gorm.Save(&symbolSpotPrices)
 	return result.Error
 }
+
+// UpsertSymbolSpotPrices 按 (exchange, symbol) 写入最新价格, 已存在时更新价格和时间
+func (db *symbolSpotPricesDB) UpsertSymbolSpotPrices(symbolSpotPrices *[]SymbolSpotPrices) error {
+	if len(*symbolSpotPrices) == 0 {
+		return nil
+	}
+	result := db.gorm.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "exchange"}, {Name: "symbol"}},
+		DoUpdates: clause.AssignmentColumns([]string{"price", "timestamp"}),
+	}).CreateInBatches(symbolSpotPrices, len(*symbolSpotPrices))
+	return result.Error
+}
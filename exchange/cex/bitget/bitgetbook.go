@@ -0,0 +1,192 @@
+package bitget
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
+	"github.com/339-Labs/exchange-market/common/orderbook"
+	"github.com/339-Labs/exchange-market/exchange/cex/bitget/constants"
+	"github.com/339-Labs/exchange-market/exchange/cex/bitget/model"
+	"github.com/ethereum/go-ethereum/log"
+	"strconv"
+)
+
+// bookState 单个深度订阅的同步状态
+type bookState struct {
+	seq     int64 // 最后一次更新的序号
+	pending bool  // 等待全量快照
+}
+
+// SubscribeBooks 订阅深度频道并维护本地订单簿, channel 为 books / books5 / books15, instType 为 SPOT / USDT-FUTURES
+// 订单簿按 instId 存放, 同一个产品只能订阅一个深度频道, 已订阅其他频道时返回错误
+func (c *BitGetWebSocketClient) SubscribeBooks(instType string, channel string, instIds []string) error {
+	if c.Books(instType) == nil {
+		return fmt.Errorf("unsupported instType %s", instType)
+	}
+
+	c.bookMu.Lock()
+	for req := range c.bookStates {
+		for _, instId := range instIds {
+			if req.InstType == instType && req.InstId == instId && req.Channel != channel {
+				c.bookMu.Unlock()
+				return fmt.Errorf("books of %s %s already subscribed with channel %s", instType, instId, req.Channel)
+			}
+		}
+	}
+	c.bookMu.Unlock()
+
+	reqs := make([]model.SubscribeReq, 0, len(instIds))
+	for _, instId := range instIds {
+		req := model.SubscribeReq{
+			InstType: instType,
+			Channel:  channel,
+			InstId:   instId,
+		}
+		c.setBookState(req, &bookState{pending: true})
+		reqs = append(reqs, req)
+	}
+
+	if len(reqs) == 0 {
+		return nil
+	}
+
+	return c.SubscribeList(reqs, c.handlerBooks)
+}
+
+// Books 获取产品类型对应的订单簿集合
+func (c *BitGetWebSocketClient) Books(instType string) *orderbook.Manager {
+	switch instType {
+	case constants.InstTypeSpot:
+		return c.SpotBooks
+	case constants.InstTypeUsdtFutures:
+		return c.FeatureBooks
+	}
+	return nil
+}
+
+// handlerBooks 处理深度推送
+func (c *BitGetWebSocketClient) handlerBooks(message string) {
+	var msg model.BookMsg
+	if err := json.Unmarshal([]byte(message), &msg); err != nil {
+		log.Error("bitget books unmarshal failed", "err", err)
+		return
+	}
+
+	for _, data := range msg.Data {
+		if err := c.applyBook(msg.Arg, msg.Action, &data); err != nil {
+			log.Warn("bitget order book corrupted, resubscribe", "instType", msg.Arg.InstType, "channel", msg.Arg.Channel, "instId", msg.Arg.InstId, "err", err)
+			c.resubscribeBook(msg.Arg)
+			return
+		}
+	}
+}
+
+// applyBook 应用一条深度数据
+func (c *BitGetWebSocketClient) applyBook(req model.SubscribeReq, action string, data *model.BookData) error {
+	c.bookMu.Lock()
+	defer c.bookMu.Unlock()
+
+	state, ok := c.bookStates[req]
+	if !ok {
+		return nil
+	}
+
+	book := c.Books(req.InstType).GetOrCreate(req.InstId)
+	ts, _ := strconv.ParseInt(data.Ts, 10, 64)
+
+	switch req.Channel {
+	case constants.ChannelBooks:
+		if action == constants.ActionSnapshot {
+			if err := book.Load(data.Bids, data.Asks, data.Seq, ts); err != nil {
+				return err
+			}
+			state.pending = false
+		} else {
+			// 等待重新订阅后的全量快照
+			if state.pending {
+				return nil
+			}
+			// seq 随订单簿更新递增, 不递增说明丢包或乱序
+			if data.Seq <= state.seq {
+				return fmt.Errorf("seq gap: last %d, got %d", state.seq, data.Seq)
+			}
+			if err := book.Apply(data.Bids, data.Asks, data.Seq, ts); err != nil {
+				return err
+			}
+		}
+
+		if checksum := book.Checksum(constants.ChecksumDepth); checksum != data.Checksum {
+			return fmt.Errorf("checksum mismatch: local %d, remote %d", checksum, data.Checksum)
+		}
+		if book.IsCrossed() {
+			return fmt.Errorf("order book crossed")
+		}
+
+	case constants.ChannelBooks5, constants.ChannelBooks15:
+		// 每次推送都是全量
+		if !state.pending && data.Seq < state.seq {
+			return fmt.Errorf("seq out of order: last %d, got %d", state.seq, data.Seq)
+		}
+		if err := book.Load(data.Bids, data.Asks, data.Seq, ts); err != nil {
+			return err
+		}
+		state.pending = false
+
+	default:
+		return fmt.Errorf("unsupported books channel %s", req.Channel)
+	}
+
+	state.seq = data.Seq
+	c.emitBook(req, action, data, ts)
+	return nil
+}
+
+// SetSink 设置订单簿统一行情事件的接收方
+func (c *BitGetWebSocketClient) SetSink(sink event.Sink) {
+	c.sink = event.OrDiscard(sink)
+}
+
+// emitBook 发送订单簿事件
+func (c *BitGetWebSocketClient) emitBook(req model.SubscribeReq, action string, data *model.BookData, ts int64) {
+	instType := common.InstTypeSpot
+	if req.InstType != constants.InstTypeSpot {
+		instType = common.InstTypePerp
+	}
+
+	c.sink.Emit(&event.BookUpdate{
+		Header:        event.NewHeader(event.NewMarket(common.BitGet, instType, req.InstId), ts),
+		Snapshot:      req.Channel != constants.ChannelBooks || action == constants.ActionSnapshot,
+		FinalUpdateId: data.Seq,
+		Bids:          event.Levels(data.Bids),
+		Asks:          event.Levels(data.Asks),
+	})
+}
+
+// resubscribeBook 订单簿损坏时取消订阅并重新订阅, 以获取新的全量快照
+func (c *BitGetWebSocketClient) resubscribeBook(req model.SubscribeReq) {
+	c.bookMu.Lock()
+	if state, ok := c.bookStates[req]; ok {
+		state.pending = true
+	}
+	c.bookMu.Unlock()
+
+	if books := c.Books(req.InstType); books != nil {
+		if book, ok := books.Get(req.InstId); ok {
+			book.Clear()
+		}
+	}
+
+	if err := c.Unsubscribe(req); err != nil {
+		log.Error("bitget books unsubscribe failed", "instId", req.InstId, "err", err)
+	}
+	if err := c.Subscribe(req, c.handlerBooks); err != nil {
+		log.Error("bitget books resubscribe failed", "instId", req.InstId, "err", err)
+	}
+}
+
+func (c *BitGetWebSocketClient) setBookState(req model.SubscribeReq, state *bookState) {
+	c.bookMu.Lock()
+	defer c.bookMu.Unlock()
+	c.bookStates[req] = state
+}
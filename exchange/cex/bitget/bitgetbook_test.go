@@ -0,0 +1,69 @@
+package bitget
+
+import (
+	"hash/crc32"
+	"testing"
+
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/exchange/cex/bitget/constants"
+	"github.com/339-Labs/exchange-market/exchange/cex/bitget/model"
+)
+
+func TestApplyBook(t *testing.T) {
+	client := NewBitGetWebSocketClient(&config.CexExchangeConfig{}, false)
+	req := model.SubscribeReq{InstType: constants.InstTypeSpot, Channel: constants.ChannelBooks, InstId: "BTCUSDT"}
+	client.setBookState(req, &bookState{pending: true})
+
+	snapshot := &model.BookData{
+		Bids:     [][]string{{"100", "1"}},
+		Asks:     [][]string{{"101", "2"}},
+		Checksum: int32(crc32.ChecksumIEEE([]byte("100:1:101:2"))),
+		Seq:      10,
+	}
+	if err := client.applyBook(req, constants.ActionSnapshot, snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	update := &model.BookData{
+		Bids:     [][]string{{"100", "0"}, {"99.5", "3"}},
+		Checksum: int32(crc32.ChecksumIEEE([]byte("99.5:3:101:2"))),
+		Seq:      12,
+	}
+	if err := client.applyBook(req, constants.ActionUpdate, update); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := client.FeatureBooks.Get("BTCUSDT"); ok {
+		t.Fatal("spot book leaked into futures books")
+	}
+
+	// 序号回退
+	if err := client.applyBook(req, constants.ActionUpdate, &model.BookData{Seq: 12}); err == nil {
+		t.Fatal("expected seq gap error")
+	}
+
+	// 校验和不一致
+	update = &model.BookData{Asks: [][]string{{"102", "1"}}, Checksum: 1, Seq: 13}
+	if err := client.applyBook(req, constants.ActionUpdate, update); err == nil {
+		t.Fatal("expected checksum error")
+	}
+}
+
+func TestSubscribeBooks_SecondChannel(t *testing.T) {
+	client := NewBitGetWebSocketClient(&config.CexExchangeConfig{}, false)
+	// 未连接时发送失败, 但订阅状态已记录
+	_ = client.SubscribeBooks(constants.InstTypeSpot, constants.ChannelBooks, []string{"BTCUSDT"})
+
+	req := model.SubscribeReq{InstType: constants.InstTypeSpot, Channel: constants.ChannelBooks5, InstId: "BTCUSDT"}
+	if err := client.SubscribeBooks(req.InstType, req.Channel, []string{req.InstId}); err == nil {
+		t.Fatal("expected error for a second books channel")
+	}
+	if _, ok := client.bookStates[req]; ok {
+		t.Fatal("books5 should not be tracked")
+	}
+
+	// 合约的订单簿与现货分开存放
+	_ = client.SubscribeBooks(constants.InstTypeUsdtFutures, constants.ChannelBooks5, []string{"BTCUSDT"})
+	if _, ok := client.bookStates[model.SubscribeReq{InstType: constants.InstTypeUsdtFutures, Channel: constants.ChannelBooks5, InstId: "BTCUSDT"}]; !ok {
+		t.Fatal("futures books5 should be tracked")
+	}
+}
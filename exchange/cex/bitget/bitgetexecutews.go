@@ -3,10 +3,16 @@ package bitget
 import (
 	"fmt"
 	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
 	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/common/ws"
 	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/exchange/cex/bitget/constants"
 	"github.com/339-Labs/exchange-market/exchange/cex/bitget/model"
 	"github.com/ethereum/go-ethereum/log"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -15,30 +21,49 @@ type BitGetExClient struct {
 	config                *config.CexExchangeConfig
 	spotPriceMap          *maps.PriceMap
 	featurePriceMap       *maps.PriceMap
+	sink                  event.Sink // 统一行情事件
+
+	// ticker 订阅, 按单连接订阅数分片
+	tickerPool *ws.ShardPool[model.SubscribeReq]
+	universe   map[string][]model.SubscribeReq // spot/perp -> 订阅
+	universeMu sync.Mutex
 }
 
 func NewBitGetExClient(config *config.CexExchangeConfig, spotPriceMap *maps.PriceMap, featurePriceMap *maps.PriceMap) (*BitGetExClient, error) {
 	// 创建bitget WebSocket客户端
 	client := NewBitGetWebSocketClient(config, false) // true表示需要登录
-	return &BitGetExClient{
+	bg := &BitGetExClient{
 		BitGetWebSocketClient: client,
 		config:                config,
 		spotPriceMap:          spotPriceMap,
 		featurePriceMap:       featurePriceMap,
-	}, nil
+		universe:              make(map[string][]model.SubscribeReq),
+		sink:                  event.Discard,
+	}
+	bg.tickerPool = ws.NewShardPool(ws.ShardPoolConfig{
+		MaxTopicsPerConn: constants.MaxTopicsPerConn,
+		BatchSize:        constants.SubscribeBatchSize,
+		BatchInterval:    constants.SubscribeIntervalMs * time.Millisecond,
+	}, bg.newTickerConn)
+	return bg, nil
+}
+
+// SetSink 设置统一行情事件的接收方, 同时用于订单簿推送, 需在 ExecuteWs 之前调用
+func (bg *BitGetExClient) SetSink(sink event.Sink) {
+	bg.sink = event.OrDiscard(sink)
+	bg.BitGetWebSocketClient.SetSink(sink)
 }
 
-// bitget 通过 InstId 来区别现货还是合约 BTC-USDT 和 BTC-USD-SWAP
+// ExecuteWs 启动客户端, 交易对由 SyncSymbols 按 market_symbol 订阅
 func (bg *BitGetExClient) ExecuteWs() {
 
-	// 创建bitget WebSocket客户端
-	client := NewBitGetWebSocketClient(bg.config, false) // true表示需要登录
+	client := bg.BitGetWebSocketClient
 
 	// 设置全局消息监听器
 	client.SetListeners(
 		func(message string) {
 
-			fmt.Println("全局消息: %s\n", message)
+			fmt.Printf("全局消息: %s\n", message)
 
 		},
 		func(message string) {
@@ -48,84 +73,185 @@ func (bg *BitGetExClient) ExecuteWs() {
 
 	// 启动客户端
 	if err := client.Start(); err != nil {
-		log.Info("启动客户端失败:", err)
+		log.Error("Failed to start bitget client", "err", err)
 	}
 
 	// 等待登录完成
 	time.Sleep(2 * time.Second)
+}
 
-	var reqs []model.SubscribeReq
-
-	// todo spotSymbols get spot from db
-	var spotSymbols []string
-	spotSymbols = append(spotSymbols, "ETHUSDT")
-	spotSymbols = append(spotSymbols, "BTCUSDT")
-	for _, symbol := range spotSymbols {
-		// 订阅特定现货的数据流
-		subscribeReq := model.SubscribeReq{
-			Channel:  "ticker",
-			InstId:   symbol,
-			InstType: "SPOT",
+// SyncSymbols 调整现货或USDT永续的 ticker 订阅, 超过单连接订阅数时新建连接
+// bitget 通过 InstType 区别现货和合约, 合约只订阅 USDT-FUTURES 下的交易对
+func (bg *BitGetExClient) SyncSymbols(instType string, symbols []string) error {
+	reqs := make([]model.SubscribeReq, 0, len(symbols))
+	for _, symbol := range symbols {
+		req := model.SubscribeReq{Channel: constants.ChannelTicker, InstId: symbol, InstType: constants.InstTypeSpot}
+		if instType == common.InstTypePerp {
+			if !strings.HasSuffix(symbol, "USDT") {
+				continue
+			}
+			req.InstType = constants.InstTypeUsdtFutures
 		}
-		reqs = append(reqs, subscribeReq)
+		reqs = append(reqs, req)
 	}
 
-	// todo spotSymbols get feature from db
-	var featureSymbols []string
-	featureSymbols = append(featureSymbols, "ETHUSDT")
-	featureSymbols = append(featureSymbols, "BTCUSDT")
-	for _, symbol := range featureSymbols {
-		// 订阅特定合约的数据流
-		subscribeReq := model.SubscribeReq{
-			Channel:  "ticker",
-			InstId:   symbol,
-			InstType: "USDT-FUTURES",
-		}
-		reqs = append(reqs, subscribeReq)
+	bg.universeMu.Lock()
+	defer bg.universeMu.Unlock()
+
+	bg.universe[instType] = reqs
+	desired := make([]model.SubscribeReq, 0)
+	for _, key := range []string{common.InstTypeSpot, common.InstTypePerp} {
+		desired = append(desired, bg.universe[key]...)
 	}
 
-	err := client.SubscribeList(reqs, func(message string) {
-		fmt.Printf("订阅价格更新，处理价格: %s\n", message)
+	added, removed, err := bg.tickerPool.Sync(desired)
+	log.Info("bitget subscriptions synced", "instType", instType, "added", added, "removed", removed, "conns", bg.tickerPool.Conns())
+	return err
+}
 
-		jsonMap := common.JSONToMap(message)
-		arg, _ := jsonMap["arg"].(map[string]interface{})
-		channel, _ := arg["channel"].(string)
-		instType, _ := arg["instType"].(string)
+// Close 关闭连接池中新建的连接, 主连接由调用方关闭
+func (bg *BitGetExClient) Close() error {
+	return bg.tickerPool.Close()
+}
 
-		if dataList, ok := jsonMap["data"].([]interface{}); ok {
-			data := dataList[0].(map[string]interface{})
-			if channel == "ticker" && instType == "SPOT" {
-				bg.handlerSpot(data)
-			} else if channel == "ticker" && instType == "USDT-FUTURES" {
-				bg.handlerFeature(data)
-			}
+// newTickerConn 连接池的第一个连接复用主连接, 其余连接新建
+func (bg *BitGetExClient) newTickerConn(index int) (ws.ShardConn[model.SubscribeReq], error) {
+	if index == 0 {
+		return &tickerConn{client: bg.BitGetWebSocketClient, listener: bg.handlerTickers, primary: true}, nil
+	}
+
+	client := NewBitGetWebSocketClient(bg.config, false)
+	if err := client.Start(); err != nil {
+		return nil, err
+	}
+	return &tickerConn{client: client, listener: bg.handlerTickers}, nil
+}
+
+// handlerTickers 处理现货和USDT永续 ticker 推送
+func (bg *BitGetExClient) handlerTickers(message string) {
+	jsonMap := common.JSONToMap(message)
+	arg, _ := jsonMap["arg"].(map[string]interface{})
+	channel, _ := arg["channel"].(string)
+	instType, _ := arg["instType"].(string)
+
+	if dataList, ok := jsonMap["data"].([]interface{}); ok && len(dataList) > 0 {
+		data, _ := dataList[0].(map[string]interface{})
+		if channel == constants.ChannelTicker && instType == constants.InstTypeSpot {
+			bg.handlerSpot(data)
+		} else if channel == constants.ChannelTicker && instType == constants.InstTypeUsdtFutures {
+			bg.handlerFeature(data)
 		}
-	})
+	}
+}
 
-	if err != nil {
-		log.Error("订阅失败: %v", err)
+// tickerConn 连接池中的连接
+type tickerConn struct {
+	client   *BitGetWebSocketClient
+	listener OnReceive
+	primary  bool // 主连接由 BitGetExClient 的调用方关闭
+}
+
+func (c *tickerConn) SubscribeTopics(reqs []model.SubscribeReq) error {
+	return c.client.SubscribeList(reqs, c.listener)
+}
+
+func (c *tickerConn) UnsubscribeTopics(reqs []model.SubscribeReq) error {
+	return c.client.UnsubscribeList(reqs)
+}
+
+func (c *tickerConn) Close() error {
+	if c.primary {
+		return nil
 	}
-	log.Info("执行")
+	return c.client.Stop()
 }
 
-func (bg *BitGetExClient) handlerSpot(spot map[string]interface{}) {
+// ExecuteBooksWs 订阅现货和USDT永续深度并维护本地订单簿, 需在 ExecuteWs 启动客户端之后调用
+// books 频道校验 checksum 和 seq, 订单簿损坏时强制重新订阅
+func (bg *BitGetExClient) ExecuteBooksWs(channel string, spotSymbols []string, featureSymbols []string) {
+	if err := bg.BitGetWebSocketClient.SubscribeBooks(constants.InstTypeSpot, channel, spotSymbols); err != nil {
+		log.Error("Failed to subscribe spot books", "channel", channel, "err", err)
+	}
+	if err := bg.BitGetWebSocketClient.SubscribeBooks(constants.InstTypeUsdtFutures, channel, featureSymbols); err != nil {
+		log.Error("Failed to subscribe feature books", "channel", channel, "err", err)
+	}
+}
 
-	log.Info("spot ------ ,instId: %s , lastPr: %s", spot["instId"], spot["lastPr"])
-	bg.spotPriceMap.Write(spot["instId"].(string), &maps.PriceData{
-		Symbol:    spot["instId"].(string),
-		Price:     spot["lastPr"].(string),
-		Timestamp: spot["ts"].(string),
+// handlerSpot 缺少 instId 的推送忽略
+func (bg *BitGetExClient) handlerSpot(spot map[string]interface{}) {
+	instId := stringValue(spot, "instId")
+	if instId == "" {
+		return
+	}
+	bg.spotPriceMap.Write(instId, &maps.PriceData{
+		Symbol:    instId,
+		Price:     common.DecimalFromString(stringValue(spot, "lastPr")),
+		Timestamp: stringValue(spot, "ts"),
 	})
-
+	bg.emitTicker(common.InstTypeSpot, spot)
 }
 
+// handlerFeature 缺少 instId 的推送忽略
 func (bg *BitGetExClient) handlerFeature(feature map[string]interface{}) {
-	log.Info("feature ------ ,instId: %s , lastPr: %s , fundingRate: %s", feature["instId"], feature["lastPr"], feature["fundingRate"])
-	bg.featurePriceMap.Write(feature["instId"].(string), &maps.PriceData{
-		Symbol:      feature["instId"].(string),
-		Price:       feature["lastPr"].(string),
-		FundingRate: feature["fundingRate"].(string),
-		MarkPrice:   feature["markPrice"].(string),
-		Timestamp:   feature["ts"].(string),
+	instId := stringValue(feature, "instId")
+	if instId == "" {
+		return
+	}
+	bg.featurePriceMap.Write(instId, &maps.PriceData{
+		Symbol:      instId,
+		Price:       common.DecimalFromString(stringValue(feature, "lastPr")),
+		FundingRate: common.DecimalFromString(stringValue(feature, "fundingRate")),
+		MarkPrice:   common.DecimalFromString(stringValue(feature, "markPrice")),
+		Timestamp:   stringValue(feature, "ts"),
+	})
+	bg.emitTicker(common.InstTypePerp, feature)
+}
+
+// emitTicker 发送 ticker 频道的统一行情事件, 合约推送中的标记价格、资金费率、持仓量分别发送
+func (bg *BitGetExClient) emitTicker(instType string, data map[string]interface{}) {
+	header := event.NewHeader(event.NewMarket(common.BitGet, instType, stringValue(data, "instId")), int64Value(data, "ts"))
+
+	bg.sink.Emit(&event.Ticker{
+		Header:      header,
+		Last:        common.DecimalFromString(stringValue(data, "lastPr")),
+		Open:        common.DecimalFromString(stringValue(data, "open24h")),
+		High:        common.DecimalFromString(stringValue(data, "high24h")),
+		Low:         common.DecimalFromString(stringValue(data, "low24h")),
+		Volume:      common.DecimalFromString(stringValue(data, "baseVolume")),
+		QuoteVolume: common.DecimalFromString(stringValue(data, "quoteVolume")),
+		Bid:         common.DecimalFromString(stringValue(data, "bidPr")),
+		BidSize:     common.DecimalFromString(stringValue(data, "bidSz")),
+		Ask:         common.DecimalFromString(stringValue(data, "askPr")),
+		AskSize:     common.DecimalFromString(stringValue(data, "askSz")),
+	})
+	if instType != common.InstTypePerp {
+		return
+	}
+
+	bg.sink.Emit(&event.MarkPrice{
+		Header:     header,
+		MarkPrice:  common.DecimalFromString(stringValue(data, "markPrice")),
+		IndexPrice: common.DecimalFromString(stringValue(data, "indexPrice")),
 	})
+	bg.sink.Emit(&event.FundingRate{
+		Header:          header,
+		Rate:            common.DecimalFromString(stringValue(data, "fundingRate")),
+		NextFundingTime: event.Millis(int64Value(data, "nextFundingTime")),
+	})
+	bg.sink.Emit(&event.OpenInterest{
+		Header:       header,
+		OpenInterest: common.DecimalFromString(stringValue(data, "holdingAmount")),
+	})
+}
+
+// stringValue 读取推送中的字符串字段, 不存在时返回空字符串
+func stringValue(data map[string]interface{}, key string) string {
+	value, _ := data[key].(string)
+	return value
+}
+
+// int64Value 读取推送中以字符串表示的整数字段, 如毫秒时间
+func int64Value(data map[string]interface{}, key string) int64 {
+	value, _ := strconv.ParseInt(stringValue(data, key), 10, 64)
+	return value
 }
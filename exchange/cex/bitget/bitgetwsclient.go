@@ -3,6 +3,8 @@ package bitget
 import (
 	"fmt"
 	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
+	"github.com/339-Labs/exchange-market/common/orderbook"
 	"github.com/339-Labs/exchange-market/common/signer"
 	"github.com/339-Labs/exchange-market/common/ws"
 	"github.com/339-Labs/exchange-market/config"
@@ -25,7 +27,7 @@ type BitGetMessageHandler struct {
 	Listener      OnReceive
 	ErrorListener OnReceive
 	ScribeMap     map[model.SubscribeReq]OnReceive
-	AllSubscribe  *model.Set
+	AllSubscribe  *ws.SubscriptionRegistry
 
 	// 同步
 	mu sync.RWMutex
@@ -40,13 +42,13 @@ type OnReceive func(message string)
 // NewBitGetMessageHandler 创建新的bitget消息处理器
 func NewBitGetMessageHandler(config *config.CexExchangeConfig, needLogin bool) *BitGetMessageHandler {
 	handler := &BitGetMessageHandler{
-		Config:       config,
-		NeedLogin:    needLogin,
-		LoginStatus:  false,
-		ScribeMap:    make(map[model.SubscribeReq]OnReceive),
-		AllSubscribe: model.NewSet(),
-		Signer:       new(signer.Signer).Init(config.ApiSecretKey),
+		Config:      config,
+		NeedLogin:   needLogin,
+		LoginStatus: false,
+		ScribeMap:   make(map[model.SubscribeReq]OnReceive),
+		Signer:      new(signer.Signer).Init(config.ApiSecretKey),
 	}
+	handler.AllSubscribe = ws.NewSubscriptionRegistry(handler, constants.SubscribeBatchSize, constants.SubscribeIntervalMs*time.Millisecond)
 
 	return handler
 }
@@ -112,9 +114,7 @@ func (h *BitGetMessageHandler) HandleSpecialMessage(message string) (handled boo
 func (h *BitGetMessageHandler) handleLoginResponse(message string) error {
 	log.Info("Login response: %s", message)
 
-	h.mu.Lock()
-	h.LoginStatus = true
-	h.mu.Unlock()
+	h.setLoginStatus(true)
 
 	if h.Listener != nil {
 		h.Listener(message)
@@ -172,6 +172,32 @@ func (h *BitGetMessageHandler) getListener(argJson interface{}) OnReceive {
 	return listener
 }
 
+// setLoginStatus 设置登录状态
+func (h *BitGetMessageHandler) setLoginStatus(status bool) {
+	h.mu.Lock()
+	h.LoginStatus = status
+	h.mu.Unlock()
+}
+
+// RequireLogin 是否需要登录
+func (h *BitGetMessageHandler) RequireLogin() bool {
+	return h.NeedLogin
+}
+
+// SendSubscribe 批量发送订阅请求，用于重连后重放订阅
+func (h *BitGetMessageHandler) SendSubscribe(topics []interface{}) error {
+	if h.wsClient == nil {
+		return fmt.Errorf("WebSocket client is not set")
+	}
+
+	baseReq := model.WsBaseReq{
+		Op:   constants.WsOpSubscribe,
+		Args: topics,
+	}
+
+	return h.wsClient.SendJSON(baseReq)
+}
+
 // IsLoggedIn 检查是否已登录
 func (h *BitGetMessageHandler) IsLoggedIn() bool {
 	h.mu.RLock()
@@ -201,6 +227,13 @@ func (h *BitGetMessageHandler) RemoveSubscription(req model.SubscribeReq) {
 type BitGetWebSocketClient struct {
 	*ws.GenericWebSocketClient
 	MessageHandler *BitGetMessageHandler
+
+	// 本地订单簿, 现货和合约的 instId 相同, 分开维护
+	SpotBooks    *orderbook.Manager
+	FeatureBooks *orderbook.Manager
+	bookStates   map[model.SubscribeReq]*bookState
+	bookMu       sync.Mutex
+	sink         event.Sink // 订单簿统一行情事件
 }
 
 // NewBitGetWebSocketClient 创建新的Okx WebSocket客户端
@@ -222,20 +255,18 @@ func NewBitGetWebSocketClient(config *config.CexExchangeConfig, needLogin bool)
 	messageHandler := NewBitGetMessageHandler(config, needLogin)
 	messageHandler.SetWebSocketClient(genericClient)
 
-	// 设置消息处理器
+	// 设置消息处理器和订阅注册表
 	genericClient.SetMessageHandler(messageHandler)
+	genericClient.SetSubscriptionRegistry(messageHandler.AllSubscribe)
 
 	// 设置回调函数
 	genericClient.SetCallbacks(
 		func() {
 			log.Info("Okx WebSocket connected")
-			// 如果需要登录，则自动登录
-			if needLogin {
-				messageHandler.Login()
-			}
 		},
 		func() {
 			log.Info("Okx WebSocket disconnected")
+			messageHandler.setLoginStatus(false)
 		},
 		func(attempt int) {
 			log.Info("Okx WebSocket reconnecting, attempt: %d", attempt)
@@ -245,6 +276,10 @@ func NewBitGetWebSocketClient(config *config.CexExchangeConfig, needLogin bool)
 	return &BitGetWebSocketClient{
 		GenericWebSocketClient: genericClient,
 		MessageHandler:         messageHandler,
+		SpotBooks:              orderbook.NewManager(string(common.BitGet)),
+		FeatureBooks:           orderbook.NewManager(string(common.BitGet)),
+		bookStates:             make(map[model.SubscribeReq]*bookState),
+		sink:                   event.Discard,
 	}
 }
 
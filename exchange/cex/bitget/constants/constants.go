@@ -31,6 +31,30 @@ const (
 	WsOpSubscribe       = "subscribe"
 	TimerIntervalSecond = 5
 	ReconnectWaitSecond = 60
+	SubscribeBatchSize  = 50  // 单次订阅请求的最大频道数量
+	SubscribeIntervalMs = 100 // 订阅请求间隔
+	MaxTopicsPerConn    = 50  // 单连接的订阅数, 官方建议不超过50个以保证连接稳定
+
+	/*
+	 * instType
+	 */
+	InstTypeSpot        = "SPOT"         // 现货
+	InstTypeUsdtFutures = "USDT-FUTURES" // USDT永续
+
+	/*
+	 * channel
+	 */
+	ChannelTicker  = "ticker"
+	ChannelBooks   = "books"   // 全量深度, 首次全量后增量推送
+	ChannelBooks5  = "books5"  // 5档深度, 每次全量推送
+	ChannelBooks15 = "books15" // 15档深度, 每次全量推送
+
+	/*
+	 * order book
+	 */
+	ActionSnapshot = "snapshot" // 全量
+	ActionUpdate   = "update"   // 增量
+	ChecksumDepth  = 25         // 校验和档位
 
 	/*
 	 * SignType
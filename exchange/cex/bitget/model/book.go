@@ -0,0 +1,18 @@
+package model
+
+// BookData 深度数据 books / books5 / books15
+type BookData struct {
+	Asks     [][]string `json:"asks"`     // 卖盘 [价格, 数量]
+	Bids     [][]string `json:"bids"`     // 买盘
+	Checksum int32      `json:"checksum"` // 前25档校验和, 仅 books 频道
+	Seq      int64      `json:"seq"`      // 序号, 订单簿每次更新递增
+	Ts       string     `json:"ts"`       // 数据更新时间
+}
+
+// BookMsg 深度推送
+type BookMsg struct {
+	Action string       `json:"action"` // snapshot 全量, update 增量
+	Arg    SubscribeReq `json:"arg"`
+	Data   []BookData   `json:"data"`
+	Ts     int64        `json:"ts"`
+}
@@ -0,0 +1,107 @@
+package bn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
+	"github.com/339-Labs/exchange-market/common/orderbook"
+	"github.com/339-Labs/exchange-market/exchange/cex/bn/constants"
+	"github.com/339-Labs/exchange-market/exchange/cex/bn/model"
+	"github.com/ethereum/go-ethereum/log"
+	"strings"
+)
+
+// ExecuteWsDepth 订阅增量深度并维护本地订单簿
+// 增量先缓存，拉取 /api/v3/depth 快照后丢弃 u <= lastUpdateId 的增量，之后要求 U 连续，断档自动重新同步
+func (bn *BnExClient) ExecuteWsDepth(symbols []string) {
+	streams := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(symbol)
+		bn.depthSynchronizer(symbol)
+		streams = append(streams, strings.ToLower(symbol)+constants.StreamDepthSuffix)
+	}
+
+	if len(streams) == 0 {
+		return
+	}
+
+	err := bn.BnWebSocketClient.SubscribeList(streams, bn.handlerDepth)
+	if err != nil {
+		log.Error("Failed to subscribe depth", "err", err)
+	}
+}
+
+// depthSynchronizer 获取交易对的订单簿同步器，不存在时创建
+func (bn *BnExClient) depthSynchronizer(symbol string) *orderbook.Synchronizer {
+	bn.depthMu.Lock()
+	defer bn.depthMu.Unlock()
+
+	if synchronizer, ok := bn.depthSyncs[symbol]; ok {
+		return synchronizer
+	}
+
+	synchronizer := orderbook.NewSynchronizer(bn.Books.GetOrCreate(symbol), bn.fetchDepthSnapshot)
+	synchronizer.OnResync = func(symbol string, reason error) {
+		log.Warn("bn order book resync", "symbol", symbol, "reason", reason)
+	}
+	bn.depthSyncs[symbol] = synchronizer
+	return synchronizer
+}
+
+// handlerDepth 处理增量深度推送
+func (bn *BnExClient) handlerDepth(message string) {
+	var depth model.BinanceDepth
+	if err := json.Unmarshal([]byte(message), &depth); err != nil {
+		log.Error("bn depth unmarshal failed", "err", err)
+		return
+	}
+
+	if depth.EventType != constants.EventDepth {
+		return
+	}
+
+	err := bn.depthSynchronizer(depth.Symbol).Push(&orderbook.Delta{
+		FirstUpdateId: depth.FirstUpdateId,
+		FinalUpdateId: depth.FinalUpdateId,
+		EventTime:     depth.EventTime,
+		Bids:          depth.Bids,
+		Asks:          depth.Asks,
+	})
+	if err != nil {
+		log.Warn("bn depth apply failed", "symbol", depth.Symbol, "err", err)
+		return
+	}
+
+	bn.sink.Emit(&event.BookUpdate{
+		Header:        event.NewHeader(event.NewMarket(common.BN, common.InstTypeSpot, depth.Symbol), depth.EventTime),
+		FirstUpdateId: depth.FirstUpdateId,
+		FinalUpdateId: depth.FinalUpdateId,
+		Bids:          event.Levels(depth.Bids),
+		Asks:          event.Levels(depth.Asks),
+	})
+}
+
+// fetchDepthSnapshot 通过REST拉取深度快照
+func (bn *BnExClient) fetchDepthSnapshot(symbol string) (*orderbook.Snapshot, error) {
+	path := fmt.Sprintf("%s?symbol=%s&limit=%d", constants.DepthSnapshotPath, symbol, constants.DepthSnapshotLimit)
+	resp, err := bn.rest.GET(context.Background(), path, make(map[string]string))
+	if err != nil {
+		return nil, err
+	}
+	if !resp.IsSuccess() {
+		return nil, fmt.Errorf("bn depth snapshot request failed: status code %d, body %s", resp.StatusCode, resp.String())
+	}
+
+	var snapshot model.BinanceDepthSnapshot
+	if err := resp.Unmarshal(&snapshot); err != nil {
+		return nil, err
+	}
+
+	return &orderbook.Snapshot{
+		LastUpdateId: snapshot.LastUpdateId,
+		Bids:         snapshot.Bids,
+		Asks:         snapshot.Asks,
+	}, nil
+}
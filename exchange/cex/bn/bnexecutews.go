@@ -1,36 +1,67 @@
 package bn
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/client"
+	"github.com/339-Labs/exchange-market/common/event"
 	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/common/orderbook"
 	"github.com/339-Labs/exchange-market/config"
 	"github.com/339-Labs/exchange-market/exchange/cex/bn/constants"
+	"github.com/339-Labs/exchange-market/exchange/cex/bn/model"
 	"github.com/ethereum/go-ethereum/log"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type BnExClient struct {
-	BnWebSocketClient *BnWebSocketClient
-	config            *config.CexExchangeConfig
-	spotPriceMap      *maps.PriceMap
-	featurePriceMap   *maps.PriceMap
-	markPriceMap      *maps.PriceMap
+	BnWebSocketClient      *BnWebSocketClient // 现货
+	FeatureWebSocketClient *BnWebSocketClient // U本位合约
+	config                 *config.CexExchangeConfig
+	rest                   client.REST
+	spotPriceMap           *maps.PriceMap
+	featurePriceMap        *maps.PriceMap
+	markPriceMap           *maps.PriceMap
+	decodeErrors           atomic.Uint64 // 无法解析的推送数量
+	sink                   event.Sink    // 统一行情事件
+
+	// 本地订单簿
+	Books      *orderbook.Manager
+	depthSyncs map[string]*orderbook.Synchronizer
+	depthMu    sync.Mutex
 }
 
 func NewBnExClient(config *config.CexExchangeConfig, spotPriceMap *maps.PriceMap, featurePriceMap *maps.PriceMap, markPriceMap *maps.PriceMap) (*BnExClient, error) {
 	// 创建WebSocket客户端
-	client := NewBnWebSocketClient(config, false)
+	wsClient := NewBnWebSocketClient(config, false)
+	featureConfig := *config
+	featureConfig.WsUrl = config.WsUrlFeature
+	featureClient := NewBnWebSocketClient(&featureConfig, false)
 
 	return &BnExClient{
-		BnWebSocketClient: client,
-		config:            config,
-		spotPriceMap:      spotPriceMap,
-		featurePriceMap:   featurePriceMap,
-		markPriceMap:      markPriceMap,
+		BnWebSocketClient:      wsClient,
+		FeatureWebSocketClient: featureClient,
+		config:                 config,
+		rest:                   client.NewRESTClient(config.ApiUrl),
+		spotPriceMap:           spotPriceMap,
+		featurePriceMap:        featurePriceMap,
+		markPriceMap:           markPriceMap,
+		Books:                  orderbook.NewManager(string(common.BN)),
+		depthSyncs:             make(map[string]*orderbook.Synchronizer),
+		sink:                   event.Discard,
 	}, nil
 }
 
+// SetSink 设置统一行情事件的接收方, 需在 ExecuteWs 之前调用
+func (bn *BnExClient) SetSink(sink event.Sink) {
+	bn.sink = event.OrDiscard(sink)
+}
+
 func (bn *BnExClient) ExecuteWsSpot() {
 
 	// 设置全局监听器
@@ -52,9 +83,7 @@ func (bn *BnExClient) ExecuteWsSpot() {
 	time.Sleep(2 * time.Second)
 
 	// 订阅所有交易对精简 24小时价格变动统计
-	err := bn.BnWebSocketClient.SubscribeMiniTickerAll(func(message string) {
-		bn.handlerDataType(message, constants.Spot)
-	})
+	err := bn.BnWebSocketClient.SubscribeMiniTickerAll(bn.handlerSpot)
 	if err != nil {
 		log.Error("Failed to subscribe ticker: %v", err)
 	}
@@ -63,13 +92,7 @@ func (bn *BnExClient) ExecuteWsSpot() {
 
 func (bn *BnExClient) ExecuteWsFeature() {
 
-	// 创建WebSocket客户端
-	cf := &config.CexExchangeConfig{
-		WsUrl:        bn.config.WsUrlFeature,
-		ApiKey:       bn.config.ApiKey,
-		ApiSecretKey: bn.config.ApiSecretKey,
-	}
-	client := NewBnWebSocketClient(cf, false)
+	client := bn.FeatureWebSocketClient
 
 	// 设置全局监听器
 	client.SetListeners(
@@ -90,92 +113,141 @@ func (bn *BnExClient) ExecuteWsFeature() {
 	time.Sleep(2 * time.Second)
 
 	// 全部交易对精简信息 订阅24小时价格变动统计
-	err := client.SubscribeAllTicker(func(message string) {
-		bn.handlerDataType(message, constants.Feature)
-	})
+	err := client.SubscribeAllTicker(bn.handlerFeature)
 	if err != nil {
 		log.Info("Failed to subscribe ticker: %v", err)
 	}
 
 	// 全部交易对标记价格 订阅24小b价格变动统计
-	err = client.SubscribeMarkPriceAll(func(message string) {
-		bn.handlerDataType(message, constants.Feature)
-	})
+	err = client.SubscribeMarkPriceAll(bn.handlerFeatureMark)
 	if err != nil {
 		log.Info("Failed to subscribe ticker: %v", err)
 	}
 
 }
 
-func (bn *BnExClient) handlerDataType(message string, t string) {
-	var v interface{}
-	err := json.Unmarshal([]byte(message), &v)
+// handlerSpot 处理现货精简行情推送
+func (bn *BnExClient) handlerSpot(message string) {
+	tickers, err := decodeEvents[model.BinanceMiniTicker](message)
 	if err != nil {
-		panic(err)
+		bn.decodeFailed(constants.Spot, message, err)
+		return
 	}
-	switch vv := v.(type) {
-	case map[string]interface{}:
-		bn.handlerData(vv, t)
-	case []interface{}:
-		for _, data := range vv {
-			if item, ok := data.(map[string]interface{}); ok {
-				bn.handlerData(item, t)
-			}
+
+	for _, ticker := range tickers {
+		if ticker.EventType != constants.EventMiniTicker {
+			continue
 		}
-	default:
-		fmt.Println("未知类型 s%", vv)
+		bn.spotPriceMap.Write(ticker.Symbol, &maps.PriceData{
+			Symbol:    ticker.Symbol,
+			Price:     common.DecimalFromString(ticker.LastPrice),
+			Timestamp: strconv.FormatInt(ticker.EventTime, 10),
+		})
+		bn.sink.Emit(&event.Ticker{
+			Header:      event.NewHeader(event.NewMarket(common.BN, common.InstTypeSpot, ticker.Symbol), ticker.EventTime),
+			Last:        common.DecimalFromString(ticker.LastPrice),
+			Open:        common.DecimalFromString(ticker.OpenPrice),
+			High:        common.DecimalFromString(ticker.HighPrice),
+			Low:         common.DecimalFromString(ticker.LowPrice),
+			Volume:      common.DecimalFromString(ticker.Volume),
+			QuoteVolume: common.DecimalFromString(ticker.QuoteVolume),
+		})
 	}
 }
 
-func (bn *BnExClient) handlerData(data map[string]interface{}, t string) {
-
-	e, _ := data["e"]
-
-	if t == constants.Spot && constants.EventTicker == e.(string) {
-		bn.handlerSpot(data)
-	}
-	if t == constants.Spot && constants.EventMiniTicker == e.(string) {
-		bn.handlerSpot(data)
-	}
-	if t == constants.Feature && constants.EventTicker == e.(string) {
-		bn.handlerFeature(data)
-	}
-	if t == constants.Feature && constants.EventMiniTicker == e.(string) {
-		bn.handlerFeature(data)
+// handlerFeature 处理永续合约行情推送
+func (bn *BnExClient) handlerFeature(message string) {
+	tickers, err := decodeEvents[model.BinanceTicker](message)
+	if err != nil {
+		bn.decodeFailed(constants.Feature, message, err)
+		return
 	}
-	if t == constants.Feature && constants.EventMarkPrice == e.(string) {
-		bn.handlerFeatureMark(data)
+
+	for _, ticker := range tickers {
+		if ticker.EventType != constants.EventTicker {
+			continue
+		}
+		bn.featurePriceMap.Write(ticker.Symbol, &maps.PriceData{
+			Symbol:    ticker.Symbol,
+			Price:     common.DecimalFromString(ticker.LastPrice),
+			Timestamp: strconv.FormatInt(ticker.EventTime, 10),
+		})
+		bn.sink.Emit(&event.Ticker{
+			Header:      event.NewHeader(event.NewMarket(common.BN, common.InstTypePerp, ticker.Symbol), ticker.EventTime),
+			Last:        common.DecimalFromString(ticker.LastPrice),
+			Open:        common.DecimalFromString(ticker.OpenPrice),
+			High:        common.DecimalFromString(ticker.HighPrice),
+			Low:         common.DecimalFromString(ticker.LowPrice),
+			Volume:      common.DecimalFromString(ticker.Volume),
+			QuoteVolume: common.DecimalFromString(ticker.QuoteVolume),
+			Bid:         common.DecimalFromString(ticker.BidPrice),
+			BidSize:     common.DecimalFromString(ticker.BidQty),
+			Ask:         common.DecimalFromString(ticker.AskPrice),
+			AskSize:     common.DecimalFromString(ticker.AskQty),
+		})
 	}
 }
 
-func (bn *BnExClient) handlerSpot(spot map[string]interface{}) {
-	log.Info("spot ------ ,instId: %s , lastPr: %s", spot["s"], spot["c"])
+// handlerFeatureMark 处理永续合约标记价格和资金费率推送
+func (bn *BnExClient) handlerFeatureMark(message string) {
+	marks, err := decodeEvents[model.BinanceMarkPrice](message)
+	if err != nil {
+		bn.decodeFailed(constants.Feature, message, err)
+		return
+	}
 
-	bn.spotPriceMap.Write(spot["s"].(string), &maps.PriceData{
-		Symbol:    spot["s"].(string),
-		Price:     spot["c"].(string),
-		Timestamp: spot["E"].(string),
-	})
+	for _, mark := range marks {
+		if mark.EventType != constants.EventMarkPrice {
+			continue
+		}
+		bn.markPriceMap.Write(mark.Symbol, &maps.PriceData{
+			Symbol:      mark.Symbol,
+			FundingRate: common.DecimalFromString(mark.FundingRate),
+			MarkPrice:   common.DecimalFromString(mark.MarkPrice),
+			Timestamp:   strconv.FormatInt(mark.EventTime, 10),
+		})
+
+		header := event.NewHeader(event.NewMarket(common.BN, common.InstTypePerp, mark.Symbol), mark.EventTime)
+		bn.sink.Emit(&event.MarkPrice{
+			Header:     header,
+			MarkPrice:  common.DecimalFromString(mark.MarkPrice),
+			IndexPrice: common.DecimalFromString(mark.IndexPrice),
+		})
+		bn.sink.Emit(&event.FundingRate{
+			Header:          header,
+			Rate:            common.DecimalFromString(mark.FundingRate),
+			NextFundingTime: event.Millis(mark.NextFundingTime),
+		})
+	}
 }
 
-func (bn *BnExClient) handlerFeature(feature map[string]interface{}) {
-	log.Info("feature ------ ,instId: %s , lastPr: %s , fundingRate: %s", feature["s"], feature["c"])
-
-	bn.featurePriceMap.Write(feature["s"].(string), &maps.PriceData{
-		Symbol:    feature["s"].(string),
-		Price:     feature["c"].(string),
-		Timestamp: feature["E"].(string),
-	})
+// decodeFailed 记录无法解析的推送
+func (bn *BnExClient) decodeFailed(instType string, message string, err error) {
+	bn.decodeErrors.Add(1)
+	log.Error("bn decode message failed", "instType", instType, "message", message, "err", err)
+}
 
+// DecodeErrors 无法解析的消息数量, 包括现货和合约连接
+func (bn *BnExClient) DecodeErrors() uint64 {
+	return bn.decodeErrors.Load() +
+		bn.BnWebSocketClient.MessageHandler.DecodeErrors() +
+		bn.FeatureWebSocketClient.MessageHandler.DecodeErrors()
 }
 
-func (bn *BnExClient) handlerFeatureMark(feature map[string]interface{}) {
-	log.Info("mark feature  ------ ,instId: %s , lastPr: %s , fundingRate: %s", feature["s"], feature["p"], feature["r"])
+// decodeEvents 解析单个交易对推送或全市场数组推送
+func decodeEvents[T any](message string) ([]T, error) {
+	data := bytes.TrimSpace([]byte(message))
+	if len(data) > 0 && data[0] == '[' {
+		var events []T
+		if err := json.Unmarshal(data, &events); err != nil {
+			return nil, err
+		}
+		return events, nil
+	}
 
-	bn.markPriceMap.Write(feature["s"].(string), &maps.PriceData{
-		Symbol:      feature["s"].(string),
-		FundingRate: feature["r"].(string),
-		MarkPrice:   feature["p"].(string),
-		Timestamp:   feature["E"].(string),
-	})
+	var event T
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+	return []T{event}, nil
 }
@@ -0,0 +1,122 @@
+package bn
+
+import (
+	"testing"
+	"time"
+
+	"github.com/339-Labs/exchange-market/common/event"
+	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/exchange/cex/bn/constants"
+)
+
+func TestBnTickers(t *testing.T) {
+	spotPriceMap := maps.NewPriceMap(10)
+	featurePriceMap := maps.NewPriceMap(10)
+	markPriceMap := maps.NewPriceMap(10)
+	client, _ := NewBnExClient(&config.CexExchangeConfig{}, spotPriceMap, featurePriceMap, markPriceMap)
+	var events []event.Event
+	client.SetSink(event.SinkFunc(func(e event.Event) {
+		events = append(events, e)
+	}))
+
+	spot := client.BnWebSocketClient.MessageHandler
+	spot.AddSubscription(constants.StreamMiniTickerArr, client.handlerSpot)
+	err := spot.HandleMessage(`[{"e":"24hrMiniTicker","E":1672515782136,"s":"BTCUSDT","c":"16500.10","o":"16400.00","h":"16600.00","l":"16300.00","v":"100","q":"1650000"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := spotPriceMap.Read("BTCUSDT"); !ok || data.Price.String() != "16500.10" || data.Timestamp != "1672515782136" {
+		t.Fatalf("unexpected spot price %+v", data)
+	}
+
+	feature := client.FeatureWebSocketClient.MessageHandler
+	feature.AddSubscription(constants.StreamMarkPriceArr, client.handlerFeatureMark)
+	err = feature.HandleMessage(`[{"e":"markPriceUpdate","E":1562305380000,"s":"BTCUSDT","p":"11794.15","i":"11784.62","P":"11784.25","r":"0.00038167","T":1562306400000}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := markPriceMap.Read("BTCUSDT"); !ok || data.MarkPrice.String() != "11794.15" || data.FundingRate.String() != "0.00038167" {
+		t.Fatalf("unexpected mark price %+v", data)
+	}
+
+	// 现货行情、标记价格、资金费率
+	if len(events) != 3 || events[0].Type() != event.TypeTicker || events[1].Type() != event.TypeMarkPrice || events[2].Type() != event.TypeFundingRate {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	if rate := events[2].(*event.FundingRate); rate.Unified != "BTC/USDT" || rate.NextFundingTime != 1562306400000*int64(time.Millisecond) {
+		t.Fatalf("unexpected funding rate %+v", rate)
+	}
+
+	// 单个交易对推送按流名称分发
+	feature.AddSubscription("btcusdt"+constants.StreamTickerSuffix, client.handlerFeature)
+	err = feature.HandleMessage(`{"e":"24hrTicker","E":1672515782136,"s":"BTCUSDT","c":"16510.00","O":1672429382136,"C":1672515782136}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := featurePriceMap.Read("BTCUSDT"); !ok || data.Price.String() != "16510.00" {
+		t.Fatalf("unexpected feature price %+v", data)
+	}
+}
+
+func TestBnTradesAndKlines(t *testing.T) {
+	client, _ := NewBnExClient(&config.CexExchangeConfig{}, maps.NewPriceMap(10), maps.NewPriceMap(10), maps.NewPriceMap(10))
+	var events []event.Event
+	client.SetSink(event.SinkFunc(func(e event.Event) {
+		events = append(events, e)
+	}))
+
+	spot := client.BnWebSocketClient.MessageHandler
+	spot.AddSubscription("btcusdt"+constants.StreamTradeSuffix, client.handlerTrade)
+	spot.AddSubscription("btcusdt"+constants.StreamKlineSuffix+constants.KlineInterval1m, client.handlerKline)
+	err := spot.HandleMessage(`{"e":"trade","E":1672515782136,"s":"BTCUSDT","t":12345,"p":"16500.10","q":"0.5","T":1672515782134,"m":true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = spot.HandleMessage(`{"e":"kline","E":1672515782136,"s":"BTCUSDT","k":{"t":1672515780000,"T":1672515839999,"s":"BTCUSDT","i":"1m","o":"16490.00","c":"16500.10","h":"16510.00","l":"16480.00","v":"12.5","q":"206250","x":false}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 || events[0].Type() != event.TypeTrade || events[1].Type() != event.TypeKline {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	trade := events[0].(*event.Trade)
+	if trade.Unified != "BTC/USDT" || trade.TradeId != "12345" || trade.Side != event.SideSell || trade.Price.String() != "16500.10" || trade.Size.String() != "0.5" {
+		t.Fatalf("unexpected trade %+v", trade)
+	}
+	kline := events[1].(*event.Kline)
+	if kline.Interval != "1m" || kline.OpenTime != 1672515780000*int64(time.Millisecond) || kline.Close.String() != "16500.10" || kline.Closed {
+		t.Fatalf("unexpected kline %+v", kline)
+	}
+}
+
+func TestBnMalformedMessage(t *testing.T) {
+	client, _ := NewBnExClient(&config.CexExchangeConfig{}, maps.NewPriceMap(10), maps.NewPriceMap(10), maps.NewPriceMap(10))
+	handler := client.BnWebSocketClient.MessageHandler
+
+	messages := []string{
+		`{"e":"24hrMiniTicker","E":`,
+		`[{"e":"24hrMiniTicker"`,
+		`{"e":"24hrMiniTicker","E":"1672515782136","s":"BTCUSDT"}`,
+		`{"e":"kline","E":1672515782136,"s":"BTCUSDT"}`,
+	}
+	for _, message := range messages {
+		if err := handler.HandleMessage(message); err == nil {
+			t.Fatalf("expected error for %s", message)
+		}
+	}
+	if handler.DecodeErrors() != uint64(len(messages)) {
+		t.Fatalf("expected %d decode errors, got %d", len(messages), handler.DecodeErrors())
+	}
+
+	if err := handler.HandleMessage(`[]`); err != nil {
+		t.Fatal(err)
+	}
+
+	// 监听器解析失败同样计数
+	client.handlerSpot(`[{"e":"24hrMiniTicker","c":16500}]`)
+	if client.DecodeErrors() != uint64(len(messages))+1 {
+		t.Fatalf("unexpected decode errors %d", client.DecodeErrors())
+	}
+}
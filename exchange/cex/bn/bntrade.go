@@ -0,0 +1,98 @@
+package bn
+
+import (
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
+	"github.com/339-Labs/exchange-market/exchange/cex/bn/constants"
+	"github.com/339-Labs/exchange-market/exchange/cex/bn/model"
+	"github.com/ethereum/go-ethereum/log"
+	"strconv"
+	"strings"
+)
+
+// ExecuteWsTrade 订阅现货逐笔成交
+func (bn *BnExClient) ExecuteWsTrade(symbols []string) {
+	streams := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		streams = append(streams, strings.ToLower(symbol)+constants.StreamTradeSuffix)
+	}
+	if len(streams) == 0 {
+		return
+	}
+
+	err := bn.BnWebSocketClient.SubscribeList(streams, bn.handlerTrade)
+	if err != nil {
+		log.Error("Failed to subscribe trade", "err", err)
+	}
+}
+
+// ExecuteWsKline 订阅现货K线, interval 为币安的K线间隔, 如 1m
+func (bn *BnExClient) ExecuteWsKline(symbols []string, interval string) {
+	streams := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		streams = append(streams, strings.ToLower(symbol)+constants.StreamKlineSuffix+interval)
+	}
+	if len(streams) == 0 {
+		return
+	}
+
+	err := bn.BnWebSocketClient.SubscribeList(streams, bn.handlerKline)
+	if err != nil {
+		log.Error("Failed to subscribe kline", "interval", interval, "err", err)
+	}
+}
+
+// handlerTrade 处理逐笔成交推送, 买方为 maker 时主动成交方向为卖出
+func (bn *BnExClient) handlerTrade(message string) {
+	trades, err := decodeEvents[model.BinanceTrade](message)
+	if err != nil {
+		bn.decodeFailed(constants.Spot, message, err)
+		return
+	}
+
+	for _, trade := range trades {
+		if trade.EventType != constants.EventTrade {
+			continue
+		}
+		side := event.SideBuy
+		if trade.IsBuyerMaker {
+			side = event.SideSell
+		}
+		bn.sink.Emit(&event.Trade{
+			Header:  event.NewHeader(event.NewMarket(common.BN, common.InstTypeSpot, trade.Symbol), trade.TradeTime),
+			TradeId: strconv.FormatInt(trade.TradeId, 10),
+			Price:   common.DecimalFromString(trade.Price),
+			Size:    common.DecimalFromString(trade.Quantity),
+			Side:    side,
+		})
+	}
+}
+
+// handlerKline 处理K线推送
+func (bn *BnExClient) handlerKline(message string) {
+	klines, err := decodeEvents[model.BinanceKlineStream](message)
+	if err != nil {
+		bn.decodeFailed(constants.Spot, message, err)
+		return
+	}
+
+	for _, stream := range klines {
+		if stream.EventType != constants.EventKline {
+			continue
+		}
+		kline := stream.Kline
+		bn.sink.Emit(&event.Kline{
+			Header:      event.NewHeader(event.NewMarket(common.BN, common.InstTypeSpot, stream.Symbol), stream.EventTime),
+			Interval:    kline.Interval,
+			OpenTime:    event.Millis(kline.OpenTime),
+			CloseTime:   event.Millis(kline.CloseTime),
+			Open:        common.DecimalFromString(kline.OpenPrice),
+			High:        common.DecimalFromString(kline.HighPrice),
+			Low:         common.DecimalFromString(kline.LowPrice),
+			Close:       common.DecimalFromString(kline.ClosePrice),
+			Volume:      common.DecimalFromString(kline.Volume),
+			QuoteVolume: common.DecimalFromString(kline.QuoteAssetVolume),
+			Closed:      kline.IsClosed,
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package bn
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/339-Labs/exchange-market/common/signer"
@@ -11,6 +12,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -25,7 +27,10 @@ type BnMessageHandler struct {
 	Listener      OnReceive
 	ErrorListener OnReceive
 	StreamMap     map[string]OnReceive // 币安使用流名称作为key
-	AllSubscribe  *model.Set
+	AllSubscribe  *ws.SubscriptionRegistry
+
+	// 无法解析的消息数量
+	decodeErrors atomic.Uint64
 
 	// 同步
 	mu sync.RWMutex
@@ -40,13 +45,13 @@ type OnReceive func(message string)
 // NewBnMessageHandler 创建新的币安消息处理器
 func NewBnMessageHandler(config *config.CexExchangeConfig, needLogin bool) *BnMessageHandler {
 	handler := &BnMessageHandler{
-		Config:       config,
-		NeedLogin:    needLogin,
-		LoginStatus:  false,
-		StreamMap:    make(map[string]OnReceive),
-		AllSubscribe: model.NewSet(),
-		Signer:       new(signer.Signer).Init(config.ApiSecretKey),
+		Config:      config,
+		NeedLogin:   needLogin,
+		LoginStatus: false,
+		StreamMap:   make(map[string]OnReceive),
+		Signer:      new(signer.Signer).Init(config.ApiSecretKey),
 	}
+	handler.AllSubscribe = ws.NewSubscriptionRegistry(handler, constants.SubscribeBatchSize, constants.SubscribeIntervalMs*time.Millisecond)
 
 	return handler
 }
@@ -62,84 +67,102 @@ func (h *BnMessageHandler) SetListeners(msgListener OnReceive, errorListener OnR
 	h.ErrorListener = errorListener
 }
 
-// HandleMessage 处理普通消息
+// bnFrame 推送消息的外层字段, 只解析分发需要的字段, 具体结构由监听器解析
+type bnFrame struct {
+	model.BinanceEvent
+	Id     *int64 `json:"id"`     // 订阅响应的请求ID
+	Code   *int   `json:"code"`   // 错误码
+	Status *int   `json:"status"` // 状态码
+	Error  *struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	} `json:"error"` // 订阅请求错误
+	Kline *struct {
+		Interval string `json:"i"`
+	} `json:"k"` // K线间隔, 用于拼接流名称
+}
+
+// HandleMessage 处理普通消息, 先解析事件类型再分发到对应流的监听器, 无法解析的消息计数后返回错误
 func (h *BnMessageHandler) HandleMessage(message string) error {
+	data := bytes.TrimSpace([]byte(message))
+	if len(data) > 0 && data[0] == '[' {
+		return h.handleArrayMessage(message, data)
+	}
 
-	var v interface{}
-	err := json.Unmarshal([]byte(message), &v)
-	if err != nil {
-		panic(err)
+	var frame bnFrame
+	if err := json.Unmarshal(data, &frame); err != nil {
+		h.decodeErrors.Add(1)
+		return fmt.Errorf("decode binance message: %w", err)
 	}
 
-	switch vv := v.(type) {
-	case map[string]interface{}:
-		fmt.Println("处理对象:", message)
+	// 检查是否有错误
+	if frame.Error != nil {
+		return fmt.Errorf("received error code: %d, msg: %s", frame.Error.Code, frame.Error.Msg)
+	}
+	if frame.Code != nil && *frame.Code != constants.StatusOK {
+		return fmt.Errorf("received error code: %d", *frame.Code)
+	}
+	if frame.Status != nil && *frame.Status != constants.StatusOK {
+		return fmt.Errorf("received error code: %d", *frame.Status)
+	}
 
-		// 检查是否有错误
-		if errorCode, exists := vv["code"]; exists {
-			if code, ok := errorCode.(float64); ok && int(code) != 200 {
-				return fmt.Errorf("received error code: %d", int(code))
-			}
-		}
+	// 处理订阅确认响应
+	if frame.EventType == "" && frame.Id != nil {
+		return h.handleSubscribeResponse(message)
+	}
 
-		// 检查是否有错误
-		if errorCode, exists := vv["status"]; exists {
-			if code, ok := errorCode.(float64); ok && int(code) != 200 {
-				return fmt.Errorf("received error code: %d", int(code))
-			}
+	symbol := strings.ToLower(frame.Symbol)
+	switch frame.EventType {
+	case constants.EventTicker:
+		return h.handleDataMessage(message, symbol+constants.StreamTickerSuffix)
+	case constants.EventMiniTicker:
+		return h.handleDataMessage(message, symbol+constants.StreamMiniTickerSuffix)
+	case constants.EventMarkPrice:
+		return h.handleDataMessage(message, symbol+constants.StreamMarkPriceSuffix)
+	case constants.EventDepth:
+		return h.handleDataMessage(message, symbol+constants.StreamDepthSuffix)
+	case constants.EventTrade:
+		return h.handleDataMessage(message, symbol+constants.StreamTradeSuffix)
+	case constants.EventKline:
+		if frame.Kline == nil {
+			h.decodeErrors.Add(1)
+			return fmt.Errorf("binance kline message without k: %s", message)
 		}
+		return h.handleDataMessage(message, symbol+constants.StreamKlineSuffix+frame.Kline.Interval)
+	}
 
-		// 处理订阅确认响应
-		if result, exists := vv["result"]; exists {
-			if result == nil {
-				return h.handleSubscribeResponse(message, vv)
-			}
-		}
+	// 处理其他消息
+	return h.handleOtherMessage(message)
+}
 
-		// 处理有 e 的数据消息
-		if e, exists := vv["e"]; exists {
-
-			s, _ := vv["s"]
-			switch e {
-			case constants.EventTicker:
-				stream := fmt.Sprintf("%s@ticker", strings.ToLower(s.(string)))
-				return h.handleDataMessage(message, stream)
-			case constants.EventMiniTicker:
-				stream := fmt.Sprintf("%s@miniTicker", strings.ToLower(s.(string)))
-				return h.handleDataMessage(message, stream)
-			case constants.EventMarkPrice:
-				stream := fmt.Sprintf("%s@markPrice@1s", strings.ToLower(s.(string)))
-				return h.handleDataMessage(message, stream)
-			default:
-				fmt.Println("未知订阅推送 s%", message)
-			}
-		}
+// handleArrayMessage 处理全市场数组推送, 按第一个元素的事件类型分发
+func (h *BnMessageHandler) handleArrayMessage(message string, data []byte) error {
+	var events []model.BinanceEvent
+	if err := json.Unmarshal(data, &events); err != nil {
+		h.decodeErrors.Add(1)
+		return fmt.Errorf("decode binance array message: %w", err)
+	}
+	if len(events) == 0 {
+		return nil
+	}
 
-	case []interface{}:
-		// 处理数组对象  先断言 vv[0] 是 map[string]interface{}
-		if item, ok := vv[0].(map[string]interface{}); len(vv) > 0 && ok {
-			e := item["e"]
-			switch e {
-			case constants.EventTicker:
-				return h.handleDataMessage(message, constants.StreamTickerArr)
-			case constants.EventMiniTicker:
-				return h.handleDataMessage(message, constants.StreamMiniTickerArr)
-			case constants.EventMarkPrice:
-				return h.handleDataMessage(message, constants.StreamMarkPriceArr)
-			default:
-				fmt.Println("未知订阅推送 s%", message)
-			}
-		} else {
-			fmt.Println("元素不是 map 类型")
-		}
-	default:
-		fmt.Println("未知类型 s%", vv)
+	switch events[0].EventType {
+	case constants.EventTicker:
+		return h.handleDataMessage(message, constants.StreamTickerArr)
+	case constants.EventMiniTicker:
+		return h.handleDataMessage(message, constants.StreamMiniTickerArr)
+	case constants.EventMarkPrice:
+		return h.handleDataMessage(message, constants.StreamMarkPriceArr)
 	}
 
-	// 处理其他消息
 	return h.handleOtherMessage(message)
 }
 
+// DecodeErrors 无法解析的消息数量
+func (h *BnMessageHandler) DecodeErrors() uint64 {
+	return h.decodeErrors.Load()
+}
+
 // HandleError 处理错误消息
 func (h *BnMessageHandler) HandleError(message string) error {
 	log.Error("Received error message: %s", message)
@@ -169,7 +192,7 @@ func (h *BnMessageHandler) HandleSpecialMessage(message string) (handled bool, e
 }
 
 // handleSubscribeResponse 处理订阅响应
-func (h *BnMessageHandler) handleSubscribeResponse(message string, jsonMap map[string]interface{}) error {
+func (h *BnMessageHandler) handleSubscribeResponse(message string) error {
 	log.Info("Subscribe response: %s", message)
 
 	if h.Listener != nil {
@@ -262,8 +285,9 @@ func NewBnWebSocketClient(config *config.CexExchangeConfig, needLogin bool) *BnW
 	messageHandler := NewBnMessageHandler(config, needLogin)
 	messageHandler.SetWebSocketClient(genericClient)
 
-	// 设置消息处理器
+	// 设置消息处理器和订阅注册表
 	genericClient.SetMessageHandler(messageHandler)
+	genericClient.SetSubscriptionRegistry(messageHandler.AllSubscribe)
 
 	// 创建客户端实例
 	client := &BnWebSocketClient{
@@ -275,13 +299,10 @@ func NewBnWebSocketClient(config *config.CexExchangeConfig, needLogin bool) *BnW
 	genericClient.SetCallbacks(
 		func() {
 			log.Info("Binance WebSocket connected")
-			// 币安现货不需要登录
-			if needLogin {
-				messageHandler.LoginStatus = true
-			}
 		},
 		func() {
 			log.Info("Binance WebSocket disconnected")
+			messageHandler.setLoginStatus(false)
 		},
 		func(attempt int) {
 			log.Info("Binance WebSocket reconnecting, attempt: %d", attempt)
@@ -363,19 +384,19 @@ func (c *BnWebSocketClient) SubscribeDepth(symbol string, level string, listener
 
 // SubscribeTicker 订阅24小时价格变动统计
 func (c *BnWebSocketClient) SubscribeMiniTicker(symbol string, listener OnReceive) error {
-	stream := fmt.Sprintf("%s@miniTicker", strings.ToLower(symbol))
+	stream := strings.ToLower(symbol) + constants.StreamMiniTickerSuffix
 	return c.Subscribe(stream, listener)
 }
 
 // SubscribeKline 订阅K线数据
 func (c *BnWebSocketClient) SubscribeKline(symbol string, interval string, listener OnReceive) error {
-	stream := fmt.Sprintf("%s@kline_%s", strings.ToLower(symbol), interval)
+	stream := strings.ToLower(symbol) + constants.StreamKlineSuffix + interval
 	return c.Subscribe(stream, listener)
 }
 
 // SubscribeTrade 订阅成交数据
 func (c *BnWebSocketClient) SubscribeTrade(symbol string, listener OnReceive) error {
-	stream := fmt.Sprintf("%s@trade", strings.ToLower(symbol))
+	stream := strings.ToLower(symbol) + constants.StreamTradeSuffix
 	return c.Subscribe(stream, listener)
 }
 
@@ -412,10 +433,42 @@ func (c *BnWebSocketClient) IsLoggedIn() bool {
 
 // Login 登录（币安现货不需要登录，但为了兼容性保留）
 func (h *BnMessageHandler) Login() error {
+	h.setLoginStatus(true)
+	return nil
+}
+
+// setLoginStatus 设置登录状态
+func (h *BnMessageHandler) setLoginStatus(status bool) {
 	h.mu.Lock()
-	h.LoginStatus = true
+	h.LoginStatus = status
 	h.mu.Unlock()
-	return nil
+}
+
+// RequireLogin 是否需要登录
+func (h *BnMessageHandler) RequireLogin() bool {
+	return h.NeedLogin
+}
+
+// SendSubscribe 批量发送订阅请求，用于重连后重放订阅
+func (h *BnMessageHandler) SendSubscribe(topics []interface{}) error {
+	if h.wsClient == nil {
+		return fmt.Errorf("WebSocket client is not set")
+	}
+
+	streams := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		if stream, ok := topic.(string); ok {
+			streams = append(streams, stream)
+		}
+	}
+
+	subscribeReq := map[string]interface{}{
+		"method": "SUBSCRIBE",
+		"params": streams,
+		"id":     time.Now().UnixNano(),
+	}
+
+	return h.wsClient.SendJSON(subscribeReq)
 }
 
 // SendPing 发送ping消息（币安使用json格式）
@@ -2,15 +2,30 @@ package constants
 
 const (
 	// 币安WebSocket消息类型
-	StatusOK            = 200
-	Spot                = "Spot"
-	Feature             = "Feature"
-	EventTicker         = "24hrTicker"        // 交易对详细信息
-	EventMiniTicker     = "24hrMiniTicker"    // 交易对精简信息
-	EventMarkPrice      = "markPriceUpdate"   // 交易对标记价格
-	StreamTickerArr     = "!ticker@arr"       // 交易对详细信息 - 订阅所有交易对
-	StreamMiniTickerArr = "!miniTicker@arr"   // 交易对精简信息 - 订阅所有交易对
-	StreamMarkPriceArr  = "!markPrice@arr@1s" // 交易对标记价格 - 订阅所有交易对
+	StatusOK                = 200
+	Spot                    = "Spot"
+	Feature                 = "Feature"
+	EventTicker             = "24hrTicker"               // 交易对详细信息
+	EventMiniTicker         = "24hrMiniTicker"           // 交易对精简信息
+	EventMarkPrice          = "markPriceUpdate"          // 交易对标记价格
+	EventDepth              = "depthUpdate"              // 增量深度
+	EventTrade              = "trade"                    // 逐笔成交
+	EventKline              = "kline"                    // K线
+	StreamTickerArr         = "!ticker@arr"              // 交易对详细信息 - 订阅所有交易对
+	StreamMiniTickerArr     = "!miniTicker@arr"          // 交易对精简信息 - 订阅所有交易对
+	StreamMarkPriceArr      = "!markPrice@arr@1s"        // 交易对标记价格 - 订阅所有交易对
+	StreamDepthSuffix       = "@depth@100ms"             // 增量深度流后缀
+	StreamTickerSuffix      = "@ticker"                  // 交易对详细信息流后缀
+	StreamMiniTickerSuffix  = "@miniTicker"              // 交易对精简信息流后缀
+	StreamMarkPriceSuffix   = "@markPrice@1s"            // 交易对标记价格流后缀
+	StreamTradeSuffix       = "@trade"                   // 逐笔成交流后缀
+	StreamKlineSuffix       = "@kline_"                  // K线流后缀, 后接K线间隔
+	KlineInterval1m         = "1m"                       // 1分钟K线
+	DepthSnapshotPath       = "/api/v3/depth"            // 深度快照接口
+	DepthSnapshotLimit      = 1000                       // 深度快照档位
+	SpotExchangeInfoPath    = "/api/v3/exchangeInfo"     // 现货交易规则, 用于加载交易对
+	FuturesApiUrl           = "https://fapi.binance.com" // U本位合约接口地址
+	FuturesExchangeInfoPath = "/fapi/v1/exchangeInfo"    // U本位合约交易规则, 用于加载交易对
 
 	/*
 	 * http headers
@@ -42,6 +57,8 @@ const (
 	WsOpSubscribe       = "subscribe"
 	TimerIntervalSecond = 5
 	ReconnectWaitSecond = 60
+	SubscribeBatchSize  = 100 // 单次订阅请求的最大流数量
+	SubscribeIntervalMs = 250 // 订阅请求间隔, 币安限制每秒最多5条消息
 
 	/*
 	 * SignType
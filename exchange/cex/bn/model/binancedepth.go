@@ -9,3 +9,10 @@ type BinanceDepth struct {
 	Bids          [][]string `json:"b"` // 买盘更新
 	Asks          [][]string `json:"a"` // 卖盘更新
 }
+
+// BinanceDepthSnapshot 深度快照 /api/v3/depth
+type BinanceDepthSnapshot struct {
+	LastUpdateId int64      `json:"lastUpdateId"` // 最后更新ID
+	Bids         [][]string `json:"bids"`         // 买盘
+	Asks         [][]string `json:"asks"`         // 卖盘
+}
@@ -0,0 +1,8 @@
+package model
+
+// BinanceEvent 推送消息的公共字段, 用于按事件类型分发
+type BinanceEvent struct {
+	EventType string `json:"e"` // 事件类型
+	EventTime int64  `json:"E"` // 事件时间
+	Symbol    string `json:"s"` // 交易对
+}
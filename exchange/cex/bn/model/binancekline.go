@@ -5,7 +5,6 @@ type BinanceKline struct {
 	Symbol                   string `json:"s"` // 交易对
 	OpenTime                 int64  `json:"t"` // 开盘时间
 	CloseTime                int64  `json:"T"` // 收盘时间
-	Symbol2                  string `json:"s"` // 交易对
 	Interval                 string `json:"i"` // K线间隔
 	FirstTradeID             int64  `json:"f"` // 第一笔交易ID
 	LastTradeID              int64  `json:"L"` // 最后一笔交易ID
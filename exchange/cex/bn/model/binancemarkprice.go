@@ -0,0 +1,13 @@
+package model
+
+// BinanceMarkPrice 标记价格和资金费率 <symbol>@markPrice@1s / !markPrice@arr@1s
+type BinanceMarkPrice struct {
+	EventType            string `json:"e"` // 事件类型
+	EventTime            int64  `json:"E"` // 事件时间
+	Symbol               string `json:"s"` // 交易对
+	MarkPrice            string `json:"p"` // 标记价格
+	IndexPrice           string `json:"i"` // 现货指数价格
+	EstimatedSettlePrice string `json:"P"` // 预估结算价
+	FundingRate          string `json:"r"` // 资金费率
+	NextFundingTime      int64  `json:"T"` // 下次资金时间
+}
@@ -0,0 +1,14 @@
+package model
+
+// BinanceMiniTicker 精简24小时行情 <symbol>@miniTicker / !miniTicker@arr
+type BinanceMiniTicker struct {
+	EventType   string `json:"e"` // 事件类型
+	EventTime   int64  `json:"E"` // 事件时间
+	Symbol      string `json:"s"` // 交易对
+	LastPrice   string `json:"c"` // 最新价格
+	OpenPrice   string `json:"o"` // 开盘价
+	HighPrice   string `json:"h"` // 最高价
+	LowPrice    string `json:"l"` // 最低价
+	Volume      string `json:"v"` // 成交量
+	QuoteVolume string `json:"q"` // 成交额
+}
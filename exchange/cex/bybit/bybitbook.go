@@ -0,0 +1,166 @@
+package bybit
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
+	"github.com/339-Labs/exchange-market/exchange/cex/bybit/constants"
+	"github.com/339-Labs/exchange-market/exchange/cex/bybit/model"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// bookState 单个深度订阅的同步状态
+type bookState struct {
+	symbol  string
+	depth   int
+	seq     int64 // 最后一次更新的撮合引擎序号
+	pending bool  // 等待全量快照
+}
+
+// SubscribeOrderBook 订阅深度并维护本地订单簿, depth 为 1 / 50 / 200 / 500
+// 收到 snapshot 时重建订单簿, delta 的 u/seq 不递增时重新订阅以获取新的全量
+// 订单簿按交易对存放, 同一个交易对只能订阅一个深度, 已订阅其他深度时返回错误
+func (c *ByBitWebSocketClient) SubscribeOrderBook(depth int, symbols []string) error {
+	if !isOrderBookDepth(depth) {
+		return fmt.Errorf("unsupported orderbook depth %d", depth)
+	}
+
+	c.bookMu.Lock()
+	for _, state := range c.bookStates {
+		for _, symbol := range symbols {
+			if state.symbol == symbol && state.depth != depth {
+				c.bookMu.Unlock()
+				return fmt.Errorf("orderbook of %s already subscribed with depth %d", symbol, state.depth)
+			}
+		}
+	}
+	c.bookMu.Unlock()
+
+	topics := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		topic := fmt.Sprintf("%s.%d.%s", constants.TopicOrderBook, depth, symbol)
+		c.setBookState(topic, &bookState{symbol: symbol, depth: depth, pending: true})
+		topics = append(topics, topic)
+	}
+
+	if len(topics) == 0 {
+		return nil
+	}
+
+	return c.SubscribeTopics(topics, c.handlerOrderBook)
+}
+
+// handlerOrderBook 处理深度推送
+func (c *ByBitWebSocketClient) handlerOrderBook(message string) {
+	var msg model.OrderBookMsg
+	if err := json.Unmarshal([]byte(message), &msg); err != nil {
+		log.Error("bybit orderbook unmarshal failed", "err", err)
+		return
+	}
+
+	if err := c.applyOrderBook(&msg); err != nil {
+		log.Warn("bybit order book out of order, resubscribe", "topic", msg.Topic, "err", err)
+		c.resubscribeOrderBook(msg.Topic, msg.Data.Symbol)
+	}
+}
+
+// applyOrderBook 应用一条深度数据
+func (c *ByBitWebSocketClient) applyOrderBook(msg *model.OrderBookMsg) error {
+	c.bookMu.Lock()
+	defer c.bookMu.Unlock()
+
+	state, ok := c.bookStates[msg.Topic]
+	if !ok {
+		return nil
+	}
+
+	data := &msg.Data
+	book := c.Books.GetOrCreate(data.Symbol)
+
+	// 全量, 或 u 为1表示服务重启后推送的全量
+	if msg.Type == constants.TypeSnapshot || data.UpdateId == constants.OrderBookResetId {
+		if err := book.Load(data.Bids, data.Asks, data.UpdateId, msg.Ts); err != nil {
+			return err
+		}
+		state.seq = data.Seq
+		state.pending = false
+		c.emitOrderBook(msg, true)
+		return nil
+	}
+
+	if msg.Type != constants.TypeDelta {
+		return fmt.Errorf("unknown orderbook type %s", msg.Type)
+	}
+
+	// 等待重新订阅后的全量
+	if state.pending {
+		return nil
+	}
+
+	if last := book.GetLastUpdateId(); data.UpdateId <= last {
+		return fmt.Errorf("update id out of order: last %d, got %d", last, data.UpdateId)
+	}
+	if data.Seq < state.seq {
+		return fmt.Errorf("seq out of order: last %d, got %d", state.seq, data.Seq)
+	}
+
+	if err := book.Apply(data.Bids, data.Asks, data.UpdateId, msg.Ts); err != nil {
+		return err
+	}
+	book.Truncate(state.depth)
+	state.seq = data.Seq
+	c.emitOrderBook(msg, false)
+	return nil
+}
+
+// SetSink 设置订单簿统一行情事件的接收方, instType 为连接的产品类型
+func (c *ByBitWebSocketClient) SetSink(sink event.Sink, instType string) {
+	c.sink = event.OrDiscard(sink)
+	c.instType = instType
+}
+
+// emitOrderBook 发送订单簿事件
+func (c *ByBitWebSocketClient) emitOrderBook(msg *model.OrderBookMsg, snapshot bool) {
+	c.sink.Emit(&event.BookUpdate{
+		Header:        event.NewHeader(event.NewMarket(common.ByBit, c.instType, msg.Data.Symbol), msg.Ts),
+		Snapshot:      snapshot,
+		FinalUpdateId: msg.Data.UpdateId,
+		Bids:          event.Levels(msg.Data.Bids),
+		Asks:          event.Levels(msg.Data.Asks),
+	})
+}
+
+// resubscribeOrderBook 订单簿乱序时取消订阅并重新订阅, bybit 订阅后会先推送全量
+func (c *ByBitWebSocketClient) resubscribeOrderBook(topic string, symbol string) {
+	c.bookMu.Lock()
+	if state, ok := c.bookStates[topic]; ok {
+		state.pending = true
+	}
+	c.bookMu.Unlock()
+
+	if book, ok := c.Books.Get(symbol); ok {
+		book.Clear()
+	}
+
+	if err := c.UnsubscribeTopics([]string{topic}); err != nil {
+		log.Error("bybit orderbook unsubscribe failed", "topic", topic, "err", err)
+	}
+	if err := c.SubscribeTopics([]string{topic}, c.handlerOrderBook); err != nil {
+		log.Error("bybit orderbook resubscribe failed", "topic", topic, "err", err)
+	}
+}
+
+func (c *ByBitWebSocketClient) setBookState(topic string, state *bookState) {
+	c.bookMu.Lock()
+	defer c.bookMu.Unlock()
+	c.bookStates[topic] = state
+}
+
+func isOrderBookDepth(depth int) bool {
+	switch depth {
+	case constants.OrderBookDepth1, constants.OrderBookDepth50, constants.OrderBookDepth200, constants.OrderBookDepth500:
+		return true
+	}
+	return false
+}
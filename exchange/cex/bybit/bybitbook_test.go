@@ -0,0 +1,74 @@
+package bybit
+
+import (
+	"testing"
+
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/exchange/cex/bybit/constants"
+	"github.com/339-Labs/exchange-market/exchange/cex/bybit/model"
+)
+
+func TestApplyOrderBook(t *testing.T) {
+	client := NewByBitWebSocketClient(&config.CexExchangeConfig{}, false)
+	topic := "orderbook.1.BTCUSDT"
+	client.setBookState(topic, &bookState{symbol: "BTCUSDT", depth: 1, pending: true})
+
+	// 全量之前的增量被忽略
+	delta := &model.OrderBookMsg{Topic: topic, Type: constants.TypeDelta, Data: model.OrderBookData{
+		Symbol: "BTCUSDT", Bids: [][]string{{"99", "1"}}, UpdateId: 9, Seq: 90,
+	}}
+	if err := client.applyOrderBook(delta); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := &model.OrderBookMsg{Topic: topic, Type: constants.TypeSnapshot, Data: model.OrderBookData{
+		Symbol: "BTCUSDT", Bids: [][]string{{"100", "1"}}, Asks: [][]string{{"101", "1"}}, UpdateId: 10, Seq: 100,
+	}}
+	if err := client.applyOrderBook(snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	// 新的买一, 超出深度的档位被截断
+	delta.Data = model.OrderBookData{Symbol: "BTCUSDT", Bids: [][]string{{"100.5", "2"}}, UpdateId: 11, Seq: 101}
+	if err := client.applyOrderBook(delta); err != nil {
+		t.Fatal(err)
+	}
+	book, _ := client.Books.Get("BTCUSDT")
+	if bids, _ := book.Depth(); bids != 1 {
+		t.Fatalf("expected 1 bid level, got %d", bids)
+	}
+	if bid, _ := book.BestBid(); bid.Price != 100.5 {
+		t.Fatalf("expected best bid 100.5, got %v", bid.Price)
+	}
+
+	// 更新ID回退
+	delta.Data = model.OrderBookData{Symbol: "BTCUSDT", UpdateId: 11, Seq: 102}
+	if err := client.applyOrderBook(delta); err == nil {
+		t.Fatal("expected out of order error")
+	}
+}
+
+func TestSubscribeOrderBook_SecondDepth(t *testing.T) {
+	client := NewByBitWebSocketClient(&config.CexExchangeConfig{}, false)
+	topic := "orderbook.200.BTCUSDT"
+	client.setBookState(topic, &bookState{symbol: "BTCUSDT", depth: 200, pending: true})
+
+	snapshot := &model.OrderBookMsg{Topic: topic, Type: constants.TypeSnapshot, Data: model.OrderBookData{
+		Symbol: "BTCUSDT", Bids: [][]string{{"100", "1"}, {"99", "1"}}, Asks: [][]string{{"101", "1"}}, UpdateId: 10, Seq: 100,
+	}}
+	if err := client.applyOrderBook(snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一个交易对的第二个深度与 200 档共用订单簿, 需要拒绝
+	if err := client.SubscribeOrderBook(1, []string{"BTCUSDT"}); err == nil {
+		t.Fatal("expected error for a second depth of the same symbol")
+	}
+	if _, ok := client.bookStates["orderbook.1.BTCUSDT"]; ok {
+		t.Fatal("rejected depth should not be tracked")
+	}
+	book, _ := client.Books.Get("BTCUSDT")
+	if bids, _ := book.Depth(); bids != 2 {
+		t.Fatalf("expected 200 level book intact with 2 bids, got %d", bids)
+	}
+}
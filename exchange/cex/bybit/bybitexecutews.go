@@ -1,38 +1,76 @@
 package bybit
 
 import (
+	"errors"
 	"fmt"
 	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
 	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/common/ws"
 	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/exchange/cex/bybit/constants"
 	"github.com/ethereum/go-ethereum/log"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type ByBitExClient struct {
-	ByBitWebSocketClient *ByBitWebSocketClient
-	config               *config.CexExchangeConfig
-	spotPriceMap         *maps.PriceMap
-	featurePriceMap      *maps.PriceMap
+	ByBitWebSocketClient   *ByBitWebSocketClient // 现货
+	FeatureWebSocketClient *ByBitWebSocketClient // USDT永续
+	config                 *config.CexExchangeConfig
+	spotPriceMap           *maps.PriceMap
+	featurePriceMap        *maps.PriceMap
+	sink                   event.Sink // 统一行情事件
+
+	// tickers 订阅, 按单连接订阅数分片
+	spotPool    *ws.ShardPool[string]
+	featurePool *ws.ShardPool[string]
 }
 
 func NewByBitExClient(config *config.CexExchangeConfig, spotPriceMap *maps.PriceMap, featurePriceMap *maps.PriceMap) (*ByBitExClient, error) {
 	// 创建bitget WebSocket客户端
 	client := NewByBitWebSocketClient(config, false) // true表示需要登录
 
-	return &ByBitExClient{
-		ByBitWebSocketClient: client,
-		config:               config,
-		spotPriceMap:         spotPriceMap,
-		featurePriceMap:      featurePriceMap,
-	}, nil
+	// 合约使用独立的WebSocket地址
+	featureConfig := *config
+	featureConfig.WsUrl = config.WsUrlFeature
+	featureClient := NewByBitWebSocketClient(&featureConfig, false)
+
+	bb := &ByBitExClient{
+		ByBitWebSocketClient:   client,
+		FeatureWebSocketClient: featureClient,
+		config:                 config,
+		spotPriceMap:           spotPriceMap,
+		featurePriceMap:        featurePriceMap,
+		sink:                   event.Discard,
+	}
+	bb.spotPool = bb.newTickerPool(client, config.WsUrl, bb.handlerSpotTickers)
+	bb.featurePool = bb.newTickerPool(featureClient, config.WsUrlFeature, bb.handlerFeatureTickers)
+	return bb, nil
+}
+
+// SetSink 设置统一行情事件的接收方, 同时用于订单簿推送, 需在 ExecuteSpotWs / ExecuteFeatureWs 之前调用
+func (bb *ByBitExClient) SetSink(sink event.Sink) {
+	bb.sink = event.OrDiscard(sink)
+	bb.ByBitWebSocketClient.SetSink(sink, common.InstTypeSpot)
+	bb.FeatureWebSocketClient.SetSink(sink, common.InstTypePerp)
 }
 
+// ExecuteSpotWs 启动现货客户端, 交易对由 SyncSymbols 按 market_symbol 订阅
 func (bb *ByBitExClient) ExecuteSpotWs() {
+	bb.startClient(bb.ByBitWebSocketClient)
+}
+
+// ExecuteFeatureWs 启动USDT永续客户端, 交易对由 SyncSymbols 按 market_symbol 订阅
+func (bb *ByBitExClient) ExecuteFeatureWs() {
+	bb.startClient(bb.FeatureWebSocketClient)
+}
+
+func (bb *ByBitExClient) startClient(client *ByBitWebSocketClient) {
 
 	// 设置全局消息监听器
-	bb.ByBitWebSocketClient.SetListeners(
+	client.SetListeners(
 		func(message string) {
 			fmt.Printf("收到消息: %s\n", message)
 		},
@@ -42,92 +80,113 @@ func (bb *ByBitExClient) ExecuteSpotWs() {
 	)
 
 	// 启动客户端
-	if err := bb.ByBitWebSocketClient.Start(); err != nil {
-		log.Info("启动客户端失败:", err)
+	if err := client.Start(); err != nil {
+		log.Error("Failed to start bybit client", "err", err)
 	}
 
 	// 等待登录完成
 	time.Sleep(2 * time.Second)
+}
 
-	// todo spotSymbols get spot from db
-	var spotSymbols []string
-	spotSymbols = append(spotSymbols, "BTCUSDT")
-	spotSymbols = append(spotSymbols, "ETHUSDT")
-
-	err := bb.ByBitWebSocketClient.SubscribeList(spotSymbols, func(message string) {
-		fmt.Printf("订阅价格更新，处理价格: %s\n", message)
-		jsonMap := common.JSONToMap(message)
-
-		topic, _ := jsonMap["topic"].(string)
-		ts, _ := jsonMap["ts"].(string)
-
-		if strings.Contains(topic, "tickers") {
-			data, _ := jsonMap["data"].(map[string]interface{})
-			bb.handlerSpot(data, ts)
-		}
-	})
+// SyncSymbols 调整现货或永续的 tickers 订阅, 超过单连接订阅数时新建连接
+func (bb *ByBitExClient) SyncSymbols(instType string, symbols []string) error {
+	pool := bb.spotPool
+	if instType == common.InstTypePerp {
+		pool = bb.featurePool
+	}
 
-	if err != nil {
-		log.Error("订阅失败: %v", err)
+	topics := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		topics = append(topics, fmt.Sprintf("%s.%s", constants.TopicTickers, symbol))
 	}
-	log.Info("执行")
 
+	added, removed, err := pool.Sync(topics)
+	log.Info("bybit subscriptions synced", "instType", instType, "added", added, "removed", removed, "conns", pool.Conns())
+	return err
 }
 
-func (bb *ByBitExClient) ExecuteFeatureWs() {
+// Close 关闭连接池中新建的连接, 主连接由调用方关闭
+func (bb *ByBitExClient) Close() error {
+	return errors.Join(bb.spotPool.Close(), bb.featurePool.Close())
+}
 
-	// 创建WebSocket客户端
-	cf := &config.CexExchangeConfig{
-		WsUrl:        bb.config.WsUrlFeature,
-		ApiKey:       bb.config.ApiKey,
-		ApiSecretKey: bb.config.ApiSecretKey,
-	}
-	// 创建bitget WebSocket客户端
-	client := NewByBitWebSocketClient(cf, false) // true表示需要登录
+// newTickerPool 创建连接池, 第一个连接复用 primary, 其余连接按 wsUrl 新建
+func (bb *ByBitExClient) newTickerPool(primary *ByBitWebSocketClient, wsUrl string, listener OnReceive) *ws.ShardPool[string] {
+	return ws.NewShardPool(ws.ShardPoolConfig{
+		MaxTopicsPerConn: constants.MaxTopicsPerConn,
+		BatchSize:        constants.SubscribeBatchSize,
+		BatchInterval:    constants.SubscribeIntervalMs * time.Millisecond,
+	}, func(index int) (ws.ShardConn[string], error) {
+		if index == 0 {
+			return &tickerConn{client: primary, listener: listener, primary: true}, nil
+		}
 
-	// 设置全局消息监听器
-	client.SetListeners(
-		func(message string) {
+		connConfig := *bb.config
+		connConfig.WsUrl = wsUrl
+		client := NewByBitWebSocketClient(&connConfig, false)
+		if err := client.Start(); err != nil {
+			return nil, err
+		}
+		return &tickerConn{client: client, listener: listener}, nil
+	})
+}
 
-			fmt.Printf("收到消息: %s\n", message)
+// handlerSpotTickers 处理现货 tickers 推送
+func (bb *ByBitExClient) handlerSpotTickers(message string) {
+	jsonMap := common.JSONToMap(message)
 
-		},
-		func(message string) {
-			fmt.Printf("收到错误: %s\n", message)
-		},
-	)
+	topic, _ := jsonMap["topic"].(string)
+	ts := timestampValue(jsonMap["ts"])
 
-	// 启动客户端
-	if err := client.Start(); err != nil {
-		log.Info("启动客户端失败:", err)
+	if strings.Contains(topic, constants.TopicTickers) {
+		data, _ := jsonMap["data"].(map[string]interface{})
+		bb.handlerSpot(data, ts)
 	}
+}
 
-	// 等待登录完成
-	time.Sleep(2 * time.Second)
+// handlerFeatureTickers 处理USDT永续 tickers 推送
+func (bb *ByBitExClient) handlerFeatureTickers(message string) {
+	jsonMap := common.JSONToMap(message)
 
-	// todo spotSymbols get spot from db
-	var spotSymbols []string
-	spotSymbols = append(spotSymbols, "BTCUSDT")
-	spotSymbols = append(spotSymbols, "ETHUSDT")
+	topic, _ := jsonMap["topic"].(string)
+	ts := timestampValue(jsonMap["ts"])
 
-	err := client.SubscribeList(spotSymbols, func(message string) {
-		fmt.Println("订阅价格更新，处理价格: %s\n", message)
+	if strings.Contains(topic, constants.TopicTickers) {
+		data, _ := jsonMap["data"].(map[string]interface{})
+		bb.handlerFeature(data, ts)
+	}
+}
 
-		jsonMap := common.JSONToMap(message)
+// tickerConn 连接池中的连接
+type tickerConn struct {
+	client   *ByBitWebSocketClient
+	listener OnReceive
+	primary  bool // 主连接由 ByBitExClient 的调用方关闭
+}
 
-		topic, _ := jsonMap["topic"].(string)
-		ts, _ := jsonMap["ts"].(string)
+func (c *tickerConn) SubscribeTopics(topics []string) error {
+	return c.client.SubscribeTopics(topics, c.listener)
+}
 
-		if strings.Contains(topic, "tickers") {
-			data, _ := jsonMap["data"].(map[string]interface{})
-			bb.handlerFeature(data, ts)
-		}
-	})
+func (c *tickerConn) UnsubscribeTopics(topics []string) error {
+	return c.client.UnsubscribeTopics(topics)
+}
 
-	if err != nil {
-		log.Error("订阅失败: %v", err)
+func (c *tickerConn) Close() error {
+	if c.primary {
+		return nil
+	}
+	return c.client.Stop()
+}
+
+// ExecuteOrderBookWs 订阅现货和USDT永续深度并维护本地订单簿, 需在 ExecuteSpotWs / ExecuteFeatureWs 启动客户端之后调用
+func (bb *ByBitExClient) ExecuteOrderBookWs(depth int, spotSymbols []string, featureSymbols []string) {
+	if err := bb.ByBitWebSocketClient.SubscribeOrderBook(depth, spotSymbols); err != nil {
+		log.Error("Failed to subscribe spot orderbook", "depth", depth, "err", err)
+	}
+	if err := bb.FeatureWebSocketClient.SubscribeOrderBook(depth, featureSymbols); err != nil {
+		log.Error("Failed to subscribe feature orderbook", "depth", depth, "err", err)
 	}
-	log.Info("执行")
 }
 
 func (bb *ByBitExClient) handlerSpot(spot map[string]interface{}, ts string) {
@@ -135,9 +194,10 @@ func (bb *ByBitExClient) handlerSpot(spot map[string]interface{}, ts string) {
 
 	bb.spotPriceMap.Write(spot["symbol"].(string), &maps.PriceData{
 		Symbol:    spot["symbol"].(string),
-		Price:     spot["lastPrice"].(string),
+		Price:     common.DecimalFromString(stringValue(spot, "lastPrice")),
 		Timestamp: ts,
 	})
+	bb.emitTicker(common.InstTypeSpot, spot, ts)
 
 }
 
@@ -146,10 +206,74 @@ func (bb *ByBitExClient) handlerFeature(feature map[string]interface{}, ts strin
 
 	bb.featurePriceMap.Write(feature["symbol"].(string), &maps.PriceData{
 		Symbol:      feature["symbol"].(string),
-		Price:       feature["lastPrice"].(string),
-		FundingRate: feature["fundingRate"].(string),
-		MarkPrice:   feature["markPrice"].(string),
+		Price:       common.DecimalFromString(stringValue(feature, "lastPrice")),
+		FundingRate: common.DecimalFromString(stringValue(feature, "fundingRate")),
+		MarkPrice:   common.DecimalFromString(stringValue(feature, "markPrice")),
 		Timestamp:   ts,
 	})
+	bb.emitTicker(common.InstTypePerp, feature, ts)
+
+}
+
+// emitTicker 发送 tickers 推送的统一行情事件, 永续推送中的标记价格、资金费率、持仓量分别发送
+func (bb *ByBitExClient) emitTicker(instType string, data map[string]interface{}, ts string) {
+	tsMs, _ := strconv.ParseInt(ts, 10, 64)
+	header := event.NewHeader(event.NewMarket(common.ByBit, instType, stringValue(data, "symbol")), tsMs)
+
+	bb.sink.Emit(&event.Ticker{
+		Header:      header,
+		Last:        common.DecimalFromString(stringValue(data, "lastPrice")),
+		Open:        common.DecimalFromString(stringValue(data, "prevPrice24h")),
+		High:        common.DecimalFromString(stringValue(data, "highPrice24h")),
+		Low:         common.DecimalFromString(stringValue(data, "lowPrice24h")),
+		Volume:      common.DecimalFromString(stringValue(data, "volume24h")),
+		QuoteVolume: common.DecimalFromString(stringValue(data, "turnover24h")),
+		Bid:         common.DecimalFromString(stringValue(data, "bid1Price")),
+		BidSize:     common.DecimalFromString(stringValue(data, "bid1Size")),
+		Ask:         common.DecimalFromString(stringValue(data, "ask1Price")),
+		AskSize:     common.DecimalFromString(stringValue(data, "ask1Size")),
+	})
+	if instType != common.InstTypePerp {
+		return
+	}
 
+	if markPrice := stringValue(data, "markPrice"); markPrice != "" {
+		bb.sink.Emit(&event.MarkPrice{
+			Header:     header,
+			MarkPrice:  common.DecimalFromString(markPrice),
+			IndexPrice: common.DecimalFromString(stringValue(data, "indexPrice")),
+		})
+	}
+	if fundingRate := stringValue(data, "fundingRate"); fundingRate != "" {
+		nextFundingTime, _ := strconv.ParseInt(stringValue(data, "nextFundingTime"), 10, 64)
+		bb.sink.Emit(&event.FundingRate{
+			Header:          header,
+			Rate:            common.DecimalFromString(fundingRate),
+			NextFundingTime: event.Millis(nextFundingTime),
+		})
+	}
+	if openInterest := stringValue(data, "openInterest"); openInterest != "" {
+		bb.sink.Emit(&event.OpenInterest{
+			Header:            header,
+			OpenInterest:      common.DecimalFromString(openInterest),
+			OpenInterestValue: common.DecimalFromString(stringValue(data, "openInterestValue")),
+		})
+	}
+}
+
+// stringValue 读取推送中的字符串字段, 不存在时返回空字符串
+func stringValue(data map[string]interface{}, key string) string {
+	value, _ := data[key].(string)
+	return value
+}
+
+// timestampValue bybit 推送的 ts 为毫秒数字, 转换为字符串
+func timestampValue(value interface{}) string {
+	switch ts := value.(type) {
+	case float64:
+		return strconv.FormatInt(int64(ts), 10)
+	case string:
+		return ts
+	}
+	return ""
 }
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
+	"github.com/339-Labs/exchange-market/common/orderbook"
 	"github.com/339-Labs/exchange-market/common/signer"
 	"github.com/339-Labs/exchange-market/common/ws"
 	"github.com/339-Labs/exchange-market/config"
@@ -26,7 +28,7 @@ type BybitMessageHandler struct {
 	Listener      OnReceive
 	ErrorListener OnReceive
 	ScribeMap     map[string]OnReceive
-	AllSubscribe  *model.Set
+	AllSubscribe  *ws.SubscriptionRegistry
 
 	// 同步
 	mu sync.RWMutex
@@ -41,13 +43,13 @@ type OnReceive func(message string)
 // NewByBitMessageHandler 创建新的bybit消息处理器
 func NewByBitMessageHandler(config *config.CexExchangeConfig, needLogin bool) *BybitMessageHandler {
 	handler := &BybitMessageHandler{
-		Config:       config,
-		NeedLogin:    needLogin,
-		LoginStatus:  false,
-		ScribeMap:    make(map[string]OnReceive),
-		AllSubscribe: model.NewSet(),
-		Signer:       new(signer.Signer).Init(config.ApiSecretKey),
+		Config:      config,
+		NeedLogin:   needLogin,
+		LoginStatus: false,
+		ScribeMap:   make(map[string]OnReceive),
+		Signer:      new(signer.Signer).Init(config.ApiSecretKey),
 	}
+	handler.AllSubscribe = ws.NewSubscriptionRegistry(handler, constants.SubscribeBatchSize, constants.SubscribeIntervalMs*time.Millisecond)
 
 	return handler
 }
@@ -70,7 +72,7 @@ func (h *BybitMessageHandler) HandleMessage(message string) error {
 	// 检查是否有错误代码
 	if success, exists := jsonMap["success"]; exists {
 		if !success.(bool) {
-			return fmt.Errorf("received error msg: %d", message)
+			return fmt.Errorf("received error msg: %s", message)
 		}
 	}
 
@@ -113,9 +115,7 @@ func (h *BybitMessageHandler) HandleSpecialMessage(message string) (handled bool
 func (h *BybitMessageHandler) handleLoginResponse(message string) error {
 	log.Info("Login response: %s", message)
 
-	h.mu.Lock()
-	h.LoginStatus = true
-	h.mu.Unlock()
+	h.setLoginStatus(true)
 
 	if h.Listener != nil {
 		h.Listener(message)
@@ -162,6 +162,32 @@ func (h *BybitMessageHandler) getListener(topic interface{}) OnReceive {
 	return listener
 }
 
+// setLoginStatus 设置登录状态
+func (h *BybitMessageHandler) setLoginStatus(status bool) {
+	h.mu.Lock()
+	h.LoginStatus = status
+	h.mu.Unlock()
+}
+
+// RequireLogin 是否需要登录
+func (h *BybitMessageHandler) RequireLogin() bool {
+	return h.NeedLogin
+}
+
+// SendSubscribe 批量发送订阅请求，用于重连后重放订阅
+func (h *BybitMessageHandler) SendSubscribe(topics []interface{}) error {
+	if h.wsClient == nil {
+		return fmt.Errorf("WebSocket client is not set")
+	}
+
+	baseReq := model.WsBaseReq{
+		Op:   constants.WsOpSubscribe,
+		Args: topics,
+	}
+
+	return h.wsClient.SendJSON(baseReq)
+}
+
 // IsLoggedIn 检查是否已登录
 func (h *BybitMessageHandler) IsLoggedIn() bool {
 	h.mu.RLock()
@@ -191,6 +217,13 @@ func (h *BybitMessageHandler) RemoveSubscription(req string) {
 type ByBitWebSocketClient struct {
 	*ws.GenericWebSocketClient
 	MessageHandler *BybitMessageHandler
+
+	// 本地订单簿
+	Books      *orderbook.Manager
+	bookStates map[string]*bookState // 按topic记录的同步状态
+	bookMu     sync.Mutex
+	sink       event.Sink // 订单簿统一行情事件
+	instType   string     // 连接的产品类型 spot 或 perp
 }
 
 // NewByBitWebSocketClient 创建新的bybit WebSocket客户端
@@ -213,20 +246,18 @@ func NewByBitWebSocketClient(config *config.CexExchangeConfig, needLogin bool) *
 	messageHandler := NewByBitMessageHandler(config, needLogin)
 	messageHandler.SetWebSocketClient(genericClient)
 
-	// 设置消息处理器
+	// 设置消息处理器和订阅注册表
 	genericClient.SetMessageHandler(messageHandler)
+	genericClient.SetSubscriptionRegistry(messageHandler.AllSubscribe)
 
 	// 设置回调函数
 	genericClient.SetCallbacks(
 		func() {
 			log.Info("Okx WebSocket connected")
-			// 如果需要登录，则自动登录
-			if needLogin {
-				messageHandler.Login()
-			}
 		},
 		func() {
 			log.Info("Okx WebSocket disconnected")
+			messageHandler.setLoginStatus(false)
 		},
 		func(attempt int) {
 			log.Info("Okx WebSocket reconnecting, attempt: %d", attempt)
@@ -236,6 +267,9 @@ func NewByBitWebSocketClient(config *config.CexExchangeConfig, needLogin bool) *
 	return &ByBitWebSocketClient{
 		GenericWebSocketClient: genericClient,
 		MessageHandler:         messageHandler,
+		Books:                  orderbook.NewManager(string(common.ByBit)),
+		bookStates:             make(map[string]*bookState),
+		sink:                   event.Discard,
 	}
 }
 
@@ -282,16 +316,24 @@ func (c *ByBitWebSocketClient) Subscribe(req string, listener OnReceive) error {
 
 // SubscribeList 订阅列表
 func (c *ByBitWebSocketClient) SubscribeList(reqs []string, listener OnReceive) error {
-
-	var args []interface{}
+	topics := make([]string, 0, len(reqs))
 	for _, req := range reqs {
+		topics = append(topics, fmt.Sprintf("%s.%s", constants.TopicTickers, req))
+	}
 
-		stream := fmt.Sprintf("tickers.%s", req)
+	return c.SubscribeTopics(topics, listener)
+}
+
+// SubscribeTopics 订阅完整topic列表, 如 orderbook.50.BTCUSDT
+func (c *ByBitWebSocketClient) SubscribeTopics(topics []string, listener OnReceive) error {
+
+	var args []interface{}
+	for _, topic := range topics {
 		// 添加到订阅映射
-		c.MessageHandler.AddSubscription(stream, listener)
+		c.MessageHandler.AddSubscription(topic, listener)
 
 		// 发送订阅请求
-		args = append(args, stream)
+		args = append(args, topic)
 	}
 
 	baseReq := model.WsBaseReq{
@@ -302,6 +344,24 @@ func (c *ByBitWebSocketClient) SubscribeList(reqs []string, listener OnReceive)
 	return c.SendJSON(baseReq)
 }
 
+// UnsubscribeTopics 取消订阅完整topic列表
+func (c *ByBitWebSocketClient) UnsubscribeTopics(topics []string) error {
+	var args []interface{}
+	for _, topic := range topics {
+		// 从订阅映射中移除
+		c.MessageHandler.RemoveSubscription(topic)
+		// 发送取消订阅请求
+		args = append(args, topic)
+	}
+
+	baseReq := model.WsBaseReq{
+		Op:   constants.WsOpUnsubscribe,
+		Args: args,
+	}
+
+	return c.SendJSON(baseReq)
+}
+
 // Unsubscribe 取消订阅
 func (c *ByBitWebSocketClient) Unsubscribe(req string) error {
 	stream := fmt.Sprintf("tickers.%s", req)
@@ -310,7 +370,7 @@ func (c *ByBitWebSocketClient) Unsubscribe(req string) error {
 
 	// 发送取消订阅请求
 	var args []interface{}
-	args = append(args, req)
+	args = append(args, stream)
 
 	baseReq := model.WsBaseReq{
 		Op:   constants.WsOpUnsubscribe,
@@ -322,13 +382,13 @@ func (c *ByBitWebSocketClient) Unsubscribe(req string) error {
 
 // UnsubscribeList  取消订阅
 func (c *ByBitWebSocketClient) UnsubscribeList(req []string) error {
-	stream := fmt.Sprintf("tickers.%s", req)
 	var args []interface{}
 	for _, req := range req {
+		stream := fmt.Sprintf("tickers.%s", req)
 		// 从订阅映射中移除
 		c.MessageHandler.RemoveSubscription(stream)
 		// 发送取消订阅请求
-		args = append(args, req)
+		args = append(args, stream)
 	}
 
 	baseReq := model.WsBaseReq{
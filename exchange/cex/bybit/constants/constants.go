@@ -31,6 +31,26 @@ const (
 	WsOpSubscribe       = "subscribe"
 	TimerIntervalSecond = 5
 	ReconnectWaitSecond = 60
+	SubscribeBatchSize  = 10  // 单次订阅请求的最大频道数量
+	SubscribeIntervalMs = 100 // 订阅请求间隔
+	MaxTopicsPerConn    = 200 // 单连接的最大订阅数, 超出后新建连接
+
+	/*
+	 * topic
+	 */
+	TopicTickers   = "tickers"   // tickers.{symbol}
+	TopicOrderBook = "orderbook" // orderbook.{depth}.{symbol}
+
+	/*
+	 * order book
+	 */
+	TypeSnapshot      = "snapshot" // 全量
+	TypeDelta         = "delta"    // 增量
+	OrderBookResetId  = 1          // u 为1表示服务重启, 需重置订单簿
+	OrderBookDepth1   = 1          // 1档深度
+	OrderBookDepth50  = 50         // 50档深度
+	OrderBookDepth200 = 200        // 200档深度
+	OrderBookDepth500 = 500        // 500档深度, 仅合约
 
 	/*
 	 * SignType
@@ -0,0 +1,19 @@
+package model
+
+// OrderBookData 深度数据
+type OrderBookData struct {
+	Symbol   string     `json:"s"`   // 交易对
+	Bids     [][]string `json:"b"`   // 买盘 [价格, 数量], 数量为0表示删除
+	Asks     [][]string `json:"a"`   // 卖盘
+	UpdateId int64      `json:"u"`   // 更新ID, 为1时表示服务重启后的全量
+	Seq      int64      `json:"seq"` // 撮合引擎序号
+}
+
+// OrderBookMsg 深度推送 orderbook.{depth}.{symbol}
+type OrderBookMsg struct {
+	Topic string        `json:"topic"`
+	Type  string        `json:"type"` // snapshot 全量, delta 增量
+	Ts    int64         `json:"ts"`   // 推送时间
+	Data  OrderBookData `json:"data"`
+	Cts   int64         `json:"cts"` // 撮合引擎时间
+}
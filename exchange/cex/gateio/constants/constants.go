@@ -0,0 +1,42 @@
+package constants
+
+const (
+	/*
+	 * channel
+	 */
+	ChannelSpotTickers    = "spot.tickers"    // 现货ticker
+	ChannelFuturesTickers = "futures.tickers" // 合约ticker
+	ChannelSpotPing       = "spot.ping"
+	ChannelSpotPong       = "spot.pong"
+	ChannelFuturesPing    = "futures.ping"
+	ChannelFuturesPong    = "futures.pong"
+
+	/*
+	 * http path
+	 */
+	SpotCurrencyPairsPath = "/api/v4/spot/currency_pairs"     // 现货交易对
+	FuturesContractsPath  = "/api/v4/futures/usdt/contracts"  // USDT永续合约
+	DeliveryContractsPath = "/api/v4/delivery/usdt/contracts" // USDT交割合约
+	SpotTradable          = "tradable"                        // 现货可交易状态
+	SpotBuyable           = "buyable"                         // 现货只可买
+	SpotSellable          = "sellable"                        // 现货只可卖
+
+	/*
+	 * http methods
+	 */
+	GET  = "GET"
+	POST = "POST"
+
+	/*
+	 * websocket
+	 */
+	WsEventSubscribe    = "subscribe"
+	WsEventUnsubscribe  = "unsubscribe"
+	WsEventUpdate       = "update"
+	WsEventAll          = "all"
+	TimerIntervalSecond = 5
+	ReconnectWaitSecond = 60
+	SubscribeBatchSize  = 100 // 单次订阅请求的最大交易对数量
+	SubscribeIntervalMs = 100 // 订阅请求间隔
+	MaxTopicsPerConn    = 200 // 单连接的最大订阅数, 超出后新建连接
+)
@@ -0,0 +1,209 @@
+package gateio
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
+	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/common/ws"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/exchange/cex/gateio/constants"
+	"github.com/339-Labs/exchange-market/exchange/cex/gateio/model"
+	"github.com/ethereum/go-ethereum/log"
+	"strconv"
+	"time"
+)
+
+type GateIoExClient struct {
+	GateIoWebSocketClient  *GateIoWebSocketClient // 现货
+	FeatureWebSocketClient *GateIoWebSocketClient // USDT永续
+	config                 *config.CexExchangeConfig
+	spotPriceMap           *maps.PriceMap
+	featurePriceMap        *maps.PriceMap
+	sink                   event.Sink // 统一行情事件
+
+	// ticker 订阅, 按单连接订阅数分片
+	spotPool    *ws.ShardPool[model.SubscribeReq]
+	featurePool *ws.ShardPool[model.SubscribeReq]
+}
+
+func NewGateIoExClient(config *config.CexExchangeConfig, spotPriceMap *maps.PriceMap, featurePriceMap *maps.PriceMap) (*GateIoExClient, error) {
+	// 创建gateio WebSocket客户端
+	client := NewGateIoWebSocketClient(config, constants.ChannelSpotPing, false)
+
+	// 合约使用独立的WebSocket地址
+	featureConfig := *config
+	featureConfig.WsUrl = config.WsUrlFeature
+	featureClient := NewGateIoWebSocketClient(&featureConfig, constants.ChannelFuturesPing, false)
+
+	gt := &GateIoExClient{
+		GateIoWebSocketClient:  client,
+		FeatureWebSocketClient: featureClient,
+		config:                 config,
+		spotPriceMap:           spotPriceMap,
+		featurePriceMap:        featurePriceMap,
+		sink:                   event.Discard,
+	}
+	gt.spotPool = gt.newTickerPool(client, config.WsUrl, constants.ChannelSpotPing, gt.handlerSpot)
+	gt.featurePool = gt.newTickerPool(featureClient, config.WsUrlFeature, constants.ChannelFuturesPing, gt.handlerFeature)
+	return gt, nil
+}
+
+// SetSink 设置统一行情事件的接收方, 需在 ExecuteSpotWs / ExecuteFeatureWs 之前调用
+func (gt *GateIoExClient) SetSink(sink event.Sink) {
+	gt.sink = event.OrDiscard(sink)
+}
+
+// ExecuteSpotWs 启动现货客户端, 交易对由 SyncSymbols 按 market_symbol 订阅
+func (gt *GateIoExClient) ExecuteSpotWs() {
+	if err := gt.GateIoWebSocketClient.Start(); err != nil {
+		log.Error("Failed to start gateio spot client", "err", err)
+	}
+}
+
+// ExecuteFeatureWs 启动USDT永续客户端, 交易对由 SyncSymbols 按 market_symbol 订阅
+func (gt *GateIoExClient) ExecuteFeatureWs() {
+	if err := gt.FeatureWebSocketClient.Start(); err != nil {
+		log.Error("Failed to start gateio feature client", "err", err)
+	}
+}
+
+// SyncSymbols 调整现货或永续的 ticker 订阅, 超过单连接订阅数时新建连接
+func (gt *GateIoExClient) SyncSymbols(instType string, symbols []string) error {
+	pool, channel := gt.spotPool, constants.ChannelSpotTickers
+	if instType == common.InstTypePerp {
+		pool, channel = gt.featurePool, constants.ChannelFuturesTickers
+	}
+
+	reqs := make([]model.SubscribeReq, 0, len(symbols))
+	for _, symbol := range symbols {
+		reqs = append(reqs, model.SubscribeReq{Channel: channel, Symbol: symbol})
+	}
+
+	added, removed, err := pool.Sync(reqs)
+	log.Info("gateio subscriptions synced", "instType", instType, "added", added, "removed", removed, "conns", pool.Conns())
+	return err
+}
+
+// Close 关闭连接池中新建的连接, 主连接由调用方关闭
+func (gt *GateIoExClient) Close() error {
+	return errors.Join(gt.spotPool.Close(), gt.featurePool.Close())
+}
+
+// newTickerPool 创建连接池, 第一个连接复用 primary, 其余连接按 wsUrl 新建
+func (gt *GateIoExClient) newTickerPool(primary *GateIoWebSocketClient, wsUrl string, pingChannel string, listener OnReceive) *ws.ShardPool[model.SubscribeReq] {
+	return ws.NewShardPool(ws.ShardPoolConfig{
+		MaxTopicsPerConn: constants.MaxTopicsPerConn,
+		BatchSize:        constants.SubscribeBatchSize,
+		BatchInterval:    constants.SubscribeIntervalMs * time.Millisecond,
+	}, func(index int) (ws.ShardConn[model.SubscribeReq], error) {
+		if index == 0 {
+			return &tickerConn{client: primary, listener: listener, primary: true}, nil
+		}
+
+		connConfig := *gt.config
+		connConfig.WsUrl = wsUrl
+		client := NewGateIoWebSocketClient(&connConfig, pingChannel, false)
+		if err := client.Start(); err != nil {
+			return nil, err
+		}
+		return &tickerConn{client: client, listener: listener}, nil
+	})
+}
+
+// tickerConn 连接池中的连接
+type tickerConn struct {
+	client   *GateIoWebSocketClient
+	listener OnReceive
+	primary  bool // 主连接由 GateIoExClient 的调用方关闭
+}
+
+func (c *tickerConn) SubscribeTopics(reqs []model.SubscribeReq) error {
+	return c.client.SubscribeList(reqs, c.listener)
+}
+
+func (c *tickerConn) UnsubscribeTopics(reqs []model.SubscribeReq) error {
+	return c.client.UnsubscribeList(reqs)
+}
+
+func (c *tickerConn) Close() error {
+	if c.primary {
+		return nil
+	}
+	return c.client.Stop()
+}
+
+// handlerSpot 处理现货ticker, result 为单个对象
+func (gt *GateIoExClient) handlerSpot(message string) {
+	var rsp model.WsBaseRsp
+	if err := json.Unmarshal([]byte(message), &rsp); err != nil {
+		log.Error("gateio spot ticker unmarshal failed", "err", err)
+		return
+	}
+
+	var ticker model.SpotTicker
+	if err := json.Unmarshal(rsp.Result, &ticker); err != nil {
+		log.Error("gateio spot ticker unmarshal failed", "err", err)
+		return
+	}
+
+	gt.spotPriceMap.Write(ticker.CurrencyPair, &maps.PriceData{
+		Symbol:    ticker.CurrencyPair,
+		Price:     common.DecimalFromString(ticker.Last),
+		Timestamp: strconv.FormatInt(rsp.TimeMs, 10),
+	})
+	gt.sink.Emit(&event.Ticker{
+		Header:      event.NewHeader(event.NewMarket(common.GateIo, common.InstTypeSpot, ticker.CurrencyPair), rsp.TimeMs),
+		Last:        common.DecimalFromString(ticker.Last),
+		High:        common.DecimalFromString(ticker.High24h),
+		Low:         common.DecimalFromString(ticker.Low24h),
+		Volume:      common.DecimalFromString(ticker.BaseVolume),
+		QuoteVolume: common.DecimalFromString(ticker.QuoteVolume),
+		Bid:         common.DecimalFromString(ticker.HighestBid),
+		Ask:         common.DecimalFromString(ticker.LowestAsk),
+	})
+}
+
+// handlerFeature 处理合约ticker, result 为数组
+func (gt *GateIoExClient) handlerFeature(message string) {
+	var rsp model.WsBaseRsp
+	if err := json.Unmarshal([]byte(message), &rsp); err != nil {
+		log.Error("gateio feature ticker unmarshal failed", "err", err)
+		return
+	}
+
+	var tickers []model.FuturesTicker
+	if err := json.Unmarshal(rsp.Result, &tickers); err != nil {
+		log.Error("gateio feature ticker unmarshal failed", "err", err)
+		return
+	}
+
+	ts := strconv.FormatInt(rsp.TimeMs, 10)
+	for _, ticker := range tickers {
+		gt.featurePriceMap.Write(ticker.Contract, &maps.PriceData{
+			Symbol:      ticker.Contract,
+			Price:       common.DecimalFromString(ticker.Last),
+			FundingRate: common.DecimalFromString(ticker.FundingRate),
+			MarkPrice:   common.DecimalFromString(ticker.MarkPrice),
+			Timestamp:   ts,
+		})
+
+		header := event.NewHeader(event.NewMarket(common.GateIo, common.InstTypePerp, ticker.Contract), rsp.TimeMs)
+		gt.sink.Emit(&event.Ticker{
+			Header:      header,
+			Last:        common.DecimalFromString(ticker.Last),
+			High:        common.DecimalFromString(ticker.High24h),
+			Low:         common.DecimalFromString(ticker.Low24h),
+			Volume:      common.DecimalFromString(ticker.Volume24hBase),
+			QuoteVolume: common.DecimalFromString(ticker.Volume24hQuote),
+		})
+		gt.sink.Emit(&event.MarkPrice{
+			Header:     header,
+			MarkPrice:  common.DecimalFromString(ticker.MarkPrice),
+			IndexPrice: common.DecimalFromString(ticker.IndexPrice),
+		})
+		gt.sink.Emit(&event.FundingRate{Header: header, Rate: common.DecimalFromString(ticker.FundingRate)})
+		gt.sink.Emit(&event.OpenInterest{Header: header, OpenInterest: common.DecimalFromString(ticker.TotalSize)})
+	}
+}
@@ -0,0 +1,42 @@
+package gateio
+
+import (
+	"testing"
+
+	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/exchange/cex/gateio/constants"
+	"github.com/339-Labs/exchange-market/exchange/cex/gateio/model"
+)
+
+func TestGateIoTickers(t *testing.T) {
+	spotPriceMap := maps.NewPriceMap(10)
+	featurePriceMap := maps.NewPriceMap(10)
+	client, _ := NewGateIoExClient(&config.CexExchangeConfig{}, spotPriceMap, featurePriceMap)
+
+	spot := client.GateIoWebSocketClient.MessageHandler
+	spot.AddSubscription(model.SubscribeReq{Channel: constants.ChannelSpotTickers, Symbol: "BTC_USDT"}, client.handlerSpot)
+	err := spot.HandleMessage(`{"time":1606291803,"time_ms":1606291803123,"channel":"spot.tickers","event":"update","result":{"currency_pair":"BTC_USDT","last":"19106.55"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := spotPriceMap.Read("BTC_USDT"); !ok || data.Price.String() != "19106.55" || data.Timestamp != "1606291803123" {
+		t.Fatalf("unexpected spot price %+v", data)
+	}
+
+	feature := client.FeatureWebSocketClient.MessageHandler
+	feature.AddSubscription(model.SubscribeReq{Channel: constants.ChannelFuturesTickers, Symbol: "BTC_USDT"}, client.handlerFeature)
+	err = feature.HandleMessage(`{"time":1541659086,"time_ms":1541659086001,"channel":"futures.tickers","event":"update","result":[{"contract":"BTC_USDT","last":"118.4","funding_rate":"-0.000233","mark_price":"118.35"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := featurePriceMap.Read("BTC_USDT"); !ok || data.MarkPrice.String() != "118.35" || data.FundingRate.String() != "-0.000233" {
+		t.Fatalf("unexpected feature price %+v", data)
+	}
+
+	// 频道下的交易对全部取消后移除监听器
+	feature.RemoveSubscription(model.SubscribeReq{Channel: constants.ChannelFuturesTickers, Symbol: "BTC_USDT"})
+	if _, ok := feature.ChannelMap[constants.ChannelFuturesTickers]; ok {
+		t.Fatal("expected channel listener removed")
+	}
+}
@@ -0,0 +1,333 @@
+package gateio
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common/ws"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/exchange/cex/gateio/constants"
+	"github.com/339-Labs/exchange-market/exchange/cex/gateio/model"
+	"github.com/ethereum/go-ethereum/log"
+	"sync"
+	"time"
+)
+
+// GateIoMessageHandler gateio消息处理器
+type GateIoMessageHandler struct {
+	// 配置和认证
+	Config      *config.CexExchangeConfig
+	LoginStatus bool
+	NeedLogin   bool
+
+	// 消息处理
+	Listener      OnReceive
+	ErrorListener OnReceive
+	ChannelMap    map[string]OnReceive // gateio 推送不区分交易对, 使用频道名称作为key
+	AllSubscribe  *ws.SubscriptionRegistry
+
+	// 同步
+	mu sync.RWMutex
+
+	// WebSocket客户端引用
+	wsClient *ws.GenericWebSocketClient
+}
+
+// OnReceive 消息接收回调函数类型
+type OnReceive func(message string)
+
+// NewGateIoMessageHandler 创建新的gateio消息处理器
+func NewGateIoMessageHandler(config *config.CexExchangeConfig, needLogin bool) *GateIoMessageHandler {
+	handler := &GateIoMessageHandler{
+		Config:      config,
+		NeedLogin:   needLogin,
+		LoginStatus: false,
+		ChannelMap:  make(map[string]OnReceive),
+	}
+	handler.AllSubscribe = ws.NewSubscriptionRegistry(handler, constants.SubscribeBatchSize, constants.SubscribeIntervalMs*time.Millisecond)
+
+	return handler
+}
+
+// SetWebSocketClient 设置WebSocket客户端引用
+func (h *GateIoMessageHandler) SetWebSocketClient(client *ws.GenericWebSocketClient) {
+	h.wsClient = client
+}
+
+// SetListeners 设置消息监听器
+func (h *GateIoMessageHandler) SetListeners(msgListener OnReceive, errorListener OnReceive) {
+	h.Listener = msgListener
+	h.ErrorListener = errorListener
+}
+
+// HandleMessage 处理普通消息
+func (h *GateIoMessageHandler) HandleMessage(message string) error {
+	var rsp model.WsBaseRsp
+	if err := json.Unmarshal([]byte(message), &rsp); err != nil {
+		return fmt.Errorf("unmarshal gateio message failed: %w", err)
+	}
+
+	// 检查是否有错误
+	if rsp.Error != nil {
+		return fmt.Errorf("received error code: %d, message: %s", rsp.Error.Code, rsp.Error.Message)
+	}
+
+	switch rsp.Event {
+	case constants.WsEventUpdate, constants.WsEventAll:
+		return h.handleDataMessage(message, rsp.Channel)
+	case constants.WsEventSubscribe, constants.WsEventUnsubscribe:
+		return h.handleSubscribeResponse(message)
+	}
+
+	// 处理其他消息
+	return h.handleOtherMessage(message)
+}
+
+// HandleError 处理错误消息
+func (h *GateIoMessageHandler) HandleError(message string) error {
+	log.Error("Received error message", "message", message)
+
+	if h.ErrorListener != nil {
+		h.ErrorListener(message)
+	}
+
+	return nil
+}
+
+// HandleSpecialMessage 处理特殊消息（如pong）
+func (h *GateIoMessageHandler) HandleSpecialMessage(message string) (handled bool, err error) {
+	var rsp model.WsBaseRsp
+	if json.Unmarshal([]byte(message), &rsp) != nil {
+		return false, nil
+	}
+
+	if rsp.Channel == constants.ChannelSpotPong || rsp.Channel == constants.ChannelFuturesPong {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// handleSubscribeResponse 处理订阅响应
+func (h *GateIoMessageHandler) handleSubscribeResponse(message string) error {
+	log.Info("Subscribe response", "message", message)
+
+	if h.Listener != nil {
+		h.Listener(message)
+	}
+
+	return nil
+}
+
+// handleDataMessage 处理数据消息
+func (h *GateIoMessageHandler) handleDataMessage(message string, channel string) error {
+	listener := h.getListener(channel)
+	if listener != nil {
+		listener(message)
+	}
+
+	return nil
+}
+
+// handleOtherMessage 处理其他消息
+func (h *GateIoMessageHandler) handleOtherMessage(message string) error {
+	log.Info("Received other message", "message", message)
+
+	if h.Listener != nil {
+		h.Listener(message)
+	}
+
+	return nil
+}
+
+// getListener 获取特定频道的监听器
+func (h *GateIoMessageHandler) getListener(channel string) OnReceive {
+	h.mu.RLock()
+	listener, exists := h.ChannelMap[channel]
+	h.mu.RUnlock()
+
+	if !exists {
+		return h.Listener
+	}
+
+	return listener
+}
+
+// setLoginStatus 设置登录状态
+func (h *GateIoMessageHandler) setLoginStatus(status bool) {
+	h.mu.Lock()
+	h.LoginStatus = status
+	h.mu.Unlock()
+}
+
+// RequireLogin 是否需要登录
+func (h *GateIoMessageHandler) RequireLogin() bool {
+	return h.NeedLogin
+}
+
+// Login 登录（gateio 公共频道不需要登录，但为了兼容性保留）
+func (h *GateIoMessageHandler) Login() error {
+	h.setLoginStatus(true)
+	return nil
+}
+
+// IsLoggedIn 检查是否已登录
+func (h *GateIoMessageHandler) IsLoggedIn() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.LoginStatus
+}
+
+// SendSubscribe 批量发送订阅请求，用于重连后重放订阅
+func (h *GateIoMessageHandler) SendSubscribe(topics []interface{}) error {
+	if h.wsClient == nil {
+		return fmt.Errorf("WebSocket client is not set")
+	}
+
+	reqs := make([]model.SubscribeReq, 0, len(topics))
+	for _, topic := range topics {
+		if req, ok := topic.(model.SubscribeReq); ok {
+			reqs = append(reqs, req)
+		}
+	}
+
+	return sendEvent(h.wsClient, constants.WsEventSubscribe, reqs)
+}
+
+// AddSubscription 添加订阅
+func (h *GateIoMessageHandler) AddSubscription(req model.SubscribeReq, listener OnReceive) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.ChannelMap[req.Channel] = listener
+	h.AllSubscribe.Add(req)
+}
+
+// RemoveSubscription 移除订阅, 频道下没有其他交易对时移除监听器
+func (h *GateIoMessageHandler) RemoveSubscription(req model.SubscribeReq) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.AllSubscribe.Remove(req)
+	for _, topic := range h.AllSubscribe.List() {
+		if sub, ok := topic.(model.SubscribeReq); ok && sub.Channel == req.Channel {
+			return
+		}
+	}
+	delete(h.ChannelMap, req.Channel)
+}
+
+// GateIoWebSocketClient gateio WebSocket客户端
+type GateIoWebSocketClient struct {
+	*ws.GenericWebSocketClient
+	MessageHandler *GateIoMessageHandler
+}
+
+// NewGateIoWebSocketClient 创建新的gateio WebSocket客户端, pingChannel 为 spot.ping 或 futures.ping
+func NewGateIoWebSocketClient(config *config.CexExchangeConfig, pingChannel string, needLogin bool) *GateIoWebSocketClient {
+	// 创建WebSocket配置
+	wsConfig := &ws.ConnectionConfig{
+		WsUrl:               config.WsUrl,
+		PingInterval:        15 * time.Second,
+		ReconnectWaitSecond: float64(constants.ReconnectWaitSecond),
+		TimerIntervalSecond: constants.TimerIntervalSecond * time.Second,
+		EnableAutoReconnect: true,
+		EnablePing:          true,
+		PingMsg:             fmt.Sprintf(`{"channel":"%s"}`, pingChannel),
+	}
+
+	// 创建通用WebSocket客户端
+	genericClient := ws.NewGenericWebSocketClient(wsConfig)
+
+	// 创建gateio消息处理器
+	messageHandler := NewGateIoMessageHandler(config, needLogin)
+	messageHandler.SetWebSocketClient(genericClient)
+
+	// 设置消息处理器和订阅注册表
+	genericClient.SetMessageHandler(messageHandler)
+	genericClient.SetSubscriptionRegistry(messageHandler.AllSubscribe)
+
+	// 设置回调函数
+	genericClient.SetCallbacks(
+		func() {
+			log.Info("GateIo WebSocket connected", "url", config.WsUrl)
+		},
+		func() {
+			log.Info("GateIo WebSocket disconnected", "url", config.WsUrl)
+			messageHandler.setLoginStatus(false)
+		},
+		func(attempt int) {
+			log.Info("GateIo WebSocket reconnecting", "url", config.WsUrl, "attempt", attempt)
+		},
+	)
+
+	return &GateIoWebSocketClient{
+		GenericWebSocketClient: genericClient,
+		MessageHandler:         messageHandler,
+	}
+}
+
+// Subscribe 订阅
+func (c *GateIoWebSocketClient) Subscribe(req model.SubscribeReq, listener OnReceive) error {
+	return c.SubscribeList([]model.SubscribeReq{req}, listener)
+}
+
+// SubscribeList 订阅列表
+func (c *GateIoWebSocketClient) SubscribeList(reqs []model.SubscribeReq, listener OnReceive) error {
+	for _, req := range reqs {
+		// 添加到订阅映射
+		c.MessageHandler.AddSubscription(req, listener)
+	}
+
+	return sendEvent(c.GenericWebSocketClient, constants.WsEventSubscribe, reqs)
+}
+
+// Unsubscribe 取消订阅
+func (c *GateIoWebSocketClient) Unsubscribe(req model.SubscribeReq) error {
+	return c.UnsubscribeList([]model.SubscribeReq{req})
+}
+
+// UnsubscribeList 取消订阅
+func (c *GateIoWebSocketClient) UnsubscribeList(reqs []model.SubscribeReq) error {
+	for _, req := range reqs {
+		// 从订阅映射中移除
+		c.MessageHandler.RemoveSubscription(req)
+	}
+
+	return sendEvent(c.GenericWebSocketClient, constants.WsEventUnsubscribe, reqs)
+}
+
+// SetListeners 设置监听器
+func (c *GateIoWebSocketClient) SetListeners(msgListener OnReceive, errorListener OnReceive) {
+	c.MessageHandler.SetListeners(msgListener, errorListener)
+}
+
+// IsLoggedIn 检查是否已登录
+func (c *GateIoWebSocketClient) IsLoggedIn() bool {
+	return c.MessageHandler.IsLoggedIn()
+}
+
+// sendEvent 按频道合并交易对后发送请求, 每个频道一条请求
+func sendEvent(client *ws.GenericWebSocketClient, event string, reqs []model.SubscribeReq) error {
+	channels := make([]string, 0)
+	payloads := make(map[string][]string)
+	for _, req := range reqs {
+		if _, ok := payloads[req.Channel]; !ok {
+			channels = append(channels, req.Channel)
+		}
+		payloads[req.Channel] = append(payloads[req.Channel], req.Symbol)
+	}
+
+	for _, channel := range channels {
+		baseReq := model.WsBaseReq{
+			Time:    time.Now().Unix(),
+			Channel: channel,
+			Event:   event,
+			Payload: payloads[channel],
+		}
+		if err := client.SendJSON(baseReq); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
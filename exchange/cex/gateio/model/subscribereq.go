@@ -0,0 +1,7 @@
+package model
+
+// SubscribeReq 单个订阅, gateio 同一频道的多个交易对合并在一个请求的 payload 中发送
+type SubscribeReq struct {
+	Channel string `json:"channel"`
+	Symbol  string `json:"symbol"`
+}
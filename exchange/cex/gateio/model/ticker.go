@@ -0,0 +1,30 @@
+package model
+
+// SpotTicker 现货ticker spot.tickers
+type SpotTicker struct {
+	CurrencyPair     string `json:"currency_pair"`
+	Last             string `json:"last"`
+	LowestAsk        string `json:"lowest_ask"`
+	HighestBid       string `json:"highest_bid"`
+	ChangePercentage string `json:"change_percentage"`
+	BaseVolume       string `json:"base_volume"`
+	QuoteVolume      string `json:"quote_volume"`
+	High24h          string `json:"high_24h"`
+	Low24h           string `json:"low_24h"`
+}
+
+// FuturesTicker 合约ticker futures.tickers
+type FuturesTicker struct {
+	Contract         string `json:"contract"`
+	Last             string `json:"last"`
+	ChangePercentage string `json:"change_percentage"`
+	FundingRate      string `json:"funding_rate"`
+	MarkPrice        string `json:"mark_price"`
+	IndexPrice       string `json:"index_price"`
+	TotalSize        string `json:"total_size"`
+	Volume24h        string `json:"volume_24h"`
+	Volume24hBase    string `json:"volume_24h_base"`
+	Volume24hQuote   string `json:"volume_24h_quote"`
+	High24h          string `json:"high_24h"`
+	Low24h           string `json:"low_24h"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import "encoding/json"
+
+// WsBaseReq 请求
+type WsBaseReq struct {
+	Time    int64    `json:"time"`
+	Channel string   `json:"channel"`
+	Event   string   `json:"event"`
+	Payload []string `json:"payload"`
+}
+
+// WsError 错误信息
+type WsError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// WsBaseRsp 推送和响应
+type WsBaseRsp struct {
+	Time    int64           `json:"time"`
+	TimeMs  int64           `json:"time_ms"`
+	Channel string          `json:"channel"`
+	Event   string          `json:"event"`
+	Error   *WsError        `json:"error"`
+	Result  json.RawMessage `json:"result"`
+}
@@ -31,6 +31,29 @@ const (
 	WsOpSubscribe       = "subscribe"
 	TimerIntervalSecond = 5
 	ReconnectWaitSecond = 60
+	SubscribeBatchSize  = 50  // 单次订阅请求的最大频道数量
+	SubscribeIntervalMs = 350 // 订阅请求间隔
+	MaxTopicsPerConn    = 200 // 单连接的最大订阅数, 超出后新建连接
+
+	/*
+	 * market data
+	 */
+	ChannelTickers     = "tickers"
+	ChannelMarkPrice   = "mark-price"
+	ChannelFundingRate = "funding-rate"
+	InstTypeSpot       = "SPOT"
+	InstTypeSwap       = "SWAP"
+	SwapSuffix         = "-SWAP" // 永续合约 instId 后缀, 如 BTC-USDT-SWAP
+
+	/*
+	 * order book
+	 */
+	ChannelBooks   = "books"    // 400档深度, 首次全量后增量推送
+	ChannelBooks5  = "books5"   // 5档深度, 每次全量推送
+	ChannelBboTbt  = "bbo-tbt"  // 1档深度, 每次全量推送
+	ActionSnapshot = "snapshot" // 全量
+	ActionUpdate   = "update"   // 增量
+	ChecksumDepth  = 25         // 校验和档位
 
 	/*
 	 * SignType
@@ -0,0 +1,18 @@
+package model
+
+// BookData 深度数据 books / books5 / bbo-tbt
+type BookData struct {
+	Asks      [][]string `json:"asks"`      // 卖盘 [价格, 数量, 废弃字段, 订单数]
+	Bids      [][]string `json:"bids"`      // 买盘
+	Ts        string     `json:"ts"`        // 数据更新时间
+	Checksum  int32      `json:"checksum"`  // 前25档校验和, 仅 books 频道
+	SeqId     int64      `json:"seqId"`     // 推送序号
+	PrevSeqId int64      `json:"prevSeqId"` // 上一个推送序号, 快照为 -1
+}
+
+// BookMsg 深度推送
+type BookMsg struct {
+	Arg    SubscribeReq `json:"arg"`
+	Action string       `json:"action"` // snapshot 全量, update 增量, 仅 books 频道
+	Data   []BookData   `json:"data"`
+}
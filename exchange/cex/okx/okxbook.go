@@ -0,0 +1,170 @@
+package okx
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
+	"github.com/339-Labs/exchange-market/exchange/cex/okx/constants"
+	"github.com/339-Labs/exchange-market/exchange/cex/okx/model"
+	"github.com/ethereum/go-ethereum/log"
+	"strconv"
+	"strings"
+)
+
+// SubscribeBooks 订阅深度频道并维护本地订单簿, channel 为 books / books5 / bbo-tbt
+// 订单簿按 instId 存放在 Books 中, 同一个产品只能订阅一个深度频道, 已订阅其他频道时返回错误
+func (c *OkxWebSocketClient) SubscribeBooks(channel string, instIds []string) error {
+	c.bookMu.Lock()
+	for _, instId := range instIds {
+		if subscribed, ok := c.bookChannels[instId]; ok && subscribed != channel {
+			c.bookMu.Unlock()
+			return fmt.Errorf("books of %s already subscribed with channel %s", instId, subscribed)
+		}
+	}
+	for _, instId := range instIds {
+		c.bookChannels[instId] = channel
+	}
+	c.bookMu.Unlock()
+
+	reqs := make([]model.SubscribeReq, 0, len(instIds))
+	for _, instId := range instIds {
+		req := model.SubscribeReq{
+			Channel: channel,
+			InstId:  instId,
+		}
+		c.setBookPending(req, true)
+		reqs = append(reqs, req)
+	}
+
+	if len(reqs) == 0 {
+		return nil
+	}
+
+	return c.SubscribeList(reqs, c.handlerBooks)
+}
+
+// handlerBooks 处理深度推送
+func (c *OkxWebSocketClient) handlerBooks(message string) {
+	var msg model.BookMsg
+	if err := json.Unmarshal([]byte(message), &msg); err != nil {
+		log.Error("okx books unmarshal failed", "err", err)
+		return
+	}
+
+	for _, data := range msg.Data {
+		if err := c.applyBook(msg.Arg, msg.Action, &data); err != nil {
+			log.Warn("okx order book corrupted, resubscribe", "channel", msg.Arg.Channel, "instId", msg.Arg.InstId, "err", err)
+			c.resubscribeBook(msg.Arg)
+			return
+		}
+	}
+}
+
+// SetSink 设置订单簿统一行情事件的接收方
+func (c *OkxWebSocketClient) SetSink(sink event.Sink) {
+	c.sink = event.OrDiscard(sink)
+}
+
+// emitBook 发送订单簿事件, bbo-tbt 频道发送最优买卖价
+func (c *OkxWebSocketClient) emitBook(req model.SubscribeReq, action string, data *model.BookData) {
+	instType := common.InstTypeSpot
+	if strings.HasSuffix(req.InstId, constants.SwapSuffix) {
+		instType = common.InstTypePerp
+	}
+	ts, _ := strconv.ParseInt(data.Ts, 10, 64)
+	header := event.NewHeader(event.NewMarket(common.Okx, instType, req.InstId), ts)
+	bids, asks := event.Levels(data.Bids), event.Levels(data.Asks)
+
+	if req.Channel == constants.ChannelBboTbt {
+		if len(bids) == 0 || len(asks) == 0 {
+			return
+		}
+		c.sink.Emit(&event.BBO{Header: header, Bid: bids[0].Price, BidSize: bids[0].Size, Ask: asks[0].Price, AskSize: asks[0].Size})
+		return
+	}
+
+	c.sink.Emit(&event.BookUpdate{
+		Header:        header,
+		Snapshot:      req.Channel != constants.ChannelBooks || action == constants.ActionSnapshot,
+		FinalUpdateId: data.SeqId,
+		Bids:          bids,
+		Asks:          asks,
+	})
+}
+
+// applyBook 应用一条深度数据
+func (c *OkxWebSocketClient) applyBook(req model.SubscribeReq, action string, data *model.BookData) error {
+	book := c.Books.GetOrCreate(req.InstId)
+	ts, _ := strconv.ParseInt(data.Ts, 10, 64)
+
+	switch req.Channel {
+	case constants.ChannelBooks:
+		if action == constants.ActionSnapshot {
+			if err := book.Load(data.Bids, data.Asks, data.SeqId, ts); err != nil {
+				return err
+			}
+			c.setBookPending(req, false)
+		} else {
+			// 等待重新订阅后的全量快照
+			if c.isBookPending(req) {
+				return nil
+			}
+			if data.PrevSeqId != book.GetLastUpdateId() {
+				return fmt.Errorf("seqId gap: expect prevSeqId %d, got %d", book.GetLastUpdateId(), data.PrevSeqId)
+			}
+			if err := book.Apply(data.Bids, data.Asks, data.SeqId, ts); err != nil {
+				return err
+			}
+		}
+
+		if checksum := book.Checksum(constants.ChecksumDepth); checksum != data.Checksum {
+			return fmt.Errorf("checksum mismatch: local %d, remote %d", checksum, data.Checksum)
+		}
+
+	case constants.ChannelBooks5, constants.ChannelBboTbt:
+		// 每次推送都是全量
+		if err := book.Load(data.Bids, data.Asks, data.SeqId, ts); err != nil {
+			return err
+		}
+		c.setBookPending(req, false)
+
+	default:
+		return fmt.Errorf("unsupported books channel %s", req.Channel)
+	}
+
+	c.emitBook(req, action, data)
+	return nil
+}
+
+// resubscribeBook 订单簿损坏时取消订阅并重新订阅, 以获取新的全量快照
+func (c *OkxWebSocketClient) resubscribeBook(req model.SubscribeReq) {
+	c.setBookPending(req, true)
+	if book, ok := c.Books.Get(req.InstId); ok {
+		book.Clear()
+	}
+
+	if err := c.Unsubscribe(req); err != nil {
+		log.Error("okx books unsubscribe failed", "instId", req.InstId, "err", err)
+	}
+	if err := c.Subscribe(req, c.handlerBooks); err != nil {
+		log.Error("okx books resubscribe failed", "instId", req.InstId, "err", err)
+	}
+}
+
+func (c *OkxWebSocketClient) setBookPending(req model.SubscribeReq, pending bool) {
+	c.bookMu.Lock()
+	defer c.bookMu.Unlock()
+
+	if pending {
+		c.pendingBooks[req] = true
+	} else {
+		delete(c.pendingBooks, req)
+	}
+}
+
+func (c *OkxWebSocketClient) isBookPending(req model.SubscribeReq) bool {
+	c.bookMu.Lock()
+	defer c.bookMu.Unlock()
+	return c.pendingBooks[req]
+}
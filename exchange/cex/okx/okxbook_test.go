@@ -0,0 +1,119 @@
+package okx
+
+import (
+	"hash/crc32"
+	"testing"
+
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/exchange/cex/okx/constants"
+	"github.com/339-Labs/exchange-market/exchange/cex/okx/model"
+)
+
+func checksum(value string) int32 {
+	return int32(crc32.ChecksumIEEE([]byte(value)))
+}
+
+func TestApplyBook(t *testing.T) {
+	client := NewOkxWebSocketClient(&config.CexExchangeConfig{}, false)
+	req := model.SubscribeReq{Channel: constants.ChannelBooks, InstId: "BTC-USDT"}
+	client.setBookPending(req, true)
+
+	snapshot := &model.BookData{
+		Bids:      [][]string{{"100", "1", "0", "1"}},
+		Asks:      [][]string{{"101", "2", "0", "1"}},
+		Checksum:  checksum("100:1:101:2"),
+		SeqId:     10,
+		PrevSeqId: -1,
+	}
+	if err := client.applyBook(req, constants.ActionSnapshot, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if client.isBookPending(req) {
+		t.Fatal("snapshot should clear pending")
+	}
+
+	update := &model.BookData{
+		Bids:      [][]string{{"100", "0", "0", "0"}, {"99.5", "3", "0", "1"}},
+		Checksum:  checksum("99.5:3:101:2"),
+		SeqId:     12,
+		PrevSeqId: 10,
+	}
+	if err := client.applyBook(req, constants.ActionUpdate, update); err != nil {
+		t.Fatal(err)
+	}
+	book, _ := client.Books.Get("BTC-USDT")
+	if book.GetLastUpdateId() != 12 {
+		t.Fatalf("last seqId = %d, want 12", book.GetLastUpdateId())
+	}
+
+	// 序号断档
+	gap := &model.BookData{Bids: [][]string{{"99", "1", "0", "1"}}, SeqId: 15, PrevSeqId: 13}
+	if err := client.applyBook(req, constants.ActionUpdate, gap); err == nil {
+		t.Fatal("expected seqId gap error")
+	}
+
+	// 校验和不一致
+	mismatch := &model.BookData{Asks: [][]string{{"101", "1", "0", "1"}}, Checksum: checksum("99.5:3:101:2"), SeqId: 13, PrevSeqId: 12}
+	if err := client.applyBook(req, constants.ActionUpdate, mismatch); err == nil {
+		t.Fatal("expected checksum mismatch")
+	}
+}
+
+func TestHandlerBooks_Resubscribe(t *testing.T) {
+	client := NewOkxWebSocketClient(&config.CexExchangeConfig{}, false)
+	req := model.SubscribeReq{Channel: constants.ChannelBooks, InstId: "BTC-USDT"}
+	snapshot := &model.BookData{
+		Bids:     [][]string{{"100", "1", "0", "1"}},
+		Asks:     [][]string{{"101", "2", "0", "1"}},
+		Checksum: checksum("100:1:101:2"),
+		SeqId:    10,
+	}
+	if err := client.applyBook(req, constants.ActionSnapshot, snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	// 断档后清空订单簿并等待新的全量, 未连接时重新订阅只记录错误
+	client.handlerBooks(`{"arg":{"channel":"books","instId":"BTC-USDT"},"action":"update","data":[{"bids":[["99","1","0","1"]],"asks":[],"ts":"1","checksum":0,"seqId":15,"prevSeqId":13}]}`)
+	if !client.isBookPending(req) {
+		t.Fatal("gap should mark the book pending")
+	}
+	book, _ := client.Books.Get("BTC-USDT")
+	if bids, asks := book.Depth(); bids != 0 || asks != 0 {
+		t.Fatalf("book should be cleared, got %d bids %d asks", bids, asks)
+	}
+	if _, ok := client.MessageHandler.ScribeMap[req]; !ok {
+		t.Fatal("books should be subscribed again")
+	}
+
+	// 等待全量期间忽略增量
+	update := &model.BookData{Bids: [][]string{{"98", "1", "0", "1"}}, SeqId: 16, PrevSeqId: 15}
+	if err := client.applyBook(req, constants.ActionUpdate, update); err != nil {
+		t.Fatal(err)
+	}
+	if bids, _ := book.Depth(); bids != 0 {
+		t.Fatalf("update applied while pending, got %d bids", bids)
+	}
+
+	if err := client.applyBook(req, constants.ActionSnapshot, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if client.isBookPending(req) {
+		t.Fatal("snapshot should clear pending")
+	}
+}
+
+func TestSubscribeBooks_SecondChannel(t *testing.T) {
+	client := NewOkxWebSocketClient(&config.CexExchangeConfig{}, false)
+	// 未连接时发送失败, 但频道已记录
+	_ = client.SubscribeBooks(constants.ChannelBooks, []string{"BTC-USDT"})
+
+	if err := client.SubscribeBooks(constants.ChannelBooks5, []string{"ETH-USDT", "BTC-USDT"}); err == nil {
+		t.Fatal("expected error for a second books channel")
+	}
+	if client.isBookPending(model.SubscribeReq{Channel: constants.ChannelBooks5, InstId: "ETH-USDT"}) {
+		t.Fatal("rejected request should not subscribe any instId")
+	}
+	if _, ok := client.MessageHandler.ScribeMap[model.SubscribeReq{Channel: constants.ChannelBooks5, InstId: "BTC-USDT"}]; ok {
+		t.Fatal("books5 should not be subscribed")
+	}
+}
@@ -3,10 +3,15 @@ package okx
 import (
 	"fmt"
 	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
 	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/common/ws"
 	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/exchange/cex/okx/constants"
 	"github.com/339-Labs/exchange-market/exchange/cex/okx/model"
 	"github.com/ethereum/go-ethereum/log"
+	"strconv"
+	"sync"
 	"time"
 )
 
@@ -17,23 +22,44 @@ type OkxExClient struct {
 	featurePriceMap    *maps.PriceMap
 	markPriceMap       *maps.PriceMap
 	rateMap            *maps.PriceMap
+	sink               event.Sink // 统一行情事件
+
+	// 行情订阅, 按单连接订阅数分片
+	tickerPool *ws.ShardPool[model.SubscribeReq]
+	universe   map[string][]model.SubscribeReq // spot/perp -> 订阅
+	universeMu sync.Mutex
 }
 
 func NewOkxExClient(config *config.CexExchangeConfig, spotPriceMap *maps.PriceMap, featurePriceMap *maps.PriceMap, markPriceMap *maps.PriceMap, rateMap *maps.PriceMap) (*OkxExClient, error) {
 	// 创建okx WebSocket客户端
 	client := NewOkxWebSocketClient(config, false) // true表示需要登录
 
-	return &OkxExClient{
+	okx := &OkxExClient{
 		OkxWebSocketClient: client,
 		config:             config,
 		spotPriceMap:       spotPriceMap,
 		featurePriceMap:    featurePriceMap,
 		markPriceMap:       markPriceMap,
 		rateMap:            rateMap,
-	}, nil
+		universe:           make(map[string][]model.SubscribeReq),
+		sink:               event.Discard,
+	}
+	okx.tickerPool = ws.NewShardPool(ws.ShardPoolConfig{
+		MaxTopicsPerConn: constants.MaxTopicsPerConn,
+		BatchSize:        constants.SubscribeBatchSize,
+		BatchInterval:    constants.SubscribeIntervalMs * time.Millisecond,
+	}, okx.newTickerConn)
+	return okx, nil
+}
+
+// SetSink 设置统一行情事件的接收方, 同时用于订单簿推送, 需在 ExecuteWs 之前调用
+func (okx *OkxExClient) SetSink(sink event.Sink) {
+	okx.sink = event.OrDiscard(sink)
+	okx.OkxWebSocketClient.SetSink(sink)
 }
 
-func (okx *OkxExClient) ExecuteSpotWs() {
+// ExecuteWs 启动客户端, 交易对由 SyncSymbols 按 market_symbol 订阅
+func (okx *OkxExClient) ExecuteWs() {
 
 	// 设置全局消息监听器
 	okx.OkxWebSocketClient.SetListeners(
@@ -47,143 +73,111 @@ func (okx *OkxExClient) ExecuteSpotWs() {
 
 	// 启动客户端
 	if err := okx.OkxWebSocketClient.Start(); err != nil {
-		log.Info("启动客户端失败:", err)
+		log.Error("Failed to start okx client", "err", err)
 	}
 
 	// 等待登录完成
 	time.Sleep(2 * time.Second)
+}
 
-	var reqs []model.SubscribeReq
-
-	// InstId 来区别现货还是合约 BTC-USDT 和 BTC-USD-SWAP
-	// todo spotSymbols get feature from db
-	var spotSymbols []string
-	spotSymbols = append(spotSymbols, "BTC-USDT")
-	spotSymbols = append(spotSymbols, "ETH-USDT")
-	for _, symbol := range spotSymbols {
-		// 订阅特定合约的数据流
-		subscribeReq := model.SubscribeReq{
-			Channel: "tickers",
-			InstId:  symbol,
+// SyncSymbols 调整现货或永续的订阅, 永续同时订阅标记价格和资金费率; 超过单连接订阅数时新建连接
+func (okx *OkxExClient) SyncSymbols(instType string, symbols []string) error {
+	reqs := make([]model.SubscribeReq, 0, len(symbols))
+	for _, symbol := range symbols {
+		// InstId 来区别现货还是合约 BTC-USDT 和 BTC-USD-SWAP
+		reqs = append(reqs, model.SubscribeReq{Channel: constants.ChannelTickers, InstId: symbol})
+		if instType == common.InstTypePerp {
+			reqs = append(reqs, model.SubscribeReq{Channel: constants.ChannelMarkPrice, InstId: symbol})
+			reqs = append(reqs, model.SubscribeReq{Channel: constants.ChannelFundingRate, InstId: symbol})
 		}
-		reqs = append(reqs, subscribeReq)
 	}
 
-	err := okx.OkxWebSocketClient.SubscribeList(reqs, func(message string) {
-		fmt.Printf("订阅价格更新，处理价格: %s\n", message)
-
-		jsonMap := common.JSONToMap(message)
-		if arg, exists := jsonMap["arg"].(map[string]interface{}); exists {
-
-			channel, _ := arg["channel"].(string)
-
-			dataList, _ := jsonMap["data"].([]interface{})
-			data := dataList[0].(map[string]interface{})
-			instType := data["instType"].(string)
-
-			switch channel {
-
-			case "tickers":
-				if instType == "SWAP" {
-					okx.handlerFeature(data)
-				} else if instType == "SPOT" {
-					okx.handlerSpot(data)
-				}
-			case "funding-rate":
-				if instType == "SWAP" {
-					okx.handlerFeatureRate(data)
-				}
-			case "mark-price":
-				if instType == "SWAP" {
-					okx.handlerFeatureMark(data)
-				}
-			}
-		}
+	okx.universeMu.Lock()
+	defer okx.universeMu.Unlock()
 
-	})
-
-	if err != nil {
-		log.Error("订阅失败: %v", err)
+	okx.universe[instType] = reqs
+	desired := make([]model.SubscribeReq, 0)
+	for _, key := range []string{common.InstTypeSpot, common.InstTypePerp} {
+		desired = append(desired, okx.universe[key]...)
 	}
-	log.Info("执行")
 
+	added, removed, err := okx.tickerPool.Sync(desired)
+	log.Info("okx subscriptions synced", "instType", instType, "added", added, "removed", removed, "conns", okx.tickerPool.Conns())
+	return err
 }
 
-func (okx *OkxExClient) ExecuteFeatureWs() {
-
-	// 设置全局消息监听器
-	okx.OkxWebSocketClient.SetListeners(
-		func(message string) {
-			fmt.Printf("收到消息: %s\n", message)
-		},
-		func(message string) {
-			fmt.Printf("收到错误: %s\n", message)
-		},
-	)
+// Close 关闭连接池中新建的连接, 主连接由调用方关闭
+func (okx *OkxExClient) Close() error {
+	return okx.tickerPool.Close()
+}
 
-	// 启动客户端
-	if err := okx.OkxWebSocketClient.Start(); err != nil {
-		log.Info("启动客户端失败:", err)
+// newTickerConn 连接池的第一个连接复用主连接, 其余连接新建
+func (okx *OkxExClient) newTickerConn(index int) (ws.ShardConn[model.SubscribeReq], error) {
+	if index == 0 {
+		return &tickerConn{client: okx.OkxWebSocketClient, listener: okx.handlerTickers, primary: true}, nil
 	}
 
-	// 等待登录完成
-	time.Sleep(2 * time.Second)
+	client := NewOkxWebSocketClient(okx.config, false)
+	if err := client.Start(); err != nil {
+		return nil, err
+	}
+	return &tickerConn{client: client, listener: okx.handlerTickers}, nil
+}
 
-	var reqs []model.SubscribeReq
+// handlerTickers 处理 tickers、mark-price、funding-rate 推送
+func (okx *OkxExClient) handlerTickers(message string) {
+	jsonMap := common.JSONToMap(message)
+	if arg, exists := jsonMap["arg"].(map[string]interface{}); exists {
 
-	// InstId 来区别现货还是合约 BTC-USDT 和 BTC-USD-SWAP
+		channel, _ := arg["channel"].(string)
 
-	// todo featureSymbols get feature from db
-	var featureSymbols []string
-	featureSymbols = append(featureSymbols, "BTC-USDT-SWAP")
-	featureSymbols = append(featureSymbols, "ETH-USDT-SWAP")
-	for _, symbol := range featureSymbols {
-		// 订阅特定合约的数据流
-		subscribeReq := model.SubscribeReq{
-			Channel: "tickers",
-			InstId:  symbol,
+		dataList, _ := jsonMap["data"].([]interface{})
+		if len(dataList) == 0 {
+			return
 		}
-		reqs = append(reqs, subscribeReq)
-	}
+		data, _ := dataList[0].(map[string]interface{})
+		instType, _ := data["instType"].(string)
+
+		switch channel {
 
-	err := okx.OkxWebSocketClient.SubscribeList(reqs, func(message string) {
-		fmt.Printf("订阅价格更新，处理价格: %s\n", message)
-
-		jsonMap := common.JSONToMap(message)
-		if arg, exists := jsonMap["arg"].(map[string]interface{}); exists {
-
-			channel, _ := arg["channel"].(string)
-
-			dataList, _ := jsonMap["data"].([]interface{})
-			data := dataList[0].(map[string]interface{})
-			instType := data["instType"].(string)
-
-			switch channel {
-
-			case "tickers":
-				if instType == "SWAP" {
-					okx.handlerFeature(data)
-				} else if instType == "SPOT" {
-					okx.handlerSpot(data)
-				}
-			case "funding-rate":
-				if instType == "SWAP" {
-					okx.handlerFeatureRate(data)
-				}
-			case "mark-price":
-				if instType == "SWAP" {
-					okx.handlerFeatureMark(data)
-				}
+		case constants.ChannelTickers:
+			if instType == constants.InstTypeSwap {
+				okx.handlerFeature(data)
+			} else if instType == constants.InstTypeSpot {
+				okx.handlerSpot(data)
+			}
+		case constants.ChannelFundingRate:
+			if instType == constants.InstTypeSwap {
+				okx.handlerFeatureRate(data)
+			}
+		case constants.ChannelMarkPrice:
+			if instType == constants.InstTypeSwap {
+				okx.handlerFeatureMark(data)
 			}
 		}
+	}
+}
 
-	})
+// tickerConn 连接池中的连接
+type tickerConn struct {
+	client   *OkxWebSocketClient
+	listener OnReceive
+	primary  bool // 主连接由 OkxExClient 的调用方关闭
+}
 
-	if err != nil {
-		log.Error("订阅失败: %v", err)
-	}
-	log.Info("执行")
+func (c *tickerConn) SubscribeTopics(reqs []model.SubscribeReq) error {
+	return c.client.SubscribeList(reqs, c.listener)
+}
 
+func (c *tickerConn) UnsubscribeTopics(reqs []model.SubscribeReq) error {
+	return c.client.UnsubscribeList(reqs)
+}
+
+func (c *tickerConn) Close() error {
+	if c.primary {
+		return nil
+	}
+	return c.client.Stop()
 }
 
 func (okx *OkxExClient) handlerSpot(spot map[string]interface{}) {
@@ -191,9 +185,10 @@ func (okx *OkxExClient) handlerSpot(spot map[string]interface{}) {
 
 	okx.spotPriceMap.Write(spot["instId"].(string), &maps.PriceData{
 		Symbol:    spot["instId"].(string),
-		Price:     spot["last"].(string),
+		Price:     common.DecimalFromString(stringValue(spot, "last")),
 		Timestamp: spot["ts"].(string),
 	})
+	okx.emitTicker(common.InstTypeSpot, spot)
 
 }
 
@@ -202,9 +197,10 @@ func (okx *OkxExClient) handlerFeature(feature map[string]interface{}) {
 
 	okx.featurePriceMap.Write(feature["instId"].(string), &maps.PriceData{
 		Symbol:    feature["instId"].(string),
-		Price:     feature["last"].(string),
+		Price:     common.DecimalFromString(stringValue(feature, "last")),
 		Timestamp: feature["ts"].(string),
 	})
+	okx.emitTicker(common.InstTypePerp, feature)
 
 }
 
@@ -213,9 +209,13 @@ func (okx *OkxExClient) handlerFeatureMark(feature map[string]interface{}) {
 
 	okx.markPriceMap.Write(feature["instId"].(string), &maps.PriceData{
 		Symbol:    feature["instId"].(string),
-		MarkPrice: feature["markPx"].(string),
+		MarkPrice: common.DecimalFromString(stringValue(feature, "markPx")),
 		Timestamp: feature["ts"].(string),
 	})
+	okx.sink.Emit(&event.MarkPrice{
+		Header:    event.NewHeader(event.NewMarket(common.Okx, common.InstTypePerp, stringValue(feature, "instId")), int64Value(feature, "ts")),
+		MarkPrice: common.DecimalFromString(stringValue(feature, "markPx")),
+	})
 }
 
 func (okx *OkxExClient) handlerFeatureRate(feature map[string]interface{}) {
@@ -223,7 +223,48 @@ func (okx *OkxExClient) handlerFeatureRate(feature map[string]interface{}) {
 
 	okx.rateMap.Write(feature["instId"].(string), &maps.PriceData{
 		Symbol:      feature["instId"].(string),
-		FundingRate: feature["fundingRate"].(string),
+		FundingRate: common.DecimalFromString(stringValue(feature, "fundingRate")),
 		Timestamp:   feature["ts"].(string),
 	})
+	okx.sink.Emit(&event.FundingRate{
+		Header:          event.NewHeader(event.NewMarket(common.Okx, common.InstTypePerp, stringValue(feature, "instId")), int64Value(feature, "ts")),
+		Rate:            common.DecimalFromString(stringValue(feature, "fundingRate")),
+		NextFundingTime: event.Millis(int64Value(feature, "fundingTime")),
+	})
+}
+
+// emitTicker 发送 tickers 频道的统一行情事件, 永续的成交量为合约张数
+func (okx *OkxExClient) emitTicker(instType string, data map[string]interface{}) {
+	okx.sink.Emit(&event.Ticker{
+		Header:      event.NewHeader(event.NewMarket(common.Okx, instType, stringValue(data, "instId")), int64Value(data, "ts")),
+		Last:        common.DecimalFromString(stringValue(data, "last")),
+		Open:        common.DecimalFromString(stringValue(data, "open24h")),
+		High:        common.DecimalFromString(stringValue(data, "high24h")),
+		Low:         common.DecimalFromString(stringValue(data, "low24h")),
+		Volume:      common.DecimalFromString(stringValue(data, "vol24h")),
+		QuoteVolume: common.DecimalFromString(stringValue(data, "volCcy24h")),
+		Bid:         common.DecimalFromString(stringValue(data, "bidPx")),
+		BidSize:     common.DecimalFromString(stringValue(data, "bidSz")),
+		Ask:         common.DecimalFromString(stringValue(data, "askPx")),
+		AskSize:     common.DecimalFromString(stringValue(data, "askSz")),
+	})
+}
+
+// stringValue 读取推送中的字符串字段, 不存在时返回空字符串
+func stringValue(data map[string]interface{}, key string) string {
+	value, _ := data[key].(string)
+	return value
+}
+
+// int64Value 读取推送中以字符串表示的整数字段, 如毫秒时间
+func int64Value(data map[string]interface{}, key string) int64 {
+	value, _ := strconv.ParseInt(stringValue(data, key), 10, 64)
+	return value
+}
+
+// ExecuteBooksWs 订阅深度频道并维护本地订单簿，books 频道校验 seqId 连续性和 checksum，不一致时重新订阅
+func (okx *OkxExClient) ExecuteBooksWs(channel string, instIds []string) {
+	if err := okx.OkxWebSocketClient.SubscribeBooks(channel, instIds); err != nil {
+		log.Error("Failed to subscribe books", "channel", channel, "err", err)
+	}
 }
@@ -3,6 +3,8 @@ package okx
 import (
 	"fmt"
 	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
+	"github.com/339-Labs/exchange-market/common/orderbook"
 	"github.com/339-Labs/exchange-market/common/signer"
 	"github.com/339-Labs/exchange-market/common/ws"
 	"github.com/339-Labs/exchange-market/config"
@@ -25,7 +27,7 @@ type OkxMessageHandler struct {
 	Listener      OnReceive
 	ErrorListener OnReceive
 	ScribeMap     map[model.SubscribeReq]OnReceive
-	AllSubscribe  *model.Set
+	AllSubscribe  *ws.SubscriptionRegistry
 
 	// 同步
 	mu sync.RWMutex
@@ -40,13 +42,13 @@ type OnReceive func(message string)
 // NewOkxMessageHandler 创建新的Okx消息处理器
 func NewOkxMessageHandler(config *config.CexExchangeConfig, needLogin bool) *OkxMessageHandler {
 	handler := &OkxMessageHandler{
-		Config:       config,
-		NeedLogin:    needLogin,
-		LoginStatus:  false,
-		ScribeMap:    make(map[model.SubscribeReq]OnReceive),
-		AllSubscribe: model.NewSet(),
-		Signer:       new(signer.Signer).Init(config.ApiSecretKey),
+		Config:      config,
+		NeedLogin:   needLogin,
+		LoginStatus: false,
+		ScribeMap:   make(map[model.SubscribeReq]OnReceive),
+		Signer:      new(signer.Signer).Init(config.ApiSecretKey),
 	}
+	handler.AllSubscribe = ws.NewSubscriptionRegistry(handler, constants.SubscribeBatchSize, constants.SubscribeIntervalMs*time.Millisecond)
 
 	return handler
 }
@@ -112,9 +114,7 @@ func (h *OkxMessageHandler) HandleSpecialMessage(message string) (handled bool,
 func (h *OkxMessageHandler) handleLoginResponse(message string) error {
 	log.Info("Login response: %s", message)
 
-	h.mu.Lock()
-	h.LoginStatus = true
-	h.mu.Unlock()
+	h.setLoginStatus(true)
 
 	if h.Listener != nil {
 		h.Listener(message)
@@ -170,6 +170,32 @@ func (h *OkxMessageHandler) getListener(argJson interface{}) OnReceive {
 	return listener
 }
 
+// setLoginStatus 设置登录状态
+func (h *OkxMessageHandler) setLoginStatus(status bool) {
+	h.mu.Lock()
+	h.LoginStatus = status
+	h.mu.Unlock()
+}
+
+// RequireLogin 是否需要登录
+func (h *OkxMessageHandler) RequireLogin() bool {
+	return h.NeedLogin
+}
+
+// SendSubscribe 批量发送订阅请求，用于重连后重放订阅
+func (h *OkxMessageHandler) SendSubscribe(topics []interface{}) error {
+	if h.wsClient == nil {
+		return fmt.Errorf("WebSocket client is not set")
+	}
+
+	baseReq := model.WsBaseReq{
+		Op:   constants.WsOpSubscribe,
+		Args: topics,
+	}
+
+	return h.wsClient.SendJSON(baseReq)
+}
+
 // IsLoggedIn 检查是否已登录
 func (h *OkxMessageHandler) IsLoggedIn() bool {
 	h.mu.RLock()
@@ -199,6 +225,13 @@ func (h *OkxMessageHandler) RemoveSubscription(req model.SubscribeReq) {
 type OkxWebSocketClient struct {
 	*ws.GenericWebSocketClient
 	MessageHandler *OkxMessageHandler
+
+	// 本地订单簿
+	Books        *orderbook.Manager
+	pendingBooks map[model.SubscribeReq]bool // 等待全量快照的订阅
+	bookChannels map[string]string           // instId -> 已订阅的深度频道
+	bookMu       sync.Mutex
+	sink         event.Sink // 订单簿统一行情事件
 }
 
 // NewOkxWebSocketClient 创建新的Okx WebSocket客户端
@@ -220,20 +253,18 @@ func NewOkxWebSocketClient(config *config.CexExchangeConfig, needLogin bool) *Ok
 	messageHandler := NewOkxMessageHandler(config, needLogin)
 	messageHandler.SetWebSocketClient(genericClient)
 
-	// 设置消息处理器
+	// 设置消息处理器和订阅注册表
 	genericClient.SetMessageHandler(messageHandler)
+	genericClient.SetSubscriptionRegistry(messageHandler.AllSubscribe)
 
 	// 设置回调函数
 	genericClient.SetCallbacks(
 		func() {
 			log.Info("Okx WebSocket connected")
-			// 如果需要登录，则自动登录
-			if needLogin {
-				messageHandler.Login()
-			}
 		},
 		func() {
 			log.Info("Okx WebSocket disconnected")
+			messageHandler.setLoginStatus(false)
 		},
 		func(attempt int) {
 			log.Info("Okx WebSocket reconnecting, attempt: %d", attempt)
@@ -243,6 +274,10 @@ func NewOkxWebSocketClient(config *config.CexExchangeConfig, needLogin bool) *Ok
 	return &OkxWebSocketClient{
 		GenericWebSocketClient: genericClient,
 		MessageHandler:         messageHandler,
+		Books:                  orderbook.NewManager(string(common.Okx)),
+		pendingBooks:           make(map[model.SubscribeReq]bool),
+		bookChannels:           make(map[string]string),
+		sink:                   event.Discard,
 	}
 }
 
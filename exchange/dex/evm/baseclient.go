@@ -34,19 +34,7 @@ type EvmClient interface {
 }
 
 func NewEvmClient(ctx context.Context, rpcUrl string, contractAddress string) (EvmClient, error) {
-	ctx, cancel := context.WithTimeout(ctx, defaultDialTimeout)
-	defer cancel()
-	off := retry.Exponential()
-	ethClient, err := retry.Do(ctx, defaultDialAttempts, off, func() (*ethclient.Client, error) {
-		if !IsURLAvailable(rpcUrl) {
-			return nil, fmt.Errorf("rpc url %s is not available", rpcUrl)
-		}
-		client, err := ethclient.DialContext(ctx, rpcUrl)
-		if err != nil {
-			return nil, err
-		}
-		return client, nil
-	})
+	ethClient, err := DialEthClient(ctx, rpcUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -80,6 +68,23 @@ func NewEvmClient(ctx context.Context, rpcUrl string, contractAddress string) (E
 	}, nil
 }
 
+// DialEthClient 连接节点, 失败时按指数退避重试, rpcUrl 可以是 http 或 ws
+func DialEthClient(ctx context.Context, rpcUrl string) (*ethclient.Client, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultDialTimeout)
+	defer cancel()
+	off := retry.Exponential()
+	return retry.Do(ctx, defaultDialAttempts, off, func() (*ethclient.Client, error) {
+		if !IsURLAvailable(rpcUrl) {
+			return nil, fmt.Errorf("rpc url %s is not available", rpcUrl)
+		}
+		client, err := ethclient.DialContext(ctx, rpcUrl)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	})
+}
+
 func IsURLAvailable(address string) bool {
 	u, err := url.Parse(address)
 	if err != nil {
@@ -0,0 +1,147 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+)
+
+// ErrReorgTooDeep 回滚超出区块头窗口, 窗口之前的区块无法校验
+var ErrReorgTooDeep = errors.New("reorg deeper than the header window")
+
+// HeaderReader 按高度读取规范链的区块头, ethclient.Client 实现该接口
+type HeaderReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// BlockRef 区块头中用于校验链连续性的字段
+type BlockRef struct {
+	Number     uint64
+	Hash       common.Hash
+	ParentHash common.Hash
+}
+
+// BlockTracker 保存最近的区块头窗口, 按父哈希校验新区块, 发现不一致时回退到共同祖先
+// 不是并发安全的, 由调用方在同一个协程中使用
+type BlockTracker struct {
+	reader HeaderReader
+	size   int
+	window []BlockRef // 按高度升序且连续
+}
+
+// NewBlockTracker 创建区块跟踪, size 为保留的区块头数量, 需大于可能发生的回滚深度
+func NewBlockTracker(reader HeaderReader, size int) (*BlockTracker, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid block window size %d", size)
+	}
+	return &BlockTracker{
+		reader: reader,
+		size:   size,
+		window: make([]BlockRef, 0, size),
+	}, nil
+}
+
+// Tip 窗口中最新的区块, 窗口为空时返回false
+func (t *BlockTracker) Tip() (BlockRef, bool) {
+	if len(t.window) == 0 {
+		return BlockRef{}, false
+	}
+	return t.window[len(t.window)-1], true
+}
+
+// Hash 窗口中该高度区块的哈希, 不在窗口内时返回false
+func (t *BlockTracker) Hash(number uint64) (common.Hash, bool) {
+	if len(t.window) == 0 || number < t.window[0].Number || number > t.window[len(t.window)-1].Number {
+		return common.Hash{}, false
+	}
+	return t.window[number-t.window[0].Number].Hash, true
+}
+
+// Sync 校验窗口并读取到 head 的区块头, 发现回滚时返回第一个被回滚的区块高度, 没有回滚时 reorged 为false
+// 回滚超出窗口时从 head 重新开始并返回 ErrReorgTooDeep, fork 为窗口中最早的区块; 返回其他错误时 fork 和 reorged 仍然有效
+func (t *BlockTracker) Sync(ctx context.Context, head uint64) (fork uint64, reorged bool, err error) {
+	if len(t.window) == 0 {
+		return 0, false, t.reset(ctx, head)
+	}
+
+	// 从窗口末尾向前弹出与规范链不一致的区块, 节点上已不存在的区块同样视为被回滚
+	for len(t.window) > 0 {
+		tip := t.window[len(t.window)-1]
+		header, err := t.header(ctx, tip.Number)
+		if err != nil {
+			return fork, reorged, err
+		}
+		if header != nil && header.Hash() == tip.Hash {
+			break
+		}
+		t.window = t.window[:len(t.window)-1]
+		fork, reorged = tip.Number, true
+	}
+	if len(t.window) == 0 {
+		return fork, true, errors.Join(ErrReorgTooDeep, t.reset(ctx, head))
+	}
+
+	// 末尾已在规范链上, 只需要读取最新的窗口, 落后过多时跳过中间的区块
+	start := t.window[len(t.window)-1].Number + 1
+	if head >= uint64(t.size) && head-uint64(t.size)+1 > start {
+		start = head - uint64(t.size) + 1
+		t.window = t.window[:0]
+	}
+	for number := start; number <= head; number++ {
+		header, err := t.header(ctx, number)
+		if err != nil {
+			return fork, reorged, err
+		}
+		if header == nil {
+			return fork, reorged, fmt.Errorf("block %d not found", number)
+		}
+		if tip, ok := t.Tip(); ok && header.ParentHash != tip.Hash {
+			// 读取期间发生回滚, 下次同步时从窗口末尾重新校验
+			return fork, reorged, fmt.Errorf("block %d does not extend %s", number, tip.Hash)
+		}
+		t.push(header)
+	}
+	return fork, reorged, nil
+}
+
+// reset 清空窗口并从 head 重新开始
+func (t *BlockTracker) reset(ctx context.Context, head uint64) error {
+	t.window = t.window[:0]
+	header, err := t.header(ctx, head)
+	if err != nil {
+		return err
+	}
+	if header == nil {
+		return fmt.Errorf("block %d not found", head)
+	}
+	t.push(header)
+	return nil
+}
+
+// push 追加区块头, 超出窗口时丢弃最早的区块
+func (t *BlockTracker) push(header *types.Header) {
+	if len(t.window) == t.size {
+		t.window = append(t.window[:0], t.window[1:]...)
+	}
+	t.window = append(t.window, BlockRef{
+		Number:     header.Number.Uint64(),
+		Hash:       header.Hash(),
+		ParentHash: header.ParentHash,
+	})
+}
+
+// header 读取规范链的区块头, 区块不存在时返回空
+func (t *BlockTracker) header(ctx context.Context, number uint64) (*types.Header, error) {
+	header, err := t.reader.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get header %d: %w", number, err)
+	}
+	return header, nil
+}
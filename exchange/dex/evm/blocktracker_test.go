@@ -0,0 +1,73 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeChain 按高度保存规范链的区块头
+type fakeChain map[uint64]*types.Header
+
+func (c fakeChain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if header, ok := c[number.Uint64()]; ok {
+		return header, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+// extend 在 from 之后追加区块直到 to, fork 用于区分分叉上的区块
+func (c fakeChain) extend(from, to uint64, fork byte) {
+	for number := from + 1; number <= to; number++ {
+		header := &types.Header{Number: new(big.Int).SetUint64(number), Extra: []byte{fork}}
+		if parent, ok := c[number-1]; ok {
+			header.ParentHash = parent.Hash()
+		}
+		c[number] = header
+	}
+}
+
+func TestBlockTracker_Sync(t *testing.T) {
+	ctx := context.Background()
+	chain := fakeChain{}
+	chain.extend(0, 10, 0)
+
+	tracker, err := NewBlockTracker(chain, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, reorged, err := tracker.Sync(ctx, 10); err != nil || reorged {
+		t.Fatalf("initial sync reorged=%v err=%v", reorged, err)
+	}
+	chain.extend(10, 12, 0)
+	if _, reorged, err := tracker.Sync(ctx, 12); err != nil || reorged {
+		t.Fatalf("extend reorged=%v err=%v", reorged, err)
+	}
+
+	// 11 和 12 被替换, 新链延长到 14
+	chain.extend(10, 14, 1)
+	fork, reorged, err := tracker.Sync(ctx, 14)
+	if err != nil || !reorged || fork != 11 {
+		t.Fatalf("fork=%d reorged=%v err=%v, want fork 11", fork, reorged, err)
+	}
+	if hash, _ := tracker.Hash(12); hash != chain[12].Hash() {
+		t.Fatal("block 12 should follow the canonical chain")
+	}
+	if tip, _ := tracker.Tip(); tip.Number != 14 {
+		t.Fatalf("tip = %d, want 14", tip.Number)
+	}
+
+	// 回滚超出窗口, 窗口从首次同步的区块 10 开始
+	chain.extend(5, 16, 2)
+	fork, reorged, err = tracker.Sync(ctx, 16)
+	if !errors.Is(err, ErrReorgTooDeep) || !reorged || fork != 10 {
+		t.Fatalf("fork=%d reorged=%v err=%v, want too deep from 10", fork, reorged, err)
+	}
+	if tip, _ := tracker.Tip(); tip.Hash != chain[16].Hash() {
+		t.Fatal("tracker should restart from head")
+	}
+}
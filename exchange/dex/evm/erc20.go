@@ -0,0 +1,117 @@
+package evm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"strings"
+	"sync"
+)
+
+// erc20ABI 只包含读取代币符号和精度的方法
+const erc20ABI = `[
+	{"constant":true,"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+	{"constant":true,"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"}
+]`
+
+// erc20Bytes32ABI 早期代币(如 MKR)的 symbol 返回 bytes32
+const erc20Bytes32ABI = `[
+	{"constant":true,"inputs":[],"name":"symbol","outputs":[{"name":"","type":"bytes32"}],"stateMutability":"view","type":"function"}
+]`
+
+// ErrInvalidToken 合约不存在、调用回滚或返回值无法解码, 与网络错误不同, 重试不会成功
+var ErrInvalidToken = errors.New("invalid erc20 token")
+
+var (
+	erc20Parsed        = mustParseABI(erc20ABI)
+	erc20Bytes32Parsed = mustParseABI(erc20Bytes32ABI)
+)
+
+// Token ERC-20 代币的地址、符号和精度
+type Token struct {
+	Address  common.Address
+	Symbol   string
+	Decimals uint8
+}
+
+// Tokens 读取并缓存代币信息, 代币信息不可变, 缓存不过期
+type Tokens struct {
+	backend bind.ContractCaller
+	mu      sync.RWMutex
+	tokens  map[common.Address]Token
+}
+
+// NewTokens 创建代币信息缓存
+func NewTokens(backend bind.ContractCaller) *Tokens {
+	return &Tokens{
+		backend: backend,
+		tokens:  make(map[common.Address]Token),
+	}
+}
+
+// Get 读取代币的符号和精度, 未缓存时调用合约
+func (t *Tokens) Get(ctx context.Context, address common.Address) (Token, error) {
+	t.mu.RLock()
+	token, ok := t.tokens[address]
+	t.mu.RUnlock()
+	if ok {
+		return token, nil
+	}
+
+	opts := &bind.CallOpts{Context: ctx}
+	contract := bind.NewBoundContract(address, erc20Parsed, t.backend, nil, nil)
+
+	var out []interface{}
+	if err := contract.Call(opts, &out, "decimals"); err != nil {
+		return Token{}, fmt.Errorf("call decimals of %s: %w", address, tokenError(err))
+	}
+	decimals := *abi.ConvertType(out[0], new(uint8)).(*uint8)
+
+	symbol, err := t.symbol(opts, address, contract)
+	if err != nil {
+		return Token{}, err
+	}
+
+	token = Token{Address: address, Symbol: symbol, Decimals: decimals}
+	t.mu.Lock()
+	t.tokens[address] = token
+	t.mu.Unlock()
+	return token, nil
+}
+
+// symbol 先按 string 解码, 失败时按 bytes32 解码
+func (t *Tokens) symbol(opts *bind.CallOpts, address common.Address, contract *bind.BoundContract) (string, error) {
+	var out []interface{}
+	if err := contract.Call(opts, &out, "symbol"); err == nil {
+		return strings.TrimSpace(*abi.ConvertType(out[0], new(string)).(*string)), nil
+	}
+
+	out = nil
+	legacy := bind.NewBoundContract(address, erc20Bytes32Parsed, t.backend, nil, nil)
+	if err := legacy.Call(opts, &out, "symbol"); err != nil {
+		return "", fmt.Errorf("call symbol of %s: %w", address, tokenError(err))
+	}
+	raw := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+	return strings.TrimSpace(string(bytes.TrimRight(raw[:], "\x00"))), nil
+}
+
+// tokenError 合约本身导致的错误包装为 ErrInvalidToken
+func tokenError(err error) error {
+	message := err.Error()
+	if errors.Is(err, bind.ErrNoCode) || strings.Contains(message, "execution reverted") || strings.HasPrefix(message, "abi:") {
+		return fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	return err
+}
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
@@ -0,0 +1,170 @@
+package evm
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"math/big"
+	"slices"
+	"sync"
+)
+
+const (
+	MaxLogRange     = 2_000 // 单次 eth_getLogs 查询的最大区块数
+	maxLogAddresses = 500   // 单次查询或订阅的最大合约数量
+)
+
+// ErrNoSubscription 未配置 websocket 节点时无法订阅日志
+var ErrNoSubscription = errors.New("log subscription requires a websocket rpc url")
+
+// LogFeed 读取一组合约的指定事件日志, 配置了 websocket 节点时可以订阅, 否则按区块范围轮询
+type LogFeed struct {
+	client   *ethclient.Client
+	wsClient *ethclient.Client // 可为空
+	topics   []common.Hash     // 事件签名, 任一匹配即可
+}
+
+// NewLogFeed 创建日志读取, wsClient 为空时只能轮询
+func NewLogFeed(client *ethclient.Client, wsClient *ethclient.Client, topics []common.Hash) *LogFeed {
+	return &LogFeed{
+		client:   client,
+		wsClient: wsClient,
+		topics:   topics,
+	}
+}
+
+// CanSubscribe 是否可以订阅日志
+func (f *LogFeed) CanSubscribe() bool {
+	return f.wsClient != nil
+}
+
+// HeadBlock 最新区块高度
+func (f *LogFeed) HeadBlock(ctx context.Context) (uint64, error) {
+	return f.client.BlockNumber(ctx)
+}
+
+// HeaderByNumber 读取区块头, 实现 HeaderReader
+func (f *LogFeed) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return f.client.HeaderByNumber(ctx, number)
+}
+
+// FilterLogs 按 eth_getLogs 读取 [from, to] 区块内的日志, 按区块范围和合约数量分批查询, 结果按区块和日志下标排序
+func (f *LogFeed) FilterLogs(ctx context.Context, addresses []common.Address, from, to uint64) ([]types.Log, error) {
+	var result []types.Log
+	if len(addresses) == 0 || from > to {
+		return result, nil
+	}
+
+	for start := from; start <= to; start += MaxLogRange {
+		end := min(to, start+MaxLogRange-1)
+		var batch []types.Log
+		for _, chunk := range chunkAddresses(addresses) {
+			logs, err := f.client.FilterLogs(ctx, f.query(chunk, new(big.Int).SetUint64(start), new(big.Int).SetUint64(end)))
+			if err != nil {
+				return nil, fmt.Errorf("filter logs %d-%d: %w", start, end, err)
+			}
+			batch = append(batch, logs...)
+		}
+		sortLogs(batch)
+		result = append(result, batch...)
+	}
+	return result, nil
+}
+
+// Subscribe 订阅新区块中的日志, 合约数量超过单次上限时拆分为多个订阅, 任一订阅出错时全部取消
+func (f *LogFeed) Subscribe(ctx context.Context, addresses []common.Address, ch chan<- types.Log) (ethereum.Subscription, error) {
+	if f.wsClient == nil {
+		return nil, ErrNoSubscription
+	}
+
+	subs := make([]ethereum.Subscription, 0)
+	for _, chunk := range chunkAddresses(addresses) {
+		sub, err := f.wsClient.SubscribeFilterLogs(ctx, f.query(chunk, nil, nil), ch)
+		if err != nil {
+			for _, s := range subs {
+				s.Unsubscribe()
+			}
+			return nil, fmt.Errorf("subscribe logs: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+	return newMultiSubscription(subs), nil
+}
+
+func (f *LogFeed) query(addresses []common.Address, from, to *big.Int) ethereum.FilterQuery {
+	return ethereum.FilterQuery{
+		FromBlock: from,
+		ToBlock:   to,
+		Addresses: addresses,
+		Topics:    [][]common.Hash{f.topics},
+	}
+}
+
+// chunkAddresses 按 maxLogAddresses 拆分合约地址
+func chunkAddresses(addresses []common.Address) [][]common.Address {
+	chunks := make([][]common.Address, 0, len(addresses)/maxLogAddresses+1)
+	for start := 0; start < len(addresses); start += maxLogAddresses {
+		chunks = append(chunks, addresses[start:min(len(addresses), start+maxLogAddresses)])
+	}
+	return chunks
+}
+
+// sortLogs 按区块高度和日志下标排序
+func sortLogs(logs []types.Log) {
+	slices.SortFunc(logs, func(a, b types.Log) int {
+		if a.BlockNumber != b.BlockNumber {
+			return cmp.Compare(a.BlockNumber, b.BlockNumber)
+		}
+		return cmp.Compare(a.Index, b.Index)
+	})
+}
+
+// multiSubscription 合并多个订阅, 任一订阅出错时通过 Err 返回
+type multiSubscription struct {
+	subs []ethereum.Subscription
+	err  chan error
+	quit chan struct{}
+	once sync.Once
+}
+
+func newMultiSubscription(subs []ethereum.Subscription) *multiSubscription {
+	m := &multiSubscription{
+		subs: subs,
+		err:  make(chan error, 1),
+		quit: make(chan struct{}),
+	}
+	for _, sub := range subs {
+		go func(sub ethereum.Subscription) {
+			select {
+			case err, ok := <-sub.Err():
+				if !ok {
+					return
+				}
+				select {
+				case m.err <- err:
+				default:
+				}
+			case <-m.quit:
+			}
+		}(sub)
+	}
+	return m
+}
+
+func (m *multiSubscription) Unsubscribe() {
+	m.once.Do(func() {
+		close(m.quit)
+		for _, sub := range m.subs {
+			sub.Unsubscribe()
+		}
+	})
+}
+
+func (m *multiSubscription) Err() <-chan error {
+	return m.err
+}
@@ -0,0 +1,106 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	uniswapv2 "github.com/339-Labs/exchange-market/bindings/uniswapv2"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"math/big"
+)
+
+// V2Pair factory allPairs 中的一个交易对, 价格方向为 Token1/Token0
+type V2Pair struct {
+	Index   uint64
+	Address common.Address
+	Token0  Token
+	Token1  Token
+}
+
+// UniswapV2Indexer 遍历 Uniswap V2 factory 的 allPairs, 读取交易对的代币和储备量
+type UniswapV2Indexer struct {
+	client  *ethclient.Client
+	factory *uniswapv2.UniswapV2Factory
+	tokens  *Tokens
+}
+
+// NewUniswapV2Indexer 创建索引器, factory 为 UniswapV2Factory 合约地址
+func NewUniswapV2Indexer(client *ethclient.Client, factory common.Address) (*UniswapV2Indexer, error) {
+	factoryContract, err := uniswapv2.NewUniswapV2Factory(factory, client)
+	if err != nil {
+		return nil, fmt.Errorf("bind uniswap v2 factory %s: %w", factory, err)
+	}
+	return &UniswapV2Indexer{
+		client:  client,
+		factory: factoryContract,
+		tokens:  NewTokens(client),
+	}, nil
+}
+
+// ChainId 节点返回的 chain id
+func (i *UniswapV2Indexer) ChainId(ctx context.Context) (string, error) {
+	chainId, err := i.client.ChainID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return chainId.String(), nil
+}
+
+// HeadBlock 最新区块高度
+func (i *UniswapV2Indexer) HeadBlock(ctx context.Context) (uint64, error) {
+	return i.client.BlockNumber(ctx)
+}
+
+// PairsLength 区块 block 时 factory 已创建的交易对数量
+func (i *UniswapV2Indexer) PairsLength(ctx context.Context, block uint64) (uint64, error) {
+	length, err := i.factory.AllPairsLength(&bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(block)})
+	if err != nil {
+		return 0, err
+	}
+	return length.Uint64(), nil
+}
+
+// Pair 按区块 block 的状态读取 allPairs[index] 的地址和两个代币
+func (i *UniswapV2Indexer) Pair(ctx context.Context, index uint64, block uint64) (*V2Pair, error) {
+	opts := &bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(block)}
+	address, err := i.factory.AllPairs(opts, new(big.Int).SetUint64(index))
+	if err != nil {
+		return nil, fmt.Errorf("get pair %d: %w", index, err)
+	}
+	pair, err := uniswapv2.NewUniswapV2Pair(address, i.client)
+	if err != nil {
+		return nil, err
+	}
+
+	token0, err := pair.Token0(opts)
+	if err != nil {
+		return nil, fmt.Errorf("get token0 of %s: %w", address, err)
+	}
+	token1, err := pair.Token1(opts)
+	if err != nil {
+		return nil, fmt.Errorf("get token1 of %s: %w", address, err)
+	}
+
+	result := &V2Pair{Index: index, Address: address}
+	if result.Token0, err = i.tokens.Get(ctx, token0); err != nil {
+		return nil, err
+	}
+	if result.Token1, err = i.tokens.Get(ctx, token1); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Reserves 读取交易对当前的储备量
+func (i *UniswapV2Indexer) Reserves(ctx context.Context, pair common.Address) (*big.Int, *big.Int, error) {
+	contract, err := uniswapv2.NewUniswapV2Pair(pair, i.client)
+	if err != nil {
+		return nil, nil, err
+	}
+	reserves, err := contract.GetReserves(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return nil, nil, fmt.Errorf("get reserves of %s: %w", pair, err)
+	}
+	return reserves.Reserve0, reserves.Reserve1, nil
+}
@@ -2,7 +2,12 @@ package evm
 
 import (
 	"context"
+	uniswapv2 "github.com/339-Labs/exchange-market/bindings/uniswapv2"
+	common2 "github.com/339-Labs/exchange-market/common"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"log"
 	"math/big"
 )
@@ -21,3 +26,34 @@ func (c Client) GetReserves() (*big.Int, *big.Int, error) {
 
 	return reserves.Reserve0, reserves.Reserve1, nil
 }
+
+// ReservePrice 按储备量计算 token1/token0 的中间价, 已按两个代币的精度调整, 任一储备为0时返回未设置
+func ReservePrice(reserve0, reserve1 *big.Int, decimals0, decimals1 uint8) common2.Decimal {
+	if reserve0 == nil || reserve1 == nil || reserve0.Sign() <= 0 || reserve1.Sign() <= 0 {
+		return common2.Decimal{}
+	}
+	// price = (reserve1 / 10^decimals1) / (reserve0 / 10^decimals0)
+	num := new(big.Int).Mul(reserve1, pow10(decimals0))
+	den := new(big.Int).Mul(reserve0, pow10(decimals1))
+	return common2.NewDecimalFromRat(new(big.Rat).SetFrac(num, den), priceScale)
+}
+
+// pow10 10^n
+func pow10(n uint8) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// v2PairFilterer 解析任意交易对的日志, 只使用 ABI, 不绑定合约地址
+var v2PairFilterer, _ = uniswapv2.NewUniswapV2PairFilterer(common.Address{}, nil)
+
+// SyncTopic 交易对储备量变化的 Sync 事件签名, 每次 Swap、Mint、Burn 后都会触发
+var SyncTopic = crypto.Keccak256Hash([]byte("Sync(uint112,uint112)"))
+
+// ParseSync 解析 Sync 事件中的储备量
+func ParseSync(log types.Log) (*big.Int, *big.Int, error) {
+	event, err := v2PairFilterer.ParseSync(log)
+	if err != nil {
+		return nil, nil, err
+	}
+	return event.Reserve0, event.Reserve1, nil
+}
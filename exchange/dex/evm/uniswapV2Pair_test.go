@@ -0,0 +1,49 @@
+package evm
+
+import (
+	"math/big"
+	"testing"
+
+	common2 "github.com/339-Labs/exchange-market/common"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestReservePrice(t *testing.T) {
+	// 1000 WETH(18位) / 3,500,000 USDC(6位)
+	reserve0, _ := new(big.Int).SetString("1000000000000000000000", 10)
+	reserve1 := big.NewInt(3_500_000_000_000)
+
+	price := ReservePrice(reserve0, reserve1, 18, 6)
+	if !price.Equal(common2.MustParseDecimal("3500")) {
+		t.Fatalf("price = %s, want 3500", price)
+	}
+
+	inverse := ReservePrice(reserve1, reserve0, 6, 18)
+	if want := common2.MustParseDecimal("0.000285714285714285714285714285714286"); !inverse.Equal(want) {
+		t.Fatalf("inverse = %s, want %s", inverse, want)
+	}
+
+	if ReservePrice(big.NewInt(0), reserve1, 18, 6).IsSet() {
+		t.Fatal("empty reserve should be unset")
+	}
+}
+
+func TestParseSync(t *testing.T) {
+	if SyncTopic.Hex() != "0x1c411e9a96e071241c2f21f7726b17ae89e3cab4c78be50e062b03a9fffbbad1" {
+		t.Fatalf("sync topic = %s", SyncTopic.Hex())
+	}
+
+	data := append(common.LeftPadBytes(big.NewInt(1_000).Bytes(), 32), common.LeftPadBytes(big.NewInt(2_500).Bytes(), 32)...)
+	reserve0, reserve1, err := ParseSync(types.Log{Topics: []common.Hash{SyncTopic}, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reserve0.Int64() != 1_000 || reserve1.Int64() != 2_500 {
+		t.Fatalf("reserves = %s, %s", reserve0, reserve1)
+	}
+
+	if _, _, err := ParseSync(types.Log{Topics: []common.Hash{{}}, Data: data}); err == nil {
+		t.Fatal("expected error for other events")
+	}
+}
@@ -1,4 +1,103 @@
 package evm
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	uniswapv3 "github.com/339-Labs/exchange-market/bindings/uniswapv3"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// UniswapV3Factory 按 PoolCreated 事件发现池子
 type UniswapV3Factory interface {
+	PoolCreated(ctx context.Context, from, to uint64) ([]V3Pool, error)
+}
+
+// V3Pool factory 创建的池子, 价格方向为 Token1/Token0
+type V3Pool struct {
+	Address     common.Address
+	Token0      Token // 代币不符合 ERC-20 时只有地址
+	Token1      Token
+	Fee         uint32 // 手续费档位, 单位为百万分之一, 例如 3000 为 0.3%
+	TickSpacing int32
+	Block       uint64 // 创建池子的区块
+}
+
+// UniswapV3Indexer 读取 Uniswap V3 factory 的 PoolCreated 事件
+type UniswapV3Indexer struct {
+	client  *ethclient.Client
+	factory *uniswapv3.UniswapV3Factory
+	tokens  *Tokens
+}
+
+// NewUniswapV3Indexer 创建索引器, factory 为 UniswapV3Factory 合约地址
+func NewUniswapV3Indexer(client *ethclient.Client, factory common.Address) (*UniswapV3Indexer, error) {
+	factoryContract, err := uniswapv3.NewUniswapV3Factory(factory, client)
+	if err != nil {
+		return nil, fmt.Errorf("bind uniswap v3 factory %s: %w", factory, err)
+	}
+	return &UniswapV3Indexer{
+		client:  client,
+		factory: factoryContract,
+		tokens:  NewTokens(client),
+	}, nil
+}
+
+// ChainId 节点返回的 chain id
+func (i *UniswapV3Indexer) ChainId(ctx context.Context) (string, error) {
+	chainId, err := i.client.ChainID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return chainId.String(), nil
+}
+
+// HeadBlock 最新区块高度
+func (i *UniswapV3Indexer) HeadBlock(ctx context.Context) (uint64, error) {
+	return i.client.BlockNumber(ctx)
+}
+
+// PoolCreated 读取 [from, to] 区块内创建的池子, 区块范围不应超过 MaxLogRange
+// 代币不符合 ERC-20 时保留池子但不填充代币符号和精度, 网络错误时返回错误
+func (i *UniswapV3Indexer) PoolCreated(ctx context.Context, from, to uint64) ([]V3Pool, error) {
+	iter, err := i.factory.FilterPoolCreated(&bind.FilterOpts{Start: from, End: &to, Context: ctx}, nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("filter pool created %d-%d: %w", from, to, err)
+	}
+	defer iter.Close()
+
+	pools := make([]V3Pool, 0)
+	for iter.Next() {
+		event := iter.Event
+		pool := V3Pool{
+			Address:     event.Pool,
+			Fee:         uint32(event.Fee.Uint64()),
+			TickSpacing: int32(event.TickSpacing.Int64()),
+			Block:       event.Raw.BlockNumber,
+		}
+		if pool.Token0, err = i.token(ctx, event.Token0); err != nil {
+			return nil, err
+		}
+		if pool.Token1, err = i.token(ctx, event.Token1); err != nil {
+			return nil, err
+		}
+		pools = append(pools, pool)
+	}
+	if err := iter.Error(); err != nil {
+		return nil, fmt.Errorf("filter pool created %d-%d: %w", from, to, err)
+	}
+	return pools, nil
+}
+
+// token 读取代币信息, 不符合 ERC-20 时只返回地址
+func (i *UniswapV3Indexer) token(ctx context.Context, address common.Address) (Token, error) {
+	token, err := i.tokens.Get(ctx, address)
+	if errors.Is(err, ErrInvalidToken) {
+		log.Warn("skip invalid uniswap v3 token", "token", address, "err", err)
+		return Token{Address: address}, nil
+	}
+	return token, err
 }
@@ -0,0 +1,228 @@
+package evm
+
+import (
+	"fmt"
+	common2 "github.com/339-Labs/exchange-market/common"
+	"math"
+	"math/big"
+)
+
+const (
+	MinTick = -887272 // TickMath.MIN_TICK
+	MaxTick = 887272  // TickMath.MAX_TICK
+)
+
+// Q96 sqrtPriceX96 的分母 2^96
+var Q96 = new(big.Int).Lsh(big.NewInt(1), 96)
+
+// sqrtRatioFactors TickMath.getSqrtRatioAtTick 中 tick 各二进制位对应的 Q128 乘数, 第 i 项对应 1<<(i+1)
+var sqrtRatioFactors = []string{
+	"fff97272373d413259a46990580e213a",
+	"fff2e50f5f656932ef12357cf3c7fdcc",
+	"ffe5caca7e10e4e61c3624eaa0941cd0",
+	"ffcb9843d60f6159c9db58835c926644",
+	"ff973b41fa98c081472e6896dfb254c0",
+	"ff2ea16466c96a3843ec78b326b52861",
+	"fe5dee046a99a2a811c461f1969c3053",
+	"fcbe86c7900a88aedcffc83b479aa3a4",
+	"f987a7253ac413176f2b074cf7815e54",
+	"f3392b0822b70005940c7a398e4b70f3",
+	"e7159475a2c29b7443b29c7fa6e889d9",
+	"d097f3bdfd2022b8845ad8f792aa5825",
+	"a9f746462d870fdf8a65dc1f90e061e5",
+	"70d869a156d2a1b890bb3df62baf32f7",
+	"31be135f97d08fd981231505542fcfa6",
+	"9aa508b5b7a84e1c677de54f3e99bc9",
+	"5d6af8dedb81196699c329225ee604",
+	"2216e584f5fa1ea926041bedfe98",
+	"48a170391f7dc42444e8fa2",
+}
+
+// V3State 池子的当前状态
+type V3State struct {
+	SqrtPriceX96 *big.Int // 为0时池子尚未初始化
+	Tick         int32
+	Liquidity    *big.Int // 当前价格区间的活跃流动性
+}
+
+// V3Tick 已初始化的 tick, 价格向上穿过时活跃流动性增加 LiquidityNet, 向下穿过时减少
+type V3Tick struct {
+	Index        int32
+	LiquidityNet *big.Int
+}
+
+// V3DepthLevel 价格从当前价移动 Percent% 需要成交的累计数量, 已按代币精度调整, 不含手续费
+// 买盘为卖出 Amount0 得到 Amount1, 卖盘为支付 Amount1 买入 Amount0
+type V3DepthLevel struct {
+	Percent common2.Decimal
+	Price   common2.Decimal // 目标价格 token1/token0
+	Amount0 common2.Decimal
+	Amount1 common2.Decimal
+}
+
+// SqrtRatioAtTick 与 TickMath.getSqrtRatioAtTick 相同, 返回 sqrt(1.0001^tick) * 2^96
+func SqrtRatioAtTick(tick int32) (*big.Int, error) {
+	if tick < MinTick || tick > MaxTick {
+		return nil, fmt.Errorf("tick %d out of range", tick)
+	}
+	absTick := tick
+	if absTick < 0 {
+		absTick = -absTick
+	}
+
+	ratio := new(big.Int).Lsh(big.NewInt(1), 128)
+	if absTick&1 != 0 {
+		ratio.SetString("fffcb933bd6fad37aa2d162d1a594001", 16)
+	}
+	for i, factor := range sqrtRatioFactors {
+		if absTick&(2<<i) != 0 {
+			value, _ := new(big.Int).SetString(factor, 16)
+			ratio.Mul(ratio, value).Rsh(ratio, 128)
+		}
+	}
+	if tick > 0 {
+		maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+		ratio.Div(maxUint256, ratio)
+	}
+
+	// Q128 转为 Q96, 向上取整
+	remainder := new(big.Int).And(ratio, big.NewInt(1<<32-1))
+	ratio.Rsh(ratio, 32)
+	if remainder.Sign() != 0 {
+		ratio.Add(ratio, big.NewInt(1))
+	}
+	return ratio, nil
+}
+
+// SqrtPriceX96Price 按代币精度调整后的价格, price 为 token1/token0, inverse 为 token0/token1, 未初始化的池子返回未设置
+func SqrtPriceX96Price(sqrtPriceX96 *big.Int, decimals0, decimals1 uint8) (price, inverse common2.Decimal) {
+	if sqrtPriceX96 == nil || sqrtPriceX96.Sign() <= 0 {
+		return common2.Decimal{}, common2.Decimal{}
+	}
+	// price = sqrtPriceX96^2 / 2^192 * 10^decimals0 / 10^decimals1, 用有理数计算避免浮点误差
+	square := new(big.Int).Mul(sqrtPriceX96, sqrtPriceX96)
+	num := new(big.Int).Mul(square, pow10(decimals0))
+	den := new(big.Int).Mul(Q192, pow10(decimals1))
+	return common2.NewDecimalFromRat(new(big.Rat).SetFrac(num, den), priceScale),
+		common2.NewDecimalFromRat(new(big.Rat).SetFrac(den, num), priceScale)
+}
+
+// V3TickRange 价格上下移动 percent% 覆盖的 tick 范围, 向外多留一个 tickSpacing, percent 需在 (0, 100) 内
+func V3TickRange(tick, tickSpacing int32, percent common2.Decimal) (lower, upper int32) {
+	p := percent.Float64() / 100
+	step := math.Log(1.0001)
+	up := int32(math.Ceil(math.Log(1+p)/step)) + tickSpacing
+	down := int32(math.Ceil(-math.Log(1-p)/step)) + tickSpacing
+	return max(tick-down, MinTick), min(tick+up, MaxTick)
+}
+
+// V3Depth 按活跃流动性和已初始化的 tick 计算价格移动各个百分比时的深度, ticks 需按 Index 升序且覆盖 V3TickRange 的范围
+// percents 需在 (0, 100) 内, 返回的买盘和卖盘与 percents 一一对应
+func V3Depth(state V3State, ticks []V3Tick, decimals0, decimals1 uint8, percents []common2.Decimal) ([]V3DepthLevel, []V3DepthLevel, error) {
+	if state.SqrtPriceX96 == nil || state.SqrtPriceX96.Sign() <= 0 {
+		return nil, nil, fmt.Errorf("pool not initialized")
+	}
+	if state.Liquidity == nil {
+		return nil, nil, fmt.Errorf("missing liquidity")
+	}
+	mid, _ := SqrtPriceX96Price(state.SqrtPriceX96, decimals0, decimals1)
+	current := new(big.Rat).SetFrac(state.SqrtPriceX96, Q96)
+	hundred := common2.NewDecimal(100, 0)
+
+	bids := make([]V3DepthLevel, 0, len(percents))
+	asks := make([]V3DepthLevel, 0, len(percents))
+	for _, percent := range percents {
+		if percent.Sign() <= 0 || !percent.LessThan(hundred) {
+			return nil, nil, fmt.Errorf("invalid depth percent %s", percent)
+		}
+		p := percent.Rat()
+		p.Quo(p, big.NewRat(100, 1))
+
+		for _, up := range []bool{false, true} {
+			factor := new(big.Rat).Sub(big.NewRat(1, 1), p)
+			if up {
+				factor.Add(big.NewRat(1, 1), p)
+			}
+			target := new(big.Rat).Mul(current, sqrtRat(factor))
+			amount0, amount1, err := swapToSqrtPrice(state, ticks, current, target, up)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			level := V3DepthLevel{
+				Percent: percent,
+				Price:   common2.NewDecimalFromRat(new(big.Rat).Mul(mid.Rat(), factor), priceScale),
+				Amount0: common2.NewDecimalFromRat(amount0.Quo(amount0, new(big.Rat).SetInt(pow10(decimals0))), int32(decimals0)),
+				Amount1: common2.NewDecimalFromRat(amount1.Quo(amount1, new(big.Rat).SetInt(pow10(decimals1))), int32(decimals1)),
+			}
+			if up {
+				asks = append(asks, level)
+			} else {
+				bids = append(bids, level)
+			}
+		}
+	}
+	return bids, asks, nil
+}
+
+// swapToSqrtPrice 价格从 current 移动到 target 时成交的 token0 和 token1 原始数量, 依次穿过沿途已初始化的 tick
+// 区间内 amount0 = L * (sa - sb) / (sa * sb), amount1 = L * (sa - sb), sa > sb 为区间两端的 sqrt 价格
+func swapToSqrtPrice(state V3State, ticks []V3Tick, current, target *big.Rat, up bool) (*big.Rat, *big.Rat, error) {
+	liquidity := new(big.Int).Set(state.Liquidity)
+	amount0, amount1 := new(big.Rat), new(big.Rat)
+
+	// 向下依次穿过 Index <= Tick 的 tick, 向上依次穿过 Index > Tick 的 tick
+	crossing := make([]V3Tick, 0)
+	for i := range ticks {
+		if up && ticks[i].Index > state.Tick {
+			crossing = append(crossing, ticks[i])
+		}
+	}
+	for i := len(ticks) - 1; i >= 0; i-- {
+		if !up && ticks[i].Index <= state.Tick {
+			crossing = append(crossing, ticks[i])
+		}
+	}
+
+	price := current
+	for i := 0; ; i++ {
+		next := target
+		if i < len(crossing) {
+			boundary, err := SqrtRatioAtTick(crossing[i].Index)
+			if err != nil {
+				return nil, nil, err
+			}
+			if sqrt := new(big.Rat).SetFrac(boundary, Q96); (up && sqrt.Cmp(target) < 0) || (!up && sqrt.Cmp(target) > 0) {
+				next = sqrt
+			}
+		}
+
+		if liquidity.Sign() > 0 {
+			high, low := price, next
+			if up {
+				high, low = next, price
+			}
+			delta := new(big.Rat).Sub(high, low)
+			delta.Mul(delta, new(big.Rat).SetInt(liquidity))
+			amount1.Add(amount1, delta)
+			amount0.Add(amount0, delta.Quo(delta, new(big.Rat).Mul(high, low)))
+		}
+		if next == target {
+			return amount0, amount1, nil
+		}
+
+		if up {
+			liquidity.Add(liquidity, crossing[i].LiquidityNet)
+		} else {
+			liquidity.Sub(liquidity, crossing[i].LiquidityNet)
+		}
+		price = next
+	}
+}
+
+// sqrtRat 有理数的平方根, 精度远高于价格保留的小数位
+func sqrtRat(value *big.Rat) *big.Rat {
+	f := new(big.Float).SetPrec(512).SetRat(value)
+	result, _ := f.Sqrt(f).Rat(nil)
+	return result
+}
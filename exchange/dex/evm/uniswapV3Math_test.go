@@ -0,0 +1,92 @@
+package evm
+
+import (
+	"math/big"
+	"testing"
+
+	common2 "github.com/339-Labs/exchange-market/common"
+)
+
+func TestSqrtRatioAtTick(t *testing.T) {
+	cases := []struct {
+		tick int32
+		want string
+	}{
+		{0, "79228162514264337593543950336"},
+		{1, "79232123823359799118286999568"},
+		{-1, "79224201403219477170569942574"},
+		{MinTick, "4295128739"},
+		{MaxTick, "1461446703485210103287273052203988822378723970342"},
+	}
+	for _, c := range cases {
+		got, err := SqrtRatioAtTick(c.tick)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != c.want {
+			t.Fatalf("tick %d = %s, want %s", c.tick, got, c.want)
+		}
+	}
+	if _, err := SqrtRatioAtTick(MaxTick + 1); err == nil {
+		t.Fatal("expected error for tick out of range")
+	}
+}
+
+func TestSqrtPriceX96Price(t *testing.T) {
+	// WETH(18位)/USDC(6位) 价格 2500, sqrtPriceX96 = sqrt(2500 * 10^6 / 10^18) * 2^96 = 0.00005 * 2^96
+	sqrtPriceX96 := new(big.Int).Div(new(big.Int).Mul(Q96, big.NewInt(5)), big.NewInt(100_000))
+	price, inverse := SqrtPriceX96Price(sqrtPriceX96, 18, 6)
+	if !price.Round(6).Equal(common2.MustParseDecimal("2500")) {
+		t.Fatalf("price = %s, want 2500", price)
+	}
+	if !inverse.Round(10).Equal(common2.MustParseDecimal("0.0004")) {
+		t.Fatalf("inverse = %s, want 0.0004", inverse)
+	}
+
+	if price, _ := SqrtPriceX96Price(big.NewInt(0), 18, 6); price.IsSet() {
+		t.Fatal("uninitialized pool should be unset")
+	}
+}
+
+func TestV3Depth(t *testing.T) {
+	// sqrt 价格为1, 活跃流动性 1e18, 上涨 21% 时 sqrt 价格为 1.1, 下跌 19% 时为 0.9
+	liquidity := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	state := V3State{SqrtPriceX96: new(big.Int).Set(Q96), Tick: 0, Liquidity: liquidity}
+	percents := []common2.Decimal{common2.MustParseDecimal("19"), common2.MustParseDecimal("21")}
+
+	bids, asks, err := V3Depth(state, nil, 18, 18, percents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 卖出 L * (1/0.9 - 1) 个 token0 得到 L * (1 - 0.9) 个 token1
+	if !bids[0].Amount0.Equal(common2.MustParseDecimal("0.111111111111111111")) || !bids[0].Amount1.Equal(common2.MustParseDecimal("0.1")) || !bids[0].Price.Equal(common2.MustParseDecimal("0.81")) {
+		t.Fatalf("bid = %+v", bids[0])
+	}
+	// 支付 L * (1.1 - 1) 个 token1 买入 L * (1 - 1/1.1) 个 token0
+	if !asks[1].Amount0.Equal(common2.MustParseDecimal("0.090909090909090909")) || !asks[1].Amount1.Equal(common2.MustParseDecimal("0.1")) || !asks[1].Price.Equal(common2.MustParseDecimal("1.21")) {
+		t.Fatalf("ask = %+v", asks[1])
+	}
+
+	// 流动性只在 [-100, 100] 内, 价格移出区间后数量不再增加
+	ticks := []V3Tick{{Index: -100, LiquidityNet: liquidity}, {Index: 100, LiquidityNet: new(big.Int).Neg(liquidity)}}
+	bids, asks, err = V3Depth(state, ticks, 18, 18, percents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	boundary, _ := SqrtRatioAtTick(100)
+	upper := new(big.Rat).SetFrac(boundary, Q96)
+	want1 := new(big.Rat).Mul(new(big.Rat).Sub(upper, big.NewRat(1, 1)), new(big.Rat).SetInt(liquidity))
+	if got := asks[1].Amount1; !got.Equal(common2.NewDecimalFromRat(want1.Quo(want1, new(big.Rat).SetInt(liquidity)), 18)) {
+		t.Fatalf("ask amount1 = %s, want %s", got, want1.FloatString(18))
+	}
+	if !asks[0].Amount1.Equal(asks[1].Amount1) {
+		t.Fatalf("asks beyond liquidity = %s, %s", asks[0].Amount1, asks[1].Amount1)
+	}
+	if !bids[0].Amount0.Equal(bids[1].Amount0) || bids[0].Amount0.Sign() <= 0 {
+		t.Fatalf("bids beyond liquidity = %s, %s", bids[0].Amount0, bids[1].Amount0)
+	}
+
+	if _, _, err := V3Depth(state, nil, 18, 18, []common2.Decimal{common2.MustParseDecimal("100")}); err == nil {
+		t.Fatal("expected error for 100%")
+	}
+}
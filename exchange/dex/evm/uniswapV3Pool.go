@@ -2,8 +2,13 @@ package evm
 
 import (
 	"context"
+	"fmt"
+	uniswapv3 "github.com/339-Labs/exchange-market/bindings/uniswapv3"
+	common2 "github.com/339-Labs/exchange-market/common"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"math/big"
 )
 
@@ -11,7 +16,7 @@ type UniswapV3Pool interface {
 	Token0() (common.Address, error)
 	Token1() (common.Address, error)
 	GetFee() (*big.Int, error)
-	Slot0() (*big.Float, *big.Int, error)
+	Slot0(decimals0, decimals1 uint8) (common2.Decimal, common2.Decimal, *big.Int, error)
 }
 
 func (c Client) Token0() (common.Address, error) {
@@ -34,21 +39,102 @@ func (c Client) GetFee() (*big.Int, error) {
 	return fee, err
 }
 
-var Q96 = new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1), 96))
+// priceScale 价格保留的小数位, 覆盖不同精度代币之间的价格
+const priceScale = 36
 
-func (c Client) Slot0() (*big.Float, *big.Int, error) {
+// Q192 sqrtPriceX96 平方后的分母 2^192
+var Q192 = new(big.Int).Lsh(big.NewInt(1), 192)
+
+// Slot0 返回按代币精度调整后的 token1/token0 价格、token0/token1 价格和当前 tick
+func (c Client) Slot0(decimals0, decimals1 uint8) (common2.Decimal, common2.Decimal, *big.Int, error) {
 	rsp, err := c.UniswapV3Pool.Slot0(&bind.CallOpts{
 		Context: context.Background(),
 	})
 	if err != nil {
-		return nil, nil, err
+		return common2.Decimal{}, common2.Decimal{}, nil, err
+	}
+	price, inverse := SqrtPriceX96Price(rsp.SqrtPriceX96, decimals0, decimals1)
+	return price, inverse, rsp.Tick, nil
+}
+
+// State 读取池子的 slot0 和活跃流动性
+func (i *UniswapV3Indexer) State(ctx context.Context, pool common.Address) (V3State, error) {
+	caller, err := uniswapv3.NewUniswapV3PoolCaller(pool, i.client)
+	if err != nil {
+		return V3State{}, err
+	}
+	slot0, err := caller.Slot0(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return V3State{}, fmt.Errorf("read slot0 of %s: %w", pool, err)
+	}
+	liquidity, err := caller.Liquidity(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return V3State{}, fmt.Errorf("read liquidity of %s: %w", pool, err)
+	}
+	return V3State{
+		SqrtPriceX96: slot0.SqrtPriceX96,
+		Tick:         int32(slot0.Tick.Int64()),
+		Liquidity:    liquidity,
+	}, nil
+}
+
+// Ticks 按 tickBitmap 读取 [lower, upper] 内已初始化的 tick, 按 Index 升序
+func (i *UniswapV3Indexer) Ticks(ctx context.Context, pool common.Address, tickSpacing, lower, upper int32) ([]V3Tick, error) {
+	if tickSpacing <= 0 {
+		return nil, fmt.Errorf("invalid tick spacing %d", tickSpacing)
+	}
+	caller, err := uniswapv3.NewUniswapV3PoolCaller(pool, i.client)
+	if err != nil {
+		return nil, err
 	}
-	// 转为 big.Float
-	sqrtPrice := new(big.Float).SetInt(rsp.SqrtPriceX96)
-	// 除以 2^96
-	ratio := new(big.Float).Quo(sqrtPrice, Q96)
-	// 平方得到 token1/token0 的价格
-	price := new(big.Float).Mul(ratio, ratio)
 
-	return price, rsp.Tick, nil
+	// tickBitmap 的每个字保存 256 个压缩后的 tick, 压缩后的 tick = floor(tick / tickSpacing)
+	compress := func(tick int32) int32 {
+		compressed := tick / tickSpacing
+		if tick < 0 && tick%tickSpacing != 0 {
+			compressed--
+		}
+		return compressed
+	}
+	ticks := make([]V3Tick, 0)
+	for word := compress(lower) >> 8; word <= compress(upper)>>8; word++ {
+		bitmap, err := caller.TickBitmap(&bind.CallOpts{Context: ctx}, int16(word))
+		if err != nil {
+			return nil, fmt.Errorf("read tick bitmap %d of %s: %w", word, pool, err)
+		}
+		for bit := 0; bit < 256; bit++ {
+			if bitmap.Bit(bit) == 0 {
+				continue
+			}
+			index := (word<<8 + int32(bit)) * tickSpacing
+			if index < lower || index > upper {
+				continue
+			}
+			tick, err := caller.Ticks(&bind.CallOpts{Context: ctx}, big.NewInt(int64(index)))
+			if err != nil {
+				return nil, fmt.Errorf("read tick %d of %s: %w", index, pool, err)
+			}
+			ticks = append(ticks, V3Tick{Index: index, LiquidityNet: tick.LiquidityNet})
+		}
+	}
+	return ticks, nil
+}
+
+// v3PoolFilterer 解析任意池子的日志, 只使用 ABI, 不绑定合约地址
+var v3PoolFilterer, _ = uniswapv3.NewUniswapV3PoolFilterer(common.Address{}, nil)
+
+// SwapTopic 池子成交的 Swap 事件签名, 事件中包含成交后的 sqrtPriceX96
+var SwapTopic = crypto.Keccak256Hash([]byte("Swap(address,address,int256,int256,uint160,uint128,int24)"))
+
+// ParseSwap 解析 Swap 事件中成交后的池子状态
+func ParseSwap(log types.Log) (V3State, error) {
+	event, err := v3PoolFilterer.ParseSwap(log)
+	if err != nil {
+		return V3State{}, err
+	}
+	return V3State{
+		SqrtPriceX96: event.SqrtPriceX96,
+		Tick:         int32(event.Tick.Int64()),
+		Liquidity:    event.Liquidity,
+	}, nil
 }
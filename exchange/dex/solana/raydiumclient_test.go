@@ -6,31 +6,33 @@ import (
 	"log"
 	"net/http"
 	"testing"
+
+	"github.com/339-Labs/exchange-market/common"
 )
 
 // 定义结构体用于解析 JSON 响应
 type Pair struct {
-	PairID          string  `json:"pair_id"`   // 交易对的唯一标识符
-	Name            string  `json:"name"`      // 交易对名称(如 SOL/USDC)
-	LpMint          string  `json:"lp_mint"`   // 流动性提供者(LP)代币的铸币地址
-	Official        bool    `json:"official"`  // 布尔值，表示是否是官方交易对
-	Liquidity       float64 `json:"liquidity"` // 流动性池的总流动性
-	Market          string  `json:"market"`
-	Volume          float64 `json:"volume_24h"`
-	VolumeQuote     float64 `json:"volume_24h_quote"`
-	Fee             float64 `json:"fee_24h"`
-	FeeQuote        float64 `json:"fee_24h_quote"`
-	VolumeD         float64 `json:"volume_7d"`
-	VolumeDQuote    float64 `json:"volume_7d_quote"`
-	FeeD            float64 `json:"fee_7d"`
-	FeeDQuote       float64 `json:"fee_7d_quote"`
-	Price           float64 `json:"price"`             // 当前价格(可能是基础代币相对于报价代币的价格)
-	LpPrice         float64 `json:"lp_price"`          // LP 代币的价格
-	AmmId           string  `json:"amm_id"`            // 自动化做市商(AMM)合约的地址
-	TokenAmountCoin float64 `json:"token_amount_coin"` // 基础代币(如 SOL)的数量
-	TokenAmountPc   float64 `json:"token_amount_pc"`   // 报价代币(如 USDC)的数量
-	TokenAmountLp   float64 `json:"token_amount_lp"`   // LP 代币的总供应量
-	Apy             float64 `json:"apy"`               // 年化收益率(基于手续费收入等计算)
+	PairID          string         `json:"pair_id"`   // 交易对的唯一标识符
+	Name            string         `json:"name"`      // 交易对名称(如 SOL/USDC)
+	LpMint          string         `json:"lp_mint"`   // 流动性提供者(LP)代币的铸币地址
+	Official        bool           `json:"official"`  // 布尔值，表示是否是官方交易对
+	Liquidity       common.Decimal `json:"liquidity"` // 流动性池的总流动性
+	Market          string         `json:"market"`
+	Volume          common.Decimal `json:"volume_24h"`
+	VolumeQuote     common.Decimal `json:"volume_24h_quote"`
+	Fee             common.Decimal `json:"fee_24h"`
+	FeeQuote        common.Decimal `json:"fee_24h_quote"`
+	VolumeD         common.Decimal `json:"volume_7d"`
+	VolumeDQuote    common.Decimal `json:"volume_7d_quote"`
+	FeeD            common.Decimal `json:"fee_7d"`
+	FeeDQuote       common.Decimal `json:"fee_7d_quote"`
+	Price           common.Decimal `json:"price"`             // 当前价格(可能是基础代币相对于报价代币的价格)
+	LpPrice         common.Decimal `json:"lp_price"`          // LP 代币的价格
+	AmmId           string         `json:"amm_id"`            // 自动化做市商(AMM)合约的地址
+	TokenAmountCoin common.Decimal `json:"token_amount_coin"` // 基础代币(如 SOL)的数量
+	TokenAmountPc   common.Decimal `json:"token_amount_pc"`   // 报价代币(如 USDC)的数量
+	TokenAmountLp   common.Decimal `json:"token_amount_lp"`   // LP 代币的总供应量
+	Apy             common.Decimal `json:"apy"`               // 年化收益率(基于手续费收入等计算)
 }
 
 type TokenMappingLp struct {
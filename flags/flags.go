@@ -1,6 +1,9 @@
 package flags
 
-import "github.com/urfave/cli/v2"
+import (
+	"github.com/urfave/cli/v2"
+	"time"
+)
 
 const envVarPrefix = "MARKET"
 
@@ -76,6 +79,131 @@ var (
 		Usage:   "The username of the redis",
 		EnvVars: prefixEnvVars("REDIS_USER_NAME"),
 	}
+	RedisLegacyKeysFlag = &cli.BoolFlag{
+		Name:    "redis-legacy-keys",
+		Usage:   "Also write the legacy market_data:<symbol> keys for readers not yet migrated to market:<exchange>:<inst type>:<symbol>",
+		EnvVars: prefixEnvVars("REDIS_LEGACY_KEYS"),
+		Value:   true,
+	}
+
+	// universe flags
+	UniverseQuotesFlag = &cli.StringSliceFlag{
+		Name:    "universe-quotes",
+		Usage:   "The quote currencies of subscribed symbols, e.g. USDT,USDC",
+		EnvVars: prefixEnvVars("UNIVERSE_QUOTES"),
+		Value:   cli.NewStringSlice("USDT"),
+	}
+	UniverseAllowFlag = &cli.StringSliceFlag{
+		Name:    "universe-allow",
+		Usage:   "Only subscribe these coins or symbols, e.g. BTC,ETH/USDT; empty means all",
+		EnvVars: prefixEnvVars("UNIVERSE_ALLOW"),
+	}
+	UniverseDenyFlag = &cli.StringSliceFlag{
+		Name:    "universe-deny",
+		Usage:   "Never subscribe these coins or symbols",
+		EnvVars: prefixEnvVars("UNIVERSE_DENY"),
+	}
+	UniverseRefreshFlag = &cli.DurationFlag{
+		Name:    "universe-refresh",
+		Usage:   "The interval of reloading subscribed symbols from market_symbol",
+		EnvVars: prefixEnvVars("UNIVERSE_REFRESH"),
+		Value:   5 * time.Minute,
+	}
+
+	IndexSymbolsFlag = &cli.StringSliceFlag{
+		Name:    "index-symbols",
+		Usage:   "The unified symbols to compute composite index prices for, e.g. BTC/USDT,ETH/USDT; empty disables the index",
+		EnvVars: prefixEnvVars("INDEX_SYMBOLS"),
+	}
+	IndexMethodFlag = &cli.StringFlag{
+		Name:    "index-method",
+		Usage:   "The index method: median, volume or liquidity",
+		EnvVars: prefixEnvVars("INDEX_METHOD"),
+		Value:   "median",
+	}
+	IndexIntervalFlag = &cli.DurationFlag{
+		Name:    "index-interval",
+		Usage:   "The interval of computing index prices",
+		EnvVars: prefixEnvVars("INDEX_INTERVAL"),
+		Value:   time.Second,
+	}
+	IndexMaxAgeFlag = &cli.DurationFlag{
+		Name:    "index-max-age",
+		Usage:   "Drop exchange prices not updated within this duration",
+		EnvVars: prefixEnvVars("INDEX_MAX_AGE"),
+		Value:   30 * time.Second,
+	}
+	IndexMaxDeviationFlag = &cli.Float64Flag{
+		Name:    "index-max-deviation",
+		Usage:   "Drop exchange prices deviating from the median by more than this ratio, 0 disables",
+		EnvVars: prefixEnvVars("INDEX_MAX_DEVIATION"),
+		Value:   0.05,
+	}
+	IndexMinSourcesFlag = &cli.IntFlag{
+		Name:    "index-min-sources",
+		Usage:   "The minimum number of exchanges required to publish an index price",
+		EnvVars: prefixEnvVars("INDEX_MIN_SOURCES"),
+		Value:   1,
+	}
+
+	ArbitrageEnabledFlag = &cli.BoolFlag{
+		Name:    "arbitrage",
+		Usage:   "Detect cross-exchange arbitrage opportunities from best bid/ask quotes",
+		EnvVars: prefixEnvVars("ARBITRAGE"),
+	}
+	ArbitrageTakerFeesFlag = &cli.StringSliceFlag{
+		Name:    "arbitrage-taker-fees",
+		Usage:   "The taker fee rate of each exchange, e.g. bn=0.001,okx=0.0008",
+		EnvVars: prefixEnvVars("ARBITRAGE_TAKER_FEES"),
+	}
+	ArbitrageDefaultFeeFlag = &cli.Float64Flag{
+		Name:    "arbitrage-default-fee",
+		Usage:   "The taker fee rate of exchanges not listed in --arbitrage-taker-fees",
+		EnvVars: prefixEnvVars("ARBITRAGE_DEFAULT_FEE"),
+		Value:   0.001,
+	}
+	ArbitrageThresholdFlag = &cli.Float64Flag{
+		Name:    "arbitrage-threshold",
+		Usage:   "The minimum net edge ratio after fees, e.g. 0.001 for 10 bps",
+		EnvVars: prefixEnvVars("ARBITRAGE_THRESHOLD"),
+		Value:   0.001,
+	}
+	ArbitrageMinDurationFlag = &cli.DurationFlag{
+		Name:    "arbitrage-min-duration",
+		Usage:   "The net edge must stay above the threshold for this duration before an opportunity is emitted",
+		EnvVars: prefixEnvVars("ARBITRAGE_MIN_DURATION"),
+		Value:   3 * time.Second,
+	}
+	ArbitrageIntervalFlag = &cli.DurationFlag{
+		Name:    "arbitrage-interval",
+		Usage:   "The interval of comparing quotes",
+		EnvVars: prefixEnvVars("ARBITRAGE_INTERVAL"),
+		Value:   500 * time.Millisecond,
+	}
+	ArbitrageMaxAgeFlag = &cli.DurationFlag{
+		Name:    "arbitrage-max-age",
+		Usage:   "Ignore quotes not updated within this duration",
+		EnvVars: prefixEnvVars("ARBITRAGE_MAX_AGE"),
+		Value:   5 * time.Second,
+	}
+
+	FundingEnabledFlag = &cli.BoolFlag{
+		Name:    "funding",
+		Usage:   "Compute annualized funding, basis and carry rankings, and record funding settlements into funding_rate_history",
+		EnvVars: prefixEnvVars("FUNDING"),
+	}
+	FundingIntervalFlag = &cli.DurationFlag{
+		Name:    "funding-interval",
+		Usage:   "The interval of computing funding analytics",
+		EnvVars: prefixEnvVars("FUNDING_INTERVAL"),
+		Value:   10 * time.Second,
+	}
+	FundingPeriodFlag = &cli.DurationFlag{
+		Name:    "funding-period",
+		Usage:   "The funding settlement period used until the real period is observed from the exchange",
+		EnvVars: prefixEnvVars("FUNDING_PERIOD"),
+		Value:   8 * time.Hour,
+	}
 
 	// bn flags
 	BnApiKeyFlag = &cli.StringFlag{
@@ -94,10 +222,9 @@ var (
 		EnvVars: prefixEnvVars("BN_API_URL"),
 	}
 	BnWsUrlFlag = &cli.StringFlag{
-		Name:     "bn-ws-url",
-		Usage:    "The ws url of the bn",
-		EnvVars:  prefixEnvVars("BN_WS_URL"),
-		Required: true,
+		Name:    "bn-ws-url",
+		Usage:   "The ws url of the bn",
+		EnvVars: prefixEnvVars("BN_WS_URL"),
 	}
 	BnWsUrlFeature = &cli.StringFlag{
 		Name:    "bn-ws-url-feature",
@@ -110,10 +237,9 @@ var (
 		EnvVars: prefixEnvVars("BN_PASSPHRASE"),
 	}
 	BnTimeOut = &cli.IntFlag{
-		Name:     "bn-timeout",
-		Usage:    "The timeout of the bn",
-		EnvVars:  prefixEnvVars("BN_TIMEOUT"),
-		Required: true,
+		Name:    "bn-timeout",
+		Usage:   "The timeout of the bn",
+		EnvVars: prefixEnvVars("BN_TIMEOUT"),
 	}
 
 	// okx flags
@@ -133,10 +259,9 @@ var (
 		EnvVars: prefixEnvVars("OKX_API_URL"),
 	}
 	OkxWsUrlFlag = &cli.StringFlag{
-		Name:     "okx-ws-url",
-		Usage:    "The ws url of the okx",
-		EnvVars:  prefixEnvVars("OKX_WS_URL"),
-		Required: true,
+		Name:    "okx-ws-url",
+		Usage:   "The ws url of the okx",
+		EnvVars: prefixEnvVars("OKX_WS_URL"),
 	}
 	OkxPassphrase = &cli.StringFlag{
 		Name:    "okx-passphrase",
@@ -144,10 +269,9 @@ var (
 		EnvVars: prefixEnvVars("OKX_PASSPHRASE"),
 	}
 	OkxTimeOut = &cli.IntFlag{
-		Name:     "okx-timeout",
-		Usage:    "The timeout of the okx",
-		EnvVars:  prefixEnvVars("OKX_TIMEOUT"),
-		Required: true,
+		Name:    "okx-timeout",
+		Usage:   "The timeout of the okx",
+		EnvVars: prefixEnvVars("OKX_TIMEOUT"),
 	}
 
 	// bybit flags
@@ -167,10 +291,9 @@ var (
 		EnvVars: prefixEnvVars("BYBIT_API_URL"),
 	}
 	ByBitWsUrlFlag = &cli.StringFlag{
-		Name:     "bybit-ws-url",
-		Usage:    "The ws url of the bybit",
-		EnvVars:  prefixEnvVars("BYBIT_WS_URL"),
-		Required: true,
+		Name:    "bybit-ws-url",
+		Usage:   "The ws url of the bybit",
+		EnvVars: prefixEnvVars("BYBIT_WS_URL"),
 	}
 	ByBitWsUrlFeature = &cli.StringFlag{
 		Name:    "bybit-ws-url-feature",
@@ -183,10 +306,9 @@ var (
 		EnvVars: prefixEnvVars("BYBIT_PASSPHRASE"),
 	}
 	ByBitTimeOut = &cli.IntFlag{
-		Name:     "bybit-timeout",
-		Usage:    "The timeout of the bybit",
-		EnvVars:  prefixEnvVars("BYBIT_TIMEOUT"),
-		Required: true,
+		Name:    "bybit-timeout",
+		Usage:   "The timeout of the bybit",
+		EnvVars: prefixEnvVars("BYBIT_TIMEOUT"),
 	}
 
 	// bitget flags
@@ -206,10 +328,9 @@ var (
 		EnvVars: prefixEnvVars("BITGET_API_URL"),
 	}
 	BitGetWsUrlFlag = &cli.StringFlag{
-		Name:     "bitget-ws-url",
-		Usage:    "The ws url of the bitget",
-		EnvVars:  prefixEnvVars("BITGET_WS_URL"),
-		Required: true,
+		Name:    "bitget-ws-url",
+		Usage:   "The ws url of the bitget",
+		EnvVars: prefixEnvVars("BITGET_WS_URL"),
 	}
 	BitGetPassphrase = &cli.StringFlag{
 		Name:    "bitget-passphrase",
@@ -217,10 +338,123 @@ var (
 		EnvVars: prefixEnvVars("BITGET_PASSPHRASE"),
 	}
 	BitGetTimeOut = &cli.IntFlag{
-		Name:     "bitget-timeout",
-		Usage:    "The timeout of the bitget",
-		EnvVars:  prefixEnvVars("BITGET_TIMEOUT"),
-		Required: true,
+		Name:    "bitget-timeout",
+		Usage:   "The timeout of the bitget",
+		EnvVars: prefixEnvVars("BITGET_TIMEOUT"),
+	}
+
+	// gateio flags
+	GateIoApiKeyFlag = &cli.StringFlag{
+		Name:    "gateio-api-key",
+		Usage:   "The apikey of the gateio",
+		EnvVars: prefixEnvVars("GATEIO_API_KEY"),
+	}
+	GateIoApiSecretKeyFlag = &cli.StringFlag{
+		Name:    "gateio-api-secret-key",
+		Usage:   "The secret of the gateio",
+		EnvVars: prefixEnvVars("GATEIO_API_SECRET_KEY"),
+	}
+	GateIoApiUrlFlag = &cli.StringFlag{
+		Name:    "gateio-api-url",
+		Usage:   "The api url of the gateio",
+		EnvVars: prefixEnvVars("GATEIO_API_URL"),
+	}
+	GateIoWsUrlFlag = &cli.StringFlag{
+		Name:    "gateio-ws-url",
+		Usage:   "The spot ws url of the gateio",
+		EnvVars: prefixEnvVars("GATEIO_WS_URL"),
+	}
+	GateIoWsUrlFeature = &cli.StringFlag{
+		Name:    "gateio-ws-url-feature",
+		Usage:   "The usdt futures ws url of the gateio",
+		EnvVars: prefixEnvVars("GATEIO_WS_URL_FEATURE"),
+	}
+	GateIoTimeOut = &cli.IntFlag{
+		Name:    "gateio-timeout",
+		Usage:   "The timeout of the gateio",
+		EnvVars: prefixEnvVars("GATEIO_TIMEOUT"),
+	}
+
+	// uniswap v2 flags
+	UniswapV2RpcUrlFlag = &cli.StringFlag{
+		Name:    "uniswap-v2-rpc-url",
+		Usage:   "The rpc url of the chain the uniswap v2 factory is deployed on; empty disables uniswap v2",
+		EnvVars: prefixEnvVars("UNISWAP_V2_RPC_URL"),
+	}
+	UniswapV2WsRpcUrlFlag = &cli.StringFlag{
+		Name:    "uniswap-v2-ws-rpc-url",
+		Usage:   "The websocket rpc url of the chain the uniswap v2 factory is deployed on",
+		EnvVars: prefixEnvVars("UNISWAP_V2_WS_RPC_URL"),
+	}
+	UniswapV2FactoryFlag = &cli.StringFlag{
+		Name:    "uniswap-v2-factory",
+		Usage:   "The uniswap v2 factory address, e.g. 0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f",
+		EnvVars: prefixEnvVars("UNISWAP_V2_FACTORY"),
+	}
+	UniswapV2PoolsFlag = &cli.StringSliceFlag{
+		Name:    "uniswap-v2-pools",
+		Usage:   "The pair addresses to price from reserves, at most one per unified symbol, e.g. 0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc for WETH/USDC; empty only discovers pairs",
+		EnvVars: prefixEnvVars("UNISWAP_V2_POOLS"),
+	}
+	UniswapV2IntervalFlag = &cli.DurationFlag{
+		Name:    "uniswap-v2-interval",
+		Usage:   "The interval of discovering pairs and refreshing reserves",
+		EnvVars: prefixEnvVars("UNISWAP_V2_INTERVAL"),
+		Value:   15 * time.Second,
+	}
+	UniswapV2BatchSizeFlag = &cli.IntFlag{
+		Name:    "uniswap-v2-batch-size",
+		Usage:   "The max number of pairs discovered from allPairs per interval",
+		EnvVars: prefixEnvVars("UNISWAP_V2_BATCH_SIZE"),
+		Value:   100,
+	}
+	UniswapV2ConfirmationsFlag = &cli.Uint64Flag{
+		Name:    "uniswap-v2-confirmations",
+		Usage:   "The blocks a Sync log or new pair must be buried under before it is applied; 0 applies Sync logs at head and rolls them back on reorg",
+		EnvVars: prefixEnvVars("UNISWAP_V2_CONFIRMATIONS"),
+	}
+
+	// uniswap v3 flags
+	UniswapV3RpcUrlFlag = &cli.StringFlag{
+		Name:    "uniswap-v3-rpc-url",
+		Usage:   "The rpc url of the chain the uniswap v3 factory is deployed on; empty disables uniswap v3",
+		EnvVars: prefixEnvVars("UNISWAP_V3_RPC_URL"),
+	}
+	UniswapV3WsRpcUrlFlag = &cli.StringFlag{
+		Name:    "uniswap-v3-ws-rpc-url",
+		Usage:   "The websocket rpc url of the chain the uniswap v3 factory is deployed on",
+		EnvVars: prefixEnvVars("UNISWAP_V3_WS_RPC_URL"),
+	}
+	UniswapV3FactoryFlag = &cli.StringFlag{
+		Name:    "uniswap-v3-factory",
+		Usage:   "The uniswap v3 factory address, e.g. 0x1F98431c8aD98523631AE4a59f267346ea31F984",
+		EnvVars: prefixEnvVars("UNISWAP_V3_FACTORY"),
+	}
+	UniswapV3StartBlockFlag = &cli.Uint64Flag{
+		Name:    "uniswap-v3-start-block",
+		Usage:   "The block the uniswap v3 factory was deployed at, PoolCreated events are backfilled from it, e.g. 12369621 on ethereum",
+		EnvVars: prefixEnvVars("UNISWAP_V3_START_BLOCK"),
+	}
+	UniswapV3PoolsFlag = &cli.StringSliceFlag{
+		Name:    "uniswap-v3-pools",
+		Usage:   "The pool addresses to price from slot0 and Swap logs, at most one per unified symbol, e.g. 0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640 for WETH/USDC 0.05%; empty only discovers pools",
+		EnvVars: prefixEnvVars("UNISWAP_V3_POOLS"),
+	}
+	UniswapV3DepthPercentsFlag = &cli.StringSliceFlag{
+		Name:    "uniswap-v3-depth-percents",
+		Usage:   "The price moves in percent the synthetic order book is built for, e.g. 0.5,1,2; empty disables depth",
+		EnvVars: prefixEnvVars("UNISWAP_V3_DEPTH_PERCENTS"),
+	}
+	UniswapV3IntervalFlag = &cli.DurationFlag{
+		Name:    "uniswap-v3-interval",
+		Usage:   "The interval of following new PoolCreated events and refreshing depth",
+		EnvVars: prefixEnvVars("UNISWAP_V3_INTERVAL"),
+		Value:   15 * time.Second,
+	}
+	UniswapV3ConfirmationsFlag = &cli.Uint64Flag{
+		Name:    "uniswap-v3-confirmations",
+		Usage:   "The blocks a Swap log or PoolCreated event must be buried under before it is applied; 0 applies Swap logs at head and rolls them back on reorg",
+		EnvVars: prefixEnvVars("UNISWAP_V3_CONFIRMATIONS"),
 	}
 )
 
@@ -240,6 +474,32 @@ var requireFlags = []cli.Flag{
 	RedisUserNameFlag,
 }
 var optionalFlags = []cli.Flag{
+	RedisLegacyKeysFlag,
+
+	UniverseQuotesFlag,
+	UniverseAllowFlag,
+	UniverseDenyFlag,
+	UniverseRefreshFlag,
+
+	IndexSymbolsFlag,
+	IndexMethodFlag,
+	IndexIntervalFlag,
+	IndexMaxAgeFlag,
+	IndexMaxDeviationFlag,
+	IndexMinSourcesFlag,
+
+	ArbitrageEnabledFlag,
+	ArbitrageTakerFeesFlag,
+	ArbitrageDefaultFeeFlag,
+	ArbitrageThresholdFlag,
+	ArbitrageMinDurationFlag,
+	ArbitrageIntervalFlag,
+	ArbitrageMaxAgeFlag,
+
+	FundingEnabledFlag,
+	FundingIntervalFlag,
+	FundingPeriodFlag,
+
 	BnApiKeyFlag,
 	BnApiSecretKeyFlag,
 	BnApiUrlFlag,
@@ -269,10 +529,68 @@ var optionalFlags = []cli.Flag{
 	BitGetWsUrlFlag,
 	BitGetPassphrase,
 	BitGetTimeOut,
+
+	GateIoApiKeyFlag,
+	GateIoApiSecretKeyFlag,
+	GateIoApiUrlFlag,
+	GateIoWsUrlFlag,
+	GateIoWsUrlFeature,
+	GateIoTimeOut,
+
+	UniswapV2RpcUrlFlag,
+	UniswapV2WsRpcUrlFlag,
+	UniswapV2FactoryFlag,
+	UniswapV2PoolsFlag,
+	UniswapV2IntervalFlag,
+	UniswapV2BatchSizeFlag,
+	UniswapV2ConfirmationsFlag,
+
+	UniswapV3RpcUrlFlag,
+	UniswapV3WsRpcUrlFlag,
+	UniswapV3FactoryFlag,
+	UniswapV3StartBlockFlag,
+	UniswapV3PoolsFlag,
+	UniswapV3DepthPercentsFlag,
+	UniswapV3IntervalFlag,
+	UniswapV3ConfirmationsFlag,
 }
 
 var Flags []cli.Flag
 
+// run command flags
+var (
+	ExchangesFlag = &cli.StringSliceFlag{
+		Name:    "exchanges",
+		Usage:   "The exchanges to run, e.g. bn,okx,bybit,bitget,gateio",
+		EnvVars: prefixEnvVars("EXCHANGES"),
+	}
+	AllExchangesFlag = &cli.BoolFlag{
+		Name:    "all",
+		Usage:   "Run all supported exchanges",
+		EnvVars: prefixEnvVars("ALL_EXCHANGES"),
+	}
+)
+
+// sync-symbols command flags
+var (
+	SymbolSyncIntervalFlag = &cli.DurationFlag{
+		Name:    "interval",
+		Usage:   "The interval of syncing symbols from exchanges",
+		EnvVars: prefixEnvVars("SYMBOL_SYNC_INTERVAL"),
+		Value:   time.Hour,
+	}
+	SymbolSyncOnceFlag = &cli.BoolFlag{
+		Name:    "once",
+		Usage:   "Sync symbols once and exit",
+		EnvVars: prefixEnvVars("SYMBOL_SYNC_ONCE"),
+	}
+)
+
+var RunFlags []cli.Flag
+var SyncSymbolsFlags []cli.Flag
+
 func init() {
 	Flags = append(requireFlags, optionalFlags...)
+	RunFlags = append([]cli.Flag{ExchangesFlag, AllExchangesFlag}, Flags...)
+	SyncSymbolsFlags = append([]cli.Flag{ExchangesFlag, AllExchangesFlag, SymbolSyncIntervalFlag, SymbolSyncOnceFlag}, Flags...)
 }
@@ -0,0 +1,9 @@
+ALTER TABLE market_symbol ADD COLUMN IF NOT EXISTS tick_size VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE market_symbol ADD COLUMN IF NOT EXISTS lot_size VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE market_symbol ADD COLUMN IF NOT EXISTS min_qty VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE market_symbol ADD COLUMN IF NOT EXISTS min_notional VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE market_symbol ADD COLUMN IF NOT EXISTS contract_value VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE market_symbol ADD COLUMN IF NOT EXISTS settle VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE market_symbol ADD COLUMN IF NOT EXISTS list_time BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE market_symbol ADD COLUMN IF NOT EXISTS expiry_time BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_market_symbol_expiry ON market_symbol(inst_type, expiry_time);
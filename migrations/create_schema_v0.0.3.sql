@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS arbitrage_opportunity (
+    guid        VARCHAR PRIMARY KEY,
+    unified_symbol        VARCHAR NOT NULL,
+    inst_type   VARCHAR NOT NULL,
+    buy_exchange      VARCHAR NOT NULL,
+    sell_exchange      VARCHAR NOT NULL,
+    buy_price VARCHAR NOT NULL,
+    sell_price VARCHAR NOT NULL,
+    size VARCHAR NOT NULL DEFAULT '',
+    gross_edge VARCHAR NOT NULL,
+    net_edge VARCHAR NOT NULL,
+    max_net_edge VARCHAR NOT NULL,
+    start_time   BIGINT NOT NULL CHECK (start_time > 0),
+    end_time   BIGINT NOT NULL DEFAULT 0,
+    timestamp   BIGINT NOT NULL CHECK (timestamp > 0)
+);
+CREATE INDEX IF NOT EXISTS idx_arbitrage_opportunity_symbol ON arbitrage_opportunity(unified_symbol, inst_type, start_time);
+CREATE INDEX IF NOT EXISTS idx_arbitrage_opportunity_start ON arbitrage_opportunity(start_time);
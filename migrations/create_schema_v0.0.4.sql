@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS funding_rate_history (
+    guid        VARCHAR PRIMARY KEY,
+    exchange      VARCHAR NOT NULL,
+    symbol        VARCHAR NOT NULL,
+    unified_symbol        VARCHAR NOT NULL,
+    funding_rate VARCHAR NOT NULL,
+    funding_time   BIGINT NOT NULL CHECK (funding_time > 0),
+    timestamp   BIGINT NOT NULL CHECK (timestamp > 0)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uk_funding_rate_history ON funding_rate_history(exchange, symbol, funding_time);
+CREATE INDEX IF NOT EXISTS idx_funding_rate_history_symbol ON funding_rate_history(unified_symbol, funding_time);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS dex_pool (
+    guid        VARCHAR PRIMARY KEY,
+    exchange      VARCHAR NOT NULL,
+    chain_id      VARCHAR NOT NULL,
+    address      VARCHAR NOT NULL,
+    token0      VARCHAR NOT NULL,
+    token1      VARCHAR NOT NULL,
+    symbol0      VARCHAR NOT NULL,
+    symbol1      VARCHAR NOT NULL,
+    decimals0   SMALLINT NOT NULL,
+    decimals1   SMALLINT NOT NULL,
+    timestamp   BIGINT NOT NULL CHECK (timestamp > 0)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uk_dex_pool ON dex_pool(exchange, chain_id, address);
+
+CREATE TABLE IF NOT EXISTS dex_checkpoint (
+    exchange      VARCHAR NOT NULL,
+    chain_id      VARCHAR NOT NULL,
+    name      VARCHAR NOT NULL,
+    value   BIGINT NOT NULL DEFAULT 0,
+    timestamp   BIGINT NOT NULL CHECK (timestamp > 0),
+    PRIMARY KEY (exchange, chain_id, name)
+);
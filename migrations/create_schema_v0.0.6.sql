@@ -0,0 +1,4 @@
+ALTER TABLE dex_pool ADD COLUMN IF NOT EXISTS fee INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE dex_pool ADD COLUMN IF NOT EXISTS tick_spacing INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE dex_pool ADD COLUMN IF NOT EXISTS block BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_dex_pool_block ON dex_pool(exchange, chain_id, block);
@@ -0,0 +1,17 @@
+ALTER TABLE market_symbol ADD COLUMN IF NOT EXISTS status VARCHAR NOT NULL DEFAULT 'Trading';
+ALTER TABLE market_symbol ALTER COLUMN timestamp TYPE BIGINT;
+ALTER TABLE symbol_spot_prices ALTER COLUMN timestamp TYPE BIGINT;
+ALTER TABLE symbol_futures_prices ALTER COLUMN timestamp TYPE BIGINT;
+
+-- 建唯一索引前保留每个交易对最新的一行
+DELETE FROM market_symbol a USING market_symbol b
+WHERE a.exchange = b.exchange AND a.inst_type = b.inst_type AND a.symbol = b.symbol AND (a.timestamp, a.ctid) < (b.timestamp, b.ctid);
+DELETE FROM symbol_spot_prices a USING symbol_spot_prices b
+WHERE a.exchange = b.exchange AND a.symbol = b.symbol AND (a.timestamp, a.ctid) < (b.timestamp, b.ctid);
+DELETE FROM symbol_futures_prices a USING symbol_futures_prices b
+WHERE a.exchange = b.exchange AND a.symbol = b.symbol AND (a.timestamp, a.ctid) < (b.timestamp, b.ctid);
+
+CREATE INDEX IF NOT EXISTS idx_market_symbol ON market_symbol(exchange, chain_id,inst_type);
+CREATE UNIQUE INDEX IF NOT EXISTS uk_market_symbol ON market_symbol(exchange, inst_type, symbol);
+CREATE UNIQUE INDEX IF NOT EXISTS uk_symbol_spot_prices ON symbol_spot_prices(exchange, symbol);
+CREATE UNIQUE INDEX IF NOT EXISTS uk_symbol_futures_prices ON symbol_futures_prices(exchange, symbol);
@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/index"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+// SetIndexPrices 批量写入指数价格, 成分以 json 保存在 constituents 字段, ttl 为0时不过期
+func (r *RedisClient) SetIndexPrices(ctx context.Context, results []*index.Result, ttl time.Duration) error {
+	if r.isClientClosed() {
+		return redis.ErrClosed
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if len(results) == 0 {
+		return nil
+	}
+
+	pipe := r.rdb.Pipeline()
+	for _, result := range results {
+		constituents, err := json.Marshal(result.Constituents)
+		if err != nil {
+			return fmt.Errorf("marshal index constituents %s: %w", result.Symbol, err)
+		}
+
+		key := IndexKey(result.InstType, result.Symbol)
+		pipe.HSet(ctx, key, map[string]interface{}{
+			"symbol":       result.Symbol,
+			"inst_type":    result.InstType,
+			"method":       string(result.Method),
+			"price":        result.Price.String(),
+			"timestamp":    result.Timestamp,
+			"constituents": constituents,
+		})
+		if ttl > 0 {
+			pipe.Expire(ctx, key, ttl)
+		}
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetIndexPrice 获取指数价格
+func (r *RedisClient) GetIndexPrice(ctx context.Context, instType, unifiedSymbol string) (*index.Result, error) {
+	if r.isClientClosed() {
+		return nil, redis.ErrClosed
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	data, err := r.rdb.HGetAll(ctx, IndexKey(instType, unifiedSymbol)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return nil, redis.Nil
+	}
+
+	result := &index.Result{
+		Symbol:       data["symbol"],
+		InstType:     data["inst_type"],
+		Method:       index.Method(data["method"]),
+		Price:        common.DecimalFromString(data["price"]),
+		Constituents: []index.Constituent{},
+	}
+	result.Timestamp, _ = strconv.ParseInt(data["timestamp"], 10, 64)
+	if raw := data["constituents"]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &result.Constituents); err != nil {
+			return nil, fmt.Errorf("unmarshal index constituents %s: %w", unifiedSymbol, err)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,83 @@
+package redis
+
+import (
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/maps"
+	"strings"
+)
+
+// 行情键
+//
+//	market:{exchange}:{instType}:{unifiedSymbol}   行情hash, 例如 market:BN:perp:BTC/USDT
+//	market:idx:symbol:{unifiedSymbol}             交易对索引, 成员为各交易所的行情键
+//	market:idx:venue:{exchange}:{instType}        交易所索引, 成员为该交易所的行情键
+//	market_data:{symbol}                          旧的行情键, 不区分交易所和产品类型
+//	index:{instType}:{unifiedSymbol}              跨交易所指数价格hash, 例如 index:perp:BTC/USDT
+const (
+	marketKeyPrefix      = "market:"
+	symbolIndexKeyPrefix = "market:idx:symbol:"
+	venueIndexKeyPrefix  = "market:idx:venue:"
+	legacyKeyPrefix      = "market_data:"
+	indexKeyPrefix       = "index:"
+)
+
+// MarketKey 行情键
+func MarketKey(exchange, instType, unifiedSymbol string) string {
+	return marketKeyPrefix + exchange + ":" + instType + ":" + strings.ToUpper(unifiedSymbol)
+}
+
+// SymbolIndexKey 交易对索引键
+func SymbolIndexKey(unifiedSymbol string) string {
+	return symbolIndexKeyPrefix + strings.ToUpper(unifiedSymbol)
+}
+
+// VenueIndexKey 交易所索引键
+func VenueIndexKey(exchange, instType string) string {
+	return venueIndexKeyPrefix + exchange + ":" + instType
+}
+
+// IndexKey 指数价格键
+func IndexKey(instType, unifiedSymbol string) string {
+	return indexKeyPrefix + instType + ":" + strings.ToUpper(unifiedSymbol)
+}
+
+// LegacyKey 旧的行情键
+func LegacyKey(symbol string) string {
+	return legacyKeyPrefix + symbol
+}
+
+// priceDataKey 行情数据对应的键, 缺少交易所、产品类型或统一交易对时返回false
+func priceDataKey(priceData *maps.PriceData) (string, bool) {
+	if priceData.Exchange == "" || priceData.InstType == "" || priceData.UnifiedSymbol == "" {
+		return "", false
+	}
+	return MarketKey(priceData.Exchange, priceData.InstType, priceData.UnifiedSymbol), true
+}
+
+// priceFields 行情hash字段
+func priceFields(priceData *maps.PriceData) map[string]interface{} {
+	return map[string]interface{}{
+		"symbol":         priceData.Symbol,
+		"price":          priceData.Price.String(),
+		"funding_rate":   priceData.FundingRate.String(),
+		"mark_price":     priceData.MarkPrice.String(),
+		"timestamp":      priceData.Timestamp,
+		"exchange":       priceData.Exchange,
+		"inst_type":      priceData.InstType,
+		"unified_symbol": priceData.UnifiedSymbol,
+	}
+}
+
+// parsePriceData 解析行情hash
+func parsePriceData(data map[string]string) *maps.PriceData {
+	return &maps.PriceData{
+		Symbol:        data["symbol"],
+		Price:         common.DecimalFromString(data["price"]),
+		FundingRate:   common.DecimalFromString(data["funding_rate"]),
+		MarkPrice:     common.DecimalFromString(data["mark_price"]),
+		Timestamp:     data["timestamp"],
+		Exchange:      data["exchange"],
+		InstType:      data["inst_type"],
+		UnifiedSymbol: data["unified_symbol"],
+	}
+}
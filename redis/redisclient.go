@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/339-Labs/exchange-market/common/maps"
 	"github.com/339-Labs/exchange-market/config"
+	"github.com/ethereum/go-ethereum/log"
 	"sync"
 	"time"
 
@@ -16,6 +17,8 @@ type RedisClient struct {
 	mu     sync.RWMutex
 	pool   *redis.Ring // 可选：使用Redis集群
 	closed bool
+
+	legacyKeys bool // 同时写入旧的 market_data:{symbol} 键
 }
 
 // RedisConfig Redis配置
@@ -58,8 +61,9 @@ func NewRedisClient(config config.RedisConfig) (*RedisClient, error) {
 	}
 
 	return &RedisClient{
-		rdb:    rdb,
-		closed: false,
+		rdb:        rdb,
+		closed:     false,
+		legacyKeys: config.LegacyKeys,
 	}, nil
 }
 
@@ -76,6 +80,14 @@ func (r *RedisClient) Close() error {
 	return r.rdb.Close()
 }
 
+// Ping 检查redis连接
+func (r *RedisClient) Ping(ctx context.Context) error {
+	if r.isClientClosed() {
+		return redis.ErrClosed
+	}
+	return r.rdb.Ping(ctx).Err()
+}
+
 // isClientClosed 检查客户端是否已关闭
 func (r *RedisClient) isClientClosed() bool {
 	r.mu.RLock()
@@ -87,30 +99,23 @@ func (r *RedisClient) isClientClosed() bool {
 
 // SetPriceData 并发安全地存储行情数据
 func (r *RedisClient) SetPriceData(ctx context.Context, priceData *maps.PriceData) error {
-	if r.isClientClosed() {
-		return redis.ErrClosed
-	}
-
-	if ctx == nil {
-		ctx = context.Background()
-	}
-
-	key := "market_data:" + priceData.Symbol
-
-	// 使用HMSET原子性地设置所有字段
-	data := map[string]interface{}{
-		"symbol":       priceData.Symbol,
-		"price":        priceData.Price,
-		"funding_rate": priceData.FundingRate,
-		"mark_price":   priceData.MarkPrice,
-		"timestamp":    priceData.Timestamp,
-	}
-
-	return r.rdb.HMSet(ctx, key, data).Err()
+	return r.BatchSetPriceDataWithTTL(ctx, []*maps.PriceData{priceData}, 0)
 }
 
 // SetPriceDataWithTTL 并发安全地存储行情数据并设置过期时间
 func (r *RedisClient) SetPriceDataWithTTL(ctx context.Context, priceData *maps.PriceData, ttl time.Duration) error {
+	return r.BatchSetPriceDataWithTTL(ctx, []*maps.PriceData{priceData}, ttl)
+}
+
+// BatchSetPriceData 批量并发安全地存储多个行情数据
+func (r *RedisClient) BatchSetPriceData(ctx context.Context, priceDataList []*maps.PriceData) error {
+	return r.BatchSetPriceDataWithTTL(ctx, priceDataList, 0)
+}
+
+// BatchSetPriceDataWithTTL 批量存储行情数据并设置过期时间, ttl 为0时不过期
+// 带交易所、产品类型和统一交易对的数据写入 market:{exchange}:{instType}:{unifiedSymbol} 并更新索引,
+// 开启 LegacyKeys 或缺少这些字段时写入旧的 market_data:{symbol}
+func (r *RedisClient) BatchSetPriceDataWithTTL(ctx context.Context, priceDataList []*maps.PriceData, ttl time.Duration) error {
 	if r.isClientClosed() {
 		return redis.ErrClosed
 	}
@@ -119,94 +124,133 @@ func (r *RedisClient) SetPriceDataWithTTL(ctx context.Context, priceData *maps.P
 		ctx = context.Background()
 	}
 
-	key := "market_data:" + priceData.Symbol
+	if len(priceDataList) == 0 {
+		return nil
+	}
 
-	// 使用管道确保原子性
+	// 使用管道批量操作
 	pipe := r.rdb.Pipeline()
 
-	data := map[string]interface{}{
-		"symbol":       priceData.Symbol,
-		"price":        priceData.Price,
-		"funding_rate": priceData.FundingRate,
-		"mark_price":   priceData.MarkPrice,
-		"timestamp":    priceData.Timestamp,
-	}
+	for _, priceData := range priceDataList {
+		data := priceFields(priceData)
+
+		if key, ok := priceDataKey(priceData); ok {
+			symbolIndex := SymbolIndexKey(priceData.UnifiedSymbol)
+			venueIndex := VenueIndexKey(priceData.Exchange, priceData.InstType)
+
+			pipe.HSet(ctx, key, data)
+			pipe.SAdd(ctx, symbolIndex, key)
+			pipe.SAdd(ctx, venueIndex, key)
+			if ttl > 0 {
+				// 索引随写入续期, 交易所全部停止后自动过期
+				pipe.Expire(ctx, key, ttl)
+				pipe.Expire(ctx, symbolIndex, ttl)
+				pipe.Expire(ctx, venueIndex, ttl)
+			}
 
-	pipe.HMSet(ctx, key, data)
-	pipe.Expire(ctx, key, ttl)
+			if !r.legacyKeys {
+				continue
+			}
+		}
+
+		legacyKey := LegacyKey(priceData.Symbol)
+		pipe.HSet(ctx, legacyKey, data)
+		if ttl > 0 {
+			pipe.Expire(ctx, legacyKey, ttl)
+		}
+	}
 
 	_, err := pipe.Exec(ctx)
 	return err
 }
 
-// BatchSetPriceData 批量并发安全地存储多个行情数据
-func (r *RedisClient) BatchSetPriceData(ctx context.Context, priceDataList []*maps.PriceData) error {
+// GetMarketPrice 获取交易所某个交易对的行情
+func (r *RedisClient) GetMarketPrice(ctx context.Context, exchange, instType, unifiedSymbol string) (*maps.PriceData, error) {
 	if r.isClientClosed() {
-		return redis.ErrClosed
+		return nil, redis.ErrClosed
 	}
 
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	if len(priceDataList) == 0 {
-		return nil
+	result, err := r.rdb.HGetAll(ctx, MarketKey(exchange, instType, unifiedSymbol)).Result()
+	if err != nil {
+		return nil, err
 	}
 
-	// 使用管道批量操作
-	pipe := r.rdb.Pipeline()
-
-	for _, priceData := range priceDataList {
-		key := "market_data:" + priceData.Symbol
-		data := map[string]interface{}{
-			"symbol":       priceData.Symbol,
-			"price":        priceData.Price,
-			"funding_rate": priceData.FundingRate,
-			"mark_price":   priceData.MarkPrice,
-			"timestamp":    priceData.Timestamp,
-		}
-		pipe.HMSet(ctx, key, data)
+	if len(result) == 0 {
+		return nil, redis.Nil
 	}
 
-	_, err := pipe.Exec(ctx)
-	return err
+	return parsePriceData(result), nil
 }
 
-// BatchSetPriceDataWithTTL 批量存储行情数据并设置过期时间
-func (r *RedisClient) BatchSetPriceDataWithTTL(ctx context.Context, priceDataList []*maps.PriceData, ttl time.Duration) error {
+// GetPricesBySymbol 获取所有交易所某个交易对的行情, 例如 BTC/USDT 的现货和永续
+func (r *RedisClient) GetPricesBySymbol(ctx context.Context, unifiedSymbol string) ([]*maps.PriceData, error) {
+	return r.getIndexedPrices(ctx, SymbolIndexKey(unifiedSymbol))
+}
+
+// GetPricesByVenue 获取交易所某个产品类型的全部行情, 例如 bybit 的全部永续
+func (r *RedisClient) GetPricesByVenue(ctx context.Context, exchange, instType string) ([]*maps.PriceData, error) {
+	return r.getIndexedPrices(ctx, VenueIndexKey(exchange, instType))
+}
+
+// getIndexedPrices 读取索引中的行情, 已过期的行情键从索引中移除
+func (r *RedisClient) getIndexedPrices(ctx context.Context, indexKey string) ([]*maps.PriceData, error) {
 	if r.isClientClosed() {
-		return redis.ErrClosed
+		return nil, redis.ErrClosed
 	}
 
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	if len(priceDataList) == 0 {
-		return nil
+	keys, err := r.rdb.SMembers(ctx, indexKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return []*maps.PriceData{}, nil
 	}
 
-	// 使用管道批量操作
 	pipe := r.rdb.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.HGetAll(ctx, key)
+	}
 
-	for _, priceData := range priceDataList {
-		key := "market_data:" + priceData.Symbol
-		data := map[string]interface{}{
-			"symbol":       priceData.Symbol,
-			"price":        priceData.Price,
-			"funding_rate": priceData.FundingRate,
-			"mark_price":   priceData.MarkPrice,
-			"timestamp":    priceData.Timestamp,
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	result := make([]*maps.PriceData, 0, len(keys))
+	stale := make([]interface{}, 0)
+	for i, cmd := range cmds {
+		data, err := cmd.Result()
+		if err != nil && err != redis.Nil {
+			return nil, err
 		}
-		pipe.HMSet(ctx, key, data)
-		pipe.Expire(ctx, key, ttl)
+
+		if len(data) == 0 {
+			stale = append(stale, keys[i])
+			continue
+		}
+		result = append(result, parsePriceData(data))
 	}
 
-	_, err := pipe.Exec(ctx)
-	return err
+	if len(stale) > 0 {
+		if err := r.rdb.SRem(ctx, indexKey, stale...).Err(); err != nil {
+			log.Warn("remove stale market keys failed", "index", indexKey, "err", err)
+		}
+	}
+
+	return result, nil
 }
 
 // GetPriceData 并发安全地获取行情数据
+// Deprecated: 旧键不区分交易所和产品类型, 使用 GetMarketPrice
 func (r *RedisClient) GetPriceData(ctx context.Context, symbol string) (*maps.PriceData, error) {
 	if r.isClientClosed() {
 		return nil, redis.ErrClosed
@@ -216,7 +260,7 @@ func (r *RedisClient) GetPriceData(ctx context.Context, symbol string) (*maps.Pr
 		ctx = context.Background()
 	}
 
-	key := "market_data:" + symbol
+	key := LegacyKey(symbol)
 
 	result, err := r.rdb.HGetAll(ctx, key).Result()
 	if err != nil {
@@ -227,18 +271,11 @@ func (r *RedisClient) GetPriceData(ctx context.Context, symbol string) (*maps.Pr
 		return nil, redis.Nil
 	}
 
-	priceData := &maps.PriceData{
-		Symbol:      result["symbol"],
-		Price:       result["price"],
-		FundingRate: result["funding_rate"],
-		MarkPrice:   result["mark_price"],
-		Timestamp:   result["timestamp"],
-	}
-
-	return priceData, nil
+	return parsePriceData(result), nil
 }
 
 // GetMultiplePriceData 并发安全地批量获取多个交易对的行情数据
+// Deprecated: 旧键不区分交易所和产品类型, 使用 GetPricesBySymbol 或 GetPricesByVenue
 func (r *RedisClient) GetMultiplePriceData(ctx context.Context, symbols []string) (map[string]*maps.PriceData, error) {
 	if r.isClientClosed() {
 		return nil, redis.ErrClosed
@@ -257,7 +294,7 @@ func (r *RedisClient) GetMultiplePriceData(ctx context.Context, symbols []string
 
 	cmds := make(map[string]*redis.MapStringStringCmd)
 	for _, symbol := range symbols {
-		key := "market_data:" + symbol
+		key := LegacyKey(symbol)
 		cmds[symbol] = pipe.HGetAll(ctx, key)
 	}
 
@@ -277,13 +314,7 @@ func (r *RedisClient) GetMultiplePriceData(ctx context.Context, symbols []string
 		}
 
 		if len(data) > 0 {
-			result[symbol] = &maps.PriceData{
-				Symbol:      data["symbol"],
-				Price:       data["price"],
-				FundingRate: data["funding_rate"],
-				MarkPrice:   data["mark_price"],
-				Timestamp:   data["timestamp"],
-			}
+			result[symbol] = parsePriceData(data)
 		}
 	}
 
@@ -300,7 +331,7 @@ func (r *RedisClient) UpdatePriceDataField(ctx context.Context, symbol string, f
 		ctx = context.Background()
 	}
 
-	key := "market_data:" + symbol
+	key := LegacyKey(symbol)
 	return r.rdb.HSet(ctx, key, field, value).Err()
 }
 
@@ -322,7 +353,7 @@ func (r *RedisClient) BatchUpdatePriceFields(ctx context.Context, updates map[st
 	pipe := r.rdb.Pipeline()
 
 	for symbol, fields := range updates {
-		key := "market_data:" + symbol
+		key := LegacyKey(symbol)
 		pipe.HMSet(ctx, key, fields)
 	}
 
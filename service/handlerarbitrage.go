@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"github.com/339-Labs/exchange-market/common/arbitrage"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/database"
+	"github.com/339-Labs/exchange-market/worker"
+	"github.com/ethereum/go-ethereum/log"
+	"sync/atomic"
+)
+
+// HandlerArbitrage 跨交易所套利机会检测, quotes 由调用方创建并注册到统一行情事件, 重启后继续使用
+type HandlerArbitrage struct {
+	ArbitrageTask *worker.ArbitrageTask
+
+	stopped atomic.Bool
+}
+
+func NewHandlerArbitrage(config *config.Config, db *database.DB, quotes *arbitrage.Quotes, shutdown context.CancelCauseFunc) (*HandlerArbitrage, error) {
+	arbitrageTask, err := worker.NewArbitrageTask(shutdown, config.ArbitrageConfig, quotes, db)
+	if err != nil {
+		return nil, err
+	}
+	return &HandlerArbitrage{ArbitrageTask: arbitrageTask}, nil
+}
+
+func (h *HandlerArbitrage) Start(ctx context.Context) error {
+	return h.ArbitrageTask.Start()
+}
+
+func (h *HandlerArbitrage) Stop(ctx context.Context) error {
+	err := h.ArbitrageTask.Close()
+	h.stopped.Store(true)
+	log.Info("stop arbitrage success")
+	return err
+}
+
+func (h *HandlerArbitrage) Stopped() bool {
+	return h.stopped.Load()
+}
@@ -2,10 +2,14 @@ package service
 
 import (
 	"context"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
 	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/common/symbols"
 	"github.com/339-Labs/exchange-market/config"
 	"github.com/339-Labs/exchange-market/database"
 	"github.com/339-Labs/exchange-market/exchange/cex/bitget"
+	"github.com/339-Labs/exchange-market/exchange/cex/bitget/constants"
 	"github.com/339-Labs/exchange-market/redis"
 	"github.com/339-Labs/exchange-market/worker"
 	"github.com/ethereum/go-ethereum/log"
@@ -16,34 +20,50 @@ import (
 type HandlerBitGet struct {
 	BitGetExClient *bitget.BitGetExClient
 	BitGetTask     *worker.BitGetTask
+	Universe       *worker.UniverseTask
 
 	shutdown context.CancelCauseFunc
 	stopped  atomic.Bool
 }
 
-func NewHandlerBitGet(config *config.Config, db *database.DB, redis *redis.RedisClient, shutdown context.CancelCauseFunc) (*HandlerBitGet, error) {
+func NewHandlerBitGet(config *config.Config, db *database.DB, redis *redis.RedisClient, registry *maps.Registry, sink event.Sink, shutdown context.CancelCauseFunc) (*HandlerBitGet, error) {
 
 	spotPriceMap := maps.NewPriceMap(10)
 	featurePriceMap := maps.NewPriceMap(10)
 
 	bitGetExClient, _ := bitget.NewBitGetExClient(&config.ExchangeConfig.BitGet, spotPriceMap, featurePriceMap)
-	bitGetTask, _ := worker.NewBitGetTask(shutdown, time.Second*1, spotPriceMap, featurePriceMap)
+	bitGetExClient.SetSink(sink)
+	bitGetTask, _ := worker.NewBitGetTask(shutdown, time.Second*1, db, redis, spotPriceMap, featurePriceMap)
+	universe, err := worker.NewUniverseTask(shutdown, common.BitGet, db, config.UniverseConfig, bitGetExClient)
+	if err != nil {
+		return nil, err
+	}
+	registry.Register(common.BitGet, &maps.VenueMaps{Spot: spotPriceMap, Feature: featurePriceMap})
 
 	return &HandlerBitGet{
 		BitGetExClient: bitGetExClient,
 		BitGetTask:     bitGetTask,
+		Universe:       universe,
 		shutdown:       shutdown,
 	}, nil
 }
 
 func (h *HandlerBitGet) Start(ctx context.Context) error {
 	h.BitGetExClient.ExecuteWs()
+	h.Universe.Start()
+
+	spotBooks := symbols.Default.VenueSymbols(common.BitGet, common.InstTypeSpot, bookSymbols)
+	featureBooks := symbols.Default.VenueSymbols(common.BitGet, common.InstTypePerp, bookSymbols)
+	h.BitGetExClient.ExecuteBooksWs(constants.ChannelBooks, spotBooks, featureBooks)
+
 	h.BitGetTask.Start()
 	return nil
 }
 
 func (h *HandlerBitGet) Stop(ctx context.Context) error {
+	h.Universe.Close()
 	h.BitGetTask.Close()
+	h.BitGetExClient.Close()
 	h.BitGetExClient.BitGetWebSocketClient.Stop()
 	log.Info("stop notify success")
 	return nil
@@ -2,10 +2,14 @@ package service
 
 import (
 	"context"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
 	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/common/symbols"
 	"github.com/339-Labs/exchange-market/config"
 	"github.com/339-Labs/exchange-market/database"
 	"github.com/339-Labs/exchange-market/exchange/cex/bn"
+	"github.com/339-Labs/exchange-market/exchange/cex/bn/constants"
 	"github.com/339-Labs/exchange-market/redis"
 	"github.com/339-Labs/exchange-market/worker"
 	"github.com/ethereum/go-ethereum/log"
@@ -16,36 +20,53 @@ import (
 type HandlerBN struct {
 	BnExClient  *bn.BnExClient
 	BinanceTask *worker.BinanceTask
+	Universe    *worker.UniverseTask // 只注册交易对映射, bn 订阅全市场行情
 
 	shutdown context.CancelCauseFunc
 	stopped  atomic.Bool
 }
 
-func NewHandlerBN(config *config.Config, db *database.DB, redis *redis.RedisClient, shutdown context.CancelCauseFunc) (*HandlerBN, error) {
+func NewHandlerBN(config *config.Config, db *database.DB, redis *redis.RedisClient, registry *maps.Registry, sink event.Sink, shutdown context.CancelCauseFunc) (*HandlerBN, error) {
 	spotPriceMap := maps.NewPriceMap(10)
 	featurePriceMap := maps.NewPriceMap(10)
 	markPriceMap := maps.NewPriceMap(10)
 	bnExClient, _ := bn.NewBnExClient(&config.ExchangeConfig.Bn, spotPriceMap, featurePriceMap, markPriceMap)
-	bnTask, _ := worker.NewBinanceTask(shutdown, time.Second*1, spotPriceMap, featurePriceMap, markPriceMap)
+	bnExClient.SetSink(sink)
+	bnTask, _ := worker.NewBinanceTask(shutdown, time.Second*1, db, redis, spotPriceMap, featurePriceMap, markPriceMap)
+	universe, err := worker.NewUniverseTask(shutdown, common.BN, db, config.UniverseConfig, nil)
+	if err != nil {
+		return nil, err
+	}
+	registry.Register(common.BN, &maps.VenueMaps{Spot: spotPriceMap, Feature: featurePriceMap, Mark: markPriceMap})
 
 	return &HandlerBN{
 		BnExClient:  bnExClient,
 		BinanceTask: bnTask,
+		Universe:    universe,
 		shutdown:    shutdown,
 	}, nil
 }
 
 func (h *HandlerBN) Start(ctx context.Context) error {
+	h.Universe.Start()
 	h.BnExClient.ExecuteWsSpot()
 	h.BnExClient.ExecuteWsFeature()
+
+	spotBooks := symbols.Default.VenueSymbols(common.BN, common.InstTypeSpot, bookSymbols)
+	h.BnExClient.ExecuteWsDepth(spotBooks)
+	h.BnExClient.ExecuteWsTrade(spotBooks)
+	h.BnExClient.ExecuteWsKline(spotBooks, constants.KlineInterval1m)
+
 	h.BinanceTask.Start()
 	return nil
 }
 
 func (h *HandlerBN) Stop(ctx context.Context) error {
+	h.Universe.Close()
 	h.BinanceTask.Close()
 	h.BnExClient.BnWebSocketClient.Stop()
-	log.Info("stop notify success")
+	h.BnExClient.FeatureWebSocketClient.Stop()
+	log.Info("stop notify success", "decodeErrors", h.BnExClient.DecodeErrors())
 	return nil
 }
 
@@ -2,10 +2,14 @@ package service
 
 import (
 	"context"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
 	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/common/symbols"
 	"github.com/339-Labs/exchange-market/config"
 	"github.com/339-Labs/exchange-market/database"
 	"github.com/339-Labs/exchange-market/exchange/cex/bybit"
+	"github.com/339-Labs/exchange-market/exchange/cex/bybit/constants"
 	"github.com/339-Labs/exchange-market/redis"
 	"github.com/339-Labs/exchange-market/worker"
 	"github.com/ethereum/go-ethereum/log"
@@ -16,22 +20,30 @@ import (
 type HandlerByBit struct {
 	ByBitExClient *bybit.ByBitExClient
 	ByBitTask     *worker.ByBitTask
+	Universe      *worker.UniverseTask
 
 	shutdown context.CancelCauseFunc
 	stopped  atomic.Bool
 }
 
-func NewHandlerByBit(config *config.Config, db *database.DB, redis *redis.RedisClient, shutdown context.CancelCauseFunc) (*HandlerByBit, error) {
+func NewHandlerByBit(config *config.Config, db *database.DB, redis *redis.RedisClient, registry *maps.Registry, sink event.Sink, shutdown context.CancelCauseFunc) (*HandlerByBit, error) {
 
 	spotPriceMap := maps.NewPriceMap(10)
 	featurePriceMap := maps.NewPriceMap(10)
 
 	bybitExClient, _ := bybit.NewByBitExClient(&config.ExchangeConfig.ByBit, spotPriceMap, featurePriceMap)
-	bitGetTask, _ := worker.NewByBitTask(shutdown, time.Second*1, spotPriceMap, featurePriceMap)
+	bybitExClient.SetSink(sink)
+	bitGetTask, _ := worker.NewByBitTask(shutdown, time.Second*1, db, redis, spotPriceMap, featurePriceMap)
+	universe, err := worker.NewUniverseTask(shutdown, common.ByBit, db, config.UniverseConfig, bybitExClient)
+	if err != nil {
+		return nil, err
+	}
+	registry.Register(common.ByBit, &maps.VenueMaps{Spot: spotPriceMap, Feature: featurePriceMap})
 
 	return &HandlerByBit{
 		ByBitExClient: bybitExClient,
 		ByBitTask:     bitGetTask,
+		Universe:      universe,
 		shutdown:      shutdown,
 	}, nil
 }
@@ -39,13 +51,22 @@ func NewHandlerByBit(config *config.Config, db *database.DB, redis *redis.RedisC
 func (h *HandlerByBit) Start(ctx context.Context) error {
 	h.ByBitExClient.ExecuteSpotWs()
 	h.ByBitExClient.ExecuteFeatureWs()
+	h.Universe.Start()
+
+	spotBooks := symbols.Default.VenueSymbols(common.ByBit, common.InstTypeSpot, bookSymbols)
+	featureBooks := symbols.Default.VenueSymbols(common.ByBit, common.InstTypePerp, bookSymbols)
+	h.ByBitExClient.ExecuteOrderBookWs(constants.OrderBookDepth50, spotBooks, featureBooks)
+
 	h.ByBitTask.Start()
 	return nil
 }
 
 func (h *HandlerByBit) Stop(ctx context.Context) error {
+	h.Universe.Close()
 	h.ByBitTask.Close()
+	h.ByBitExClient.Close()
 	h.ByBitExClient.ByBitWebSocketClient.Stop()
+	h.ByBitExClient.FeatureWebSocketClient.Stop()
 	log.Info("stop notify success")
 	return nil
 }
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"github.com/339-Labs/exchange-market/common/funding"
+	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/database"
+	"github.com/339-Labs/exchange-market/worker"
+	"github.com/ethereum/go-ethereum/log"
+	"sync/atomic"
+)
+
+// HandlerFunding 资金费率和基差分析, tracker 和 store 由调用方创建, 重启后继续使用
+type HandlerFunding struct {
+	FundingTask *worker.FundingTask
+
+	stopped atomic.Bool
+}
+
+func NewHandlerFunding(config *config.Config, db *database.DB, registry *maps.Registry, tracker *funding.Tracker, store *funding.Store, shutdown context.CancelCauseFunc) (*HandlerFunding, error) {
+	fundingTask, err := worker.NewFundingTask(shutdown, config.FundingConfig, registry, tracker, store, db)
+	if err != nil {
+		return nil, err
+	}
+	return &HandlerFunding{FundingTask: fundingTask}, nil
+}
+
+func (h *HandlerFunding) Start(ctx context.Context) error {
+	return h.FundingTask.Start()
+}
+
+func (h *HandlerFunding) Stop(ctx context.Context) error {
+	err := h.FundingTask.Close()
+	h.stopped.Store(true)
+	log.Info("stop funding success")
+	return err
+}
+
+func (h *HandlerFunding) Stopped() bool {
+	return h.stopped.Load()
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
+	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/database"
+	"github.com/339-Labs/exchange-market/exchange/cex/gateio"
+	"github.com/339-Labs/exchange-market/redis"
+	"github.com/339-Labs/exchange-market/worker"
+	"github.com/ethereum/go-ethereum/log"
+	"sync/atomic"
+	"time"
+)
+
+type HandlerGateIo struct {
+	GateIoExClient *gateio.GateIoExClient
+	GateIoTask     *worker.GateIoTask
+	Universe       *worker.UniverseTask
+
+	shutdown context.CancelCauseFunc
+	stopped  atomic.Bool
+}
+
+func NewHandlerGateIo(config *config.Config, db *database.DB, redis *redis.RedisClient, registry *maps.Registry, sink event.Sink, shutdown context.CancelCauseFunc) (*HandlerGateIo, error) {
+
+	spotPriceMap := maps.NewPriceMap(10)
+	featurePriceMap := maps.NewPriceMap(10)
+
+	gateIoExClient, _ := gateio.NewGateIoExClient(&config.ExchangeConfig.GateIo, spotPriceMap, featurePriceMap)
+	gateIoExClient.SetSink(sink)
+	gateIoTask, _ := worker.NewGateIoTask(shutdown, time.Second*1, db, redis, spotPriceMap, featurePriceMap)
+	universe, err := worker.NewUniverseTask(shutdown, common.GateIo, db, config.UniverseConfig, gateIoExClient)
+	if err != nil {
+		return nil, err
+	}
+	registry.Register(common.GateIo, &maps.VenueMaps{Spot: spotPriceMap, Feature: featurePriceMap})
+
+	return &HandlerGateIo{
+		GateIoExClient: gateIoExClient,
+		GateIoTask:     gateIoTask,
+		Universe:       universe,
+		shutdown:       shutdown,
+	}, nil
+}
+
+func (h *HandlerGateIo) Start(ctx context.Context) error {
+	h.GateIoExClient.ExecuteSpotWs()
+	h.GateIoExClient.ExecuteFeatureWs()
+	h.Universe.Start()
+	h.GateIoTask.Start()
+	return nil
+}
+
+func (h *HandlerGateIo) Stop(ctx context.Context) error {
+	h.Universe.Close()
+	h.GateIoTask.Close()
+	h.GateIoExClient.Close()
+	h.GateIoExClient.GateIoWebSocketClient.Stop()
+	h.GateIoExClient.FeatureWebSocketClient.Stop()
+	log.Info("stop notify success")
+	return nil
+}
+
+func (h *HandlerGateIo) Stopped() bool {
+	return h.stopped.Load()
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"github.com/339-Labs/exchange-market/common/index"
+	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/redis"
+	"github.com/339-Labs/exchange-market/worker"
+	"github.com/ethereum/go-ethereum/log"
+	"sync/atomic"
+)
+
+// HandlerIndex 跨交易所指数价格, stats 和 store 由调用方创建, 重启后继续使用
+type HandlerIndex struct {
+	IndexTask *worker.IndexTask
+
+	stopped atomic.Bool
+}
+
+func NewHandlerIndex(config *config.Config, redis *redis.RedisClient, registry *maps.Registry, stats *index.Stats, store *index.Store, shutdown context.CancelCauseFunc) (*HandlerIndex, error) {
+	var publisher worker.IndexPublisher
+	if redis != nil {
+		publisher = redis
+	}
+
+	indexTask, err := worker.NewIndexTask(shutdown, config.IndexConfig, registry, stats, store, publisher)
+	if err != nil {
+		return nil, err
+	}
+	return &HandlerIndex{IndexTask: indexTask}, nil
+}
+
+func (h *HandlerIndex) Start(ctx context.Context) error {
+	return h.IndexTask.Start()
+}
+
+func (h *HandlerIndex) Stop(ctx context.Context) error {
+	err := h.IndexTask.Close()
+	h.stopped.Store(true)
+	log.Info("stop index success")
+	return err
+}
+
+func (h *HandlerIndex) Stopped() bool {
+	return h.stopped.Load()
+}
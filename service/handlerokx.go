@@ -2,10 +2,14 @@ package service
 
 import (
 	"context"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/event"
 	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/common/symbols"
 	"github.com/339-Labs/exchange-market/config"
 	"github.com/339-Labs/exchange-market/database"
 	"github.com/339-Labs/exchange-market/exchange/cex/okx"
+	"github.com/339-Labs/exchange-market/exchange/cex/okx/constants"
 	"github.com/339-Labs/exchange-market/redis"
 	"github.com/339-Labs/exchange-market/worker"
 	"github.com/ethereum/go-ethereum/log"
@@ -16,37 +20,53 @@ import (
 type HandlerOkx struct {
 	OkxExClient *okx.OkxExClient
 	OkxtTask    *worker.OkxTask
+	Universe    *worker.UniverseTask
 
 	shutdown context.CancelCauseFunc
 	stopped  atomic.Bool
 }
 
-func NewHandlerOkx(config *config.Config, db *database.DB, redis *redis.RedisClient, shutdown context.CancelCauseFunc) (*HandlerOkx, error) {
+func NewHandlerOkx(config *config.Config, db *database.DB, redis *redis.RedisClient, registry *maps.Registry, sink event.Sink, shutdown context.CancelCauseFunc) (*HandlerOkx, error) {
 
 	spotPriceMap := maps.NewPriceMap(10)
 	featurePriceMap := maps.NewPriceMap(10)
 	markPriceMap := maps.NewPriceMap(10)
 	rateMap := maps.NewPriceMap(10)
 
-	okxExClient, _ := okx.NewOkxExClient(&config.ExchangeConfig.ByBit, spotPriceMap, featurePriceMap, markPriceMap, rateMap)
-	okxTask, _ := worker.NewOkxTask(shutdown, time.Second*1, spotPriceMap, featurePriceMap, markPriceMap, rateMap)
+	okxExClient, _ := okx.NewOkxExClient(&config.ExchangeConfig.Okx, spotPriceMap, featurePriceMap, markPriceMap, rateMap)
+	okxExClient.SetSink(sink)
+	okxTask, _ := worker.NewOkxTask(shutdown, time.Second*1, db, redis, spotPriceMap, featurePriceMap, markPriceMap, rateMap)
+	universe, err := worker.NewUniverseTask(shutdown, common.Okx, db, config.UniverseConfig, okxExClient)
+	if err != nil {
+		return nil, err
+	}
+	registry.Register(common.Okx, &maps.VenueMaps{Spot: spotPriceMap, Feature: featurePriceMap, Mark: markPriceMap, Rate: rateMap})
 
 	return &HandlerOkx{
 		OkxExClient: okxExClient,
 		OkxtTask:    okxTask,
+		Universe:    universe,
 		shutdown:    shutdown,
 	}, nil
 }
 
 func (h *HandlerOkx) Start(ctx context.Context) error {
-	h.OkxExClient.ExecuteSpotWs()
-	h.OkxExClient.ExecuteFeatureWs()
+	h.OkxExClient.ExecuteWs()
+	h.Universe.Start()
+
+	// okx 通过 instId 区分现货和永续
+	books := append(symbols.Default.VenueSymbols(common.Okx, common.InstTypeSpot, bookSymbols),
+		symbols.Default.VenueSymbols(common.Okx, common.InstTypePerp, bookSymbols)...)
+	h.OkxExClient.ExecuteBooksWs(constants.ChannelBooks, books)
+
 	h.OkxtTask.Start()
 	return nil
 }
 
 func (h *HandlerOkx) Stop(ctx context.Context) error {
+	h.Universe.Close()
 	h.OkxtTask.Close()
+	h.OkxExClient.Close()
 	h.OkxExClient.OkxWebSocketClient.Stop()
 	log.Info("stop notify success")
 	return nil
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	apiservice "github.com/339-Labs/exchange-market/api/service"
+	"github.com/339-Labs/exchange-market/api/service/bitget"
+	"github.com/339-Labs/exchange-market/api/service/bn"
+	"github.com/339-Labs/exchange-market/api/service/bybit"
+	"github.com/339-Labs/exchange-market/api/service/gateio"
+	"github.com/339-Labs/exchange-market/api/service/okx"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/database"
+	"github.com/339-Labs/exchange-market/worker"
+	"github.com/ethereum/go-ethereum/log"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// HandlerSymbolSync 定时同步交易对
+type HandlerSymbolSync struct {
+	SymbolSyncTask *worker.SymbolSyncTask
+
+	db      *database.DB
+	stopped atomic.Bool
+}
+
+func NewHandlerSymbolSync(config *config.Config, db *database.DB, names []string, interval time.Duration, shutdown context.CancelCauseFunc) (*HandlerSymbolSync, error) {
+	loaders, err := NewSymbolLoaders(names, config, db)
+	if err != nil {
+		return nil, err
+	}
+
+	symbolSyncTask, err := worker.NewSymbolSyncTask(shutdown, interval, loaders)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HandlerSymbolSync{
+		SymbolSyncTask: symbolSyncTask,
+		db:             db,
+	}, nil
+}
+
+func (h *HandlerSymbolSync) Start(ctx context.Context) error {
+	return h.SymbolSyncTask.Start()
+}
+
+func (h *HandlerSymbolSync) Stop(ctx context.Context) error {
+	err := h.SymbolSyncTask.Close()
+	if closeErr := h.db.Close(); closeErr != nil {
+		log.Error("fail to close database", "err", closeErr)
+	}
+	h.stopped.Store(true)
+	return err
+}
+
+func (h *HandlerSymbolSync) Stopped() bool {
+	return h.stopped.Load()
+}
+
+// NewSymbolLoaders 根据交易所名称创建交易对加载器, 名称与 run 命令一致
+func NewSymbolLoaders(names []string, config *config.Config, db *database.DB) (map[string]apiservice.HandlerSymbolAdaptor, error) {
+	exchangeConfig := config.ExchangeConfig
+	loaders := make(map[string]apiservice.HandlerSymbolAdaptor, len(names))
+
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := loaders[name]; ok {
+			continue
+		}
+
+		switch name {
+		case "bn":
+			if exchangeConfig.Bn.ApiUrl == "" {
+				return nil, fmt.Errorf("bn api url is required")
+			}
+			loaders[name] = bn.NewClient(*config, *db)
+		case "okx":
+			if exchangeConfig.Okx.ApiUrl == "" {
+				return nil, fmt.Errorf("okx api url is required")
+			}
+			loaders[name] = okx.NewClient(*config, *db)
+		case "bybit":
+			if exchangeConfig.ByBit.ApiUrl == "" {
+				return nil, fmt.Errorf("bybit api url is required")
+			}
+			loaders[name] = bybit.NewClient(*config, *db)
+		case "bitget":
+			if exchangeConfig.BitGet.ApiUrl == "" {
+				return nil, fmt.Errorf("bitget api url is required")
+			}
+			loaders[name] = bitget.NewClient(*config, *db)
+		case "gateio":
+			if exchangeConfig.GateIo.ApiUrl == "" {
+				return nil, fmt.Errorf("gateio api url is required")
+			}
+			loaders[name] = gateio.NewClient(*config, *db)
+		default:
+			return nil, fmt.Errorf("unknown exchange %q, supported: %s", name, strings.Join(VenueNames, ","))
+		}
+	}
+
+	return loaders, nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/database"
+	"github.com/339-Labs/exchange-market/exchange/dex/evm"
+	"github.com/339-Labs/exchange-market/redis"
+	"github.com/339-Labs/exchange-market/worker"
+	common2 "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"sync/atomic"
+)
+
+// HandlerUniswapV3 Uniswap V3 池子发现、定价和合成深度, 配置了 websocket 节点时订阅 Swap 日志, 否则轮询
+type HandlerUniswapV3 struct {
+	UniswapV3Task *worker.UniswapV3Task
+	DexLogTask    *worker.DexLogTask
+
+	ethClient *ethclient.Client
+	wsClient  *ethclient.Client // 可为空
+	stopped   atomic.Bool
+}
+
+func NewHandlerUniswapV3(config *config.Config, db *database.DB, redis *redis.RedisClient, registry *maps.Registry, shutdown context.CancelCauseFunc) (*HandlerUniswapV3, error) {
+	dexConfig := config.ExchangeConfig.UniswapV3
+	if !common2.IsHexAddress(dexConfig.Factory) {
+		return nil, fmt.Errorf("invalid uniswap v3 factory address %q", dexConfig.Factory)
+	}
+
+	ethClient, err := evm.DialEthClient(context.Background(), dexConfig.RpcUrl)
+	if err != nil {
+		return nil, fmt.Errorf("dial uniswap v3 rpc: %w", err)
+	}
+	indexer, err := evm.NewUniswapV3Indexer(ethClient, common2.HexToAddress(dexConfig.Factory))
+	if err != nil {
+		ethClient.Close()
+		return nil, err
+	}
+	chainId, err := indexer.ChainId(context.Background())
+	if err != nil {
+		ethClient.Close()
+		return nil, fmt.Errorf("get uniswap v3 chain id: %w", err)
+	}
+
+	var wsClient *ethclient.Client
+	if dexConfig.WsRpcUrl != "" {
+		if wsClient, err = evm.DialEthClient(context.Background(), dexConfig.WsRpcUrl); err != nil {
+			ethClient.Close()
+			return nil, fmt.Errorf("dial uniswap v3 ws rpc: %w", err)
+		}
+	}
+	closeClients := func() {
+		ethClient.Close()
+		if wsClient != nil {
+			wsClient.Close()
+		}
+	}
+
+	spotPriceMap := maps.NewPriceMap(10)
+	uniswapV3Task, err := worker.NewUniswapV3Task(shutdown, dexConfig, indexer, chainId, db, redis, spotPriceMap)
+	if err != nil {
+		closeClients()
+		return nil, err
+	}
+	feed := evm.NewLogFeed(ethClient, wsClient, []common2.Hash{evm.SwapTopic})
+	dexLogTask, err := worker.NewDexLogTask(shutdown, common.UniswapV3, chainId, dexConfig.Interval, dexConfig.Confirmations, feed, uniswapV3Task, db)
+	if err != nil {
+		closeClients()
+		return nil, err
+	}
+	registry.Register(common.UniswapV3, &maps.VenueMaps{Spot: spotPriceMap})
+
+	return &HandlerUniswapV3{
+		UniswapV3Task: uniswapV3Task,
+		DexLogTask:    dexLogTask,
+		ethClient:     ethClient,
+		wsClient:      wsClient,
+	}, nil
+}
+
+func (h *HandlerUniswapV3) Start(ctx context.Context) error {
+	if err := h.UniswapV3Task.Start(); err != nil {
+		return err
+	}
+	return h.DexLogTask.Start()
+}
+
+func (h *HandlerUniswapV3) Stop(ctx context.Context) error {
+	err := errors.Join(h.DexLogTask.Close(), h.UniswapV3Task.Close())
+	h.ethClient.Close()
+	if h.wsClient != nil {
+		h.wsClient.Close()
+	}
+	h.stopped.Store(true)
+	log.Info("stop uniswap v3 success")
+	return err
+}
+
+func (h *HandlerUniswapV3) Stopped() bool {
+	return h.stopped.Load()
+}
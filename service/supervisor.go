@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common/cliapp"
+	"github.com/339-Labs/exchange-market/common/retry"
+	"github.com/ethereum/go-ethereum/log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	venueStopTimeout    = 30 * time.Second // 单个交易所停止的超时时间
+	venueHealthyRunTime = time.Minute      // 运行超过该时间后重置重启退避
+)
+
+// errSupervisorStopped 主动停止, 不触发重启
+var errSupervisorStopped = errors.New("supervisor stopped")
+
+// VenueFactory 创建交易所的生命周期对象, shutdown 只会取消该交易所自身
+type VenueFactory func(shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error)
+
+// venue 受监管的交易所
+type venue struct {
+	name     string
+	factory  VenueFactory
+	restarts atomic.Int64
+}
+
+// Supervisor 在同一进程中运行多个交易所, 某个交易所崩溃时只重启该交易所
+// 每个交易所拥有独立的取消函数, 不会通过共享的 shutdown 关闭整个进程
+type Supervisor struct {
+	// RestartStrategy 重启退避策略
+	RestartStrategy retry.Strategy
+	// OnStopped 所有交易所停止后调用, 用于释放共享的数据库、redis连接
+	OnStopped func() error
+
+	venues  []*venue
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	wg      sync.WaitGroup
+	stopped atomic.Bool
+}
+
+// NewSupervisor 创建监管器
+func NewSupervisor() *Supervisor {
+	return &Supervisor{
+		RestartStrategy: &retry.ExponentialStrategy{
+			Min:       time.Second,
+			Max:       time.Minute,
+			MaxJitter: 250 * time.Millisecond,
+		},
+		venues: make([]*venue, 0),
+	}
+}
+
+// Register 注册交易所, 需在 Start 之前调用
+func (s *Supervisor) Register(name string, factory VenueFactory) {
+	s.venues = append(s.venues, &venue{
+		name:    name,
+		factory: factory,
+	})
+}
+
+// Restarts 交易所的重启次数
+func (s *Supervisor) Restarts(name string) int64 {
+	for _, v := range s.venues {
+		if v.name == name {
+			return v.restarts.Load()
+		}
+	}
+	return 0
+}
+
+// Start 启动所有交易所
+func (s *Supervisor) Start(ctx context.Context) error {
+	if len(s.venues) == 0 {
+		return fmt.Errorf("no exchange to run")
+	}
+
+	s.ctx, s.cancel = context.WithCancelCause(context.Background())
+	for _, v := range s.venues {
+		s.wg.Add(1)
+		go s.supervise(v)
+	}
+	return nil
+}
+
+// Stop 停止所有交易所
+func (s *Supervisor) Stop(ctx context.Context) error {
+	var result error
+	if s.cancel != nil {
+		s.cancel(errSupervisorStopped)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		result = errors.Join(result, fmt.Errorf("wait exchanges stop: %w", context.Cause(ctx)))
+	}
+
+	if s.OnStopped != nil {
+		if err := s.OnStopped(); err != nil {
+			result = errors.Join(result, err)
+		}
+	}
+
+	s.stopped.Store(true)
+	log.Info("supervisor stopped")
+	return result
+}
+
+// Stopped 是否已停止
+func (s *Supervisor) Stopped() bool {
+	return s.stopped.Load()
+}
+
+// supervise 运行交易所, 崩溃后按退避策略重启, 直到监管器停止
+func (s *Supervisor) supervise(v *venue) {
+	defer s.wg.Done()
+
+	attempt := 0
+	for {
+		startedAt := time.Now()
+		cause := s.runOnce(v)
+
+		// 监管器停止
+		if s.ctx.Err() != nil {
+			return
+		}
+
+		if time.Since(startedAt) > venueHealthyRunTime {
+			attempt = 0
+		}
+		wait := s.RestartStrategy.Duration(attempt)
+		attempt++
+
+		log.Error("exchange crashed, restarting", "exchange", v.name, "cause", cause, "wait", wait)
+		select {
+		case <-time.After(wait):
+			v.restarts.Add(1)
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// runOnce 创建并启动交易所, 阻塞到交易所崩溃或监管器停止, 返回崩溃原因
+func (s *Supervisor) runOnce(v *venue) error {
+	venueCtx, venueCancel := context.WithCancelCause(s.ctx)
+	defer venueCancel(nil)
+
+	handler, err := v.factory(venueCancel)
+	if err != nil {
+		return fmt.Errorf("failed to setup: %w", err)
+	}
+
+	log.Info("exchange starting", "exchange", v.name)
+	if err := handler.Start(venueCtx); err != nil {
+		venueCancel(fmt.Errorf("failed to start: %w", err))
+	}
+
+	<-venueCtx.Done()
+	cause := context.Cause(venueCtx)
+
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), venueStopTimeout)
+	defer stopCancel()
+	if err := handler.Stop(stopCtx); err != nil {
+		log.Error("failed to stop exchange", "exchange", v.name, "err", err)
+	}
+	return cause
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/339-Labs/exchange-market/common/cliapp"
+	"github.com/339-Labs/exchange-market/common/retry"
+)
+
+type mockVenue struct {
+	starts  *atomic.Int64
+	stops   *atomic.Int64
+	stopped atomic.Bool
+}
+
+func (m *mockVenue) Start(ctx context.Context) error {
+	m.starts.Add(1)
+	return nil
+}
+
+func (m *mockVenue) Stop(ctx context.Context) error {
+	m.stops.Add(1)
+	m.stopped.Store(true)
+	return nil
+}
+
+func (m *mockVenue) Stopped() bool { return m.stopped.Load() }
+
+func TestSupervisor_RestartCrashedVenue(t *testing.T) {
+	var healthyStarts, healthyStops, crashStarts, crashStops atomic.Int64
+	crash := make(chan context.CancelCauseFunc, 1)
+
+	supervisor := NewSupervisor()
+	supervisor.RestartStrategy = retry.Fixed(10 * time.Millisecond)
+	supervisor.Register("healthy", func(shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error) {
+		return &mockVenue{starts: &healthyStarts, stops: &healthyStops}, nil
+	})
+	supervisor.Register("crashy", func(shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error) {
+		select {
+		case crash <- shutdown:
+		default:
+		}
+		return &mockVenue{starts: &crashStarts, stops: &crashStops}, nil
+	})
+
+	if err := supervisor.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 交易所通过自身的 shutdown 崩溃
+	(<-crash)(errors.New("ws error"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for supervisor.Restarts("crashy") == 0 || crashStarts.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("crashed venue not restarted")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if healthyStarts.Load() != 1 || healthyStops.Load() != 0 || supervisor.Restarts("healthy") != 0 {
+		t.Fatalf("healthy venue should not restart, starts %d stops %d", healthyStarts.Load(), healthyStops.Load())
+	}
+	if crashStops.Load() != 1 {
+		t.Fatalf("crashed venue should be stopped once, got %d", crashStops.Load())
+	}
+
+	var closed bool
+	supervisor.OnStopped = func() error {
+		closed = true
+		return nil
+	}
+	if err := supervisor.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !closed || !supervisor.Stopped() {
+		t.Fatal("supervisor not stopped")
+	}
+	if healthyStops.Load() != 1 || crashStops.Load() != 2 {
+		t.Fatalf("all venues should be stopped, healthy %d crashy %d", healthyStops.Load(), crashStops.Load())
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common/cliapp"
+	"github.com/339-Labs/exchange-market/common/event"
+	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/database"
+	"github.com/339-Labs/exchange-market/redis"
+	"strings"
+)
+
+// VenueNames run 命令支持的交易所
+var VenueNames = []string{"bn", "okx", "bybit", "bitget", "gateio"}
+
+// bookSymbols 维护本地订单簿的统一交易对, 由 symbols.Default 转换为各交易所的交易对
+var bookSymbols = []string{"BTC/USDT", "ETH/USDT"}
+
+// NewVenueFactory 根据交易所名称创建工厂, 所有交易所共享同一个数据库和redis连接, 内存行情注册到 registry, 统一行情事件发送到 sink
+func NewVenueFactory(name string, config *config.Config, db *database.DB, redis *redis.RedisClient, registry *maps.Registry, sink event.Sink) (VenueFactory, error) {
+	var factory VenueFactory
+	exchangeConfig := config.ExchangeConfig
+
+	switch strings.ToLower(name) {
+	case "bn":
+		if exchangeConfig.Bn.WsUrl == "" {
+			return nil, fmt.Errorf("bn ws url is required")
+		}
+		factory = func(shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error) {
+			return NewHandlerBN(config, db, redis, registry, sink, shutdown)
+		}
+	case "okx":
+		if exchangeConfig.Okx.WsUrl == "" {
+			return nil, fmt.Errorf("okx ws url is required")
+		}
+		factory = func(shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error) {
+			return NewHandlerOkx(config, db, redis, registry, sink, shutdown)
+		}
+	case "bybit":
+		if exchangeConfig.ByBit.WsUrl == "" || exchangeConfig.ByBit.WsUrlFeature == "" {
+			return nil, fmt.Errorf("bybit ws url and feature ws url are required")
+		}
+		factory = func(shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error) {
+			return NewHandlerByBit(config, db, redis, registry, sink, shutdown)
+		}
+	case "bitget":
+		if exchangeConfig.BitGet.WsUrl == "" {
+			return nil, fmt.Errorf("bitget ws url is required")
+		}
+		factory = func(shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error) {
+			return NewHandlerBitGet(config, db, redis, registry, sink, shutdown)
+		}
+	case "gateio":
+		if exchangeConfig.GateIo.WsUrl == "" || exchangeConfig.GateIo.WsUrlFeature == "" {
+			return nil, fmt.Errorf("gateio ws url and feature ws url are required")
+		}
+		factory = func(shutdown context.CancelCauseFunc) (cliapp.Lifecycle, error) {
+			return NewHandlerGateIo(config, db, redis, registry, sink, shutdown)
+		}
+	default:
+		return nil, fmt.Errorf("unknown exchange %q, supported: %s", name, strings.Join(VenueNames, ","))
+	}
+
+	return factory, nil
+}
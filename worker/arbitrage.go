@@ -0,0 +1,171 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/arbitrage"
+	"github.com/339-Labs/exchange-market/common/tasks"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/database"
+	arbitrage2 "github.com/339-Labs/exchange-market/database/arbitrage"
+	"github.com/ethereum/go-ethereum/log"
+	"strings"
+	"time"
+)
+
+// ArbitrageTask 定时比较各交易所的最优买卖价, 打开和关闭的机会写入 arbitrage_opportunity
+type ArbitrageTask struct {
+	// OnOpportunity 机会打开和关闭时调用, EndTime 为零值表示打开, 在任务线程中调用
+	OnOpportunity func(opportunity arbitrage.Opportunity)
+
+	quotes   *arbitrage.Quotes
+	detector *arbitrage.Detector
+	db       arbitrage2.ArbitrageOpportunityDB
+
+	resourceCtx    context.Context
+	resourceCancel context.CancelFunc
+	tasks          tasks.Group
+	ticker         *time.Ticker
+}
+
+// NewArbitrageTask 创建套利检测任务, quotes 需注册到交易所的统一行情事件, db 为空时不写入数据库
+func NewArbitrageTask(shutdown context.CancelCauseFunc, config config.ArbitrageConfig, quotes *arbitrage.Quotes, db *database.DB) (*ArbitrageTask, error) {
+	if config.Interval <= 0 {
+		return nil, fmt.Errorf("invalid arbitrage interval %s", config.Interval)
+	}
+	fees, err := ParseTakerFees(config.TakerFees)
+	if err != nil {
+		return nil, err
+	}
+
+	options := arbitrage.Options{
+		Fees:        fees,
+		DefaultFee:  common.NewDecimalFromFloat(config.DefaultFee),
+		Threshold:   common.NewDecimalFromFloat(config.Threshold),
+		MinDuration: config.MinDuration,
+		MaxAge:      config.MaxAge,
+	}
+
+	resCtx, resCancel := context.WithCancel(context.Background())
+	task := &ArbitrageTask{
+		quotes:         quotes,
+		detector:       arbitrage.NewDetector(options),
+		resourceCtx:    resCtx,
+		resourceCancel: resCancel,
+		tasks: tasks.Group{HandleCrit: func(err error) {
+			shutdown(fmt.Errorf("arbitrage task error: %w", err))
+		}},
+		ticker: time.NewTicker(config.Interval),
+	}
+	if db != nil {
+		task.db = db.ArbitrageOpportunity
+	}
+	return task, nil
+}
+
+func (t *ArbitrageTask) Start() error {
+	log.Info("arbitrage task started")
+	t.tasks.Go(func() error {
+		for {
+			select {
+			case <-t.ticker.C:
+				opened, closed := t.detector.Evaluate(t.quotes.Snapshot(), time.Now())
+				t.publish(opened, closed)
+
+			case <-t.resourceCtx.Done():
+				log.Info("stop arbitrage task in work")
+				return nil
+			}
+		}
+	})
+	return nil
+}
+
+func (t *ArbitrageTask) Close() error {
+	var result error
+	t.resourceCancel()
+	t.ticker.Stop()
+	if err := t.tasks.Wait(); err != nil {
+		result = errors.Join(result, fmt.Errorf("arbitrage task wait error: %w", err))
+	}
+	// 停止后不再跟踪, 未结束的机会记为结束
+	t.publish(nil, t.detector.CloseAll(time.Now()))
+	log.Info("arbitrage task stopped success")
+	return result
+}
+
+// publish 通知并写入数据库, 写入失败只记录日志
+func (t *ArbitrageTask) publish(opened, closed []*arbitrage.Opportunity) {
+	if len(opened) == 0 && len(closed) == 0 {
+		return
+	}
+
+	rows := make([]arbitrage2.ArbitrageOpportunity, 0, len(opened)+len(closed))
+	for _, opportunity := range opened {
+		log.Info("arbitrage opportunity opened", "symbol", opportunity.Symbol, "instType", opportunity.InstType,
+			"buy", opportunity.BuyExchange, "sell", opportunity.SellExchange, "netEdge", opportunity.NetEdge)
+		rows = append(rows, opportunityRow(opportunity))
+	}
+	for _, opportunity := range closed {
+		log.Info("arbitrage opportunity closed", "symbol", opportunity.Symbol, "instType", opportunity.InstType,
+			"buy", opportunity.BuyExchange, "sell", opportunity.SellExchange, "maxNetEdge", opportunity.MaxNetEdge,
+			"duration", opportunity.EndTime.Sub(opportunity.StartTime))
+		rows = append(rows, opportunityRow(opportunity))
+	}
+
+	if t.OnOpportunity != nil {
+		for _, opportunity := range append(opened, closed...) {
+			t.OnOpportunity(*opportunity)
+		}
+	}
+
+	if t.db == nil {
+		return
+	}
+	if err := t.db.UpsertArbitrageOpportunities(&rows); err != nil {
+		log.Error("save arbitrage opportunities failed", "count", len(rows), "err", err)
+	}
+}
+
+// opportunityRow 机会转换为数据库记录
+func opportunityRow(opportunity *arbitrage.Opportunity) arbitrage2.ArbitrageOpportunity {
+	row := arbitrage2.ArbitrageOpportunity{
+		GUID:          opportunity.GUID,
+		UnifiedSymbol: opportunity.Symbol,
+		InstType:      opportunity.InstType,
+		BuyExchange:   string(opportunity.BuyExchange),
+		SellExchange:  string(opportunity.SellExchange),
+		BuyPrice:      opportunity.BuyPrice,
+		SellPrice:     opportunity.SellPrice,
+		Size:          opportunity.Size,
+		GrossEdge:     opportunity.GrossEdge,
+		NetEdge:       opportunity.NetEdge,
+		MaxNetEdge:    opportunity.MaxNetEdge,
+		StartTime:     uint64(opportunity.StartTime.UnixMilli()),
+		Timestamp:     uint64(time.Now().UnixMilli()),
+	}
+	if !opportunity.EndTime.IsZero() {
+		row.EndTime = uint64(opportunity.EndTime.UnixMilli())
+	}
+	return row
+}
+
+// ParseTakerFees 解析 交易所=手续费率 形式的配置, 例如 bn=0.001
+func ParseTakerFees(values []string) (map[common.Exchange]common.Decimal, error) {
+	fees := make(map[common.Exchange]common.Decimal, len(values))
+	for _, value := range values {
+		name, rate, ok := strings.Cut(value, "=")
+		exchange, known := common.ParseExchange(strings.TrimSpace(name))
+		if !ok || !known {
+			return nil, fmt.Errorf("invalid taker fee %q, expected <exchange>=<rate>", value)
+		}
+		fee, err := common.ParseDecimal(rate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid taker fee %q: %w", value, err)
+		}
+		fees[exchange] = fee
+	}
+	return fees, nil
+}
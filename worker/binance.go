@@ -4,23 +4,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/339-Labs/exchange-market/common"
 	"github.com/339-Labs/exchange-market/common/maps"
 	"github.com/339-Labs/exchange-market/common/tasks"
+	"github.com/339-Labs/exchange-market/database"
+	"github.com/339-Labs/exchange-market/redis"
 	"github.com/ethereum/go-ethereum/log"
 	"time"
 )
 
 type BinanceTask struct {
-	spotPriceMap    *maps.PriceMap
-	featurePriceMap *maps.PriceMap
-	resourceCtx     context.Context
+	flusher     *PriceFlusher
+	resourceCtx context.Context
 
 	resourceCancel context.CancelFunc
 	tasks          tasks.Group
 	ticker         *time.Ticker
 }
 
-func NewBinanceTask(shutdown context.CancelCauseFunc, duration time.Duration, spotPriceMap *maps.PriceMap, featurePriceMap *maps.PriceMap, markPriceMap *maps.PriceMap) (*BinanceTask, error) {
+func NewBinanceTask(shutdown context.CancelCauseFunc, duration time.Duration, db *database.DB, redisClient *redis.RedisClient, spotPriceMap *maps.PriceMap, featurePriceMap *maps.PriceMap, markPriceMap *maps.PriceMap) (*BinanceTask, error) {
 	resCtx, resCancel := context.WithCancel(context.Background())
 	return &BinanceTask{
 		resourceCtx:    resCtx,
@@ -28,9 +30,8 @@ func NewBinanceTask(shutdown context.CancelCauseFunc, duration time.Duration, sp
 		tasks: tasks.Group{HandleCrit: func(err error) {
 			shutdown(fmt.Errorf("bn ws error "))
 		}},
-		ticker:          time.NewTicker(duration),
-		spotPriceMap:    spotPriceMap,
-		featurePriceMap: featurePriceMap,
+		ticker:  time.NewTicker(duration),
+		flusher: NewPriceFlusher(common.BN, db, priceStore(redisClient), spotPriceMap, featurePriceMap, markPriceMap, nil),
 	}, nil
 }
 
@@ -42,9 +43,11 @@ func (t *BinanceTask) Start() error {
 			select {
 
 			case <-t.ticker.C:
-				// todo  bn ws data handler, spot and feature
+				t.flusher.runFlush(false)
 
 			case <-t.resourceCtx.Done():
+				// 停止前写入剩余数据
+				t.flusher.runFlush(true)
 				log.Info("stop bn task in work")
 				return nil
 
@@ -4,23 +4,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/339-Labs/exchange-market/common"
 	"github.com/339-Labs/exchange-market/common/maps"
 	"github.com/339-Labs/exchange-market/common/tasks"
+	"github.com/339-Labs/exchange-market/database"
+	"github.com/339-Labs/exchange-market/redis"
 	"github.com/ethereum/go-ethereum/log"
 	"time"
 )
 
 type ByBitTask struct {
-	spotPriceMap    *maps.PriceMap
-	featurePriceMap *maps.PriceMap
-	resourceCtx     context.Context
+	flusher     *PriceFlusher
+	resourceCtx context.Context
 
 	resourceCancel context.CancelFunc
 	tasks          tasks.Group
 	ticker         *time.Ticker
 }
 
-func NewByBitTask(shutdown context.CancelCauseFunc, duration time.Duration, spotPriceMap *maps.PriceMap, featurePriceMap *maps.PriceMap) (*ByBitTask, error) {
+func NewByBitTask(shutdown context.CancelCauseFunc, duration time.Duration, db *database.DB, redisClient *redis.RedisClient, spotPriceMap *maps.PriceMap, featurePriceMap *maps.PriceMap) (*ByBitTask, error) {
 	resCtx, resCancel := context.WithCancel(context.Background())
 	return &ByBitTask{
 		resourceCtx:    resCtx,
@@ -28,9 +30,8 @@ func NewByBitTask(shutdown context.CancelCauseFunc, duration time.Duration, spot
 		tasks: tasks.Group{HandleCrit: func(err error) {
 			shutdown(fmt.Errorf("bybit ws error "))
 		}},
-		ticker:          time.NewTicker(duration),
-		spotPriceMap:    spotPriceMap,
-		featurePriceMap: featurePriceMap,
+		ticker:  time.NewTicker(duration),
+		flusher: NewPriceFlusher(common.ByBit, db, priceStore(redisClient), spotPriceMap, featurePriceMap, nil, nil),
 	}, nil
 }
 
@@ -42,9 +43,11 @@ func (t *ByBitTask) Start() error {
 			select {
 
 			case <-t.ticker.C:
-				// todo  bybit ws data handler, spot and feature
+				t.flusher.runFlush(false)
 
 			case <-t.resourceCtx.Done():
+				// 停止前写入剩余数据
+				t.flusher.runFlush(true)
 				log.Info("stop bybit task in work")
 				return nil
 
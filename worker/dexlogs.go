@@ -0,0 +1,367 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/tasks"
+	"github.com/339-Labs/exchange-market/database"
+	"github.com/339-Labs/exchange-market/database/dex"
+	"github.com/339-Labs/exchange-market/exchange/dex/evm"
+	"github.com/ethereum/go-ethereum"
+	common2 "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"slices"
+	"time"
+)
+
+const (
+	logsCheckpoint   = "log_block" // 已处理日志的最高区块
+	maxCatchUpBlocks = 20_000      // 单次轮询最多追赶的区块数
+	logBufferSize    = 1_024       // 订阅日志的缓冲大小
+	blockWindowSize  = 256         // 校验回滚保留的区块头数量, 也是可以回滚的最大深度
+)
+
+// DexLogSource 提供需要跟踪的池子并按日志更新价格, 由各 DEX 任务实现
+// ApplyLog 在 DexLogTask 的协程中按区块和日志下标的顺序调用, 同一个池子不会收到更早的日志, 回滚时会重新收到回滚点之前的最后一条日志
+// ResetPool 在回滚后没有更早的日志可以恢复时调用, 实现方应丢弃池子的价格并重新读取合约状态
+type DexLogSource interface {
+	Pools() []common2.Address
+	ApplyLog(log types.Log)
+	ResetPool(pool common2.Address)
+}
+
+// logPosition 日志在链上的位置
+type logPosition struct {
+	block uint64
+	index uint
+}
+
+func (p logPosition) before(other logPosition) bool {
+	return p.block < other.block || (p.block == other.block && p.index < other.index)
+}
+
+// DexLogTask 跟踪池子的事件日志并交给 DexLogSource 更新价格
+// 配置了 websocket 节点且确认数为0时订阅日志, 否则按区块范围轮询 eth_getLogs 已确认的区块; 已处理的区块写入 dex_checkpoint, 重启后从断点继续
+// 每次轮询按区块头校验链是否回滚, 回滚时撤销被回滚区块中的日志, 恢复各池子回滚点之前的价格, 再从回滚点重新读取规范链的日志
+type DexLogTask struct {
+	exchange      common.Exchange
+	chainId       string
+	confirmations uint64 // 日志所在区块之后需要的区块数, 为0时处理最新区块
+	feed          *evm.LogFeed
+	tracker       *evm.BlockTracker
+	source        DexLogSource
+	db            dex.DexCheckpointDB
+
+	addresses []common2.Address               // 当前跟踪的池子, 已排序
+	journal   map[common2.Address][]types.Log // 各池子窗口内已处理的日志, 另保留窗口之前的最后一条用于回滚后恢复
+	processed uint64                          // 已处理的最高区块
+	saved     uint64                          // 已写入 checkpoint 的区块
+	sub       ethereum.Subscription           // 未订阅时为空
+	logs      chan types.Log
+
+	resourceCtx    context.Context
+	resourceCancel context.CancelFunc
+	tasks          tasks.Group
+	ticker         *time.Ticker
+}
+
+// NewDexLogTask 创建日志跟踪任务, interval 为轮询、检查池子变化和校验回滚的间隔, confirmations 需小于区块头窗口
+func NewDexLogTask(shutdown context.CancelCauseFunc, exchange common.Exchange, chainId string, interval time.Duration, confirmations uint64, feed *evm.LogFeed, source DexLogSource, db *database.DB) (*DexLogTask, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid %s log interval %s", exchange, interval)
+	}
+	if confirmations >= blockWindowSize {
+		return nil, fmt.Errorf("invalid %s confirmations %d, must be less than %d", exchange, confirmations, blockWindowSize)
+	}
+	tracker, err := evm.NewBlockTracker(feed, blockWindowSize)
+	if err != nil {
+		return nil, err
+	}
+
+	resCtx, resCancel := context.WithCancel(context.Background())
+	return &DexLogTask{
+		exchange:       exchange,
+		chainId:        chainId,
+		confirmations:  confirmations,
+		feed:           feed,
+		tracker:        tracker,
+		source:         source,
+		db:             db.DexCheckpoint,
+		journal:        make(map[common2.Address][]types.Log),
+		logs:           make(chan types.Log, logBufferSize),
+		resourceCtx:    resCtx,
+		resourceCancel: resCancel,
+		tasks: tasks.Group{HandleCrit: func(err error) {
+			shutdown(fmt.Errorf("%s log task error: %w", exchange, err))
+		}},
+		ticker: time.NewTicker(interval),
+	}, nil
+}
+
+func (t *DexLogTask) Start() error {
+	log.Info("dex log task started", "exchange", t.exchange, "subscribe", t.canSubscribe(), "confirmations", t.confirmations)
+	t.tasks.Go(func() error {
+		processed, err := t.db.GetCheckpoint(string(t.exchange), t.chainId, logsCheckpoint)
+		if err != nil {
+			return fmt.Errorf("get log checkpoint: %w", err)
+		}
+		t.processed, t.saved = processed, processed
+		t.poll()
+
+		for {
+			var subErr <-chan error
+			if t.sub != nil {
+				subErr = t.sub.Err()
+			}
+
+			select {
+			case <-t.ticker.C:
+				t.poll()
+
+			case entry := <-t.logs:
+				if entry.Removed {
+					// 节点推送的回滚日志, 撤销该区块及之后的日志, 下次轮询重新追赶
+					t.rollback(entry.BlockNumber)
+					continue
+				}
+				t.apply(entry)
+				if entry.BlockNumber > 0 {
+					// 同一区块的日志可能尚未全部收到, 只确认到上一个区块
+					t.processed = max(t.processed, entry.BlockNumber-1)
+				}
+
+			case err := <-subErr:
+				log.Warn("dex log subscription dropped, fall back to polling", "exchange", t.exchange, "err", err)
+				t.unsubscribe()
+
+			case <-t.resourceCtx.Done():
+				t.unsubscribe()
+				t.saveCheckpoint()
+				log.Info("stop dex log task in work", "exchange", t.exchange)
+				return nil
+			}
+		}
+	})
+	return nil
+}
+
+func (t *DexLogTask) Close() error {
+	var result error
+	t.resourceCancel()
+	t.ticker.Stop()
+	if err := t.tasks.Wait(); err != nil {
+		result = errors.Join(result, fmt.Errorf("%s log task wait error: %w", t.exchange, err))
+	}
+	log.Info("dex log task stopped success", "exchange", t.exchange)
+	return result
+}
+
+// poll 池子变化时重新订阅; 校验回滚后, 未订阅时从 checkpoint 追赶到已确认的区块, 追上后尝试订阅
+func (t *DexLogTask) poll() {
+	ctx, cancel := context.WithTimeout(t.resourceCtx, dexCallTimeout)
+	defer cancel()
+	defer t.saveCheckpoint()
+
+	pools := t.source.Pools()
+	slices.SortFunc(pools, func(a, b common2.Address) int { return a.Cmp(b) })
+	if !slices.Equal(pools, t.addresses) {
+		t.unsubscribe()
+		t.addresses = pools
+	}
+
+	head, err := t.feed.HeadBlock(ctx)
+	if err != nil {
+		log.Error("get head block failed", "exchange", t.exchange, "err", err)
+		return
+	}
+	if !t.checkReorg(ctx, head) {
+		return
+	}
+	defer t.prune()
+
+	confirmed := uint64(0)
+	if head > t.confirmations {
+		confirmed = head - t.confirmations
+	}
+	if t.processed == 0 {
+		// 首次启动从已确认的区块开始, 之前的价格由任务直接读取合约状态
+		t.processed = confirmed
+	}
+	if t.sub != nil {
+		if head > 0 {
+			t.processed = max(t.processed, head-1)
+		}
+		return
+	}
+	if len(t.addresses) == 0 {
+		t.processed = max(t.processed, confirmed)
+		return
+	}
+
+	// 先订阅再追赶, 避免追赶期间出块造成遗漏, 重复的日志由 apply 忽略
+	if t.canSubscribe() && confirmed-t.processed <= maxCatchUpBlocks {
+		sub, err := t.feed.Subscribe(t.resourceCtx, t.addresses, t.logs)
+		if err != nil {
+			log.Warn("subscribe dex logs failed, polling", "exchange", t.exchange, "err", err)
+		} else {
+			t.sub = sub
+			log.Info("dex logs subscribed", "exchange", t.exchange, "pools", len(t.addresses))
+		}
+	}
+
+	if t.processed >= confirmed {
+		return
+	}
+	to := min(confirmed, t.processed+maxCatchUpBlocks)
+	logs, err := t.feed.FilterLogs(ctx, t.addresses, t.processed+1, to)
+	if err != nil {
+		log.Error("filter dex logs failed", "exchange", t.exchange, "from", t.processed+1, "to", to, "err", err)
+		// 追赶失败时取消订阅, 下次从 checkpoint 重新追赶, 避免订阅推进 checkpoint 跳过未追赶的区块
+		t.unsubscribe()
+		return
+	}
+	for _, entry := range logs {
+		if t.orphaned(entry) {
+			// 查询期间发生回滚, 下次轮询校验区块头后重新读取
+			log.Warn("dex log from orphaned block, retry later", "exchange", t.exchange, "block", entry.BlockNumber, "hash", entry.BlockHash)
+			t.unsubscribe()
+			return
+		}
+	}
+	for _, entry := range logs {
+		t.apply(entry)
+	}
+	t.processed = to
+}
+
+// checkReorg 按区块头校验到 head 的链, 回滚时撤销被回滚区块中的日志, 区块头读取失败时返回false
+func (t *DexLogTask) checkReorg(ctx context.Context, head uint64) bool {
+	fork, reorged, err := t.tracker.Sync(ctx, head)
+	if err != nil && !errors.Is(err, evm.ErrReorgTooDeep) {
+		if reorged {
+			t.rollback(fork)
+		}
+		log.Error("sync block headers failed", "exchange", t.exchange, "head", head, "err", err)
+		return false
+	}
+	if err != nil {
+		log.Warn("dex chain reorg deeper than the header window", "exchange", t.exchange, "fork", fork, "window", blockWindowSize)
+	}
+
+	// 订阅收到的日志可能来自同步区块头之前已被替换的区块, 按窗口中的哈希再校验一次
+	for _, entries := range t.journal {
+		for _, entry := range entries {
+			if t.orphaned(entry) && (!reorged || entry.BlockNumber < fork) {
+				fork, reorged = entry.BlockNumber, true
+			}
+		}
+	}
+	if reorged {
+		t.rollback(fork)
+	}
+	return true
+}
+
+// rollback 撤销 fork 及之后区块中的日志, 池子恢复为之前最后一条日志的价格, 没有更早的日志时由 DexLogSource 重新读取
+// 随后取消订阅并丢弃缓冲中的日志, 由本次轮询从 fork 重新读取规范链的日志
+func (t *DexLogTask) rollback(fork uint64) {
+	t.unsubscribe()
+drain:
+	for {
+		select {
+		case <-t.logs:
+		default:
+			break drain
+		}
+	}
+
+	restored, reset := 0, 0
+	for address, entries := range t.journal {
+		keep := slices.IndexFunc(entries, func(entry types.Log) bool { return entry.BlockNumber >= fork })
+		if keep < 0 {
+			continue
+		}
+		if keep == 0 {
+			delete(t.journal, address)
+			t.source.ResetPool(address)
+			reset++
+			continue
+		}
+		t.journal[address] = entries[:keep]
+		t.source.ApplyLog(entries[keep-1])
+		restored++
+	}
+	if fork > 0 && t.processed >= fork {
+		t.processed = fork - 1
+	}
+	log.Warn("dex chain reorg, rolled back", "exchange", t.exchange, "fork", fork, "restored", restored, "reset", reset, "processed", t.processed)
+}
+
+// prune 丢弃区块头窗口之前的日志, 每个池子保留窗口之前的最后一条
+func (t *DexLogTask) prune() {
+	tip, ok := t.tracker.Tip()
+	if !ok || tip.Number < blockWindowSize {
+		return
+	}
+	oldest := tip.Number - blockWindowSize + 1
+	for address, entries := range t.journal {
+		first := slices.IndexFunc(entries, func(entry types.Log) bool { return entry.BlockNumber >= oldest })
+		if first < 0 {
+			first = len(entries)
+		}
+		if first > 1 {
+			t.journal[address] = slices.Clone(entries[first-1:])
+		}
+	}
+}
+
+// apply 忽略已回滚、来自被回滚区块和已处理过的日志
+func (t *DexLogTask) apply(entry types.Log) {
+	if entry.Removed || t.orphaned(entry) {
+		return
+	}
+	entries := t.journal[entry.Address]
+	position := logPosition{block: entry.BlockNumber, index: entry.Index}
+	if len(entries) > 0 {
+		last := entries[len(entries)-1]
+		if !(logPosition{block: last.BlockNumber, index: last.Index}).before(position) {
+			return
+		}
+	}
+	t.journal[entry.Address] = append(entries, entry)
+	t.source.ApplyLog(entry)
+}
+
+// orphaned 日志所在区块与区块头窗口中同一高度的区块不一致
+func (t *DexLogTask) orphaned(entry types.Log) bool {
+	hash, ok := t.tracker.Hash(entry.BlockNumber)
+	return ok && hash != entry.BlockHash
+}
+
+// canSubscribe 确认数为0且配置了 websocket 节点时订阅日志
+func (t *DexLogTask) canSubscribe() bool {
+	return t.confirmations == 0 && t.feed.CanSubscribe()
+}
+
+func (t *DexLogTask) unsubscribe() {
+	if t.sub == nil {
+		return
+	}
+	t.sub.Unsubscribe()
+	t.sub = nil
+}
+
+// saveCheckpoint 区块推进时写入 checkpoint, 失败时下次重试
+func (t *DexLogTask) saveCheckpoint() {
+	if t.processed == t.saved {
+		return
+	}
+	if err := t.db.SaveCheckpoint(string(t.exchange), t.chainId, logsCheckpoint, t.processed); err != nil {
+		log.Error("save log checkpoint failed", "exchange", t.exchange, "block", t.processed, "err", err)
+		return
+	}
+	t.saved = t.processed
+}
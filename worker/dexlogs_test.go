@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/339-Labs/exchange-market/exchange/dex/evm"
+	common2 "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type recordSource struct {
+	applied []types.Log
+	reset   []common2.Address
+}
+
+func (s *recordSource) Pools() []common2.Address { return nil }
+
+func (s *recordSource) ApplyLog(entry types.Log) { s.applied = append(s.applied, entry) }
+
+func (s *recordSource) ResetPool(pool common2.Address) { s.reset = append(s.reset, pool) }
+
+func newTestDexLogTask(source DexLogSource) *DexLogTask {
+	tracker, _ := evm.NewBlockTracker(nil, blockWindowSize)
+	return &DexLogTask{
+		source:  source,
+		tracker: tracker,
+		journal: make(map[common2.Address][]types.Log),
+		logs:    make(chan types.Log, logBufferSize),
+	}
+}
+
+func TestDexLogTask_ApplyInOrder(t *testing.T) {
+	source := &recordSource{}
+	task := newTestDexLogTask(source)
+	pool := common2.HexToAddress("0x01")
+	other := common2.HexToAddress("0x02")
+
+	task.apply(types.Log{Address: pool, BlockNumber: 10, Index: 3})
+	task.apply(types.Log{Address: pool, BlockNumber: 10, Index: 3})                // 订阅和轮询重复
+	task.apply(types.Log{Address: pool, BlockNumber: 9, Index: 7})                 // 更早的日志
+	task.apply(types.Log{Address: pool, BlockNumber: 11, Index: 0, Removed: true}) // 已回滚
+	task.apply(types.Log{Address: other, BlockNumber: 9, Index: 1})
+	task.apply(types.Log{Address: pool, BlockNumber: 10, Index: 4})
+
+	if len(source.applied) != 3 {
+		t.Fatalf("applied %d logs, want 3", len(source.applied))
+	}
+	if last := source.applied[2]; last.Address != pool || last.Index != 4 {
+		t.Fatalf("last applied = %+v", last)
+	}
+}
+
+func TestDexLogTask_Rollback(t *testing.T) {
+	source := &recordSource{}
+	task := newTestDexLogTask(source)
+	pool := common2.HexToAddress("0x01")
+	other := common2.HexToAddress("0x02")
+
+	task.apply(types.Log{Address: pool, BlockNumber: 10, Index: 0})
+	task.apply(types.Log{Address: pool, BlockNumber: 12, Index: 0})
+	task.apply(types.Log{Address: other, BlockNumber: 12, Index: 1})
+	task.processed = 12
+
+	task.rollback(11)
+	if task.processed != 10 {
+		t.Fatalf("processed = %d, want 10", task.processed)
+	}
+	// pool 恢复为区块 10 的日志, other 没有更早的日志, 需要重新读取
+	if last := source.applied[len(source.applied)-1]; last.Address != pool || last.BlockNumber != 10 {
+		t.Fatalf("restored = %+v", last)
+	}
+	if len(source.reset) != 1 || source.reset[0] != other {
+		t.Fatalf("reset = %v", source.reset)
+	}
+
+	// 规范链上同一区块的日志下标可能更小, 回滚后不应被忽略
+	task.apply(types.Log{Address: pool, BlockNumber: 11, Index: 0})
+	if last := source.applied[len(source.applied)-1]; last.BlockNumber != 11 {
+		t.Fatalf("canonical log not applied, last = %+v", last)
+	}
+}
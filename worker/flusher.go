@@ -0,0 +1,308 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/common/symbols"
+	"github.com/339-Labs/exchange-market/database"
+	"github.com/339-Labs/exchange-market/database/symbol"
+	"github.com/339-Labs/exchange-market/redis"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/google/uuid"
+	"strconv"
+	"time"
+)
+
+const (
+	flushBatchSize  = 500              // 单次写入redis/数据库的最大条数
+	priceTTL        = 5 * time.Minute  // redis 行情过期时间, 交易所断流后旧价格自动失效
+	persistInterval = 30 * time.Second // 写入数据库的间隔
+	flushTimeout    = 10 * time.Second // 单次刷新超时时间
+	defaultChainId  = "999999"         // 中心化交易所的 chain id
+)
+
+// PriceStore 行情缓存, 由 redis.RedisClient 实现
+type PriceStore interface {
+	BatchSetPriceDataWithTTL(ctx context.Context, priceDataList []*maps.PriceData, ttl time.Duration) error
+}
+
+// PriceFlusher 把交易所的 PriceMap 刷新到 redis 和数据库
+// 每次刷新对比上次写入的值, 只写入变化的交易对; 变化的交易对累积后按 persistInterval 批量写入数据库
+type PriceFlusher struct {
+	exchange common.Exchange
+	chainId  string
+
+	spotPriceMap    *maps.PriceMap
+	featurePriceMap *maps.PriceMap
+	markPriceMap    *maps.PriceMap // 可为空
+	rateMap         *maps.PriceMap // 可为空
+
+	store     PriceStore
+	spotDB    symbol.SymbolSpotPricesDB
+	futuresDB symbol.SymbolFuturesPricesDB
+
+	// 上次写入redis的值, 用于判断是否变化
+	lastSpot    map[string]maps.PriceData
+	lastFutures map[string]maps.PriceData
+
+	// 等待写入数据库的交易对
+	pendingSpot    map[string]maps.PriceData
+	pendingFutures map[string]maps.PriceData
+	lastPersist    time.Time
+}
+
+// NewPriceFlusher 创建刷新器, db 或 store 为空时跳过对应的写入
+func NewPriceFlusher(exchange common.Exchange, db *database.DB, store PriceStore, spotPriceMap, featurePriceMap, markPriceMap, rateMap *maps.PriceMap) *PriceFlusher {
+	flusher := &PriceFlusher{
+		exchange:        exchange,
+		chainId:         defaultChainId,
+		spotPriceMap:    spotPriceMap,
+		featurePriceMap: featurePriceMap,
+		markPriceMap:    markPriceMap,
+		rateMap:         rateMap,
+		store:           store,
+		lastSpot:        make(map[string]maps.PriceData),
+		lastFutures:     make(map[string]maps.PriceData),
+		pendingSpot:     make(map[string]maps.PriceData),
+		pendingFutures:  make(map[string]maps.PriceData),
+		lastPersist:     time.Now(),
+	}
+	if db != nil {
+		flusher.spotDB = db.SymbolSpotPrices
+		flusher.futuresDB = db.SymbolFuturesPrices
+	}
+	return flusher
+}
+
+// SetChainId 链上交易所写入数据库时使用的 chain id, 默认为中心化交易所的 chain id
+func (f *PriceFlusher) SetChainId(chainId string) {
+	f.chainId = chainId
+}
+
+// Flush 刷新变化的行情到redis, 到达持久化间隔或 force 为 true 时写入数据库
+func (f *PriceFlusher) Flush(ctx context.Context, force bool) error {
+	var result error
+
+	spot := diffPrices(f.tagPrices(snapshotPrices(f.spotPriceMap), common.InstTypeSpot), f.lastSpot)
+	futures := diffPrices(f.tagPrices(mergeFuturesPrices(f.featurePriceMap, f.markPriceMap, f.rateMap), common.InstTypePerp), f.lastFutures)
+
+	if err := f.writeStore(ctx, spot); err != nil {
+		result = errors.Join(result, fmt.Errorf("write spot prices to redis: %w", err))
+	} else {
+		commitPrices(spot, f.lastSpot, f.pendingSpot)
+	}
+	if err := f.writeStore(ctx, futures); err != nil {
+		result = errors.Join(result, fmt.Errorf("write futures prices to redis: %w", err))
+	} else {
+		commitPrices(futures, f.lastFutures, f.pendingFutures)
+	}
+
+	if force || time.Since(f.lastPersist) >= persistInterval {
+		if err := f.persist(); err != nil {
+			result = errors.Join(result, err)
+		}
+	}
+
+	return result
+}
+
+// writeStore 分批写入redis
+func (f *PriceFlusher) writeStore(ctx context.Context, prices map[string]maps.PriceData) error {
+	if f.store == nil || len(prices) == 0 {
+		return nil
+	}
+
+	batch := make([]*maps.PriceData, 0, min(len(prices), flushBatchSize))
+	for _, price := range prices {
+		price := price
+		batch = append(batch, &price)
+		if len(batch) == flushBatchSize {
+			if err := f.store.BatchSetPriceDataWithTTL(ctx, batch, priceTTL); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		return f.store.BatchSetPriceDataWithTTL(ctx, batch, priceTTL)
+	}
+	return nil
+}
+
+// persist 写入数据库, 失败时保留待写入数据, 下次重试
+func (f *PriceFlusher) persist() error {
+	f.lastPersist = time.Now()
+	var result error
+
+	if f.spotDB != nil && len(f.pendingSpot) > 0 {
+		rows := make([]symbol.SymbolSpotPrices, 0, len(f.pendingSpot))
+		for _, price := range f.pendingSpot {
+			rows = append(rows, symbol.SymbolSpotPrices{
+				GUID:          uuid.New(),
+				Symbol:        price.Symbol,
+				UnifiedSymbol: price.UnifiedSymbol,
+				Price:         price.Price,
+				Exchange:      string(f.exchange),
+				ChainId:       f.chainId,
+				Timestamp:     parseTimestamp(price.Timestamp),
+			})
+		}
+		if err := persistBatches(rows, f.spotDB.UpsertSymbolSpotPrices); err != nil {
+			result = errors.Join(result, fmt.Errorf("persist spot prices: %w", err))
+		} else {
+			clear(f.pendingSpot)
+		}
+	}
+
+	if f.futuresDB != nil && len(f.pendingFutures) > 0 {
+		rows := make([]symbol.SymbolFuturesPrices, 0, len(f.pendingFutures))
+		for _, price := range f.pendingFutures {
+			rows = append(rows, symbol.SymbolFuturesPrices{
+				GUID:          uuid.New(),
+				Symbol:        price.Symbol,
+				UnifiedSymbol: price.UnifiedSymbol,
+				Price:         price.Price,
+				MarkPrice:     price.MarkPrice,
+				FundingRate:   price.FundingRate,
+				Exchange:      string(f.exchange),
+				ChainId:       f.chainId,
+				Timestamp:     parseTimestamp(price.Timestamp),
+			})
+		}
+		if err := persistBatches(rows, f.futuresDB.UpsertSymbolFuturesPrices); err != nil {
+			result = errors.Join(result, fmt.Errorf("persist futures prices: %w", err))
+		} else {
+			clear(f.pendingFutures)
+		}
+	}
+
+	return result
+}
+
+// runFlush 定时任务中执行一次刷新
+func (f *PriceFlusher) runFlush(force bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	if err := f.Flush(ctx, force); err != nil {
+		log.Error("flush prices failed", "exchange", f.exchange, "err", err)
+	}
+}
+
+// tagPrices 填充交易所、产品类型和统一交易对, 用于生成redis键
+func (f *PriceFlusher) tagPrices(prices map[string]maps.PriceData, instType string) map[string]maps.PriceData {
+	for key, price := range prices {
+		price.Exchange = string(f.exchange)
+		price.InstType = instType
+		price.UnifiedSymbol = symbols.Default.Unify(f.exchange, instType, price.Symbol)
+		prices[key] = price
+	}
+	return prices
+}
+
+// snapshotPrices 复制 PriceMap 当前的值
+func snapshotPrices(priceMap *maps.PriceMap) map[string]maps.PriceData {
+	if priceMap == nil {
+		return map[string]maps.PriceData{}
+	}
+
+	all := priceMap.ReadAll()
+	result := make(map[string]maps.PriceData, len(all))
+	for key, value := range all {
+		if value != nil {
+			result[key] = *value
+		}
+	}
+	return result
+}
+
+// mergeFuturesPrices 合并合约的最新价、标记价格和资金费率, 部分交易所分不同频道推送
+func mergeFuturesPrices(featurePriceMap, markPriceMap, rateMap *maps.PriceMap) map[string]maps.PriceData {
+	result := snapshotPrices(featurePriceMap)
+
+	merge := func(priceMap *maps.PriceMap, apply func(dst *maps.PriceData, src maps.PriceData)) {
+		for key, src := range snapshotPrices(priceMap) {
+			dst, ok := result[key]
+			if !ok {
+				dst = maps.PriceData{Symbol: src.Symbol}
+			}
+			apply(&dst, src)
+			if parseMillis(src.Timestamp) > parseMillis(dst.Timestamp) {
+				dst.Timestamp = src.Timestamp
+			}
+			result[key] = dst
+		}
+	}
+
+	merge(markPriceMap, func(dst *maps.PriceData, src maps.PriceData) {
+		if src.MarkPrice.IsSet() {
+			dst.MarkPrice = src.MarkPrice
+		}
+	})
+	merge(rateMap, func(dst *maps.PriceData, src maps.PriceData) {
+		if src.FundingRate.IsSet() {
+			dst.FundingRate = src.FundingRate
+		}
+	})
+	return result
+}
+
+// diffPrices 返回与上次写入不同的交易对
+func diffPrices(current map[string]maps.PriceData, last map[string]maps.PriceData) map[string]maps.PriceData {
+	changed := make(map[string]maps.PriceData)
+	for key, price := range current {
+		if prev, ok := last[key]; ok && prev.Equal(price) {
+			continue
+		}
+		changed[key] = price
+	}
+	return changed
+}
+
+// commitPrices 记录已写入redis的值, 并加入待写入数据库的集合
+func commitPrices(changed map[string]maps.PriceData, last map[string]maps.PriceData, pending map[string]maps.PriceData) {
+	for key, price := range changed {
+		last[key] = price
+		pending[key] = price
+	}
+}
+
+// persistBatches 分批写入数据库
+func persistBatches[T any](rows []T, upsert func(*[]T) error) error {
+	for start := 0; start < len(rows); start += flushBatchSize {
+		end := min(start+flushBatchSize, len(rows))
+		batch := rows[start:end]
+		if err := upsert(&batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseTimestamp 解析毫秒时间戳, 无效时使用当前时间
+func parseTimestamp(ts string) uint64 {
+	if value := parseMillis(ts); value > 0 {
+		return value
+	}
+	return uint64(time.Now().UnixMilli())
+}
+
+// parseMillis 解析毫秒时间戳, 无效时返回0
+func parseMillis(ts string) uint64 {
+	value, err := strconv.ParseUint(ts, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// priceStore redis 未配置时返回空, 避免空指针被包装成非空接口
+func priceStore(client *redis.RedisClient) PriceStore {
+	if client == nil {
+		return nil
+	}
+	return client
+}
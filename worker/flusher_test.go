@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/maps"
+)
+
+type mockPriceStore struct {
+	writes map[string]maps.PriceData
+	calls  int
+}
+
+func (m *mockPriceStore) BatchSetPriceDataWithTTL(ctx context.Context, priceDataList []*maps.PriceData, ttl time.Duration) error {
+	m.calls++
+	for _, price := range priceDataList {
+		m.writes[price.Symbol] = *price
+	}
+	return nil
+}
+
+func TestPriceFlusher_Flush(t *testing.T) {
+	spotPriceMap := maps.NewPriceMap(10)
+	featurePriceMap := maps.NewPriceMap(10)
+	markPriceMap := maps.NewPriceMap(10)
+	store := &mockPriceStore{writes: make(map[string]maps.PriceData)}
+	flusher := NewPriceFlusher(common.Okx, nil, store, spotPriceMap, featurePriceMap, markPriceMap, nil)
+
+	spotPriceMap.Write("BTC-USDT", &maps.PriceData{Symbol: "BTC-USDT", Price: common.MustParseDecimal("100"), Timestamp: "1"})
+	featurePriceMap.Write("BTC-USDT-SWAP", &maps.PriceData{Symbol: "BTC-USDT-SWAP", Price: common.MustParseDecimal("101"), Timestamp: "1"})
+	markPriceMap.Write("BTC-USDT-SWAP", &maps.PriceData{Symbol: "BTC-USDT-SWAP", MarkPrice: common.MustParseDecimal("102"), Timestamp: "2"})
+
+	if err := flusher.Flush(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+	futures := store.writes["BTC-USDT-SWAP"]
+	if futures.Price.String() != "101" || futures.MarkPrice.String() != "102" || futures.Timestamp != "2" ||
+		futures.InstType != common.InstTypePerp || futures.UnifiedSymbol != "BTC/USDT" {
+		t.Fatalf("unexpected merged futures price %+v", futures)
+	}
+	if len(flusher.pendingSpot) != 1 || len(flusher.pendingFutures) != 1 {
+		t.Fatalf("expected pending rows, got spot=%d futures=%d", len(flusher.pendingSpot), len(flusher.pendingFutures))
+	}
+
+	// 未变化时不写入
+	calls := store.calls
+	if err := flusher.Flush(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+	if store.calls != calls {
+		t.Fatalf("unchanged prices written again")
+	}
+
+	spotPriceMap.Write("BTC-USDT", &maps.PriceData{Symbol: "BTC-USDT", Price: common.MustParseDecimal("99"), Timestamp: "3"})
+	if err := flusher.Flush(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+	if store.calls != calls+1 || store.writes["BTC-USDT"].Price.String() != "99" {
+		t.Fatalf("changed spot price not written, calls=%d price=%s", store.calls, store.writes["BTC-USDT"].Price)
+	}
+}
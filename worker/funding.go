@@ -0,0 +1,160 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/funding"
+	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/common/tasks"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/database"
+	funding2 "github.com/339-Labs/exchange-market/database/funding"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/google/uuid"
+	"time"
+)
+
+// FundingTask 定时从各交易所的内存行情计算年化资金费率、基差和跨所费率差, 并保存资金费结算记录
+type FundingTask struct {
+	period   time.Duration
+	registry *maps.Registry
+	tracker  *funding.Tracker
+	store    *funding.Store
+	db       funding2.FundingRateHistoryDB
+
+	resourceCtx    context.Context
+	resourceCancel context.CancelFunc
+	tasks          tasks.Group
+	ticker         *time.Ticker
+}
+
+// NewFundingTask 创建资金费率分析任务, tracker 需注册到交易所的统一行情事件, db 为空时不保存结算记录
+func NewFundingTask(shutdown context.CancelCauseFunc, config config.FundingConfig, registry *maps.Registry, tracker *funding.Tracker, store *funding.Store, db *database.DB) (*FundingTask, error) {
+	if config.Interval <= 0 {
+		return nil, fmt.Errorf("invalid funding interval %s", config.Interval)
+	}
+	if config.Period <= 0 {
+		return nil, fmt.Errorf("invalid funding period %s", config.Period)
+	}
+
+	resCtx, resCancel := context.WithCancel(context.Background())
+	task := &FundingTask{
+		period:         config.Period,
+		registry:       registry,
+		tracker:        tracker,
+		store:          store,
+		resourceCtx:    resCtx,
+		resourceCancel: resCancel,
+		tasks: tasks.Group{HandleCrit: func(err error) {
+			shutdown(fmt.Errorf("funding task error: %w", err))
+		}},
+		ticker: time.NewTicker(config.Interval),
+	}
+	if db != nil {
+		task.db = db.FundingRateHistory
+	}
+	return task, nil
+}
+
+func (t *FundingTask) Start() error {
+	log.Info("funding task started", "period", t.period)
+	t.tasks.Go(func() error {
+		for {
+			select {
+			case <-t.ticker.C:
+				t.store.Set(t.reports(time.Now()))
+				t.saveSettlements()
+
+			case <-t.resourceCtx.Done():
+				log.Info("stop funding task in work")
+				return nil
+			}
+		}
+	})
+	return nil
+}
+
+func (t *FundingTask) Close() error {
+	var result error
+	t.resourceCancel()
+	t.ticker.Stop()
+	if err := t.tasks.Wait(); err != nil {
+		result = errors.Join(result, fmt.Errorf("funding task wait error: %w", err))
+	}
+	t.saveSettlements()
+	log.Info("funding task stopped success")
+	return result
+}
+
+// reports 按统一交易对汇总各交易所永续的资金费率和同交易所的现货价格, 没有资金费率的交易对跳过
+func (t *FundingTask) reports(now time.Time) []*funding.Report {
+	venues := make(map[string][]funding.Venue)
+	for _, exchange := range t.registry.Exchanges() {
+		venueMaps, _ := t.registry.Get(exchange)
+
+		spot := make(map[string]common.Decimal)
+		for _, price := range venueMaps.All(exchange, common.InstTypeSpot) {
+			spot[price.UnifiedSymbol] = price.Price
+		}
+
+		for _, price := range venueMaps.All(exchange, common.InstTypePerp) {
+			if !price.FundingRate.IsSet() || price.UnifiedSymbol == "" {
+				continue
+			}
+			venue := funding.Venue{
+				Exchange:    string(exchange),
+				Symbol:      price.Symbol,
+				FundingRate: price.FundingRate,
+				Interval:    t.period.Milliseconds(),
+				PerpPrice:   price.MarkPrice,
+				SpotPrice:   spot[price.UnifiedSymbol],
+			}
+			if !venue.PerpPrice.IsSet() {
+				venue.PerpPrice = price.Price
+			}
+			if schedule, ok := t.tracker.Schedule(exchange, price.UnifiedSymbol); ok {
+				venue.NextFundingTime = schedule.NextFundingTime.UnixMilli()
+				if schedule.Interval > 0 {
+					venue.Interval = schedule.Interval.Milliseconds()
+				}
+			}
+			venues[price.UnifiedSymbol] = append(venues[price.UnifiedSymbol], venue)
+		}
+	}
+
+	reports := make([]*funding.Report, 0, len(venues))
+	for symbol, list := range venues {
+		reports = append(reports, funding.NewReport(symbol, list, now))
+	}
+	return reports
+}
+
+// saveSettlements 保存资金费结算记录, 失败时放回等待下次重试
+func (t *FundingTask) saveSettlements() {
+	settlements := t.tracker.DrainSettlements()
+	if t.db == nil || len(settlements) == 0 {
+		return
+	}
+
+	now := uint64(time.Now().UnixMilli())
+	rows := make([]funding2.FundingRateHistory, 0, len(settlements))
+	for _, settlement := range settlements {
+		rows = append(rows, funding2.FundingRateHistory{
+			GUID:          uuid.New(),
+			Exchange:      string(settlement.Exchange),
+			Symbol:        settlement.Symbol,
+			UnifiedSymbol: settlement.UnifiedSymbol,
+			FundingRate:   settlement.Rate,
+			FundingTime:   uint64(settlement.FundingTime.UnixMilli()),
+			Timestamp:     now,
+		})
+	}
+	if err := t.db.SaveFundingRateHistory(&rows); err != nil {
+		log.Error("save funding rate history failed", "count", len(rows), "err", err)
+		t.tracker.Restore(settlements)
+		return
+	}
+	log.Info("funding settlements saved", "count", len(rows))
+}
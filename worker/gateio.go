@@ -0,0 +1,71 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/common/tasks"
+	"github.com/339-Labs/exchange-market/database"
+	"github.com/339-Labs/exchange-market/redis"
+	"github.com/ethereum/go-ethereum/log"
+	"time"
+)
+
+type GateIoTask struct {
+	flusher     *PriceFlusher
+	resourceCtx context.Context
+
+	resourceCancel context.CancelFunc
+	tasks          tasks.Group
+	ticker         *time.Ticker
+}
+
+func NewGateIoTask(shutdown context.CancelCauseFunc, duration time.Duration, db *database.DB, redisClient *redis.RedisClient, spotPriceMap *maps.PriceMap, featurePriceMap *maps.PriceMap) (*GateIoTask, error) {
+	resCtx, resCancel := context.WithCancel(context.Background())
+	return &GateIoTask{
+		resourceCtx:    resCtx,
+		resourceCancel: resCancel,
+		tasks: tasks.Group{HandleCrit: func(err error) {
+			shutdown(fmt.Errorf("gateio ws error "))
+		}},
+		ticker:  time.NewTicker(duration),
+		flusher: NewPriceFlusher(common.GateIo, db, priceStore(redisClient), spotPriceMap, featurePriceMap, nil, nil),
+	}, nil
+}
+
+func (t *GateIoTask) Start() error {
+	log.Info("gateio task started")
+	t.tasks.Go(func() error {
+		for {
+
+			select {
+
+			case <-t.ticker.C:
+				t.flusher.runFlush(false)
+
+			case <-t.resourceCtx.Done():
+				// 停止前写入剩余数据
+				t.flusher.runFlush(true)
+				log.Info("stop gateio task in work")
+				return nil
+
+			}
+
+		}
+	})
+	return nil
+}
+
+func (t *GateIoTask) Close() error {
+	var result error
+	t.resourceCancel()
+	t.ticker.Stop()
+	log.Info("gateio task stopped")
+	if err := t.tasks.Wait(); err != nil {
+		result = errors.Join(result, fmt.Errorf("gateio ws task wait error"))
+	}
+	log.Info("gateio task stopped success")
+	return nil
+}
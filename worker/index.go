@@ -0,0 +1,155 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/index"
+	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/common/symbols"
+	"github.com/339-Labs/exchange-market/common/tasks"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/ethereum/go-ethereum/log"
+	"strconv"
+	"time"
+)
+
+const indexTTL = time.Minute // redis 指数价格过期时间, 停止计算后旧价格自动失效
+
+// IndexPublisher 指数价格缓存, 由 redis.RedisClient 实现
+type IndexPublisher interface {
+	SetIndexPrices(ctx context.Context, results []*index.Result, ttl time.Duration) error
+}
+
+// IndexTask 定时从各交易所的内存行情计算跨交易所指数价格, 写入 index.Store 和 redis
+type IndexTask struct {
+	symbols   []string
+	options   index.Options
+	registry  *maps.Registry
+	stats     *index.Stats
+	store     *index.Store
+	publisher IndexPublisher
+
+	resourceCtx    context.Context
+	resourceCancel context.CancelFunc
+	tasks          tasks.Group
+	ticker         *time.Ticker
+}
+
+// NewIndexTask 创建指数价格任务, stats 为空时只能使用中位数, publisher 为空时不写入redis
+func NewIndexTask(shutdown context.CancelCauseFunc, config config.IndexConfig, registry *maps.Registry, stats *index.Stats, store *index.Store, publisher IndexPublisher) (*IndexTask, error) {
+	if config.Interval <= 0 {
+		return nil, fmt.Errorf("invalid index interval %s", config.Interval)
+	}
+	method, err := index.ParseMethod(config.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	unified := make([]string, 0, len(config.Symbols))
+	for _, value := range config.Symbols {
+		symbol, err := symbols.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index symbol %q: %w", value, err)
+		}
+		unified = append(unified, symbol.Pair())
+	}
+
+	options := index.Options{Method: method, MaxAge: config.MaxAge, MinSources: config.MinSources}
+	if config.MaxDeviation > 0 {
+		options.MaxDeviation = common.NewDecimalFromFloat(config.MaxDeviation)
+	}
+
+	resCtx, resCancel := context.WithCancel(context.Background())
+	return &IndexTask{
+		symbols:        unified,
+		options:        options,
+		registry:       registry,
+		stats:          stats,
+		store:          store,
+		publisher:      publisher,
+		resourceCtx:    resCtx,
+		resourceCancel: resCancel,
+		tasks: tasks.Group{HandleCrit: func(err error) {
+			shutdown(fmt.Errorf("index task error: %w", err))
+		}},
+		ticker: time.NewTicker(config.Interval),
+	}, nil
+}
+
+func (t *IndexTask) Start() error {
+	log.Info("index task started", "symbols", t.symbols, "method", t.options.Method)
+	t.tasks.Go(func() error {
+		for {
+			select {
+			case <-t.ticker.C:
+				t.compute()
+
+			case <-t.resourceCtx.Done():
+				log.Info("stop index task in work")
+				return nil
+			}
+		}
+	})
+	return nil
+}
+
+func (t *IndexTask) Close() error {
+	var result error
+	t.resourceCancel()
+	t.ticker.Stop()
+	if err := t.tasks.Wait(); err != nil {
+		result = errors.Join(result, fmt.Errorf("index task wait error: %w", err))
+	}
+	log.Info("index task stopped success")
+	return result
+}
+
+// compute 计算现货和永续的指数价格, 成分不足的交易对跳过
+func (t *IndexTask) compute() {
+	now := time.Now()
+	results := make([]*index.Result, 0, len(t.symbols)*2)
+	for _, symbol := range t.symbols {
+		for _, instType := range []string{common.InstTypeSpot, common.InstTypePerp} {
+			result, err := index.Compute(symbol, instType, t.sources(instType, symbol), t.options, now)
+			if err != nil {
+				log.Debug("skip index price", "symbol", symbol, "instType", instType, "err", err)
+				continue
+			}
+			t.store.Set(result)
+			results = append(results, result)
+		}
+	}
+
+	if t.publisher == nil || len(results) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(t.resourceCtx, flushTimeout)
+	defer cancel()
+	if err := t.publisher.SetIndexPrices(ctx, results, indexTTL); err != nil {
+		log.Error("write index prices to redis failed", "count", len(results), "err", err)
+	}
+}
+
+// sources 各交易所的最新价及权重, 更新时间取交易所推送的毫秒时间
+func (t *IndexTask) sources(instType string, unifiedSymbol string) []index.Source {
+	sources := make([]index.Source, 0)
+	for _, exchange := range t.registry.Exchanges() {
+		venue, _ := t.registry.Get(exchange)
+		price, ok := venue.Lookup(exchange, instType, unifiedSymbol)
+		if !ok {
+			continue
+		}
+
+		source := index.Source{Exchange: exchange, Price: price.Price}
+		if ms, err := strconv.ParseInt(price.Timestamp, 10, 64); err == nil && ms > 0 {
+			source.UpdatedAt = time.UnixMilli(ms)
+		}
+		if t.stats != nil {
+			source.Volume, source.Liquidity = t.stats.Lookup(exchange, instType, unifiedSymbol)
+		}
+		sources = append(sources, source)
+	}
+	return sources
+}
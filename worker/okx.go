@@ -4,25 +4,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/339-Labs/exchange-market/common"
 	"github.com/339-Labs/exchange-market/common/maps"
 	"github.com/339-Labs/exchange-market/common/tasks"
+	"github.com/339-Labs/exchange-market/database"
+	"github.com/339-Labs/exchange-market/redis"
 	"github.com/ethereum/go-ethereum/log"
 	"time"
 )
 
 type OkxTask struct {
-	spotPriceMap    *maps.PriceMap
-	featurePriceMap *maps.PriceMap
-	markPriceMap    *maps.PriceMap
-	rateMap         *maps.PriceMap
-	resourceCtx     context.Context
+	flusher     *PriceFlusher
+	resourceCtx context.Context
 
 	resourceCancel context.CancelFunc
 	tasks          tasks.Group
 	ticker         *time.Ticker
 }
 
-func NewOkxTask(shutdown context.CancelCauseFunc, duration time.Duration, spotPriceMap *maps.PriceMap, featurePriceMap *maps.PriceMap, markPriceMap *maps.PriceMap, rateMap *maps.PriceMap) (*OkxTask, error) {
+func NewOkxTask(shutdown context.CancelCauseFunc, duration time.Duration, db *database.DB, redisClient *redis.RedisClient, spotPriceMap *maps.PriceMap, featurePriceMap *maps.PriceMap, markPriceMap *maps.PriceMap, rateMap *maps.PriceMap) (*OkxTask, error) {
 	resCtx, resCancel := context.WithCancel(context.Background())
 	return &OkxTask{
 		resourceCtx:    resCtx,
@@ -30,9 +30,8 @@ func NewOkxTask(shutdown context.CancelCauseFunc, duration time.Duration, spotPr
 		tasks: tasks.Group{HandleCrit: func(err error) {
 			shutdown(fmt.Errorf("okx ws error "))
 		}},
-		ticker:          time.NewTicker(duration),
-		spotPriceMap:    spotPriceMap,
-		featurePriceMap: featurePriceMap,
+		ticker:  time.NewTicker(duration),
+		flusher: NewPriceFlusher(common.Okx, db, priceStore(redisClient), spotPriceMap, featurePriceMap, markPriceMap, rateMap),
 	}, nil
 }
 
@@ -44,9 +43,11 @@ func (t *OkxTask) Start() error {
 			select {
 
 			case <-t.ticker.C:
-				// todo  okx ws data handler, spot and feature
+				t.flusher.runFlush(false)
 
 			case <-t.resourceCtx.Done():
+				// 停止前写入剩余数据
+				t.flusher.runFlush(true)
 				log.Info("stop okx task in work")
 				return nil
 
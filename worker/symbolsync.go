@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/339-Labs/exchange-market/api/service"
+	"github.com/339-Labs/exchange-market/common/tasks"
+	"github.com/ethereum/go-ethereum/log"
+	"sort"
+	"time"
+)
+
+// SymbolSyncTask 定时从交易所加载交易对并写入 market_symbol
+type SymbolSyncTask struct {
+	loaders     map[string]service.HandlerSymbolAdaptor
+	resourceCtx context.Context
+
+	resourceCancel context.CancelFunc
+	tasks          tasks.Group
+	ticker         *time.Ticker
+}
+
+func NewSymbolSyncTask(shutdown context.CancelCauseFunc, duration time.Duration, loaders map[string]service.HandlerSymbolAdaptor) (*SymbolSyncTask, error) {
+	if len(loaders) == 0 {
+		return nil, fmt.Errorf("no symbol loader")
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("invalid symbol sync interval %s", duration)
+	}
+
+	resCtx, resCancel := context.WithCancel(context.Background())
+	return &SymbolSyncTask{
+		loaders:        loaders,
+		resourceCtx:    resCtx,
+		resourceCancel: resCancel,
+		tasks: tasks.Group{HandleCrit: func(err error) {
+			shutdown(fmt.Errorf("symbol sync error: %w", err))
+		}},
+		ticker: time.NewTicker(duration),
+	}, nil
+}
+
+func (t *SymbolSyncTask) Start() error {
+	log.Info("symbol sync task started")
+	t.tasks.Go(func() error {
+		// 启动后立即同步一次
+		if err := SyncSymbols(t.loaders); err != nil {
+			log.Error("sync symbols failed", "err", err)
+		}
+
+		for {
+			select {
+			case <-t.ticker.C:
+				if err := SyncSymbols(t.loaders); err != nil {
+					log.Error("sync symbols failed", "err", err)
+				}
+
+			case <-t.resourceCtx.Done():
+				log.Info("stop symbol sync task in work")
+				return nil
+			}
+		}
+	})
+	return nil
+}
+
+func (t *SymbolSyncTask) Close() error {
+	var result error
+	t.resourceCancel()
+	t.ticker.Stop()
+	if err := t.tasks.Wait(); err != nil {
+		result = errors.Join(result, fmt.Errorf("symbol sync task wait error: %w", err))
+	}
+	log.Info("symbol sync task stopped success")
+	return result
+}
+
+// SyncSymbols 依次同步各交易所的现货和合约交易对, 某个交易所失败不影响其他交易所
+func SyncSymbols(loaders map[string]service.HandlerSymbolAdaptor) error {
+	names := make([]string, 0, len(loaders))
+	for name := range loaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result error
+	for _, name := range names {
+		loader := loaders[name]
+		if err := loader.InitSpotSymbol(); err != nil {
+			result = errors.Join(result, fmt.Errorf("%s spot: %w", name, err))
+		}
+		if err := loader.InitFeatureSymbol(); err != nil {
+			result = errors.Join(result, fmt.Errorf("%s feature: %w", name, err))
+		}
+	}
+	return result
+}
@@ -0,0 +1,375 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/maps"
+	symbols2 "github.com/339-Labs/exchange-market/common/symbols"
+	"github.com/339-Labs/exchange-market/common/tasks"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/database"
+	"github.com/339-Labs/exchange-market/database/dex"
+	"github.com/339-Labs/exchange-market/database/symbol"
+	"github.com/339-Labs/exchange-market/exchange/dex/evm"
+	"github.com/339-Labs/exchange-market/redis"
+	common2 "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	pairsCheckpoint = "all_pairs"      // allPairs 下一个待处理的下标
+	maxPairAttempts = 3                // 代币不符合 ERC-20 的连续次数上限, 超过后跳过, 避免不规范的代币阻塞遍历
+	dexCallTimeout  = 30 * time.Second // 单轮链上调用的超时时间
+)
+
+// UniswapV2Task 从 checkpoint 开始遍历 factory 的 allPairs, 交易对写入 dex_pool 和 market_symbol,
+// 按储备量计算选定交易对的中间价, 价格方向为 token1/token0; 初始价格读取合约, 之后由 DexLogTask 按 Sync 日志更新
+// allPairs 按已确认的区块读取, 避免记录被回滚的交易对
+// 代币符号可以被仿冒, 只有按地址选定的交易对注册统一交易对并写入行情, 每个统一交易对只由一个交易对定价
+type UniswapV2Task struct {
+	indexer       *evm.UniswapV2Indexer
+	chainId       string
+	batchSize     int
+	confirmations uint64                   // 按最新区块之前 confirmations 个区块的状态发现交易对
+	selected      map[common2.Address]bool // 计算价格的交易对地址
+	db            *database.DB
+
+	mu           sync.RWMutex
+	pools        map[common2.Address]dex.DexPool // 计算价格的交易对, 由 mu 保护
+	pairs        map[string]string               // 统一交易对 -> 定价的交易对地址, 由 mu 保护
+	failures     map[uint64]int                  // allPairs 下标因代币不符合 ERC-20 连续失败的次数
+	spotPriceMap *maps.PriceMap
+	flusher      *PriceFlusher
+
+	resourceCtx    context.Context
+	resourceCancel context.CancelFunc
+	tasks          tasks.Group
+	ticker         *time.Ticker
+}
+
+// NewUniswapV2Task 创建 Uniswap V2 索引任务, chainId 由节点返回
+func NewUniswapV2Task(shutdown context.CancelCauseFunc, config config.DexExchangeConfig, indexer *evm.UniswapV2Indexer, chainId string, db *database.DB, redisClient *redis.RedisClient, spotPriceMap *maps.PriceMap) (*UniswapV2Task, error) {
+	if config.Interval <= 0 {
+		return nil, fmt.Errorf("invalid uniswap v2 interval %s", config.Interval)
+	}
+	if config.BatchSize <= 0 {
+		return nil, fmt.Errorf("invalid uniswap v2 batch size %d", config.BatchSize)
+	}
+
+	selected, err := parsePools(common.UniswapV2, config.Pools)
+	if err != nil {
+		return nil, err
+	}
+
+	flusher := NewPriceFlusher(common.UniswapV2, db, priceStore(redisClient), spotPriceMap, nil, nil, nil)
+	flusher.SetChainId(chainId)
+
+	resCtx, resCancel := context.WithCancel(context.Background())
+	return &UniswapV2Task{
+		indexer:        indexer,
+		chainId:        chainId,
+		batchSize:      config.BatchSize,
+		confirmations:  config.Confirmations,
+		selected:       selected,
+		db:             db,
+		pools:          make(map[common2.Address]dex.DexPool),
+		pairs:          make(map[string]string),
+		failures:       make(map[uint64]int),
+		spotPriceMap:   spotPriceMap,
+		flusher:        flusher,
+		resourceCtx:    resCtx,
+		resourceCancel: resCancel,
+		tasks: tasks.Group{HandleCrit: func(err error) {
+			shutdown(fmt.Errorf("uniswap v2 task error: %w", err))
+		}},
+		ticker: time.NewTicker(config.Interval),
+	}, nil
+}
+
+func (t *UniswapV2Task) Start() error {
+	log.Info("uniswap v2 task started", "chainId", t.chainId, "confirmations", t.confirmations)
+	t.tasks.Go(func() error {
+		if err := t.load(); err != nil {
+			return err
+		}
+		t.run()
+
+		for {
+			select {
+			case <-t.ticker.C:
+				t.run()
+
+			case <-t.resourceCtx.Done():
+				// 停止前写入剩余数据
+				t.flusher.runFlush(true)
+				log.Info("stop uniswap v2 task in work")
+				return nil
+			}
+		}
+	})
+	return nil
+}
+
+func (t *UniswapV2Task) Close() error {
+	var result error
+	t.resourceCancel()
+	t.ticker.Stop()
+	if err := t.tasks.Wait(); err != nil {
+		result = errors.Join(result, fmt.Errorf("uniswap v2 task wait error: %w", err))
+	}
+	log.Info("uniswap v2 task stopped success")
+	return result
+}
+
+// load 加载已发现的交易对
+func (t *UniswapV2Task) load() error {
+	pools, err := t.db.DexPool.QueryDexPools(string(common.UniswapV2), t.chainId)
+	if err != nil {
+		return fmt.Errorf("query uniswap v2 pools: %w", err)
+	}
+	t.track(pools)
+	log.Info("uniswap v2 pools loaded", "count", len(pools), "tracked", len(t.Pools()))
+	return nil
+}
+
+// run 发现新的交易对、刷新价格并写入redis
+func (t *UniswapV2Task) run() {
+	ctx, cancel := context.WithTimeout(t.resourceCtx, dexCallTimeout)
+	defer cancel()
+
+	if err := t.discover(ctx); err != nil {
+		log.Error("discover uniswap v2 pairs failed", "err", err)
+	}
+	t.refresh(ctx)
+	t.flusher.runFlush(false)
+}
+
+// discover 从 checkpoint 开始最多处理 batchSize 个已确认的交易对, 写入成功后推进 checkpoint
+func (t *UniswapV2Task) discover(ctx context.Context) error {
+	checkpoint, err := t.db.DexCheckpoint.GetCheckpoint(string(common.UniswapV2), t.chainId, pairsCheckpoint)
+	if err != nil {
+		return fmt.Errorf("get checkpoint: %w", err)
+	}
+	head, err := t.indexer.HeadBlock(ctx)
+	if err != nil {
+		return fmt.Errorf("get head block: %w", err)
+	}
+	if head < t.confirmations {
+		return nil
+	}
+	confirmed := head - t.confirmations
+	length, err := t.indexer.PairsLength(ctx, confirmed)
+	if err != nil {
+		return fmt.Errorf("get pairs length: %w", err)
+	}
+	if checkpoint >= length {
+		return nil
+	}
+
+	end := min(length, checkpoint+uint64(t.batchSize))
+	pools := make([]dex.DexPool, 0, end-checkpoint)
+	marketSymbols := make([]symbol.MarketSymbol, 0, end-checkpoint)
+	next := checkpoint
+	for index := checkpoint; index < end; index++ {
+		pair, err := t.indexer.Pair(ctx, index, confirmed)
+		if err != nil {
+			if ctx.Err() != nil || !t.skipPair(index, err) {
+				break
+			}
+			next = index + 1
+			continue
+		}
+		delete(t.failures, index)
+
+		pool := dex.DexPool{
+			Exchange:  string(common.UniswapV2),
+			ChainId:   t.chainId,
+			Address:   pair.Address.Hex(),
+			Token0:    pair.Token0.Address.Hex(),
+			Token1:    pair.Token1.Address.Hex(),
+			Symbol0:   pair.Token0.Symbol,
+			Symbol1:   pair.Token1.Symbol,
+			Decimals0: pair.Token0.Decimals,
+			Decimals1: pair.Token1.Decimals,
+		}
+		pools = append(pools, pool)
+		if unified, ok := poolSymbol(pool); ok {
+			marketSymbols = append(marketSymbols, symbol.MarketSymbol{
+				Symbol:        pool.Address,
+				UnifiedSymbol: unified.Pair(),
+				ChainId:       t.chainId,
+				Base:          unified.Base,
+				Quote:         unified.Quote,
+			})
+		}
+		next = index + 1
+	}
+
+	if next == checkpoint {
+		return nil
+	}
+	if err := t.db.DexPool.SaveDexPools(&pools); err != nil {
+		return fmt.Errorf("save pools: %w", err)
+	}
+	if err := t.db.MarketSymbol.UpsertMarketSymbols(string(common.UniswapV2), symbol.InstTypeSpot, marketSymbols); err != nil {
+		return fmt.Errorf("save market symbols: %w", err)
+	}
+	if err := t.db.DexCheckpoint.SaveCheckpoint(string(common.UniswapV2), t.chainId, pairsCheckpoint, next); err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	t.track(pools)
+	log.Info("uniswap v2 pairs discovered", "from", checkpoint, "to", next, "total", length, "block", confirmed)
+	return nil
+}
+
+// skipPair 记录读取交易对的错误, 只有代币不符合 ERC-20 计入失败次数, 达到上限时跳过该交易对
+// 网络错误不计入失败次数, 下次从该下标继续
+func (t *UniswapV2Task) skipPair(index uint64, err error) bool {
+	if !errors.Is(err, evm.ErrInvalidToken) {
+		log.Warn("read uniswap v2 pair failed, retry later", "index", index, "err", err)
+		return false
+	}
+	t.failures[index]++
+	if t.failures[index] < maxPairAttempts {
+		log.Warn("invalid uniswap v2 pair token, retry later", "index", index, "attempts", t.failures[index], "err", err)
+		return false
+	}
+	log.Warn("skip uniswap v2 pair", "index", index, "attempts", t.failures[index], "err", err)
+	delete(t.failures, index)
+	return true
+}
+
+// track 选中的交易对注册统一交易对并加入价格计算, 统一交易对已由其他交易对定价时跳过
+func (t *UniswapV2Task) track(pools []dex.DexPool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, pool := range pools {
+		address := common2.HexToAddress(pool.Address)
+		if !t.selected[address] {
+			continue
+		}
+		unified, ok := poolSymbol(pool)
+		if !ok {
+			log.Warn("skip uniswap v2 pair without valid symbols", "pair", pool.Address, "symbol0", pool.Symbol0, "symbol1", pool.Symbol1)
+			continue
+		}
+		if owner, ok := t.pairs[unified.Pair()]; ok && owner != pool.Address {
+			log.Warn("skip uniswap v2 pair, symbol already priced by another pair", "pair", pool.Address, "symbol", unified.Pair(), "owner", owner)
+			continue
+		}
+		t.pairs[unified.Pair()] = pool.Address
+		symbols2.Default.Register(common.UniswapV2, common.InstTypeSpot, pool.Address, unified)
+		t.pools[address] = pool
+	}
+}
+
+// refresh 读取尚无价格的交易对的储备量作为初始价格, 之后由 Sync 日志更新, 单个交易对失败不影响其他交易对
+func (t *UniswapV2Task) refresh(ctx context.Context) {
+	pending := make([]dex.DexPool, 0)
+	t.mu.RLock()
+	for _, pool := range t.pools {
+		if _, ok := t.spotPriceMap.Read(pool.Address); !ok {
+			pending = append(pending, pool)
+		}
+	}
+	t.mu.RUnlock()
+
+	for _, pool := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+		reserve0, reserve1, err := t.indexer.Reserves(ctx, common2.HexToAddress(pool.Address))
+		if err != nil {
+			log.Warn("read uniswap v2 reserves failed", "pair", pool.Address, "err", err)
+			continue
+		}
+
+		t.mu.Lock()
+		// 读取期间已收到 Sync 日志时保留日志的价格
+		if _, ok := t.spotPriceMap.Read(pool.Address); !ok {
+			t.writePrice(pool, reserve0, reserve1)
+		}
+		t.mu.Unlock()
+	}
+}
+
+// Pools 计算价格的交易对地址, 实现 DexLogSource
+func (t *UniswapV2Task) Pools() []common2.Address {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	addresses := make([]common2.Address, 0, len(t.pools))
+	for address := range t.pools {
+		addresses = append(addresses, address)
+	}
+	return addresses
+}
+
+// ApplyLog 按 Sync 日志中的储备量更新价格, 实现 DexLogSource
+func (t *UniswapV2Task) ApplyLog(entry types.Log) {
+	if len(entry.Topics) == 0 || entry.Topics[0] != evm.SyncTopic {
+		return
+	}
+	reserve0, reserve1, err := evm.ParseSync(entry)
+	if err != nil {
+		log.Warn("parse uniswap v2 sync failed", "pair", entry.Address, "tx", entry.TxHash, "err", err)
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if pool, ok := t.pools[entry.Address]; ok {
+		t.writePrice(pool, reserve0, reserve1)
+	}
+}
+
+// ResetPool 回滚后丢弃池子的价格, 由下一轮 refresh 重新读取储备量, 实现 DexLogSource
+func (t *UniswapV2Task) ResetPool(pool common2.Address) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if item, ok := t.pools[pool]; ok {
+		t.spotPriceMap.Delete(item.Address)
+	}
+}
+
+// writePrice 按储备量写入内存行情, 调用方需持有 mu
+func (t *UniswapV2Task) writePrice(pool dex.DexPool, reserve0, reserve1 *big.Int) {
+	price := evm.ReservePrice(reserve0, reserve1, pool.Decimals0, pool.Decimals1)
+	if !price.IsSet() {
+		return
+	}
+	t.spotPriceMap.Write(pool.Address, &maps.PriceData{
+		Symbol:    pool.Address,
+		Price:     price,
+		Timestamp: strconv.FormatInt(time.Now().UnixMilli(), 10),
+	})
+}
+
+// parsePools 解析按地址选定的池子
+func parsePools(exchange common.Exchange, items []string) (map[common2.Address]bool, error) {
+	selected := make(map[common2.Address]bool, len(items))
+	for _, item := range items {
+		if !common2.IsHexAddress(item) {
+			return nil, fmt.Errorf("invalid %s pool address %q", exchange, item)
+		}
+		selected[common2.HexToAddress(item)] = true
+	}
+	return selected, nil
+}
+
+// poolSymbol 以 token0 为 base、token1 为 quote 的统一交易对, 代币符号为空或包含分隔符时返回false
+func poolSymbol(pool dex.DexPool) (symbols2.Symbol, bool) {
+	for _, value := range []string{pool.Symbol0, pool.Symbol1} {
+		if value == "" || strings.ContainsAny(value, "/: \t\n") {
+			return symbols2.Symbol{}, false
+		}
+	}
+	return symbols2.NewSymbol(pool.Symbol0, pool.Symbol1, ""), true
+}
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/common/symbols"
+	"github.com/339-Labs/exchange-market/database/dex"
+	"github.com/339-Labs/exchange-market/exchange/dex/evm"
+	common2 "github.com/ethereum/go-ethereum/common"
+)
+
+func TestUniswapV2TrackSelected(t *testing.T) {
+	canonical := dex.DexPool{Address: "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc", Symbol0: "USDC", Symbol1: "WETH"}
+	duplicate := dex.DexPool{Address: "0x0000000000000000000000000000000000000002", Symbol0: "USDC", Symbol1: "WETH"}
+	spoofed := dex.DexPool{Address: "0x0000000000000000000000000000000000000003", Symbol0: "USDC", Symbol1: "WETH"}
+
+	selected, err := parsePools(common.UniswapV2, []string{canonical.Address, duplicate.Address})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parsePools(common.UniswapV2, []string{"USDC/WETH"}); err == nil {
+		t.Fatal("expected error for symbol instead of address")
+	}
+	task := &UniswapV2Task{
+		selected:     selected,
+		pools:        make(map[common2.Address]dex.DexPool),
+		pairs:        make(map[string]string),
+		spotPriceMap: maps.NewPriceMap(10),
+	}
+
+	// 未选中的同名交易对不注册, 选中的第二个同名交易对不定价
+	task.track([]dex.DexPool{spoofed, canonical, duplicate})
+	if pools := task.Pools(); len(pools) != 1 || pools[0] != common2.HexToAddress(canonical.Address) {
+		t.Fatalf("unexpected pools %v", pools)
+	}
+	if _, ok := symbols.Default.Resolve(common.UniswapV2, common.InstTypeSpot, spoofed.Address); ok {
+		t.Fatal("unselected pair should not be registered")
+	}
+	if venue, _ := symbols.Default.ToVenue(common.UniswapV2, common.InstTypeSpot, symbols.NewSymbol("USDC", "WETH", "")); !strings.EqualFold(venue, canonical.Address) {
+		t.Fatalf("USDC/WETH should map to %s, got %s", canonical.Address, venue)
+	}
+}
+
+func TestUniswapV2SkipPair(t *testing.T) {
+	task := &UniswapV2Task{failures: make(map[uint64]int)}
+
+	// 网络错误不计入失败次数
+	for i := 0; i < maxPairAttempts+1; i++ {
+		if task.skipPair(7, context.DeadlineExceeded) {
+			t.Fatal("transient error should not skip the pair")
+		}
+	}
+
+	invalid := fmt.Errorf("call decimals: %w", evm.ErrInvalidToken)
+	for i := 1; i < maxPairAttempts; i++ {
+		if task.skipPair(7, invalid) {
+			t.Fatalf("skipped after %d attempts", i)
+		}
+	}
+	if !task.skipPair(7, invalid) || len(task.failures) != 0 {
+		t.Fatalf("expected skip after %d invalid token errors", maxPairAttempts)
+	}
+}
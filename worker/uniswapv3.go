@@ -0,0 +1,408 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/common/orderbook"
+	symbols2 "github.com/339-Labs/exchange-market/common/symbols"
+	"github.com/339-Labs/exchange-market/common/tasks"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/database"
+	"github.com/339-Labs/exchange-market/database/dex"
+	"github.com/339-Labs/exchange-market/database/symbol"
+	"github.com/339-Labs/exchange-market/exchange/dex/evm"
+	"github.com/339-Labs/exchange-market/redis"
+	common2 "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"math/big"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	poolCreatedCheckpoint = "pool_created" // 下一个待扫描 PoolCreated 事件的区块
+	inverseSuffix         = ":inverse"     // 反向价格 token0/token1 的行情键后缀
+)
+
+// UniswapV3Task 从 factory 的部署区块开始回填 PoolCreated 事件, 追上已确认的区块后继续跟踪新池子
+// 池子写入 dex_pool, 代币符合 ERC-20 的池子写入 market_symbol, 价格方向为 token1/token0
+// 代币符号可以被仿冒且同一交易对有多个费率档位, 只有按地址选定的池子注册统一交易对并写入行情, 每个统一交易对只由一个池子定价
+// 反向价格 token0/token1 以 inverseKey 另存一条行情, 统一交易对为反向的交易对
+// 选定池子的初始价格读取 slot0, 之后由 DexLogTask 按 Swap 日志更新; 配置了深度百分比时按 tick 流动性生成合成订单簿
+type UniswapV3Task struct {
+	indexer       *evm.UniswapV3Indexer
+	chainId       string
+	startBlock    uint64
+	confirmations uint64                   // 只扫描到最新区块之前 confirmations 个区块, 避免记录被回滚的池子
+	selected      map[common2.Address]bool // 计算价格的池子地址
+	depthPercents []common.Decimal         // 升序, 为空时不计算深度
+	db            *database.DB
+
+	mu           sync.RWMutex
+	pools        map[common2.Address]dex.DexPool // 计算价格的池子, 由 mu 保护
+	pairs        map[string]string               // 统一交易对和反向交易对 -> 定价的池子地址, 由 mu 保护
+	spotPriceMap *maps.PriceMap
+	flusher      *PriceFlusher
+	Books        *orderbook.Manager // 合成订单簿, 按池子地址存放, 档位数量为 token0
+
+	resourceCtx    context.Context
+	resourceCancel context.CancelFunc
+	tasks          tasks.Group
+	ticker         *time.Ticker
+}
+
+// NewUniswapV3Task 创建 Uniswap V3 索引任务, chainId 由节点返回
+func NewUniswapV3Task(shutdown context.CancelCauseFunc, config config.DexExchangeConfig, indexer *evm.UniswapV3Indexer, chainId string, db *database.DB, redisClient *redis.RedisClient, spotPriceMap *maps.PriceMap) (*UniswapV3Task, error) {
+	if config.Interval <= 0 {
+		return nil, fmt.Errorf("invalid uniswap v3 interval %s", config.Interval)
+	}
+
+	selected, err := parsePools(common.UniswapV3, config.Pools)
+	if err != nil {
+		return nil, err
+	}
+
+	hundred := common.NewDecimal(100, 0)
+	depthPercents := make([]common.Decimal, 0, len(config.DepthPercents))
+	for _, item := range config.DepthPercents {
+		percent, err := common.ParseDecimal(item)
+		if err != nil || percent.Sign() <= 0 || !percent.LessThan(hundred) {
+			return nil, fmt.Errorf("invalid uniswap v3 depth percent %q", item)
+		}
+		depthPercents = append(depthPercents, percent)
+	}
+	slices.SortFunc(depthPercents, common.Decimal.Cmp)
+
+	flusher := NewPriceFlusher(common.UniswapV3, db, priceStore(redisClient), spotPriceMap, nil, nil, nil)
+	flusher.SetChainId(chainId)
+
+	resCtx, resCancel := context.WithCancel(context.Background())
+	return &UniswapV3Task{
+		indexer:        indexer,
+		chainId:        chainId,
+		startBlock:     config.StartBlock,
+		confirmations:  config.Confirmations,
+		selected:       selected,
+		depthPercents:  depthPercents,
+		db:             db,
+		pools:          make(map[common2.Address]dex.DexPool),
+		pairs:          make(map[string]string),
+		spotPriceMap:   spotPriceMap,
+		flusher:        flusher,
+		Books:          orderbook.NewManager(string(common.UniswapV3)),
+		resourceCtx:    resCtx,
+		resourceCancel: resCancel,
+		tasks: tasks.Group{HandleCrit: func(err error) {
+			shutdown(fmt.Errorf("uniswap v3 task error: %w", err))
+		}},
+		ticker: time.NewTicker(config.Interval),
+	}, nil
+}
+
+func (t *UniswapV3Task) Start() error {
+	log.Info("uniswap v3 task started", "chainId", t.chainId, "startBlock", t.startBlock, "confirmations", t.confirmations)
+	t.tasks.Go(func() error {
+		if err := t.load(); err != nil {
+			return err
+		}
+		t.run()
+
+		for {
+			select {
+			case <-t.ticker.C:
+				t.run()
+
+			case <-t.resourceCtx.Done():
+				// 停止前写入剩余数据
+				t.flusher.runFlush(true)
+				log.Info("stop uniswap v3 task in work")
+				return nil
+			}
+		}
+	})
+	return nil
+}
+
+func (t *UniswapV3Task) Close() error {
+	var result error
+	t.resourceCancel()
+	t.ticker.Stop()
+	if err := t.tasks.Wait(); err != nil {
+		result = errors.Join(result, fmt.Errorf("uniswap v3 task wait error: %w", err))
+	}
+	log.Info("uniswap v3 task stopped success")
+	return result
+}
+
+// load 加载已发现的池子
+func (t *UniswapV3Task) load() error {
+	pools, err := t.db.DexPool.QueryDexPools(string(common.UniswapV3), t.chainId)
+	if err != nil {
+		return fmt.Errorf("query uniswap v3 pools: %w", err)
+	}
+	t.track(pools)
+	log.Info("uniswap v3 pools loaded", "count", len(pools), "tracked", len(t.Pools()))
+	return nil
+}
+
+// run 发现新的池子、刷新价格和深度并写入redis, 回填和读取池子状态各自计算超时
+func (t *UniswapV3Task) run() {
+	t.discover()
+
+	ctx, cancel := context.WithTimeout(t.resourceCtx, dexCallTimeout)
+	defer cancel()
+	t.refresh(ctx)
+	t.flusher.runFlush(false)
+}
+
+// discover 按 MaxLogRange 分段扫描到已确认的区块, 每段写入成功后推进 checkpoint, 超时后下次继续
+func (t *UniswapV3Task) discover() {
+	ctx, cancel := context.WithTimeout(t.resourceCtx, dexCallTimeout)
+	defer cancel()
+
+	next, err := t.db.DexCheckpoint.GetCheckpoint(string(common.UniswapV3), t.chainId, poolCreatedCheckpoint)
+	if err != nil {
+		log.Error("get uniswap v3 checkpoint failed", "err", err)
+		return
+	}
+	next = max(next, t.startBlock)
+	head, err := t.indexer.HeadBlock(ctx)
+	if err != nil {
+		log.Error("get head block failed", "exchange", common.UniswapV3, "err", err)
+		return
+	}
+	if head < t.confirmations {
+		return
+	}
+	head -= t.confirmations
+
+	for next <= head && ctx.Err() == nil {
+		to := min(head, next+evm.MaxLogRange-1)
+		created, err := t.indexer.PoolCreated(ctx, next, to)
+		if err != nil {
+			log.Error("discover uniswap v3 pools failed", "from", next, "to", to, "err", err)
+			return
+		}
+		if err := t.save(created, to+1); err != nil {
+			log.Error("save uniswap v3 pools failed", "from", next, "to", to, "err", err)
+			return
+		}
+		if len(created) > 0 {
+			log.Info("uniswap v3 pools discovered", "count", len(created), "from", next, "to", to, "head", head)
+		}
+		next = to + 1
+	}
+}
+
+// save 写入池子和交易对后推进 checkpoint
+func (t *UniswapV3Task) save(created []evm.V3Pool, next uint64) error {
+	pools := make([]dex.DexPool, 0, len(created))
+	marketSymbols := make([]symbol.MarketSymbol, 0, len(created))
+	for _, item := range created {
+		pool := dex.DexPool{
+			Exchange:    string(common.UniswapV3),
+			ChainId:     t.chainId,
+			Address:     item.Address.Hex(),
+			Token0:      item.Token0.Address.Hex(),
+			Token1:      item.Token1.Address.Hex(),
+			Symbol0:     item.Token0.Symbol,
+			Symbol1:     item.Token1.Symbol,
+			Decimals0:   item.Token0.Decimals,
+			Decimals1:   item.Token1.Decimals,
+			Fee:         item.Fee,
+			TickSpacing: item.TickSpacing,
+			Block:       item.Block,
+		}
+		pools = append(pools, pool)
+		if unified, ok := poolSymbol(pool); ok {
+			marketSymbols = append(marketSymbols, symbol.MarketSymbol{
+				Symbol:        pool.Address,
+				UnifiedSymbol: unified.Pair(),
+				ChainId:       t.chainId,
+				Base:          unified.Base,
+				Quote:         unified.Quote,
+			})
+		}
+	}
+
+	if err := t.db.DexPool.SaveDexPools(&pools); err != nil {
+		return fmt.Errorf("save pools: %w", err)
+	}
+	if err := t.db.MarketSymbol.UpsertMarketSymbols(string(common.UniswapV3), symbol.InstTypeSpot, marketSymbols); err != nil {
+		return fmt.Errorf("save market symbols: %w", err)
+	}
+	if err := t.db.DexCheckpoint.SaveCheckpoint(string(common.UniswapV3), t.chainId, poolCreatedCheckpoint, next); err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	t.track(pools)
+	return nil
+}
+
+// track 选中的池子注册统一交易对和反向交易对并加入价格计算, 任一交易对已由其他池子定价时跳过
+func (t *UniswapV3Task) track(pools []dex.DexPool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, pool := range pools {
+		address := common2.HexToAddress(pool.Address)
+		if !t.selected[address] {
+			continue
+		}
+		unified, ok := poolSymbol(pool)
+		if !ok {
+			log.Warn("skip uniswap v3 pool without valid symbols", "pool", pool.Address, "symbol0", pool.Symbol0, "symbol1", pool.Symbol1)
+			continue
+		}
+		inverse := symbols2.NewSymbol(unified.Quote, unified.Base, "")
+		if owner := t.pairOwner(unified, inverse); owner != "" && owner != pool.Address {
+			log.Warn("skip uniswap v3 pool, symbol already priced by another pool", "pool", pool.Address, "symbol", unified.Pair(), "fee", pool.Fee, "owner", owner)
+			continue
+		}
+		t.pairs[unified.Pair()] = pool.Address
+		t.pairs[inverse.Pair()] = pool.Address
+		symbols2.Default.Register(common.UniswapV3, common.InstTypeSpot, pool.Address, unified)
+		symbols2.Default.Register(common.UniswapV3, common.InstTypeSpot, inverseKey(pool.Address), inverse)
+		t.pools[address] = pool
+	}
+}
+
+// pairOwner 统一交易对或反向交易对已有的定价池子, 调用方需持有 mu
+func (t *UniswapV3Task) pairOwner(unified, inverse symbols2.Symbol) string {
+	if owner, ok := t.pairs[unified.Pair()]; ok {
+		return owner
+	}
+	return t.pairs[inverse.Pair()]
+}
+
+// refresh 读取尚无价格的池子的 slot0 作为初始价格, 之后由 Swap 日志更新; 配置了深度百分比时重新生成所有池子的合成订单簿
+func (t *UniswapV3Task) refresh(ctx context.Context) {
+	pending := make([]dex.DexPool, 0)
+	t.mu.RLock()
+	for _, pool := range t.pools {
+		if _, ok := t.spotPriceMap.Read(pool.Address); !ok || len(t.depthPercents) > 0 {
+			pending = append(pending, pool)
+		}
+	}
+	t.mu.RUnlock()
+
+	for _, pool := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+		address := common2.HexToAddress(pool.Address)
+		state, err := t.indexer.State(ctx, address)
+		if err != nil {
+			log.Warn("read uniswap v3 pool state failed", "pool", pool.Address, "err", err)
+			continue
+		}
+
+		t.mu.Lock()
+		// 读取期间已收到 Swap 日志时保留日志的价格
+		if _, ok := t.spotPriceMap.Read(pool.Address); !ok {
+			t.writePrice(pool, state.SqrtPriceX96)
+		}
+		t.mu.Unlock()
+
+		if len(t.depthPercents) > 0 && state.SqrtPriceX96.Sign() > 0 {
+			if err := t.depth(ctx, pool, state); err != nil {
+				log.Warn("build uniswap v3 depth failed", "pool", pool.Address, "err", err)
+			}
+		}
+	}
+}
+
+// depth 读取覆盖最大百分比的 tick, 生成合成订单簿, 每档数量为相邻百分比之间可成交的 token0
+func (t *UniswapV3Task) depth(ctx context.Context, pool dex.DexPool, state evm.V3State) error {
+	lower, upper := evm.V3TickRange(state.Tick, pool.TickSpacing, t.depthPercents[len(t.depthPercents)-1])
+	ticks, err := t.indexer.Ticks(ctx, common2.HexToAddress(pool.Address), pool.TickSpacing, lower, upper)
+	if err != nil {
+		return err
+	}
+	bids, asks, err := evm.V3Depth(state, ticks, pool.Decimals0, pool.Decimals1, t.depthPercents)
+	if err != nil {
+		return err
+	}
+
+	updateTime := time.Now().UnixMilli()
+	return t.Books.GetOrCreate(pool.Address).Load(bookLevels(bids), bookLevels(asks), updateTime, updateTime)
+}
+
+// Pools 计算价格的池子地址, 实现 DexLogSource
+func (t *UniswapV3Task) Pools() []common2.Address {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	addresses := make([]common2.Address, 0, len(t.pools))
+	for address := range t.pools {
+		addresses = append(addresses, address)
+	}
+	return addresses
+}
+
+// ApplyLog 按 Swap 日志中成交后的 sqrtPriceX96 更新价格, 实现 DexLogSource
+func (t *UniswapV3Task) ApplyLog(entry types.Log) {
+	if len(entry.Topics) == 0 || entry.Topics[0] != evm.SwapTopic {
+		return
+	}
+	state, err := evm.ParseSwap(entry)
+	if err != nil {
+		log.Warn("parse uniswap v3 swap failed", "pool", entry.Address, "tx", entry.TxHash, "err", err)
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if pool, ok := t.pools[entry.Address]; ok {
+		t.writePrice(pool, state.SqrtPriceX96)
+	}
+}
+
+// ResetPool 回滚后丢弃池子的价格, 由下一轮 refresh 重新读取slot0, 实现 DexLogSource
+func (t *UniswapV3Task) ResetPool(pool common2.Address) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if item, ok := t.pools[pool]; ok {
+		t.spotPriceMap.Delete(item.Address)
+		t.spotPriceMap.Delete(inverseKey(item.Address))
+	}
+}
+
+// writePrice 按 sqrtPriceX96 写入内存行情和反向价格, 调用方需持有 mu
+func (t *UniswapV3Task) writePrice(pool dex.DexPool, sqrtPriceX96 *big.Int) {
+	price, inverse := evm.SqrtPriceX96Price(sqrtPriceX96, pool.Decimals0, pool.Decimals1)
+	if !price.IsSet() {
+		return
+	}
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	t.spotPriceMap.Write(pool.Address, &maps.PriceData{
+		Symbol:    pool.Address,
+		Price:     price,
+		Timestamp: timestamp,
+	})
+	t.spotPriceMap.Write(inverseKey(pool.Address), &maps.PriceData{
+		Symbol:    inverseKey(pool.Address),
+		Price:     inverse,
+		Timestamp: timestamp,
+	})
+}
+
+// inverseKey 池子反向价格的行情键
+func inverseKey(address string) string {
+	return address + inverseSuffix
+}
+
+// bookLevels 累计深度转换为订单簿档位 [price, size], size 为与上一档之间的 token0 数量
+func bookLevels(levels []evm.V3DepthLevel) [][]string {
+	result := make([][]string, 0, len(levels))
+	previous := common.NewDecimal(0, 0)
+	for _, level := range levels {
+		size := level.Amount0.Sub(previous)
+		previous = level.Amount0
+		result = append(result, []string{level.Price.String(), size.String()})
+	}
+	return result
+}
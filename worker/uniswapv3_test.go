@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/maps"
+	"github.com/339-Labs/exchange-market/common/symbols"
+	"github.com/339-Labs/exchange-market/database/dex"
+	"github.com/339-Labs/exchange-market/exchange/dex/evm"
+	common2 "github.com/ethereum/go-ethereum/common"
+)
+
+func TestUniswapV3InversePrice(t *testing.T) {
+	pool := dex.DexPool{
+		Address:   "0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640",
+		Symbol0:   "WETH",
+		Symbol1:   "USDC",
+		Decimals0: 18,
+		Decimals1: 6,
+	}
+	// 同一交易对的 0.3% 费率档位
+	feeTier := pool
+	feeTier.Address = "0x8ad599c3A0ff1De082011EFDDc58f1908eb6e6D8"
+	task := &UniswapV3Task{
+		selected:     map[common2.Address]bool{common2.HexToAddress(pool.Address): true, common2.HexToAddress(feeTier.Address): true},
+		pools:        make(map[common2.Address]dex.DexPool),
+		pairs:        make(map[string]string),
+		spotPriceMap: maps.NewPriceMap(10),
+	}
+	task.track([]dex.DexPool{pool, feeTier})
+	if pools := task.Pools(); len(pools) != 1 || pools[0] != common2.HexToAddress(pool.Address) {
+		t.Fatalf("unexpected pools %v", pools)
+	}
+	if unified := symbols.Default.Unify(common.UniswapV3, common.InstTypeSpot, inverseKey(pool.Address)); unified != "USDC/WETH" {
+		t.Fatalf("inverse unified = %s, want USDC/WETH", unified)
+	}
+
+	// 价格 2500 USDC/WETH
+	sqrtPriceX96 := new(big.Int).Div(new(big.Int).Mul(evm.Q96, big.NewInt(5)), big.NewInt(100_000))
+	task.writePrice(pool, sqrtPriceX96)
+	price, ok := task.spotPriceMap.Read(pool.Address)
+	if !ok || !price.Price.Round(6).Equal(common.MustParseDecimal("2500")) {
+		t.Fatalf("unexpected price %+v", price)
+	}
+	inverse, ok := task.spotPriceMap.Read(inverseKey(pool.Address))
+	if !ok || inverse.Symbol != inverseKey(pool.Address) || !inverse.Price.Round(10).Equal(common.MustParseDecimal("0.0004")) {
+		t.Fatalf("unexpected inverse price %+v", inverse)
+	}
+
+	task.ResetPool(common2.HexToAddress(pool.Address))
+	if _, ok := task.spotPriceMap.Read(inverseKey(pool.Address)); ok {
+		t.Fatal("inverse price should be reset with the pool")
+	}
+}
@@ -0,0 +1,171 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/339-Labs/exchange-market/common"
+	symbols2 "github.com/339-Labs/exchange-market/common/symbols"
+	"github.com/339-Labs/exchange-market/common/tasks"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/database"
+	"github.com/339-Labs/exchange-market/database/symbol"
+	"github.com/ethereum/go-ethereum/log"
+	"strings"
+	"time"
+)
+
+// SymbolSubscriber 交易所按交易对列表调整行情订阅, instType 为 spot 或 perp
+type SymbolSubscriber interface {
+	SyncSymbols(instType string, symbols []string) error
+}
+
+// UniverseTask 定时从 market_symbol 加载订阅的交易对, 新上架的交易对无需重启即可订阅, 下架的交易对取消订阅
+// subscriber 为空时只按交易所返回的币种注册交易对映射, 用于订阅全市场行情的交易所
+type UniverseTask struct {
+	exchange   common.Exchange
+	db         *database.DB
+	config     config.UniverseConfig
+	subscriber SymbolSubscriber
+
+	resourceCtx    context.Context
+	resourceCancel context.CancelFunc
+	tasks          tasks.Group
+	ticker         *time.Ticker
+}
+
+func NewUniverseTask(shutdown context.CancelCauseFunc, exchange common.Exchange, db *database.DB, config config.UniverseConfig, subscriber SymbolSubscriber) (*UniverseTask, error) {
+	if config.Refresh <= 0 {
+		return nil, fmt.Errorf("invalid universe refresh interval %s", config.Refresh)
+	}
+
+	resCtx, resCancel := context.WithCancel(context.Background())
+	return &UniverseTask{
+		exchange:       exchange,
+		db:             db,
+		config:         config,
+		subscriber:     subscriber,
+		resourceCtx:    resCtx,
+		resourceCancel: resCancel,
+		tasks: tasks.Group{HandleCrit: func(err error) {
+			shutdown(fmt.Errorf("%s universe error: %w", exchange, err))
+		}},
+		ticker: time.NewTicker(config.Refresh),
+	}, nil
+}
+
+func (t *UniverseTask) Start() error {
+	log.Info("universe task started", "exchange", t.exchange)
+	t.tasks.Go(func() error {
+		t.refresh()
+
+		for {
+			select {
+			case <-t.ticker.C:
+				t.refresh()
+
+			case <-t.resourceCtx.Done():
+				log.Info("stop universe task in work", "exchange", t.exchange)
+				return nil
+			}
+		}
+	})
+	return nil
+}
+
+func (t *UniverseTask) Close() error {
+	var result error
+	t.resourceCancel()
+	t.ticker.Stop()
+	if err := t.tasks.Wait(); err != nil {
+		result = errors.Join(result, fmt.Errorf("universe task wait error: %w", err))
+	}
+	log.Info("universe task stopped success", "exchange", t.exchange)
+	return result
+}
+
+// refresh 加载现货和永续交易对并调整订阅
+func (t *UniverseTask) refresh() {
+	for _, instType := range []string{common.InstTypeSpot, common.InstTypePerp} {
+		dbInstType := symbol.InstTypeSpot
+		if instType == common.InstTypePerp {
+			dbInstType = symbol.InstTypeFeature
+		}
+
+		symbols, err := t.db.MarketSymbol.QueryMarketSymbols(string(t.exchange), dbInstType)
+		if err != nil {
+			log.Error("load universe failed", "exchange", t.exchange, "instType", instType, "err", err)
+			continue
+		}
+
+		RegisterSymbols(symbols2.Default, t.exchange, instType, symbols)
+		if t.subscriber == nil {
+			continue
+		}
+
+		universe := FilterUniverse(symbols, t.config)
+		if len(universe) == 0 {
+			// 表为空或过滤后为空时保留当前订阅, 避免误取消全部订阅
+			log.Warn("universe is empty, run sync-symbols first or check the filters", "exchange", t.exchange, "instType", instType)
+			continue
+		}
+
+		if err := t.subscriber.SyncSymbols(instType, universe); err != nil {
+			log.Error("sync subscriptions failed", "exchange", t.exchange, "instType", instType, "err", err)
+			continue
+		}
+		log.Info("universe refreshed", "exchange", t.exchange, "instType", instType, "count", len(universe))
+	}
+}
+
+// RegisterSymbols 按交易所返回的币种注册交易对映射, 供行情打标签和接口查询使用
+func RegisterSymbols(resolver *symbols2.Resolver, exchange common.Exchange, instType string, symbols []symbol.MarketSymbol) {
+	for _, s := range symbols {
+		resolver.RegisterUnified(exchange, instType, s.Symbol, s.UnifiedSymbol)
+	}
+}
+
+// FilterUniverse 只保留可交易和临时停止交易的交易对, 按计价币种、白名单和黑名单过滤, 返回交易所原始交易对
+// 临时停止交易的交易对保留订阅, 避免短暂停牌时取消后重新订阅
+func FilterUniverse(symbols []symbol.MarketSymbol, config config.UniverseConfig) []string {
+	quotes := toSet(config.Quotes)
+	allow := toSet(config.Allow)
+	deny := toSet(config.Deny)
+
+	result := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		if s.Status != symbol.StatusTrading && s.Status != symbol.StatusSuspended {
+			continue
+		}
+		if len(quotes) > 0 && !quotes[strings.ToUpper(s.Quote)] {
+			continue
+		}
+		if len(allow) > 0 && !matchSymbol(allow, s) {
+			continue
+		}
+		if matchSymbol(deny, s) {
+			continue
+		}
+		result = append(result, s.Symbol)
+	}
+	return result
+}
+
+// matchSymbol 币种、统一交易对(含或不含结算币种)或原始交易对是否在集合中
+func matchSymbol(set map[string]bool, s symbol.MarketSymbol) bool {
+	if set[strings.ToUpper(s.Base)] || set[strings.ToUpper(s.UnifiedSymbol)] || set[strings.ToUpper(s.Symbol)] {
+		return true
+	}
+	unified, err := symbols2.Parse(s.UnifiedSymbol)
+	return err == nil && (set[unified.Base] || set[unified.Pair()])
+}
+
+func toSet(list []string) map[string]bool {
+	set := make(map[string]bool, len(list))
+	for _, item := range list {
+		if item = strings.ToUpper(strings.TrimSpace(item)); item != "" {
+			set[item] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/339-Labs/exchange-market/common"
+	"github.com/339-Labs/exchange-market/common/symbols"
+	"github.com/339-Labs/exchange-market/config"
+	"github.com/339-Labs/exchange-market/database/symbol"
+)
+
+func TestFilterUniverse(t *testing.T) {
+	symbols := []symbol.MarketSymbol{
+		{Symbol: "BTCUSDT", UnifiedSymbol: "BTC/USDT", Base: "BTC", Quote: "USDT", Status: symbol.StatusTrading},
+		{Symbol: "ETHUSDT", UnifiedSymbol: "ETH/USDT", Base: "ETH", Quote: "USDT", Status: symbol.StatusTrading},
+		{Symbol: "ETHBTC", UnifiedSymbol: "ETH/BTC", Base: "ETH", Quote: "BTC", Status: symbol.StatusTrading},
+		{Symbol: "LUNAUSDT", UnifiedSymbol: "LUNA/USDT", Base: "LUNA", Quote: "USDT", Status: symbol.StatusDelisted},
+		{Symbol: "SOLUSDT", UnifiedSymbol: "SOL/USDT", Base: "SOL", Quote: "USDT", Status: symbol.StatusSuspended},
+		{Symbol: "DOGEUSDT", UnifiedSymbol: "DOGE/USDT", Base: "DOGE", Quote: "USDT", Status: symbol.StatusTrading},
+	}
+
+	got := FilterUniverse(symbols, config.UniverseConfig{Quotes: []string{"usdt"}, Deny: []string{"DOGE/USDT"}})
+	if want := []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("FilterUniverse() = %v, want %v", got, want)
+	}
+
+	got = FilterUniverse(symbols, config.UniverseConfig{Allow: []string{"eth"}})
+	if want := []string{"ETHUSDT", "ETHBTC"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("FilterUniverse() = %v, want %v", got, want)
+	}
+}
+
+func TestRegisterSymbols(t *testing.T) {
+	resolver := symbols.NewResolver()
+	// PLN 不在按后缀识别的计价币种中, 只能通过交易所返回的币种解析
+	if _, ok := resolver.Resolve(common.BN, common.InstTypeSpot, "BTCPLN"); ok {
+		t.Fatal("BTCPLN should not resolve before registration")
+	}
+
+	RegisterSymbols(resolver, common.BN, common.InstTypeSpot, []symbol.MarketSymbol{
+		{Symbol: "BTCPLN", UnifiedSymbol: "BTC/PLN", Base: "BTC", Quote: "PLN", Status: symbol.StatusTrading},
+	})
+	got, ok := resolver.Resolve(common.BN, common.InstTypeSpot, "btcpln")
+	if !ok || got.Pair() != "BTC/PLN" {
+		t.Fatalf("Resolve() = %v, %v, want BTC/PLN", got, ok)
+	}
+	if venue, ok := resolver.ToVenue(common.BN, common.InstTypeSpot, got); !ok || venue != "BTCPLN" {
+		t.Fatalf("ToVenue() = %s, %v, want BTCPLN", venue, ok)
+	}
+}